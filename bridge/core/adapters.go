@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/grafana/sobek"
+)
+
+// Adapters for stdlib interfaces that scripts commonly implement. They have
+// the same shape as the adapters generated for hyperlinked packages.
+
+type stringerAdapter struct {
+	impl     *JSImpl
+	m_String func() string
+}
+
+func (a *stringerAdapter) String() string              { return a.m_String() }
+func (a *stringerAdapter) TypeGoObject() *sobek.Object { return a.impl.Object() }
+
+type readerAdapter struct {
+	impl   *JSImpl
+	m_Read func(p []byte) (int, error)
+}
+
+func (a *readerAdapter) Read(p []byte) (int, error)  { return a.m_Read(p) }
+func (a *readerAdapter) TypeGoObject() *sobek.Object { return a.impl.Object() }
+
+type writerAdapter struct {
+	impl    *JSImpl
+	m_Write func(p []byte) (int, error)
+}
+
+func (a *writerAdapter) Write(p []byte) (int, error) { return a.m_Write(p) }
+func (a *writerAdapter) TypeGoObject() *sobek.Object { return a.impl.Object() }
+
+type closerAdapter struct {
+	impl    *JSImpl
+	m_Close func() error
+}
+
+func (a *closerAdapter) Close() error                { return a.m_Close() }
+func (a *closerAdapter) TypeGoObject() *sobek.Object { return a.impl.Object() }
+
+type sortAdapter struct {
+	impl   *JSImpl
+	m_Len  func() int
+	m_Less func(i, j int) bool
+	m_Swap func(i, j int)
+}
+
+func (a *sortAdapter) Len() int                    { return a.m_Len() }
+func (a *sortAdapter) Less(i, j int) bool          { return a.m_Less(i, j) }
+func (a *sortAdapter) Swap(i, j int)               { a.m_Swap(i, j) }
+func (a *sortAdapter) TypeGoObject() *sobek.Object { return a.impl.Object() }
+
+func init() {
+	RegisterInterface((*fmt.Stringer)(nil), func(impl *JSImpl) (interface{}, error) {
+		a := &stringerAdapter{impl: impl}
+		return a, impl.Bind("String", &a.m_String)
+	})
+	RegisterInterface((*io.Reader)(nil), func(impl *JSImpl) (interface{}, error) {
+		a := &readerAdapter{impl: impl}
+		return a, impl.Bind("Read", &a.m_Read)
+	})
+	RegisterInterface((*io.Writer)(nil), func(impl *JSImpl) (interface{}, error) {
+		a := &writerAdapter{impl: impl}
+		return a, impl.Bind("Write", &a.m_Write)
+	})
+	RegisterInterface((*io.Closer)(nil), func(impl *JSImpl) (interface{}, error) {
+		a := &closerAdapter{impl: impl}
+		return a, impl.Bind("Close", &a.m_Close)
+	})
+	RegisterInterface((*sort.Interface)(nil), func(impl *JSImpl) (interface{}, error) {
+		a := &sortAdapter{impl: impl}
+		for name, fn := range map[string]interface{}{"Len": &a.m_Len, "Less": &a.m_Less, "Swap": &a.m_Swap} {
+			if err := impl.Bind(name, fn); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	})
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/eventloop"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// BindOption configures BindStruct and BindStructLive.
+type BindOption func(*bindOptions)
+
+type bindOptions struct {
+	el    *eventloop.EventLoop
+	async map[string]bool
+	yield func() (resume func())
+}
+
+// WithEventLoop lets bound methods run off the JS thread. Without it every
+// method is called synchronously, including context-first ones.
+func WithEventLoop(el *eventloop.EventLoop) BindOption {
+	return func(o *bindOptions) {
+		o.el = el
+	}
+}
+
+// WithYield sets the hook called before blocking channel operations. It
+// releases whatever keeps other goroutines from running JS (the VM lock) and
+// returns the function that re-acquires it.
+func WithYield(yield func() (resume func())) BindOption {
+	return func(o *bindOptions) {
+		o.yield = yield
+	}
+}
+
+// AsyncMethods marks methods, by Go name, that run on a goroutine and return
+// a promise. Methods taking a context.Context first are async by convention.
+func AsyncMethods(names ...string) BindOption {
+	return func(o *bindOptions) {
+		for _, n := range names {
+			o.async[n] = true
+		}
+	}
+}
+
+func applyBindOptions(opts []BindOption) *bindOptions {
+	o := &bindOptions{async: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *bindOptions) isAsync(method string, ctxFirst bool) bool {
+	if o == nil || o.el == nil {
+		return false
+	}
+	return ctxFirst || o.async[method]
+}
+
+// bindCtx carries per-conversion state through the recursive binders.
+type bindCtx struct {
+	visited map[uintptr]sobek.Value
+	opts    *bindOptions
+}
+
+func newBindCtx(opts *bindOptions) *bindCtx {
+	return &bindCtx{visited: make(map[uintptr]sobek.Value), opts: opts}
+}
+
+// callAsync invokes the method on a goroutine and settles the returned promise
+// on the event loop. The promise carries a cancel() that cancels the context
+// handed to context-first methods; engine shutdown or cancelling the parent
+// passed from JS cancels it as well.
+func callAsync(vm *sobek.Runtime, opts *bindOptions, methodVal reflect.Value, goArgs []reflect.Value, ctxFirst bool, parent context.Context) sobek.Value {
+	el := opts.el
+	if parent == nil {
+		parent = el.Context()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	if ctxFirst {
+		goArgs[0] = reflect.ValueOf(ctx)
+	}
+
+	promise, resolve, reject := el.CreatePromise()
+	_ = promise.Set("cancel", func(sobek.FunctionCall) sobek.Value {
+		cancel()
+		return sobek.Undefined()
+	})
+
+	go func() {
+		defer cancel()
+
+		results, err := safeCall(methodVal, goArgs)
+
+		// Results are bound on the loop because sobek values are not thread-safe
+		el.RunOnLoop(func() {
+			if err == nil {
+				var val sobek.Value
+				if val, err = bindResults(vm, methodVal.Type(), results, opts); err == nil {
+					resolve(val)
+					return
+				}
+			}
+			reject(NewGoError(vm, err))
+		})
+	}()
+
+	return promise
+}
+
+func safeCall(fn reflect.Value, args []reflect.Value) (results []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn.Call(args), nil
+}
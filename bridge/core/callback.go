@@ -0,0 +1,270 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/eventloop"
+)
+
+var errLoopStopped = errors.New("event loop stopped before the callback ran")
+
+// jsCallback adapts a JS function to a Go func type.
+type jsCallback struct {
+	vm     *sobek.Runtime
+	fn     sobek.Callable
+	this   sobek.Value
+	t      reflect.Type
+	opts   *bindOptions
+	hasErr bool // trailing error result receives exceptions
+}
+
+type callbackOutcome struct {
+	vals []reflect.Value
+	err  error
+}
+
+// wrapJSCallback turns a JS function into a Go func of goType.
+//
+// Results are converted to the declared types; several results are read from
+// an array. A trailing error result receives exceptions, rejections and
+// conversion failures. Without one, exceptions propagate to the JS caller.
+//
+// When the caller holds the runtime lock, as Go code called from JS does (on
+// the loop, in a go() task or in Engine.Run), the call is made inline.
+// Otherwise it is scheduled on the event loop and the caller blocks until it
+// completes, including settlement of a returned promise.
+func wrapJSCallback(vm *sobek.Runtime, callable sobek.Callable, goType reflect.Type, opts *bindOptions) reflect.Value {
+	return wrapJSMethod(vm, callable, sobek.Undefined(), goType, opts)
+}
+
+// wrapJSMethod is wrapJSCallback with an explicit receiver for this.
+func wrapJSMethod(vm *sobek.Runtime, callable sobek.Callable, this sobek.Value, goType reflect.Type, opts *bindOptions) reflect.Value {
+	cb := &jsCallback{
+		vm:     vm,
+		fn:     callable,
+		this:   this,
+		t:      goType,
+		opts:   opts,
+		hasErr: goType.NumOut() > 0 && goType.Out(goType.NumOut()-1) == errorType,
+	}
+	return reflect.MakeFunc(goType, cb.call)
+}
+
+func (cb *jsCallback) call(args []reflect.Value) []reflect.Value {
+	el := cb.loop()
+	if el == nil || el.OnLoop() {
+		out := cb.callOnLoop(args)
+		if out.err != nil && !cb.hasErr {
+			var ex *sobek.Exception
+			if errors.As(out.err, &ex) {
+				panic(ex)
+			}
+			panic(NewGoError(cb.vm, out.err))
+		}
+		return cb.finish(out)
+	}
+
+	ch := make(chan callbackOutcome, 1)
+	if !el.Await(func() { cb.callForeign(args, ch) }) {
+		return cb.finish(callbackOutcome{err: errLoopStopped})
+	}
+
+	var out callbackOutcome
+	select {
+	case out = <-ch:
+	case <-el.Context().Done():
+		out = callbackOutcome{err: el.Context().Err()}
+	}
+
+	// Nobody can catch the exception on this goroutine; surface it instead of
+	// dropping it silently.
+	if out.err != nil && !cb.hasErr && el.OnUnhandledRejection != nil {
+		el.OnUnhandledRejection(out.err)
+	}
+	return cb.finish(out)
+}
+
+func (cb *jsCallback) loop() *eventloop.EventLoop {
+	if cb.opts == nil {
+		return nil
+	}
+	return cb.opts.el
+}
+
+// callOnLoop runs the callback inline. A still-pending promise cannot be
+// waited for here without deadlocking the loop, so it is an error.
+func (cb *jsCallback) callOnLoop(args []reflect.Value) callbackOutcome {
+	val, err := cb.invoke(args)
+	if err != nil {
+		return callbackOutcome{err: err}
+	}
+	if p, ok := val.Export().(*sobek.Promise); ok && p.State() == sobek.PromiseStatePending {
+		return callbackOutcome{err: fmt.Errorf("callback returned a pending promise on the event loop")}
+	}
+	return cb.settle(val)
+}
+
+// callForeign runs on the loop on behalf of a foreign goroutine and delivers
+// the outcome on ch, waiting for a returned promise to settle.
+func (cb *jsCallback) callForeign(args []reflect.Value, ch chan<- callbackOutcome) {
+	val, err := cb.invoke(args)
+	if err != nil {
+		ch <- callbackOutcome{err: err}
+		return
+	}
+
+	p, ok := val.Export().(*sobek.Promise)
+	if !ok || p.State() != sobek.PromiseStatePending {
+		ch <- cb.settle(val)
+		return
+	}
+
+	obj := val.ToObject(cb.vm)
+	then, _ := sobek.AssertFunction(obj.Get("then"))
+	onFulfilled := cb.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		ch <- cb.convert(call.Argument(0))
+		return sobek.Undefined()
+	})
+	onRejected := cb.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		ch <- callbackOutcome{err: rejectionError(call.Argument(0))}
+		return sobek.Undefined()
+	})
+	if _, err := then(obj, onFulfilled, onRejected); err != nil {
+		ch <- callbackOutcome{err: err}
+	}
+}
+
+func (cb *jsCallback) invoke(args []reflect.Value) (sobek.Value, error) {
+	jsArgs := make([]sobek.Value, len(args))
+	for i, arg := range args {
+		v, err := bindValue(cb.vm, arg, newBindCtx(cb.opts))
+		if err != nil {
+			return nil, fmt.Errorf("callback argument %d: %w", i, err)
+		}
+		jsArgs[i] = v
+	}
+
+	val, err := cb.fn(cb.this, jsArgs...)
+	if err != nil {
+		return nil, thrownError(err)
+	}
+	return val, nil
+}
+
+// settle unwraps an already settled promise before converting.
+func (cb *jsCallback) settle(val sobek.Value) callbackOutcome {
+	if p, ok := val.Export().(*sobek.Promise); ok {
+		if p.State() == sobek.PromiseStateRejected {
+			return callbackOutcome{err: rejectionError(p.Result())}
+		}
+		val = p.Result()
+	}
+	return cb.convert(val)
+}
+
+// convert maps the JS return value onto the declared results. With several
+// results the callback returns an array; an extra trailing element fills
+// the error result, so [a, b, err] tuples work as well as throwing.
+func (cb *jsCallback) convert(val sobek.Value) callbackOutcome {
+	n := cb.t.NumOut()
+	if cb.hasErr {
+		n--
+	}
+
+	var elems []sobek.Value
+	switch {
+	case n == 0:
+		if cb.hasErr {
+			return callbackOutcome{err: toError(val)}
+		}
+		return callbackOutcome{}
+	case n == 1:
+		elems = []sobek.Value{val}
+	default:
+		obj, ok := val.(*sobek.Object)
+		if !ok {
+			return callbackOutcome{err: fmt.Errorf("callback must return an array of %d values", n)}
+		}
+		var err error
+		if elems, err = collectElements(cb.vm, obj); err != nil {
+			return callbackOutcome{err: err}
+		}
+	}
+
+	out := callbackOutcome{vals: make([]reflect.Value, n)}
+	for i := 0; i < n; i++ {
+		var el sobek.Value = sobek.Undefined()
+		if i < len(elems) {
+			el = elems[i]
+		}
+		v, err := convertJSToGo(cb.vm, el, cb.t.Out(i), cb.opts)
+		if err != nil {
+			return callbackOutcome{err: fmt.Errorf("callback result %d: %w", i, err)}
+		}
+		out.vals[i] = v
+	}
+	if cb.hasErr && len(elems) > n {
+		out.err = toError(elems[n])
+	}
+	return out
+}
+
+// finish pads missing results with zero values and appends the error.
+func (cb *jsCallback) finish(out callbackOutcome) []reflect.Value {
+	results := make([]reflect.Value, cb.t.NumOut())
+	for i := range results {
+		if out.err == nil && i < len(out.vals) {
+			results[i] = out.vals[i]
+		} else {
+			results[i] = reflect.Zero(cb.t.Out(i))
+		}
+	}
+	if cb.hasErr && out.err != nil {
+		results[len(results)-1] = reflect.ValueOf(&out.err).Elem()
+	}
+	return results
+}
+
+// thrownError recovers the Go error behind a rethrown GoError, so sentinel
+// identity survives a round trip through JS.
+func thrownError(err error) error {
+	var ex *sobek.Exception
+	if errors.As(err, &ex) {
+		if goErr, ok := GoErrorOf(ex.Value()); ok {
+			return goErr
+		}
+	}
+	return err
+}
+
+// ValueError converts a thrown or rejected JS value into a Go error. A
+// GoError yields the Go error it carries.
+func ValueError(reason sobek.Value) error {
+	return rejectionError(reason)
+}
+
+func rejectionError(reason sobek.Value) error {
+	if err := toError(reason); err != nil {
+		return err
+	}
+	return errors.New("promise rejected")
+}
+
+// toError converts a returned or rejected JS value into a Go error.
+func toError(v sobek.Value) error {
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil
+	}
+	if err, ok := GoErrorOf(v); ok {
+		return err
+	}
+	if obj, ok := v.(*sobek.Object); ok {
+		if msg := obj.Get("message"); msg != nil && !sobek.IsUndefined(msg) {
+			return errors.New(msg.String())
+		}
+	}
+	return errors.New(v.String())
+}
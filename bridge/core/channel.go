@@ -0,0 +1,301 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/grafana/sobek"
+)
+
+var sobekValueType = reflect.TypeOf((*sobek.Value)(nil)).Elem()
+
+// Registers Symbol.asyncIterator, which sobek lacks. esbuild's lowered
+// for-await looks the symbol up the same way, so channels become iterable.
+const asyncIteratorJS = `
+if (typeof Symbol.asyncIterator === "undefined") {
+	Object.defineProperty(Symbol, "asyncIterator", { value: Symbol.for("Symbol.asyncIterator") });
+}
+`
+
+// Channel is a Go channel exposed to JS. Untyped channels carry sobek.Values,
+// so JS objects keep their identity; typed channels carry values of a Go
+// element type and can be handed to Go code expecting chan T.
+type Channel struct {
+	vm     *sobek.Runtime
+	ch     reflect.Value
+	opts   *bindOptions
+	closed atomic.Bool
+	obj    *sobek.Object
+}
+
+// MakeChannel creates a channel with the given buffer size. A nil elem makes
+// an untyped channel.
+func MakeChannel(vm *sobek.Runtime, elem reflect.Type, size int, opts ...BindOption) *Channel {
+	if elem == nil {
+		elem = sobekValueType
+	}
+	ch := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, elem), size)
+	return newChannel(vm, ch, applyBindOptions(opts))
+}
+
+// NewChannel wraps an existing Go channel, e.g. one returned by Go code.
+func NewChannel(vm *sobek.Runtime, ch interface{}, opts ...BindOption) (*Channel, error) {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan {
+		return nil, fmt.Errorf("expected a channel, got %T", ch)
+	}
+	return newChannel(vm, v, applyBindOptions(opts)), nil
+}
+
+func newChannel(vm *sobek.Runtime, ch reflect.Value, opts *bindOptions) *Channel {
+	if opts == nil {
+		opts = applyBindOptions(nil)
+	}
+	return &Channel{vm: vm, ch: ch, opts: opts}
+}
+
+// ChannelOf returns the channel behind a JS channel object.
+func ChannelOf(v sobek.Value) (*Channel, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	tag := obj.Get("__chan")
+	if tag == nil {
+		return nil, false
+	}
+	c, ok := tag.Export().(*Channel)
+	return c, ok
+}
+
+// ToChannel accepts a channel object or a raw Go channel, as returned by
+// hyperlinked functions, which is wrapped on the fly.
+func ToChannel(vm *sobek.Runtime, v sobek.Value, opts ...BindOption) (*Channel, bool) {
+	if c, ok := ChannelOf(v); ok {
+		return c, true
+	}
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v.Export())
+	if rv.Kind() != reflect.Chan || rv.IsNil() {
+		return nil, false
+	}
+	return newChannel(vm, rv, applyBindOptions(opts)), true
+}
+
+// Chan returns the underlying Go channel.
+func (c *Channel) Chan() reflect.Value {
+	return c.ch
+}
+
+// Closed reports whether the channel was closed through the bridge or a
+// receive observed it closed.
+func (c *Channel) Closed() bool {
+	return c.closed.Load()
+}
+
+// ToGo converts a JS value into the channel's element type.
+func (c *Channel) ToGo(v sobek.Value) (reflect.Value, error) {
+	if c.ch.Type().Elem() == sobekValueType {
+		if v == nil {
+			v = sobek.Undefined()
+		}
+		return reflect.ValueOf(&v).Elem(), nil
+	}
+	return convertJSToGo(c.vm, v, c.ch.Type().Elem(), c.opts)
+}
+
+// ToJS converts a received element. It must run on the JS thread.
+func (c *Channel) ToJS(v reflect.Value, ok bool) sobek.Value {
+	if !ok {
+		c.closed.Store(true)
+		return sobek.Undefined()
+	}
+	if c.ch.Type().Elem() == sobekValueType {
+		if jsVal, _ := v.Interface().(sobek.Value); jsVal != nil {
+			return jsVal
+		}
+		return sobek.Undefined()
+	}
+	val, err := bindValue(c.vm, v, newBindCtx(c.opts))
+	if err != nil {
+		panic(NewGoError(c.vm, err))
+	}
+	return val
+}
+
+// Object returns the JS object for the channel, creating it once.
+func (c *Channel) Object() *sobek.Object {
+	if c.obj != nil {
+		return c.obj
+	}
+
+	vm := c.vm
+	obj := vm.NewObject()
+	_ = obj.DefineDataProperty("__chan", vm.ToValue(c), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+	_ = obj.DefineAccessorProperty("closed", vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+		return vm.ToValue(c.Closed())
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+
+	_ = obj.Set("send", func(call sobek.FunctionCall) sobek.Value {
+		v := c.mustConvert(call.Argument(0))
+		c.blocking(func() { c.ch.Send(v) })
+		return sobek.Undefined()
+	})
+	_ = obj.Set("recv", func(sobek.FunctionCall) sobek.Value {
+		var v reflect.Value
+		var ok bool
+		c.blocking(func() { v, ok = c.ch.Recv() })
+		return c.ToJS(v, ok)
+	})
+	_ = obj.Set("trySend", func(call sobek.FunctionCall) sobek.Value {
+		v := c.mustConvert(call.Argument(0))
+		var sent bool
+		c.guard(func() { sent = c.ch.TrySend(v) })
+		return vm.ToValue(sent)
+	})
+	_ = obj.Set("tryRecv", func(sobek.FunctionCall) sobek.Value {
+		var v reflect.Value
+		var ok bool
+		c.guard(func() { v, ok = c.ch.TryRecv() })
+		if !ok {
+			if v.IsValid() {
+				c.closed.Store(true) // zero value with !ok means closed
+			}
+			return vm.NewArray(sobek.Undefined(), false)
+		}
+		return vm.NewArray(c.ToJS(v, true), true)
+	})
+	_ = obj.Set("recvAsync", func(sobek.FunctionCall) sobek.Value {
+		return c.recvAsync(func(v reflect.Value, ok bool) sobek.Value { return c.ToJS(v, ok) })
+	})
+	_ = obj.Set("close", func(sobek.FunctionCall) sobek.Value {
+		c.guard(func() { c.ch.Close() })
+		c.closed.Store(true)
+		return sobek.Undefined()
+	})
+	_ = obj.Set("len", func(sobek.FunctionCall) sobek.Value { return vm.ToValue(c.ch.Len()) })
+	_ = obj.Set("cap", func(sobek.FunctionCall) sobek.Value { return vm.ToValue(c.ch.Cap()) })
+
+	if sym, ok := asyncIteratorSymbol(vm); ok {
+		_ = obj.SetSymbol(sym, func(sobek.FunctionCall) sobek.Value {
+			return c.asyncIterator()
+		})
+	}
+
+	c.obj = obj
+	return obj
+}
+
+func (c *Channel) mustConvert(v sobek.Value) reflect.Value {
+	goVal, err := c.ToGo(v)
+	if err != nil {
+		panic(c.vm.NewTypeError(fmt.Sprintf("channel of %s: %v", c.ch.Type().Elem(), err)))
+	}
+	return goVal
+}
+
+// guard turns Go channel panics (send on or close of a closed channel) into
+// JS exceptions carrying the same message.
+func (c *Channel) guard(op func()) {
+	if r := recovered(op); r != nil {
+		c.fail(r)
+	}
+}
+
+// blocking runs op with the runtime lock yielded, and takes the lock back
+// before a panic is turned into a JS exception.
+func (c *Channel) blocking(op func()) {
+	resume := func() {}
+	if c.opts.yield != nil {
+		resume = c.opts.yield()
+	}
+	r := recovered(op)
+	resume()
+	if r != nil {
+		c.fail(r)
+	}
+}
+
+func (c *Channel) fail(r interface{}) {
+	c.closed.Store(true)
+	panic(NewGoError(c.vm, fmt.Errorf("panic: %v", r)))
+}
+
+// recovered runs op and returns the value it panicked with, if any.
+func recovered(op func()) (r interface{}) {
+	defer func() { r = recover() }()
+	op()
+	return nil
+}
+
+// recvAsync receives on a goroutine and settles the promise on the loop.
+// Pending receives are abandoned when the engine shuts down.
+func (c *Channel) recvAsync(result func(v reflect.Value, ok bool) sobek.Value) sobek.Value {
+	el := c.opts.el
+	if el == nil {
+		panic(c.vm.NewTypeError("recvAsync requires an event loop"))
+	}
+
+	promise, resolve, reject := c.vm.NewPromise()
+	el.WGAdd(1)
+	go func() {
+		chosen, v, ok := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: c.ch},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(el.Context().Done())},
+		})
+		if chosen == 1 {
+			el.WGDone()
+			return
+		}
+		el.RunOnLoop(func() {
+			defer el.WGDone()
+			defer func() {
+				if r := recover(); r != nil {
+					_ = reject(r)
+				}
+			}()
+			_ = resolve(result(v, ok))
+		})
+	}()
+	return c.vm.ToValue(promise)
+}
+
+func (c *Channel) asyncIterator() sobek.Value {
+	vm := c.vm
+	it := vm.NewObject()
+	_ = it.Set("next", func(sobek.FunctionCall) sobek.Value {
+		return c.recvAsync(func(v reflect.Value, ok bool) sobek.Value {
+			res := vm.NewObject()
+			_ = res.Set("value", c.ToJS(v, ok))
+			_ = res.Set("done", !ok)
+			return res
+		})
+	})
+	// Breaking out of for-await leaves the channel open, as in Go.
+	_ = it.Set("return", func(call sobek.FunctionCall) sobek.Value {
+		res := vm.NewObject()
+		_ = res.Set("value", call.Argument(0))
+		_ = res.Set("done", true)
+		p, resolve, _ := vm.NewPromise()
+		_ = resolve(res)
+		return vm.ToValue(p)
+	})
+	return it
+}
+
+func asyncIteratorSymbol(vm *sobek.Runtime) (*sobek.Symbol, bool) {
+	symbol, ok := vm.Get("Symbol").(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	sym, ok := symbol.Get("asyncIterator").(*sobek.Symbol)
+	return sym, ok
+}
+
+// RegisterChannels installs the Symbol.asyncIterator polyfill.
+func RegisterChannels(vm *sobek.Runtime) {
+	_, _ = vm.RunString(asyncIteratorJS)
+}
@@ -1,38 +1,534 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/grafana/sobek"
 )
 
-type Console struct{}
+// consoleSink is where console output goes: a slog.Handler when set, else
+// the out and err writers. It is shared with the consoles of workers.
+type consoleSink struct {
+	mu      sync.Mutex
+	out     io.Writer
+	err     io.Writer
+	handler slog.Handler
+}
 
-func (c *Console) Log(call sobek.FunctionCall) sobek.Value {
-	// @optimized: Use []interface{} and fmt.Println to avoid string conversion overhead and allocation.
-	args := make([]interface{}, len(call.Arguments))
-	for i, arg := range call.Arguments {
-		args[i] = arg.Export()
+// Console implements the console global. Arguments are formatted like
+// Node's util.format and written to os.Stdout and os.Stderr, or to the
+// writers or slog.Handler set by the host.
+type Console struct {
+	vm     *sobek.Runtime
+	sink   *consoleSink
+	groups []string
+	counts map[string]int
+	timers map[string]time.Time
+}
+
+// SetOutput sends console output to stdout, and warnings, errors, traces and
+// failed assertions to stderr.
+func (c *Console) SetOutput(stdout, stderr io.Writer) {
+	s := c.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.out, s.err, s.handler = stdout, stderr, nil
+}
+
+// SetHandler sends console output to h as records: debug at LevelDebug,
+// log and info at LevelInfo, warn at LevelWarn, error and assert at
+// LevelError. Traces carry a "stack" attribute, and output inside
+// console.group a "group" attribute. A nil h restores the writers.
+func (c *Console) SetHandler(h slog.Handler) {
+	s := c.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = h
+}
+
+// Inherit makes c write where parent writes, as the consoles of workers and
+// go.parallel runtimes do, following later SetOutput and SetHandler calls
+// on either. It must be called before c is used.
+func (c *Console) Inherit(parent *Console) {
+	c.sink = parent.sink
+}
+
+// emit writes msg at level, to stderr if toErr, indented by the groups.
+func (c *Console) emit(level slog.Level, toErr bool, msg string, attrs ...slog.Attr) {
+	c.sink.write(level, toErr, msg, c.groups, attrs...)
+}
+
+// ReportError writes err where console.error writes, for failures no script
+// can catch, such as those of go() tasks nobody awaits. Unlike the console
+// methods it may be called from any goroutine.
+func (c *Console) ReportError(err error) {
+	c.sink.write(slog.LevelError, true, "Uncaught "+err.Error(), nil)
+}
+
+// write sends msg to the handler, with groups as an attribute, or to the
+// out or err writer, indented by groups.
+func (s *consoleSink) write(level slog.Level, toErr bool, msg string, groups []string, attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h := s.handler; h != nil {
+		ctx := context.Background()
+		if !h.Enabled(ctx, level) {
+			return
+		}
+		if len(groups) > 0 {
+			attrs = append(attrs, slog.String("group", strings.Join(groups, " > ")))
+		}
+		rec := slog.NewRecord(time.Now(), level, msg, 0)
+		rec.AddAttrs(attrs...)
+		_ = h.Handle(ctx, rec)
+		return
 	}
-	fmt.Println(args...)
+
+	if indent := strings.Repeat("  ", len(groups)); indent != "" {
+		msg = indent + strings.ReplaceAll(msg, "\n", "\n"+indent)
+	}
+	w := s.out
+	if toErr {
+		w = s.err
+	}
+	_, _ = io.WriteString(w, msg+"\n")
+}
+
+func (c *Console) Log(call sobek.FunctionCall) sobek.Value {
+	c.emit(slog.LevelInfo, false, c.format(call.Arguments))
+	return sobek.Undefined()
+}
+
+func (c *Console) Debug(call sobek.FunctionCall) sobek.Value {
+	c.emit(slog.LevelDebug, false, c.format(call.Arguments))
+	return sobek.Undefined()
+}
+
+func (c *Console) Warn(call sobek.FunctionCall) sobek.Value {
+	c.emit(slog.LevelWarn, true, c.format(call.Arguments))
 	return sobek.Undefined()
 }
 
 func (c *Console) Error(call sobek.FunctionCall) sobek.Value {
-	// @optimized: Use []interface{} and fmt.Println to avoid string conversion overhead and allocation.
-	args := make([]interface{}, len(call.Arguments))
-	for i, arg := range call.Arguments {
-		args[i] = arg.Export()
+	c.emit(slog.LevelError, true, c.format(call.Arguments))
+	return sobek.Undefined()
+}
+
+// Trace writes "Trace: " and its arguments, followed by the call stack.
+func (c *Console) Trace(call sobek.FunctionCall) sobek.Value {
+	msg := "Trace"
+	if len(call.Arguments) > 0 {
+		msg += ": " + c.format(call.Arguments)
+	}
+	var stack strings.Builder
+	for _, f := range c.vm.CaptureCallStack(0, nil) {
+		if f.SrcName() == "<native>" {
+			continue
+		}
+		pos := f.Position()
+		loc := fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+		if pos.Filename == "" {
+			loc = fmt.Sprintf("<eval>:%d:%d", pos.Line, pos.Column)
+		}
+		if name := f.FuncName(); name != "" {
+			loc = name + " (" + loc + ")"
+		}
+		stack.WriteString("\n    at " + loc)
+	}
+	if c.sink.handler != nil {
+		c.emit(slog.LevelDebug, true, msg, slog.String("stack", strings.TrimPrefix(stack.String(), "\n")))
+	} else {
+		c.emit(slog.LevelDebug, true, msg+stack.String())
+	}
+	return sobek.Undefined()
+}
+
+// Dir writes its first argument inspected, with options.depth levels
+// (2 by default, null for all).
+func (c *Console) Dir(call sobek.FunctionCall) sobek.Value {
+	depth := 2
+	if opts, ok := call.Argument(1).(*sobek.Object); ok {
+		if d := opts.Get("depth"); d != nil && !sobek.IsUndefined(d) {
+			depth = inspectDepth(d)
+		}
+	}
+	c.emit(slog.LevelInfo, false, Inspect(c.vm, call.Argument(0), depth))
+	return sobek.Undefined()
+}
+
+// Group writes its arguments, if any, and indents what follows until
+// GroupEnd.
+func (c *Console) Group(call sobek.FunctionCall) sobek.Value {
+	label := ""
+	if len(call.Arguments) > 0 {
+		label = c.format(call.Arguments)
+		c.emit(slog.LevelInfo, false, label)
+	}
+	c.groups = append(c.groups, label)
+	return sobek.Undefined()
+}
+
+func (c *Console) GroupEnd(call sobek.FunctionCall) sobek.Value {
+	if len(c.groups) > 0 {
+		c.groups = c.groups[:len(c.groups)-1]
+	}
+	return sobek.Undefined()
+}
+
+// Count writes how many times it was called with the label.
+func (c *Console) Count(call sobek.FunctionCall) sobek.Value {
+	label := consoleLabel(call.Argument(0))
+	c.counts[label]++
+	c.emit(slog.LevelInfo, false, fmt.Sprintf("%s: %d", label, c.counts[label]))
+	return sobek.Undefined()
+}
+
+func (c *Console) CountReset(call sobek.FunctionCall) sobek.Value {
+	label := consoleLabel(call.Argument(0))
+	if _, ok := c.counts[label]; !ok {
+		c.emit(slog.LevelWarn, true, fmt.Sprintf("Warning: Count for '%s' does not exist", label))
+	}
+	delete(c.counts, label)
+	return sobek.Undefined()
+}
+
+// Time starts a timer for the label, read by TimeLog and TimeEnd.
+func (c *Console) Time(call sobek.FunctionCall) sobek.Value {
+	label := consoleLabel(call.Argument(0))
+	if _, ok := c.timers[label]; ok {
+		c.emit(slog.LevelWarn, true, fmt.Sprintf("Warning: Label '%s' already exists for console.time()", label))
+		return sobek.Undefined()
+	}
+	c.timers[label] = time.Now()
+	return sobek.Undefined()
+}
+
+func (c *Console) TimeLog(call sobek.FunctionCall) sobek.Value {
+	c.timeLog(call, "console.timeLog()", false)
+	return sobek.Undefined()
+}
+
+func (c *Console) TimeEnd(call sobek.FunctionCall) sobek.Value {
+	c.timeLog(call, "console.timeEnd()", true)
+	return sobek.Undefined()
+}
+
+// timeLog writes the time elapsed on the label's timer and any further
+// arguments, stopping the timer if end.
+func (c *Console) timeLog(call sobek.FunctionCall, method string, end bool) {
+	label := consoleLabel(call.Argument(0))
+	start, ok := c.timers[label]
+	if !ok {
+		c.emit(slog.LevelWarn, true, fmt.Sprintf("Warning: No such label '%s' for %s", label, method))
+		return
+	}
+	if end {
+		delete(c.timers, label)
 	}
-	fmt.Print("Error: ")
-	fmt.Println(args...)
+	msg := label + ": " + formatDuration(time.Since(start))
+	if !end && len(call.Arguments) > 1 {
+		msg += " " + c.format(call.Arguments[1:])
+	}
+	c.emit(slog.LevelInfo, false, msg)
+}
+
+// Assert writes "Assertion failed" and its other arguments when value is
+// falsy.
+func (c *Console) Assert(call sobek.FunctionCall) sobek.Value {
+	if call.Argument(0).ToBoolean() {
+		return sobek.Undefined()
+	}
+	msg := "Assertion failed"
+	if data := call.Arguments[min(1, len(call.Arguments)):]; len(data) > 0 {
+		msg += ": " + c.format(data)
+	}
+	c.emit(slog.LevelError, true, msg)
 	return sobek.Undefined()
 }
 
-func RegisterConsole(vm *sobek.Runtime) {
-	c := &Console{}
+// Table writes the properties of data as a table, one row per property,
+// limited to the columns in properties if given.
+func (c *Console) Table(call sobek.FunctionCall) sobek.Value {
+	data, ok := call.Argument(0).(*sobek.Object)
+	if !ok {
+		return c.Log(call)
+	}
+	var only []string
+	if props, ok := call.Argument(1).(*sobek.Object); ok {
+		_ = c.vm.ExportTo(props, &only)
+	}
+
+	header := []string{"(index)"}
+	columns := map[string]int{}
+	hasValues := false
+	var rows [][]string
+	var values []string
+	for _, key := range data.Keys() {
+		row := map[int]string{}
+		if obj, ok := data.Get(key).(*sobek.Object); ok {
+			if _, isFn := sobek.AssertFunction(obj); !isFn {
+				keys := obj.Keys()
+				if only != nil {
+					keys = only
+				}
+				for _, k := range keys {
+					v := obj.Get(k)
+					if v == nil {
+						continue
+					}
+					col, ok := columns[k]
+					if !ok {
+						col = len(header)
+						columns[k] = col
+						header = append(header, k)
+					}
+					row[col] = Inspect(c.vm, v, 0)
+				}
+				rows = append(rows, tableRow(key, row))
+				values = append(values, "")
+				continue
+			}
+		}
+		hasValues = true
+		rows = append(rows, tableRow(key, row))
+		values = append(values, Inspect(c.vm, data.Get(key), 0))
+	}
+	for _, k := range only {
+		if _, ok := columns[k]; !ok {
+			columns[k] = len(header)
+			header = append(header, k)
+		}
+	}
+	if hasValues {
+		header = append(header, "Values")
+	}
+	for i := range rows {
+		row := make([]string, len(header))
+		copy(row, rows[i])
+		if hasValues {
+			row[len(row)-1] = values[i]
+		}
+		rows[i] = row
+	}
+	c.emit(slog.LevelInfo, false, renderTable(header, rows))
+	return sobek.Undefined()
+}
+
+// tableRow returns the cells of a row indexed by column, starting with the
+// index.
+func tableRow(index string, cells map[int]string) []string {
+	n := 1
+	for col := range cells {
+		n = max(n, col+1)
+	}
+	row := make([]string, n)
+	row[0] = index
+	for col, s := range cells {
+		row[col] = s
+	}
+	return row
+}
+
+// renderTable draws header and rows with box-drawing characters.
+func renderTable(header []string, rows [][]string) string {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h) + 2
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell)+2)
+		}
+	}
+	line := func(left, mid, right string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w)
+		}
+		return left + strings.Join(parts, mid) + right
+	}
+	renderRow := func(cells []string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			parts[i] = " " + cell + strings.Repeat(" ", w-1-utf8.RuneCountInString(cell))
+		}
+		return "│" + strings.Join(parts, "│") + "│"
+	}
+
+	lines := []string{line("┌", "┬", "┐"), renderRow(header), line("├", "┼", "┤")}
+	for _, row := range rows {
+		lines = append(lines, renderRow(row))
+	}
+	lines = append(lines, line("└", "┴", "┘"))
+	return strings.Join(lines, "\n")
+}
+
+// format renders console arguments as Node's util.format does: a first
+// string argument may hold %s, %d, %i, %f, %j, %o, %O, %c and %%
+// directives, and the remaining arguments are appended, strings as they
+// are and other values inspected.
+func (c *Console) format(args []sobek.Value) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	rest := args
+	if s, ok := args[0].(sobek.String); ok && strings.Contains(s.String(), "%") {
+		f := s.String()
+		rest = args[1:]
+		for i := 0; i < len(f); i++ {
+			if f[i] != '%' || i+1 == len(f) {
+				b.WriteByte(f[i])
+				continue
+			}
+			verb := f[i+1]
+			if verb == '%' {
+				b.WriteByte('%')
+				i++
+				continue
+			}
+			if !strings.ContainsRune("sdifjoOc", rune(verb)) || len(rest) == 0 {
+				b.WriteByte('%')
+				continue
+			}
+			b.WriteString(c.directive(verb, rest[0]))
+			rest = rest[1:]
+			i++
+		}
+	} else {
+		b.WriteString(c.formatArg(args[0]))
+		rest = args[1:]
+	}
+	for _, arg := range rest {
+		b.WriteByte(' ')
+		b.WriteString(c.formatArg(arg))
+	}
+	return b.String()
+}
+
+// formatArg renders an argument not consumed by a directive: strings as
+// they are, other values inspected.
+func (c *Console) formatArg(v sobek.Value) string {
+	if s, ok := v.(sobek.String); ok {
+		return s.String()
+	}
+	return Inspect(c.vm, v, 2)
+}
+
+// directive renders v for a format directive.
+func (c *Console) directive(verb byte, v sobek.Value) string {
+	switch verb {
+	case 's':
+		if _, ok := v.(*sobek.Object); ok {
+			return Inspect(c.vm, v, 1)
+		}
+		return c.formatArg(v)
+	case 'd', 'i', 'f':
+		if n, ok := v.Export().(*big.Int); ok && verb != 'f' {
+			return n.String() + "n"
+		}
+		if _, ok := v.(*sobek.Object); ok {
+			return "NaN"
+		}
+		if _, ok := v.(*sobek.Symbol); ok {
+			return "NaN"
+		}
+		n := v.ToFloat()
+		if verb == 'i' && !math.IsNaN(n) && !math.IsInf(n, 0) {
+			n = math.Trunc(n)
+		}
+		return Inspect(c.vm, c.vm.ToValue(n), 0)
+	case 'j':
+		stringify, _ := sobek.AssertFunction(c.vm.Get("JSON").ToObject(c.vm).Get("stringify"))
+		s, err := stringify(sobek.Undefined(), v)
+		if err != nil {
+			return "[Circular]"
+		}
+		if sobek.IsUndefined(s) {
+			return "undefined"
+		}
+		return s.String()
+	case 'o':
+		return Inspect(c.vm, v, 4)
+	case 'O':
+		return Inspect(c.vm, v, 2)
+	default: // %c styles are ignored
+		return ""
+	}
+}
+
+// inspectDepth returns the depth option of console.dir, -1 for all levels.
+func inspectDepth(v sobek.Value) int {
+	if sobek.IsNull(v) {
+		return -1
+	}
+	d := v.ToFloat()
+	if math.IsInf(d, 1) {
+		return -1
+	}
+	return int(d)
+}
+
+// consoleLabel returns the label argument of count and time.
+func consoleLabel(v sobek.Value) string {
+	if sobek.IsUndefined(v) {
+		return "default"
+	}
+	return v.String()
+}
+
+// formatDuration renders d as Node's console.timeEnd does.
+func formatDuration(d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+	if ms >= 1000 {
+		return strconv.FormatFloat(ms/1000, 'f', 3, 64) + "s"
+	}
+	return strconv.FormatFloat(ms, 'f', 3, 64) + "ms"
+}
+
+// RegisterConsole installs the console global, writing to os.Stdout and
+// os.Stderr until redirected.
+func RegisterConsole(vm *sobek.Runtime) *Console {
+	c := &Console{
+		vm:     vm,
+		sink:   &consoleSink{out: os.Stdout, err: os.Stderr},
+		counts: map[string]int{},
+		timers: map[string]time.Time{},
+	}
 	obj := vm.NewObject()
 	_ = obj.Set("log", c.Log)
+	_ = obj.Set("info", c.Log)
+	_ = obj.Set("debug", c.Debug)
+	_ = obj.Set("warn", c.Warn)
 	_ = obj.Set("error", c.Error)
+	_ = obj.Set("trace", c.Trace)
+	_ = obj.Set("dir", c.Dir)
+	_ = obj.Set("dirxml", c.Log)
+	_ = obj.Set("table", c.Table)
+	_ = obj.Set("group", c.Group)
+	_ = obj.Set("groupCollapsed", c.Group)
+	_ = obj.Set("groupEnd", c.GroupEnd)
+	_ = obj.Set("count", c.Count)
+	_ = obj.Set("countReset", c.CountReset)
+	_ = obj.Set("time", c.Time)
+	_ = obj.Set("timeLog", c.TimeLog)
+	_ = obj.Set("timeEnd", c.TimeEnd)
+	_ = obj.Set("assert", c.Assert)
 	_ = vm.Set("console", obj)
+	return c
 }
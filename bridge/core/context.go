@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/grafana/sobek"
+)
+
+// ContextObject exposes ctx to JS with Go's method names. Done() is a channel
+// usable in select, and the object converts back to ctx wherever Go expects
+// a context.Context.
+func ContextObject(vm *sobek.Runtime, ctx context.Context, opts ...BindOption) *sobek.Object {
+	return contextObject(vm, ctx, applyBindOptions(opts))
+}
+
+func contextObject(vm *sobek.Runtime, ctx context.Context, opts *bindOptions) *sobek.Object {
+	obj := vm.NewObject()
+	_ = obj.DefineDataProperty("__ctx", vm.ToValue(ctxHolder{ctx}), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+
+	var done *Channel
+	_ = obj.Set("Done", func(sobek.FunctionCall) sobek.Value {
+		if done == nil {
+			done = newChannel(vm, reflect.ValueOf(ctx.Done()), opts)
+		}
+		return done.Object()
+	})
+	_ = obj.Set("Err", func(sobek.FunctionCall) sobek.Value {
+		if err := ctx.Err(); err != nil {
+			return NewGoError(vm, err)
+		}
+		return sobek.Null()
+	})
+	_ = obj.Set("Deadline", func(sobek.FunctionCall) sobek.Value {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return sobek.Null()
+		}
+		val, err := timeToDate(vm, deadline)
+		if err != nil {
+			return sobek.Null()
+		}
+		return val
+	})
+	return obj
+}
+
+// ctxHolder keeps sobek from wrapping the context with its own reflection.
+type ctxHolder struct {
+	ctx context.Context
+}
+
+// ContextOf returns the Go context behind a context object.
+func ContextOf(v sobek.Value) (context.Context, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	tag := obj.Get("__ctx")
+	if tag == nil {
+		return nil, false
+	}
+	h, ok := tag.Export().(ctxHolder)
+	return h.ctx, ok
+}
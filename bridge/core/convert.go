@@ -0,0 +1,473 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+
+	// Export() of a JS Map yields its entries as key/value pairs.
+	jsMapExportType = reflect.TypeOf([][2]interface{}{})
+)
+
+type jsonTag struct {
+	Name      string
+	OmitEmpty bool
+	Skip      bool
+}
+
+func parseJSONTag(f reflect.StructField) jsonTag {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return jsonTag{Skip: true}
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	return jsonTag{Name: name, OmitEmpty: strings.Contains(opts, "omitempty")}
+}
+
+// isStringableKind reports whether map keys of kind k round-trip through JS
+// property names.
+func isStringableKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isScalarKind(k reflect.Kind) bool {
+	return k != reflect.String && isStringableKind(k)
+}
+
+// Durations cross the bridge in milliseconds, matching setTimeout and Date.
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// convertJSToGo converts a JS value into a Go value of goType, recursing into
+// structs, slices, arrays, maps and pointers.
+func convertJSToGo(vm *sobek.Runtime, jsVal sobek.Value, goType reflect.Type, opts *bindOptions) (reflect.Value, error) {
+	if goType.Kind() == reflect.Func {
+		callable, ok := sobek.AssertFunction(jsVal)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected function, got %T", jsVal.Export())
+		}
+		return wrapJSCallback(vm, callable, goType, opts), nil
+	}
+
+	if jsVal == nil || sobek.IsUndefined(jsVal) || sobek.IsNull(jsVal) {
+		return reflect.Zero(goType), nil
+	}
+
+	switch goType {
+	case timeType:
+		return convertToTime(jsVal)
+	case durationType:
+		return convertToDuration(jsVal)
+	case errorType:
+		err := toError(jsVal)
+		return reflect.ValueOf(&err).Elem(), nil
+	case contextType:
+		ctx, ok := ContextOf(jsVal)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected context, got %T", jsVal.Export())
+		}
+		return reflect.ValueOf(&ctx).Elem(), nil
+	}
+
+	if goType.Kind() == reflect.Chan {
+		return convertToChan(jsVal, goType)
+	}
+
+	// GoSlices pass their slice itself
+	if s, ok := SliceOf(jsVal); ok && s.v.Type().AssignableTo(goType) {
+		return s.v, nil
+	}
+
+	// Refs and live structs pass their address, or the variable for T
+	if ptr, ok := pointerOf(jsVal); ok {
+		switch {
+		case ptr.Type().AssignableTo(goType):
+			return ptr, nil
+		case ptr.Elem().Type().AssignableTo(goType):
+			return ptr.Elem(), nil
+		}
+	}
+
+	exported := jsVal.Export()
+	if exported == nil {
+		return reflect.Zero(goType), nil
+	}
+
+	goVal := reflect.ValueOf(exported)
+
+	if goVal.Type().AssignableTo(goType) {
+		return goVal, nil
+	}
+
+	// Bound Go values come back as pointers even when the parameter is a value
+	if goVal.Kind() == reflect.Ptr && !goVal.IsNil() && goVal.Elem().Type().AssignableTo(goType) {
+		return goVal.Elem(), nil
+	}
+
+	obj, isObj := jsVal.(*sobek.Object)
+
+	switch goType.Kind() {
+	case reflect.Ptr:
+		elem, err := convertJSToGo(vm, jsVal, goType.Elem(), opts)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(goType.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case reflect.Struct:
+		if isObj {
+			return convertObjectToStruct(vm, obj, goType, opts)
+		}
+	case reflect.Slice:
+		if goType.Elem().Kind() == reflect.Uint8 {
+			if b, ok := exportBytes(jsVal); ok {
+				return reflect.ValueOf(b).Convert(goType), nil
+			}
+		}
+		if isObj {
+			return convertToSlice(vm, obj, goType, opts)
+		}
+	case reflect.Array:
+		if isObj {
+			return convertToArray(vm, obj, goType, opts)
+		}
+	case reflect.Map:
+		if isObj {
+			return convertToMap(vm, obj, goType, opts)
+		}
+	case reflect.Interface:
+		if isObj && goType.NumMethod() > 0 {
+			return adaptInterface(vm, obj, goType, opts)
+		}
+	}
+
+	// Numeric conversion only; Convert would turn numbers into runes for
+	// strings, and panics between bools and numbers
+	if isScalarKind(goType.Kind()) && isScalarKind(goVal.Kind()) && goVal.Type().ConvertibleTo(goType) {
+		return goVal.Convert(goType), nil
+	}
+	if goType.Kind() == reflect.String && goVal.Kind() == reflect.String {
+		return goVal.Convert(goType), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("expected %s, got %T", goType, exported)
+}
+
+// pointerOf returns the address behind a ref or a live struct.
+func pointerOf(v sobek.Value) (reflect.Value, bool) {
+	if r, ok := RefOf(v); ok {
+		return r.ptr, true
+	}
+	if obj, ok := v.(*sobek.Object); ok {
+		if ls, ok := obj.Export().(*liveStruct); ok {
+			return ls.v.Addr(), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// convertToChan unwraps a channel object. Typed channels convert to chan T
+// and its directional forms; untyped ones only to chan sobek.Value.
+func convertToChan(jsVal sobek.Value, goType reflect.Type) (reflect.Value, error) {
+	c, ok := ChannelOf(jsVal)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("expected channel, got %T", jsVal.Export())
+	}
+	ch := c.Chan()
+	if !ch.Type().AssignableTo(goType) {
+		return reflect.Value{}, fmt.Errorf("expected %s, got %s; create it with makeChan(size, %q)", goType, ch.Type(), goType.Elem().String())
+	}
+	return ch.Convert(goType), nil
+}
+
+func convertToTime(jsVal sobek.Value) (reflect.Value, error) {
+	switch v := jsVal.Export().(type) {
+	case time.Time:
+		return reflect.ValueOf(v), nil
+	case int64:
+		return reflect.ValueOf(time.UnixMilli(v)), nil
+	case float64:
+		return reflect.ValueOf(time.UnixMilli(int64(v))), nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("expected Date, got %T", jsVal.Export())
+}
+
+func convertToDuration(jsVal sobek.Value) (reflect.Value, error) {
+	switch v := jsVal.Export().(type) {
+	case int64:
+		return reflect.ValueOf(time.Duration(v) * time.Millisecond), nil
+	case float64:
+		return reflect.ValueOf(time.Duration(v * float64(time.Millisecond))), nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(d), nil
+	case time.Duration:
+		return reflect.ValueOf(v), nil
+	}
+	return reflect.Value{}, fmt.Errorf("expected duration in ms, got %T", jsVal.Export())
+}
+
+// exportBytes returns the backing bytes of typed arrays and ArrayBuffers
+// without copying, and the UTF-8 bytes of strings.
+func exportBytes(jsVal sobek.Value) ([]byte, bool) {
+	switch v := jsVal.Export().(type) {
+	case []byte:
+		return v, true
+	case sobek.ArrayBuffer:
+		return v.Bytes(), true
+	case string:
+		return []byte(v), true
+	}
+	return nil, false
+}
+
+func convertToSlice(vm *sobek.Runtime, obj *sobek.Object, goType reflect.Type, opts *bindOptions) (reflect.Value, error) {
+	elems, err := collectElements(vm, obj)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	out := reflect.MakeSlice(goType, len(elems), len(elems))
+	for i, el := range elems {
+		v, err := convertJSToGo(vm, el, goType.Elem(), opts)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("index %d: %w", i, err)
+		}
+		out.Index(i).Set(v)
+	}
+	return out, nil
+}
+
+func convertToArray(vm *sobek.Runtime, obj *sobek.Object, goType reflect.Type, opts *bindOptions) (reflect.Value, error) {
+	elems, err := collectElements(vm, obj)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if len(elems) != goType.Len() {
+		return reflect.Value{}, fmt.Errorf("expected %d elements, got %d", goType.Len(), len(elems))
+	}
+	out := reflect.New(goType).Elem()
+	for i, el := range elems {
+		v, err := convertJSToGo(vm, el, goType.Elem(), opts)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("index %d: %w", i, err)
+		}
+		out.Index(i).Set(v)
+	}
+	return out, nil
+}
+
+// collectElements reads array-likes by index and falls back to the iterator
+// protocol for Sets and other iterables.
+func collectElements(vm *sobek.Runtime, obj *sobek.Object) ([]sobek.Value, error) {
+	if l := obj.Get("length"); l != nil && !sobek.IsUndefined(l) {
+		n := int(l.ToInteger())
+		elems := make([]sobek.Value, n)
+		for i := 0; i < n; i++ {
+			elems[i] = obj.Get(strconv.Itoa(i))
+		}
+		return elems, nil
+	}
+
+	if it := obj.GetSymbol(sobek.SymIterator); it == nil || sobek.IsUndefined(it) {
+		return nil, fmt.Errorf("expected array or iterable, got %s", obj.ClassName())
+	}
+
+	var elems []sobek.Value
+	vm.ForOf(obj, func(v sobek.Value) bool {
+		elems = append(elems, v)
+		return true
+	})
+	return elems, nil
+}
+
+func convertToMap(vm *sobek.Runtime, obj *sobek.Object, goType reflect.Type, opts *bindOptions) (reflect.Value, error) {
+	out := reflect.MakeMap(goType)
+	keyType, elemType := goType.Key(), goType.Elem()
+
+	// JS Map: keys keep their identity, so struct keys are converted as values
+	if obj.ExportType() == jsMapExportType {
+		var convErr error
+		vm.ForOf(obj, func(entry sobek.Value) bool {
+			pair := entry.ToObject(vm)
+			k, err := convertJSToGo(vm, pair.Get("0"), keyType, opts)
+			if err != nil {
+				convErr = fmt.Errorf("key: %w", err)
+				return false
+			}
+			v, err := convertJSToGo(vm, pair.Get("1"), elemType, opts)
+			if err != nil {
+				convErr = fmt.Errorf("key %v: %w", k.Interface(), err)
+				return false
+			}
+			out.SetMapIndex(k, v)
+			return true
+		})
+		if convErr != nil {
+			return reflect.Value{}, convErr
+		}
+		return out, nil
+	}
+
+	for _, key := range obj.Keys() {
+		k, err := parseMapKey(key, keyType)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v, err := convertJSToGo(vm, obj.Get(key), elemType, opts)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %q: %w", key, err)
+		}
+		out.SetMapIndex(k, v)
+	}
+	return out, nil
+}
+
+// parseMapKey turns a JS property name back into a Go map key.
+func parseMapKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	k := reflect.New(keyType).Elem()
+	switch keyType.Kind() {
+	case reflect.String:
+		k.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %q: %w", key, err)
+		}
+		k.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %q: %w", key, err)
+		}
+		k.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(key, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %q: %w", key, err)
+		}
+		k.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %q: %w", key, err)
+		}
+		k.SetBool(b)
+	case reflect.Interface:
+		k.Set(reflect.ValueOf(key))
+	default:
+		return reflect.Value{}, fmt.Errorf("map key %s requires a JS Map", keyType)
+	}
+	return k, nil
+}
+
+// convertObjectToStruct builds a Go struct of type t from a JS object.
+// Properties are matched by decorator metadata, then json tag, then Go field
+// name; embedded structs are filled from the same flattened object.
+func convertObjectToStruct(vm *sobek.Runtime, obj *sobek.Object, t reflect.Type, opts *bindOptions) (reflect.Value, error) {
+	meta := classMetaOf(vm, obj)
+	out := reflect.New(t).Elem()
+	if err := fillStruct(vm, obj, out, meta, opts); err != nil {
+		return reflect.Value{}, err
+	}
+	return out, nil
+}
+
+func fillStruct(vm *sobek.Runtime, obj *sobek.Object, out reflect.Value, meta *classMeta, opts *bindOptions) error {
+	t := out.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := parseJSONTag(f)
+		if tag.Skip {
+			continue
+		}
+
+		fm, decorated := meta.lookup(f)
+		key := f.Name
+		switch {
+		case decorated:
+			key = fm.Key
+		case tag.Name != "" && hasProperty(obj, tag.Name):
+			key = tag.Name
+		}
+
+		jsVal := obj.Get(key)
+		if jsVal == nil || sobek.IsUndefined(jsVal) {
+			if f.Anonymous {
+				if err := fillEmbedded(vm, obj, out.Field(i), meta, opts); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		target := f.Type
+		if decorated && f.Type.Kind() == reflect.Interface && fm.GoType != "" {
+			if bt, ok := basicGoTypes[fm.GoType]; ok {
+				target = bt
+			}
+		}
+
+		goVal, err := convertJSToGo(vm, jsVal, target, opts)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		if decorated && fm.Pointer && f.Type.Kind() == reflect.Interface {
+			ptr := reflect.New(goVal.Type())
+			ptr.Elem().Set(goVal)
+			goVal = ptr
+		}
+		out.Field(i).Set(goVal)
+	}
+	return nil
+}
+
+// fillEmbedded mirrors the flattening done by bindStructFields.
+func fillEmbedded(vm *sobek.Runtime, obj *sobek.Object, field reflect.Value, meta *classMeta, opts *bindOptions) error {
+	switch {
+	case field.Kind() == reflect.Struct:
+		return fillStruct(vm, obj, field, meta, opts)
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct:
+		ptr := reflect.New(field.Type().Elem())
+		if err := fillStruct(vm, obj, ptr.Elem(), meta, opts); err != nil {
+			return err
+		}
+		field.Set(ptr)
+	}
+	return nil
+}
+
+func hasProperty(obj *sobek.Object, key string) bool {
+	v := obj.Get(key)
+	return v != nil && !sobek.IsUndefined(v)
+}
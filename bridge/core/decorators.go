@@ -0,0 +1,206 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/sobek"
+)
+
+// Decorator metadata lives on the class constructor so it survives bundling and
+// is shared by every instance; the class registry lets the bridge pick the TS
+// prototype when a Go struct crosses back into JS.
+const (
+	metaKey     = "__typego_meta__"
+	classesKey  = "__typego_classes__"
+	decoratorJS = `
+(function() {
+	var classes = {};
+	Object.defineProperty(globalThis, "` + classesKey + `", { value: classes, enumerable: false });
+
+	function meta(ctor) {
+		if (!Object.prototype.hasOwnProperty.call(ctor, "` + metaKey + `")) {
+			Object.defineProperty(ctor, "` + metaKey + `", { value: { goType: "", fields: {} }, enumerable: false });
+		}
+		return ctor["` + metaKey + `"];
+	}
+
+	function field(m, key) {
+		return m.fields[key] || (m.fields[key] = { goType: "", pointer: false, jsonName: "" });
+	}
+
+	function decorate(target, key, apply) {
+		var ctor = typeof target === "function" ? target : target.constructor;
+		apply(meta(ctor), key === undefined ? undefined : String(key), ctor);
+	}
+
+	globalThis.GoType = function(name) {
+		return function(target, key) {
+			decorate(target, key, function(m, k, ctor) {
+				if (k === undefined) {
+					m.goType = name;
+					classes[name] = ctor;
+				} else {
+					field(m, k).goType = name;
+				}
+			});
+		};
+	};
+
+	globalThis.Pointer = function(target, key) {
+		decorate(target, key, function(m, k) {
+			if (k !== undefined) field(m, k).pointer = true;
+		});
+	};
+
+	globalThis.JSONName = function(name) {
+		return function(target, key) {
+			decorate(target, key, function(m, k) {
+				if (k !== undefined) field(m, k).jsonName = name;
+			});
+		};
+	};
+})();
+`
+)
+
+type fieldMeta struct {
+	Key      string
+	GoType   string
+	Pointer  bool
+	JSONName string
+}
+
+type classMeta struct {
+	GoType string
+	Fields []fieldMeta
+}
+
+// RegisterDecorators installs the @GoType, @Pointer and @JSONName decorators.
+func RegisterDecorators(vm *sobek.Runtime) {
+	_, _ = vm.RunString(decoratorJS)
+}
+
+// classMetaOf reads decorator metadata from the constructor of obj, if any.
+func classMetaOf(vm *sobek.Runtime, obj *sobek.Object) *classMeta {
+	ctor, ok := obj.Get("constructor").(*sobek.Object)
+	if !ok {
+		return nil
+	}
+	return readClassMeta(vm, ctor)
+}
+
+func readClassMeta(vm *sobek.Runtime, ctor *sobek.Object) *classMeta {
+	raw, ok := ctor.Get(metaKey).(*sobek.Object)
+	if !ok {
+		return nil
+	}
+
+	m := &classMeta{GoType: raw.Get("goType").String()}
+	fields, ok := raw.Get("fields").(*sobek.Object)
+	if !ok {
+		return m
+	}
+	for _, key := range fields.Keys() {
+		f, ok := fields.Get(key).(*sobek.Object)
+		if !ok {
+			continue
+		}
+		m.Fields = append(m.Fields, fieldMeta{
+			Key:      key,
+			GoType:   f.Get("goType").String(),
+			Pointer:  f.Get("pointer").ToBoolean(),
+			JSONName: f.Get("jsonName").String(),
+		})
+	}
+	return m
+}
+
+// registeredClass returns the constructor decorated with @GoType for t.
+// Both the qualified ("main.User") and bare ("User") names are accepted.
+func registeredClass(vm *sobek.Runtime, t reflect.Type) *sobek.Object {
+	classes, ok := vm.GlobalObject().Get(classesKey).(*sobek.Object)
+	if !ok {
+		return nil
+	}
+	for _, name := range []string{t.String(), t.Name()} {
+		if name == "" {
+			continue
+		}
+		if ctor, ok := classes.Get(name).(*sobek.Object); ok {
+			return ctor
+		}
+	}
+	return nil
+}
+
+// lookup finds the decorated JS property that maps onto the Go field f.
+// A @JSONName wins over a property named after the Go field.
+func (m *classMeta) lookup(f reflect.StructField) (fieldMeta, bool) {
+	if m == nil {
+		return fieldMeta{}, false
+	}
+	tag := parseJSONTag(f).Name
+	for _, fm := range m.Fields {
+		if fm.JSONName != "" && (fm.JSONName == f.Name || fm.JSONName == tag) {
+			return fm, true
+		}
+	}
+	for _, fm := range m.Fields {
+		if fm.JSONName == "" && (fm.Key == f.Name || (tag != "" && fm.Key == tag)) {
+			return fm, true
+		}
+	}
+	return fieldMeta{}, false
+}
+
+// basicGoTypes resolves @GoType names used on fields whose Go type is an interface.
+var basicGoTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"string":  reflect.TypeOf(""),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+	"[]byte":  reflect.TypeOf([]byte(nil)),
+}
+
+// LookupGoType resolves a Go type name as written in TS, e.g. "int",
+// "[]string" or "map[string]any", or a name given to RegisterGoType. "any"
+// is interface{}.
+func LookupGoType(name string) (reflect.Type, bool) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "any" || name == "interface{}":
+		return reflect.TypeOf((*interface{})(nil)).Elem(), true
+	case name == "error":
+		return errorType, true
+	case strings.HasPrefix(name, "[]"):
+		elem, ok := LookupGoType(name[2:])
+		if !ok {
+			return nil, false
+		}
+		return reflect.SliceOf(elem), true
+	case strings.HasPrefix(name, "map[string]"):
+		elem, ok := LookupGoType(strings.TrimPrefix(name, "map[string]"))
+		if !ok {
+			return nil, false
+		}
+		return reflect.MapOf(reflect.TypeOf(""), elem), true
+	}
+	if t, ok := basicGoTypes[name]; ok {
+		return t, true
+	}
+	if t, ok := goTypes.Load(name); ok {
+		return t.(reflect.Type), true
+	}
+	return nil, false
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/sobek"
+)
+
+// Every error thrown by the bridge is an instance of sobek's GoError class,
+// which keeps the original Go error in its "value" property. The accessors
+// installed here read that value lazily, so errors raised by sobek's own
+// reflection (hyperlinked functions) gain the same shape as ours for free.
+const goErrorJS = `
+(function(goType, chain, unwrap, is) {
+	var proto = GoError.prototype;
+	Object.defineProperty(proto, "goType", { get: function() { return goType(this); }, configurable: true });
+	Object.defineProperty(proto, "chain", { get: function() { return chain(this); }, configurable: true });
+	Object.defineProperty(proto, "unwrap", { value: function() { return unwrap(this); }, configurable: true, writable: true });
+	Object.defineProperty(proto, "is", { value: function(target) { return is(this, target); }, configurable: true, writable: true });
+})
+`
+
+// RegisterErrors extends the global GoError class with goType, chain,
+// unwrap() and is(target).
+func RegisterErrors(vm *sobek.Runtime) {
+	install, err := vm.RunString(goErrorJS)
+	if err != nil {
+		return
+	}
+	fn, ok := sobek.AssertFunction(install)
+	if !ok {
+		return
+	}
+
+	goType := func(call sobek.FunctionCall) sobek.Value {
+		if err, ok := GoErrorOf(call.Argument(0)); ok {
+			return vm.ToValue(ErrorTypeName(err))
+		}
+		return sobek.Undefined()
+	}
+	chain := func(call sobek.FunctionCall) sobek.Value {
+		err, ok := GoErrorOf(call.Argument(0))
+		if !ok {
+			return vm.NewArray()
+		}
+		var links []interface{}
+		for _, e := range ErrorChain(err) {
+			links = append(links, map[string]interface{}{
+				"type":    ErrorTypeName(e),
+				"message": e.Error(),
+			})
+		}
+		return vm.ToValue(links)
+	}
+	unwrap := func(call sobek.FunctionCall) sobek.Value {
+		err, ok := GoErrorOf(call.Argument(0))
+		if !ok {
+			return sobek.Null()
+		}
+		if inner := errors.Unwrap(err); inner != nil {
+			return NewGoError(vm, inner)
+		}
+		return sobek.Null()
+	}
+	is := func(call sobek.FunctionCall) sobek.Value {
+		err, ok := GoErrorOf(call.Argument(0))
+		if !ok {
+			return vm.ToValue(false)
+		}
+		target, ok := GoErrorOf(call.Argument(1))
+		return vm.ToValue(ok && errors.Is(err, target))
+	}
+
+	_, _ = fn(sobek.Undefined(), vm.ToValue(goType), vm.ToValue(chain), vm.ToValue(unwrap), vm.ToValue(is))
+}
+
+// NewGoError converts err into a GoError instance. Exported fields of struct
+// errors (e.g. Op and Path of *fs.PathError) are copied onto the object so
+// scripts can inspect them without a round trip through errors.as.
+func NewGoError(vm *sobek.Runtime, err error) *sobek.Object {
+	obj := vm.NewGoError(err)
+
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return obj
+	}
+
+	t := v.Type()
+	for _, f := range structFields(t) {
+		if f.Anonymous || obj.Get(f.Key) != nil {
+			continue
+		}
+		fv := v.Field(f.Index)
+		if fv.Type() == errorType {
+			if fv.IsNil() {
+				_ = obj.Set(f.Key, sobek.Null())
+			} else {
+				_ = obj.Set(f.Key, NewGoError(vm, fv.Interface().(error)))
+			}
+			continue
+		}
+		val, bindErr := bindValue(vm, fv, newBindCtx(nil))
+		if bindErr != nil {
+			continue
+		}
+		_ = obj.Set(f.Key, val)
+	}
+	return obj
+}
+
+// GoErrorOf returns the Go error carried by v: the "value" of a GoError, or
+// a bound Go error value such as an exported sentinel.
+func GoErrorOf(v sobek.Value) (error, bool) {
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil, false
+	}
+	if err, ok := v.Export().(error); ok {
+		return err, true
+	}
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	inner := obj.Get("value")
+	if inner == nil {
+		return nil, false
+	}
+	err, ok := inner.Export().(error)
+	return err, ok
+}
+
+// ErrorTypeName returns the dynamic Go type of err as printed by %T,
+// e.g. "*fs.PathError".
+func ErrorTypeName(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// ErrorChain flattens the wrap tree of err depth-first, starting with err
+// itself. Errors joined with errors.Join contribute every branch.
+func ErrorChain(err error) []error {
+	var out []error
+	var walk func(error)
+	walk = func(e error) {
+		for e != nil {
+			out = append(out, e)
+			switch u := e.(type) {
+			case interface{ Unwrap() []error }:
+				for _, inner := range u.Unwrap() {
+					walk(inner)
+				}
+				return
+			case interface{ Unwrap() error }:
+				e = u.Unwrap()
+			default:
+				return
+			}
+		}
+	}
+	walk(err)
+	return out
+}
+
+// ErrorAs finds the first error in the chain of err whose type name matches
+// typeName. Both qualified ("*fs.PathError") and bare ("PathError") names match.
+func ErrorAs(err error, typeName string) (error, bool) {
+	for _, e := range ErrorChain(err) {
+		full := ErrorTypeName(e)
+		if full == typeName {
+			return e, true
+		}
+		t := reflect.TypeOf(e)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Name() == typeName {
+			return e, true
+		}
+	}
+	return nil, false
+}
@@ -1,8 +1,47 @@
 // TypeGo Type Definitions
 // Auto-generated by typego types
 
+/**
+ * Error thrown for a Go error crossing the bridge. `value` holds the original
+ * Go error, so identity checks against sentinels keep working.
+ */
+declare class GoError extends Error {
+    /** Dynamic Go type as printed by %T, e.g. "*fs.PathError". */
+    readonly goType: string;
+    /** The wrap chain, outermost first, as produced by errors.Unwrap. */
+    readonly chain: { type: string; message: string }[];
+    readonly value: unknown;
+    /** The next error in the chain, or null. */
+    unwrap(): GoError | null;
+    /** Reports whether target is in this error's chain (errors.Is). */
+    is(target: unknown): boolean;
+}
+
+/**
+ * Maps Go error type names to their shape, used by errors.as. Generated
+ * hyperlinked package types augment this interface.
+ */
+interface GoErrorTypes {}
+
 // Helper for handling Go errors in catch blocks
-declare function isGoError(e: unknown): e is Error;
+declare function isGoError(e: unknown): e is GoError;
+
+/**
+ * Maps a class or field onto a Go type. On a class, the name is the Go struct
+ * (e.g. "main.User") whose values are returned as instances of the class.
+ * On a field, it picks the Go type used when the target field is `any`.
+ */
+declare function GoType(name: string): (target: any, key?: string | symbol) => void;
+
+/**
+ * Marks a field as a Go pointer when the target Go field is `any`.
+ */
+declare function Pointer(target: any, key: string | symbol): void;
+
+/**
+ * Binds a field to the Go struct field with this `json` tag or field name.
+ */
+declare function JSONName(name: string): (target: any, key: string | symbol) => void;
 
 // TypeGo Namespaces
 declare namespace typego {
@@ -5,22 +5,14 @@ import (
 )
 
 func RegisterGlobals(vm *sobek.Runtime) {
+	// isGoError only reports errors that carry a Go error value; plain JS
+	// errors thrown by scripts are not Go errors even though they have a message.
 	_ = vm.Set("isGoError", func(call sobek.FunctionCall) sobek.Value {
-		arg := call.Argument(0)
-		if sobek.IsNull(arg) || sobek.IsUndefined(arg) {
-			return vm.ToValue(false)
-		}
-
-		obj := arg.ToObject(vm)
-		if obj == nil {
-			return vm.ToValue(false)
-		}
-
-		msg := obj.Get("message")
-		if msg != nil && !sobek.IsUndefined(msg) {
-			return vm.ToValue(true)
-		}
-
-		return vm.ToValue(false)
+		_, ok := GoErrorOf(call.Argument(0))
+		return vm.ToValue(ok)
 	})
+
+	RegisterErrors(vm)
+	RegisterChannels(vm)
+	RegisterDecorators(vm)
 }
@@ -0,0 +1,354 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/sobek"
+)
+
+// inspectBreakLength is the width beyond which inspected objects are split
+// across lines, as in Node's util.inspect.
+const inspectBreakLength = 80
+
+// inspectMaxItems is how many array, Map and Set entries are shown.
+const inspectMaxItems = 100
+
+var identifierKey = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// inspector renders values the way Node's util.inspect does, without
+// colors. depth is how many levels of nested objects are expanded; a
+// negative depth expands all of them.
+type inspector struct {
+	vm    *sobek.Runtime
+	depth int
+	seen  []*sobek.Object
+}
+
+// Inspect returns v as Node's util.inspect shows it, expanding depth levels
+// of nested objects (all of them if depth is negative).
+func Inspect(vm *sobek.Runtime, v sobek.Value, depth int) string {
+	in := &inspector{vm: vm, depth: depth}
+	return in.value(v, 0)
+}
+
+// value renders v at nesting level.
+func (in *inspector) value(v sobek.Value, level int) string {
+	if v == nil || sobek.IsUndefined(v) {
+		return "undefined"
+	}
+	if sobek.IsNull(v) {
+		return "null"
+	}
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return in.primitive(v)
+	}
+
+	for _, s := range in.seen {
+		if s == obj {
+			return "[Circular]"
+		}
+	}
+	in.seen = append(in.seen, obj)
+	defer func() { in.seen = in.seen[:len(in.seen)-1] }()
+
+	if _, ok := sobek.AssertFunction(obj); ok {
+		return in.function(obj)
+	}
+	switch obj.ClassName() {
+	case "Error":
+		if stack, ok := obj.Get("stack").Export().(string); ok && stack != "" {
+			return stack
+		}
+		return obj.Get("name").String() + ": " + obj.Get("message").String()
+	case "Date":
+		if iso, err := in.call(obj, "toISOString"); err == nil {
+			return iso.String()
+		}
+		return "Invalid Date"
+	case "RegExp":
+		return obj.String()
+	case "Array":
+		return in.array(obj, level)
+	}
+
+	switch x := obj.Export().(type) {
+	case *sobek.Promise:
+		return in.promise(x, level)
+	case sobek.ArrayBuffer:
+		return fmt.Sprintf("ArrayBuffer { byteLength: %d }", len(x.Bytes()))
+	}
+	if in.instanceOf(obj, "Map") {
+		return in.collection(obj, "Map", level, true)
+	}
+	if in.instanceOf(obj, "Set") {
+		return in.collection(obj, "Set", level, false)
+	}
+	if in.isView(obj) {
+		return in.typedArray(obj, level)
+	}
+	return in.object(obj, level)
+}
+
+func (in *inspector) primitive(v sobek.Value) string {
+	if sym, ok := v.(*sobek.Symbol); ok {
+		return "Symbol(" + sym.String() + ")"
+	}
+	switch x := v.Export().(type) {
+	case string:
+		return quote(x)
+	case float64:
+		if x == 0 && math.Signbit(x) {
+			return "-0"
+		}
+	case *big.Int:
+		return x.String() + "n"
+	}
+	return v.String()
+}
+
+func (in *inspector) function(obj *sobek.Object) string {
+	name := ""
+	if n := obj.Get("name"); n != nil && !sobek.IsUndefined(n) {
+		name = n.String()
+	}
+	if _, isClass := obj.Export().(func(sobek.ConstructorCall) *sobek.Object); isClass {
+		if name == "" {
+			return "[class (anonymous)]"
+		}
+		return "[class " + name + "]"
+	}
+	if name == "" {
+		return "[Function (anonymous)]"
+	}
+	return "[Function: " + name + "]"
+}
+
+func (in *inspector) promise(p *sobek.Promise, level int) string {
+	switch p.State() {
+	case sobek.PromiseStateFulfilled:
+		return "Promise { " + in.value(p.Result(), level+1) + " }"
+	case sobek.PromiseStateRejected:
+		return "Promise { <rejected> " + in.value(p.Result(), level+1) + " }"
+	default:
+		return "Promise { <pending> }"
+	}
+}
+
+func (in *inspector) array(obj *sobek.Object, level int) string {
+	if in.tooDeep(level) {
+		return "[Array]"
+	}
+	n := int(obj.Get("length").ToInteger())
+	entries := make([]string, 0, min(n, inspectMaxItems+1))
+	for i := 0; i < n && i < inspectMaxItems; i++ {
+		entries = append(entries, in.value(obj.Get(strconv.Itoa(i)), level+1))
+	}
+	if n > inspectMaxItems {
+		entries = append(entries, fmt.Sprintf("... %d more items", n-inspectMaxItems))
+	}
+	return in.join("", "[", "]", entries, level)
+}
+
+func (in *inspector) typedArray(obj *sobek.Object, level int) string {
+	name := in.constructorName(obj)
+	if name == "Buffer" {
+		data, _ := obj.Export().([]byte)
+		var b strings.Builder
+		b.WriteString("<Buffer")
+		for i, c := range data {
+			if i == 50 {
+				fmt.Fprintf(&b, " ... %d more bytes", len(data)-50)
+				break
+			}
+			fmt.Fprintf(&b, " %02x", c)
+		}
+		return b.String() + ">"
+	}
+	if name == "DataView" {
+		return fmt.Sprintf("DataView { byteLength: %d, byteOffset: %d }",
+			obj.Get("byteLength").ToInteger(), obj.Get("byteOffset").ToInteger())
+	}
+	if in.tooDeep(level) {
+		return "[" + name + "]"
+	}
+	n := int(obj.Get("length").ToInteger())
+	entries := make([]string, 0, min(n, inspectMaxItems+1))
+	for i := 0; i < n && i < inspectMaxItems; i++ {
+		entries = append(entries, in.value(obj.Get(strconv.Itoa(i)), level+1))
+	}
+	if n > inspectMaxItems {
+		entries = append(entries, fmt.Sprintf("... %d more items", n-inspectMaxItems))
+	}
+	return in.join(fmt.Sprintf("%s(%d) ", name, n), "[", "]", entries, level)
+}
+
+// collection renders a Map or Set from its entries.
+func (in *inspector) collection(obj *sobek.Object, name string, level int, pairs bool) string {
+	size := obj.Get("size").ToInteger()
+	prefix := fmt.Sprintf("%s(%d) ", name, size)
+	if in.tooDeep(level) {
+		return "[" + name + "]"
+	}
+	items, err := in.call(in.vm.Get("Array").ToObject(in.vm), "from", obj)
+	if err != nil {
+		return prefix + "{}"
+	}
+	list := items.ToObject(in.vm)
+	n := int(list.Get("length").ToInteger())
+	entries := make([]string, 0, min(n, inspectMaxItems+1))
+	for i := 0; i < n && i < inspectMaxItems; i++ {
+		item := list.Get(strconv.Itoa(i))
+		if pairs {
+			pair := item.ToObject(in.vm)
+			entries = append(entries, in.value(pair.Get("0"), level+1)+" => "+in.value(pair.Get("1"), level+1))
+		} else {
+			entries = append(entries, in.value(item, level+1))
+		}
+	}
+	if n > inspectMaxItems {
+		entries = append(entries, fmt.Sprintf("... %d more items", n-inspectMaxItems))
+	}
+	return in.join(prefix, "{", "}", entries, level)
+}
+
+// object renders the own enumerable properties of obj, prefixed with its
+// class: the constructor name of JS objects, the Go type of Go values.
+func (in *inspector) object(obj *sobek.Object, level int) string {
+	prefix := ""
+	if t := reflect.TypeOf(obj.Export()); t != nil && (t.Kind() == reflect.Struct || t.Kind() == reflect.Pointer) {
+		prefix = t.String()
+	} else if name := in.constructorName(obj); name != "Object" {
+		prefix = name
+		if name == "" {
+			prefix = "[Object: null prototype]"
+		}
+	}
+	keys := obj.Keys()
+	if len(keys) == 0 {
+		if prefix == "" {
+			return "{}"
+		}
+		return prefix + " {}"
+	}
+	if in.tooDeep(level) {
+		if prefix == "" {
+			return "[Object]"
+		}
+		return "[" + prefix + "]"
+	}
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		label := key
+		if !identifierKey.MatchString(key) {
+			label = quote(key)
+		}
+		entries[i] = label + ": " + in.value(obj.Get(key), level+1)
+	}
+	if prefix != "" {
+		prefix += " "
+	}
+	return in.join(prefix, "{", "}", entries, level)
+}
+
+// join lays out entries between open and close on one line if they fit
+// in the break length, as Node measures it, else one per line, indented.
+func (in *inspector) join(prefix, open, close string, entries []string, level int) string {
+	if len(entries) == 0 {
+		return prefix + open + close
+	}
+	width := 2*len(entries) + 2*level + len(open) + len(prefix) + 10
+	multiline := false
+	for _, e := range entries {
+		width += utf8.RuneCountInString(e)
+		multiline = multiline || strings.Contains(e, "\n")
+	}
+	if !multiline && width <= inspectBreakLength {
+		return prefix + open + " " + strings.Join(entries, ", ") + " " + close
+	}
+	var b strings.Builder
+	b.WriteString(prefix + open + "\n")
+	for i, e := range entries {
+		b.WriteString("  " + strings.ReplaceAll(e, "\n", "\n  "))
+		if i < len(entries)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(close)
+	return b.String()
+}
+
+func (in *inspector) tooDeep(level int) bool {
+	return in.depth >= 0 && level > in.depth
+}
+
+// constructorName returns the name of obj's constructor, "" when it has
+// no prototype.
+func (in *inspector) constructorName(obj *sobek.Object) string {
+	if obj.Prototype() == nil {
+		return ""
+	}
+	if ctor, ok := obj.Get("constructor").(*sobek.Object); ok {
+		if name := ctor.Get("name"); name != nil {
+			return name.String()
+		}
+	}
+	return "Object"
+}
+
+func (in *inspector) instanceOf(obj *sobek.Object, ctor string) bool {
+	c, ok := in.vm.Get(ctor).(*sobek.Object)
+	return ok && in.vm.InstanceOf(obj, c)
+}
+
+func (in *inspector) isView(obj *sobek.Object) bool {
+	res, err := in.call(in.vm.Get("ArrayBuffer").ToObject(in.vm), "isView", obj)
+	return err == nil && res.ToBoolean()
+}
+
+// call calls the method name of obj.
+func (in *inspector) call(obj *sobek.Object, name string, args ...sobek.Value) (sobek.Value, error) {
+	fn, ok := sobek.AssertFunction(obj.Get(name))
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", name)
+	}
+	return fn(obj, args...)
+}
+
+// quote returns s in single quotes, or double quotes if it contains single
+// quotes but no double ones, as Node does.
+func quote(s string) string {
+	q := byte('\'')
+	if strings.Contains(s, "'") && !strings.Contains(s, `"`) {
+		q = '"'
+	}
+	var b strings.Builder
+	b.WriteByte(q)
+	for _, r := range s {
+		switch {
+		case r == rune(q) || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\x%02X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(q)
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/grafana/sobek"
+)
+
+// Go cannot create types with methods at runtime, so every interface a JS
+// object may implement needs a concrete adapter type compiled in. The linker
+// generates them for hyperlinked packages and registers them from init();
+// a few common stdlib interfaces are registered here.
+
+// AdapterFactory builds a Go value implementing an interface on top of impl.
+type AdapterFactory func(impl *JSImpl) (interface{}, error)
+
+// JSBacked is implemented by adapters so the original JS object is handed
+// back, instead of a copy, when the value crosses into JS again.
+type JSBacked interface {
+	TypeGoObject() *sobek.Object
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = make(map[reflect.Type]AdapterFactory)
+)
+
+// RegisterInterface registers the adapter used when a JS object is passed
+// where the interface is expected. iface is a nil pointer to the interface,
+// e.g. (*io.Reader)(nil).
+func RegisterInterface(iface interface{}, factory AdapterFactory) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("RegisterInterface: expected a nil interface pointer, got %T", iface))
+	}
+	adaptersMu.Lock()
+	adapters[t.Elem()] = factory
+	adaptersMu.Unlock()
+}
+
+// JSImpl is the JS object behind an adapter.
+type JSImpl struct {
+	vm    *sobek.Runtime
+	obj   *sobek.Object
+	iface reflect.Type
+	opts  *bindOptions
+}
+
+// Object returns the JS object implementing the interface.
+func (i *JSImpl) Object() *sobek.Object {
+	return i.obj
+}
+
+// Bind points fn, a pointer to a func variable, at the JS method for the Go
+// method name. Both `Read` and `read` implement Read. Calls follow the
+// callback rules: exceptions fill a trailing error result, and calls from
+// other goroutines are scheduled on the event loop.
+func (i *JSImpl) Bind(method string, fn interface{}) error {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Ptr || fv.Elem().Kind() != reflect.Func {
+		return fmt.Errorf("bind %s: expected a pointer to a func, got %T", method, fn)
+	}
+
+	callable, ok := i.method(method)
+	if !ok {
+		return fmt.Errorf("object does not implement %s (missing method %s)", i.iface, method)
+	}
+	fv.Elem().Set(wrapJSMethod(i.vm, callable, i.obj, fv.Elem().Type(), i.opts))
+	return nil
+}
+
+func (i *JSImpl) method(name string) (sobek.Callable, bool) {
+	for _, key := range []string{name, lowerFirst(name)} {
+		if fn, ok := sobek.AssertFunction(i.obj.Get(key)); ok {
+			return fn, true
+		}
+	}
+	return nil, false
+}
+
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+// adaptInterface wraps obj in the adapter registered for goType.
+func adaptInterface(vm *sobek.Runtime, obj *sobek.Object, goType reflect.Type, opts *bindOptions) (reflect.Value, error) {
+	adaptersMu.RLock()
+	factory, ok := adapters[goType]
+	adaptersMu.RUnlock()
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("no adapter for interface %s; hyperlink %s so one is generated", goType, pkgOf(goType))
+	}
+
+	v, err := factory(&JSImpl{vm: vm, obj: obj, iface: goType, opts: opts})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !rv.Type().Implements(goType) {
+		return reflect.Value{}, fmt.Errorf("adapter for %s returned %T", goType, v)
+	}
+	return rv, nil
+}
+
+func pkgOf(t reflect.Type) string {
+	if t.PkgPath() != "" {
+		return t.PkgPath()
+	}
+	return strings.SplitN(t.String(), ".", 2)[0]
+}
+
+func asJSBacked(v reflect.Value) (JSBacked, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	backed, ok := v.Interface().(JSBacked)
+	return backed, ok
+}
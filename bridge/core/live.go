@@ -0,0 +1,192 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/sobek"
+)
+
+// liveStruct implements sobek.DynamicObject over an addressable Go struct.
+// Every property access goes through reflection, so Go and JS observe each
+// other's writes instead of diverging like the snapshot made by BindStruct.
+type liveStruct struct {
+	vm      *sobek.Runtime
+	v       reflect.Value
+	fields  map[string][]int
+	keys    []string
+	methods map[string]int
+	wrapped map[string]sobek.Value
+	nested  map[string]liveChild
+	opts    *bindOptions
+}
+
+// liveChild is the live object of a nested struct field, kept while the
+// field still holds the struct at addr so that obj.Inner === obj.Inner.
+type liveChild struct {
+	addr uintptr
+	obj  *sobek.Object
+}
+
+// BindStructLive exposes a pointer to a Go struct as a live JS object.
+// Field reads and writes are forwarded to the struct on every access, and
+// nested structs are returned as live objects as well.
+//
+// Accesses are not synchronized: Go code mutating the struct concurrently
+// must coordinate with the JS thread (e.g. through EventLoop.RunOnLoop).
+func BindStructLive(vm *sobek.Runtime, name string, ptr interface{}, opts ...BindOption) error {
+	obj, err := NewLiveStruct(vm, ptr, opts...)
+	if err != nil {
+		return err
+	}
+	return vm.GlobalObject().Set(name, obj)
+}
+
+// NewLiveStruct returns a live JS object backed by ptr, which must be a
+// non-nil pointer to a struct.
+func NewLiveStruct(vm *sobek.Runtime, ptr interface{}, opts ...BindOption) (*sobek.Object, error) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("live binding requires a non-nil struct pointer, got %T", ptr)
+	}
+	return newLiveObject(vm, v.Elem(), applyBindOptions(opts)), nil
+}
+
+func newLiveObject(vm *sobek.Runtime, v reflect.Value, opts *bindOptions) *sobek.Object {
+	ls := &liveStruct{
+		vm:      vm,
+		v:       v,
+		fields:  make(map[string][]int),
+		methods: make(map[string]int),
+		wrapped: make(map[string]sobek.Value),
+		nested:  make(map[string]liveChild),
+		opts:    opts,
+	}
+	ls.collectFields(v.Type(), nil)
+	for _, m := range exportedMethods(v.Addr().Type()) {
+		ls.methods[m.Name] = m.Index
+	}
+	return vm.NewDynamicObject(ls)
+}
+
+// collectFields flattens embedded structs the same way bindStructFields does,
+// remembering the index path so promoted fields resolve at access time.
+func (ls *liveStruct) collectFields(t reflect.Type, prefix []int) {
+	for _, f := range structFields(t) {
+		path := append(append([]int(nil), prefix...), f.Index)
+
+		if f.Anonymous {
+			ft := t.Field(f.Index).Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				ls.collectFields(ft, path)
+				continue
+			}
+		}
+
+		if _, exists := ls.fields[f.Key]; exists {
+			continue
+		}
+		ls.fields[f.Key] = path
+		ls.keys = append(ls.keys, f.Key)
+	}
+}
+
+// field resolves an index path, allocating nil embedded pointers when alloc is set.
+func (ls *liveStruct) field(path []int, alloc bool) (reflect.Value, bool) {
+	v := ls.v
+	for i, idx := range path {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v, true
+}
+
+func (ls *liveStruct) Get(key string) sobek.Value {
+	if path, ok := ls.fields[key]; ok {
+		fv, ok := ls.field(path, false)
+		if !ok {
+			return sobek.Undefined()
+		}
+		return ls.wrap(key, fv)
+	}
+
+	if idx, ok := ls.methods[key]; ok {
+		if fn, ok := ls.wrapped[key]; ok {
+			return fn
+		}
+		fn := ls.vm.ToValue(createMethodWrapper(ls.vm, ls.v.Addr().Method(idx), key, ls.opts))
+		ls.wrapped[key] = fn
+		return fn
+	}
+
+	return nil
+}
+
+// wrap keeps nested structs live, reusing the object made for the field
+// key while it points at the same struct; everything else is converted by
+// value.
+func (ls *liveStruct) wrap(key string, fv reflect.Value) sobek.Value {
+	var sv reflect.Value
+	switch {
+	case fv.Kind() == reflect.Struct && fv.Type() != timeType:
+		sv = fv
+	case fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct && fv.Elem().Type() != timeType:
+		sv = fv.Elem()
+	}
+	if sv.IsValid() {
+		addr := sv.UnsafeAddr()
+		if child, ok := ls.nested[key]; ok && child.addr == addr {
+			return child.obj
+		}
+		obj := newLiveObject(ls.vm, sv, ls.opts)
+		ls.nested[key] = liveChild{addr: addr, obj: obj}
+		return obj
+	}
+	val, err := bindValue(ls.vm, fv, newBindCtx(ls.opts))
+	if err != nil {
+		panic(ls.vm.NewGoError(err))
+	}
+	return val
+}
+
+func (ls *liveStruct) Set(key string, val sobek.Value) bool {
+	path, ok := ls.fields[key]
+	if !ok {
+		return false
+	}
+	fv, _ := ls.field(path, true)
+	goVal, err := convertJSToGo(ls.vm, val, fv.Type(), ls.opts)
+	if err != nil {
+		panic(ls.vm.NewTypeError(fmt.Sprintf("field %s: %v", key, err)))
+	}
+	fv.Set(goVal)
+	return true
+}
+
+func (ls *liveStruct) Has(key string) bool {
+	if _, ok := ls.fields[key]; ok {
+		return true
+	}
+	_, ok := ls.methods[key]
+	return ok
+}
+
+// Delete refuses: a Go struct cannot drop a field.
+func (ls *liveStruct) Delete(key string) bool {
+	return !ls.Has(key)
+}
+
+func (ls *liveStruct) Keys() []string {
+	return ls.keys
+}
@@ -0,0 +1,326 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/grafana/sobek"
+)
+
+// Ref is a JS handle on a Go variable: a value boxed on the Go heap, a field
+// of a live struct or an element of a Go slice. Reads and writes go to the
+// variable itself, and Go functions taking *T receive its address.
+//
+// Word-sized numbers are accessed with sync/atomic; other types are guarded
+// by a lock shared by all refs to the same address, which Go code touching
+// the variable directly does not take.
+type Ref struct {
+	vm   *sobek.Runtime
+	ptr  reflect.Value // *T
+	opts *bindOptions
+	obj  *sobek.Object
+}
+
+// NewRef returns a ref to the variable ptr points to.
+func NewRef(vm *sobek.Runtime, ptr reflect.Value, opts ...BindOption) (*Ref, error) {
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return nil, fmt.Errorf("ref requires a non-nil pointer, got %s", ptr.Type())
+	}
+	return &Ref{vm: vm, ptr: ptr, opts: applyBindOptions(opts)}, nil
+}
+
+// RefOf returns the ref behind a JS ref object.
+func RefOf(v sobek.Value) (*Ref, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok || obj.ExportType() == proxyType {
+		return nil, false
+	}
+	tag := obj.Get("__ref")
+	if tag == nil {
+		return nil, false
+	}
+	r, ok := tag.Export().(*Ref)
+	return r, ok
+}
+
+// Pointer returns the address of the variable, as a *T.
+func (r *Ref) Pointer() reflect.Value {
+	return r.ptr
+}
+
+// RefTarget resolves ref(target, ...path): the address of target, a ref or
+// a live struct, or of the element path leads to from it. Path steps are
+// field names of structs and indexes of slices and arrays; a typed array or
+// Go slice target needs at least one.
+func RefTarget(target sobek.Value, path []sobek.Value) (reflect.Value, bool, error) {
+	var base reflect.Value
+	if r, ok := RefOf(target); ok {
+		base = r.ptr
+	} else if obj, ok := target.(*sobek.Object); ok && !jsOnlyTypes[obj.ExportType()] {
+		switch exported := obj.Export().(type) {
+		case *liveStruct:
+			base = exported.v.Addr()
+		case *Slice:
+			if len(path) > 0 {
+				base = exported.v
+			}
+		default:
+			if v := reflect.ValueOf(exported); v.Kind() == reflect.Slice && len(path) > 0 {
+				base = v
+			}
+		}
+	}
+	if !base.IsValid() {
+		if len(path) > 0 {
+			return reflect.Value{}, false, fmt.Errorf("cannot take a field ref of a plain JS value")
+		}
+		return reflect.Value{}, false, nil
+	}
+
+	for _, key := range path {
+		next, err := refStep(base, key)
+		if err != nil {
+			return reflect.Value{}, false, err
+		}
+		base = next
+	}
+	return base, true, nil
+}
+
+// jsOnlyTypes are the export types of objects that cannot hold a Go value.
+// Exporting them would call the getters of a plain object, copy every
+// element of an array, however sparse, or fire the traps of a proxy.
+var jsOnlyTypes = map[reflect.Type]bool{
+	reflect.TypeOf(map[string]interface{}{}): true,
+	reflect.TypeOf([]interface{}{}):          true,
+	proxyType:                                true,
+}
+
+var proxyType = reflect.TypeOf(sobek.Proxy{})
+
+// refStep returns the address of the field or element key of the struct,
+// slice or array base points to, or of the element of slice base.
+func refStep(base reflect.Value, key sobek.Value) (reflect.Value, error) {
+	v := base
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("cannot ref %v of a nil %s", key, v.Type())
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		name := key.String()
+		for _, f := range structFields(v.Type()) {
+			if f.Key == name {
+				return v.Field(f.Index).Addr(), nil
+			}
+		}
+		if f, ok := v.Type().FieldByName(name); ok && f.IsExported() {
+			return v.FieldByIndex(f.Index).Addr(), nil
+		}
+		return reflect.Value{}, fmt.Errorf("%s has no field %s", v.Type(), name)
+	case reflect.Slice, reflect.Array:
+		i := key.ToInteger()
+		if i < 0 || i >= int64(v.Len()) {
+			return reflect.Value{}, fmt.Errorf("index %d out of range for length %d", i, v.Len())
+		}
+		elem := v.Index(int(i))
+		if !elem.CanAddr() {
+			return reflect.Value{}, fmt.Errorf("elements of %s are not addressable", v.Type())
+		}
+		return elem.Addr(), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot ref %v of a %s", key, v.Type())
+	}
+}
+
+// refLocks guard refs to variables that cannot be accessed atomically,
+// striped by address.
+var refLocks [64]sync.Mutex
+
+func (r *Ref) lock() *sync.Mutex {
+	return &refLocks[(r.ptr.Pointer()>>3)%uintptr(len(refLocks))]
+}
+
+// atomicSize returns 4 or 8 for variables sync/atomic can access.
+func (r *Ref) atomicSize() uintptr {
+	t := r.ptr.Type().Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr, reflect.Float32, reflect.Float64:
+		if size := t.Size(); r.ptr.Pointer()%size == 0 {
+			return size
+		}
+	}
+	return 0
+}
+
+func toBits(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return uint64(v.Int())
+	case reflect.Float32:
+		return uint64(math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		return math.Float64bits(v.Float())
+	default:
+		return v.Uint()
+	}
+}
+
+func fromBits(t reflect.Type, bits uint64) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(bits) << (64 - 8*t.Size()) >> (64 - 8*t.Size()))
+	case reflect.Float32:
+		v.SetFloat(float64(math.Float32frombits(uint32(bits))))
+	case reflect.Float64:
+		v.SetFloat(math.Float64frombits(bits))
+	default:
+		v.SetUint(bits)
+	}
+	return v
+}
+
+// Load returns a copy of the variable.
+func (r *Ref) Load() reflect.Value {
+	addr := unsafe.Pointer(r.ptr.Pointer())
+	switch r.atomicSize() {
+	case 4:
+		return fromBits(r.ptr.Type().Elem(), uint64(atomic.LoadUint32((*uint32)(addr))))
+	case 8:
+		return fromBits(r.ptr.Type().Elem(), atomic.LoadUint64((*uint64)(addr)))
+	}
+	mu := r.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	v := reflect.New(r.ptr.Type().Elem()).Elem()
+	v.Set(r.ptr.Elem())
+	return v
+}
+
+// Store sets the variable to v.
+func (r *Ref) Store(v reflect.Value) {
+	r.Swap(v)
+}
+
+// Swap sets the variable to v and returns its previous value.
+func (r *Ref) Swap(v reflect.Value) reflect.Value {
+	addr := unsafe.Pointer(r.ptr.Pointer())
+	t := r.ptr.Type().Elem()
+	switch r.atomicSize() {
+	case 4:
+		return fromBits(t, uint64(atomic.SwapUint32((*uint32)(addr), uint32(toBits(v)))))
+	case 8:
+		return fromBits(t, atomic.SwapUint64((*uint64)(addr), toBits(v)))
+	}
+	mu := r.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	old := reflect.New(t).Elem()
+	old.Set(r.ptr.Elem())
+	r.ptr.Elem().Set(v)
+	return old
+}
+
+// CompareAndSwap sets the variable to v if it equals old, reporting whether
+// it did. The variable's type must be comparable.
+func (r *Ref) CompareAndSwap(old, v reflect.Value) (bool, error) {
+	addr := unsafe.Pointer(r.ptr.Pointer())
+	switch r.atomicSize() {
+	case 4:
+		return atomic.CompareAndSwapUint32((*uint32)(addr), uint32(toBits(old)), uint32(toBits(v))), nil
+	case 8:
+		return atomic.CompareAndSwapUint64((*uint64)(addr), toBits(old), toBits(v)), nil
+	}
+	t := r.ptr.Type().Elem()
+	if !t.Comparable() {
+		return false, fmt.Errorf("values of type %s cannot be compared", t)
+	}
+	mu := r.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	if r.ptr.Elem().Interface() != old.Interface() {
+		return false, nil
+	}
+	r.ptr.Elem().Set(v)
+	return true, nil
+}
+
+func (r *Ref) toGo(v sobek.Value) reflect.Value {
+	goVal, err := convertJSToGo(r.vm, v, r.ptr.Type().Elem(), r.opts)
+	if err != nil {
+		panic(r.vm.NewTypeError(fmt.Sprintf("ref to %s: %v", r.ptr.Type().Elem(), err)))
+	}
+	return goVal
+}
+
+// toJS binds a value loaded from the variable.
+func (r *Ref) toJS(v reflect.Value) sobek.Value {
+	val, err := bindValue(r.vm, v, newBindCtx(r.opts))
+	if err != nil {
+		panic(r.vm.NewGoError(err))
+	}
+	return val
+}
+
+// value returns the variable, except for structs, which are returned live
+// so their fields can be updated in place.
+func (r *Ref) value() sobek.Value {
+	if elem := r.ptr.Elem(); elem.Kind() == reflect.Struct && elem.Type() != timeType {
+		return newLiveObject(r.vm, elem, r.opts)
+	}
+	return r.toJS(r.Load())
+}
+
+// Object returns the JS object for the ref, creating it once: value, ptr
+// (the address) and type, with load, store, swap and compareAndSwap.
+func (r *Ref) Object() *sobek.Object {
+	if r.obj != nil {
+		return r.obj
+	}
+
+	vm := r.vm
+	obj := vm.NewObject()
+	_ = obj.DefineDataProperty("__ref", vm.ToValue(r), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+	_ = obj.DefineDataProperty("ptr", vm.ToValue(int64(r.ptr.Pointer())), sobek.FLAG_FALSE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+	_ = obj.DefineDataProperty("type", vm.ToValue(r.ptr.Type().Elem().String()), sobek.FLAG_FALSE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+	_ = obj.DefineAccessorProperty("value",
+		vm.ToValue(func(sobek.FunctionCall) sobek.Value { return r.value() }),
+		vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+			r.Store(r.toGo(call.Argument(0)))
+			return sobek.Undefined()
+		}),
+		sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+
+	_ = obj.Set("load", func(sobek.FunctionCall) sobek.Value {
+		return r.toJS(r.Load())
+	})
+	_ = obj.Set("store", func(call sobek.FunctionCall) sobek.Value {
+		r.Store(r.toGo(call.Argument(0)))
+		return sobek.Undefined()
+	})
+	_ = obj.Set("swap", func(call sobek.FunctionCall) sobek.Value {
+		return r.toJS(r.Swap(r.toGo(call.Argument(0))))
+	})
+	_ = obj.Set("compareAndSwap", func(call sobek.FunctionCall) sobek.Value {
+		swapped, err := r.CompareAndSwap(r.toGo(call.Argument(0)), r.toGo(call.Argument(1)))
+		if err != nil {
+			panic(vm.NewTypeError(err.Error()))
+		}
+		return vm.ToValue(swapped)
+	})
+
+	r.obj = obj
+	return obj
+}
@@ -1,9 +1,11 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/grafana/sobek"
 )
@@ -24,6 +26,8 @@ type methodInfo struct {
 type fieldInfo struct {
 	Index     int
 	Name      string
+	Key       string // JS property name, taken from the json tag when present
+	OmitEmpty bool
 	Anonymous bool
 }
 
@@ -35,17 +39,28 @@ var typeFieldCache sync.Map
 
 // BindStruct exposes a Go struct to JavaScript with full field and method access.
 // Supports nested structs (converted recursively) and callback arguments.
-func BindStruct(vm *sobek.Runtime, name string, s interface{}) error {
-	obj, err := bindValue(vm, reflect.ValueOf(s), make(map[uintptr]sobek.Value))
+func BindStruct(vm *sobek.Runtime, name string, s interface{}, opts ...BindOption) error {
+	obj, err := bindValue(vm, reflect.ValueOf(s), newBindCtx(applyBindOptions(opts)))
 	if err != nil {
 		return err
 	}
 	return vm.GlobalObject().Set(name, obj)
 }
 
+// WrapFunc exposes a Go function to JavaScript with the same argument and
+// result conversion as bound methods, so JS objects can be passed where the
+// function takes an interface. name is used in errors and by AsyncMethods.
+func WrapFunc(vm *sobek.Runtime, name string, fn interface{}, opts ...BindOption) sobek.Value {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func {
+		panic(fmt.Sprintf("WrapFunc %s: expected a func, got %T", name, fn))
+	}
+	return vm.ToValue(createMethodWrapper(vm, fv, name, applyBindOptions(opts)))
+}
+
 // bindValue recursively converts a Go value to a JavaScript value.
-// The visited map prevents infinite loops for circular references.
-func bindValue(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Value) (sobek.Value, error) {
+// The visited map in bc prevents infinite loops for circular references.
+func bindValue(vm *sobek.Runtime, v reflect.Value, bc *bindCtx) (sobek.Value, error) {
 	if !v.IsValid() {
 		return sobek.Undefined(), nil
 	}
@@ -54,73 +69,85 @@ func bindValue(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Val
 		if v.IsNil() {
 			return sobek.Null(), nil
 		}
+		if backed, ok := asJSBacked(v); ok {
+			return backed.TypeGoObject(), nil
+		}
 		ptr := v.Pointer()
-		if cached, ok := visited[ptr]; ok {
+		if cached, ok := bc.visited[ptr]; ok {
 			return cached, nil
 		}
-		return bindValue(vm, v.Elem(), visited)
+		return bindValue(vm, v.Elem(), bc)
+	}
+
+	switch v.Type() {
+	case contextType:
+		if v.IsNil() {
+			return sobek.Null(), nil
+		}
+		return contextObject(vm, v.Interface().(context.Context), bc.opts), nil
+	case timeType:
+		return timeToDate(vm, v.Interface().(time.Time))
+	case durationType:
+		return vm.ToValue(durationToMs(time.Duration(v.Int()))), nil
 	}
 
 	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return sobek.Null(), nil
+		}
+		return bindValue(vm, v.Elem(), bc)
 	case reflect.Struct:
-		return bindStruct(vm, v, visited)
+		return bindStruct(vm, v, bc)
 	case reflect.Slice, reflect.Array:
-		return bindSlice(vm, v, visited)
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return bindBytes(vm, v), nil
+		}
+		return bindSlice(vm, v, bc)
 	case reflect.Map:
-		return bindMap(vm, v, visited)
+		return bindMap(vm, v, bc)
 	case reflect.Func:
 		return vm.ToValue(v.Interface()), nil
+	case reflect.Chan:
+		if v.IsNil() {
+			return sobek.Null(), nil
+		}
+		return newChannel(vm, v, bc.opts).Object(), nil
 	default:
 		return vm.ToValue(v.Interface()), nil
 	}
 }
 
-func bindStruct(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Value) (sobek.Value, error) {
-	obj := vm.NewObject()
+func bindStruct(vm *sobek.Runtime, v reflect.Value, bc *bindCtx) (sobek.Value, error) {
+	var obj *sobek.Object
+	var meta *classMeta
+
+	// Structs claimed by a @GoType class come back as instances of that class
+	if ctor := registeredClass(vm, v.Type()); ctor != nil {
+		proto, _ := ctor.Get("prototype").(*sobek.Object)
+		obj = vm.CreateObject(proto)
+		meta = readClassMeta(vm, ctor)
+	} else {
+		obj = vm.NewObject()
+	}
 
 	if v.CanAddr() {
-		visited[v.Addr().Pointer()] = obj
+		bc.visited[v.Addr().Pointer()] = obj
 	}
 
-	if err := bindStructFields(vm, obj, v, visited); err != nil {
+	if err := bindStructFields(vm, obj, v, meta, bc); err != nil {
 		return nil, err
 	}
 
-	bindMethods(vm, obj, v, visited)
+	bindMethods(vm, obj, v, bc)
 
 	return obj, nil
 }
 
-func bindStructFields(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, visited map[uintptr]sobek.Value) error {
+func bindStructFields(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, meta *classMeta, bc *bindCtx) error {
 	t := v.Type()
 
-	// @optimized: Use cached field metadata to avoid repeated reflection overhead (NumField, IsExported).
-	var fields []fieldInfo
-	cached, loaded := typeFieldCache.Load(t)
-	if loaded {
-		if cachedFields, ok := cached.([]fieldInfo); ok {
-			fields = cachedFields
-		}
-	}
-
-	if fields == nil {
-		numFields := t.NumField()
-		fields = make([]fieldInfo, 0, numFields)
-		for i := 0; i < numFields; i++ {
-			field := t.Field(i)
-			if !field.IsExported() {
-				continue
-			}
-			fields = append(fields, fieldInfo{
-				Index:     i,
-				Name:      field.Name,
-				Anonymous: field.Anonymous,
-			})
-		}
-		typeFieldCache.Store(t, fields)
-	}
-
-	for _, field := range fields {
+	for _, field := range structFields(t) {
 		fieldVal := v.Field(field.Index)
 
 		// Support for Flattened Embedding (Anonymous Fields)
@@ -134,7 +161,7 @@ func bindStructFields(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, vis
 				actual = actual.Elem()
 			}
 			if actual.Kind() == reflect.Struct {
-				if err := bindStructFields(vm, obj, actual, visited); err != nil {
+				if err := bindStructFields(vm, obj, actual, meta, bc); err != nil {
 					return err
 				}
 				continue
@@ -142,16 +169,63 @@ func bindStructFields(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, vis
 		}
 
 	skip:
-		jsVal, err := bindValue(vm, fieldVal, visited)
+		if field.OmitEmpty && fieldVal.IsZero() {
+			continue
+		}
+		jsVal, err := bindValue(vm, fieldVal, bc)
 		if err != nil {
 			return err
 		}
-		_ = obj.Set(field.Name, jsVal)
+		key := field.Key
+		if fm, ok := meta.lookup(t.Field(field.Index)); ok {
+			key = fm.Key
+		}
+		_ = obj.Set(key, jsVal)
 	}
 	return nil
 }
 
-func bindMethods(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, visited map[uintptr]sobek.Value) {
+// structFields returns the bindable fields of t.
+func structFields(t reflect.Type) []fieldInfo {
+	// @optimized: Use cached field metadata to avoid repeated reflection overhead (NumField, IsExported).
+	var fields []fieldInfo
+	cached, loaded := typeFieldCache.Load(t)
+	if loaded {
+		if cachedFields, ok := cached.([]fieldInfo); ok {
+			fields = cachedFields
+		}
+	}
+
+	if fields == nil {
+		numFields := t.NumField()
+		fields = make([]fieldInfo, 0, numFields)
+		for i := 0; i < numFields; i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag := parseJSONTag(field)
+			if tag.Skip {
+				continue
+			}
+			key := field.Name
+			if tag.Name != "" {
+				key = tag.Name
+			}
+			fields = append(fields, fieldInfo{
+				Index:     i,
+				Name:      field.Name,
+				Key:       key,
+				OmitEmpty: tag.OmitEmpty,
+				Anonymous: field.Anonymous,
+			})
+		}
+		typeFieldCache.Store(t, fields)
+	}
+	return fields
+}
+
+func bindMethods(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, bc *bindCtx) {
 	var vPtr reflect.Value
 	if v.CanAddr() {
 		vPtr = v.Addr()
@@ -163,11 +237,17 @@ func bindMethods(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, visited
 		vPtr = vCopy
 	}
 
-	tPtr := vPtr.Type()
+	for _, m := range exportedMethods(vPtr.Type()) {
+		methodVal := vPtr.Method(m.Index)
+		_ = obj.Set(m.Name, createMethodWrapper(vm, methodVal, m.Name, bc.opts))
+	}
+}
 
+// exportedMethods returns the exported methods of t.
+func exportedMethods(t reflect.Type) []methodInfo {
 	// @optimized: Use cached method metadata to avoid repeated reflection overhead (NumMethod, IsExported).
 	var methods []methodInfo
-	cached, loaded := typeMethodCache.Load(tPtr)
+	cached, loaded := typeMethodCache.Load(t)
 	if loaded {
 		if cachedMethods, ok := cached.([]methodInfo); ok {
 			methods = cachedMethods
@@ -175,10 +255,10 @@ func bindMethods(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, visited
 	}
 
 	if methods == nil {
-		numMethods := tPtr.NumMethod()
+		numMethods := t.NumMethod()
 		methods = make([]methodInfo, 0, numMethods)
 		for i := 0; i < numMethods; i++ {
-			method := tPtr.Method(i)
+			method := t.Method(i)
 			if method.IsExported() {
 				methods = append(methods, methodInfo{
 					Name:  method.Name,
@@ -186,13 +266,9 @@ func bindMethods(vm *sobek.Runtime, obj *sobek.Object, v reflect.Value, visited
 				})
 			}
 		}
-		typeMethodCache.Store(tPtr, methods)
-	}
-
-	for _, m := range methods {
-		methodVal := vPtr.Method(m.Index)
-		_ = obj.Set(m.Name, createMethodWrapper(vm, methodVal, m.Name, visited))
+		typeMethodCache.Store(t, methods)
 	}
+	return methods
 }
 
 var argsPool = sync.Pool{
@@ -202,16 +278,25 @@ var argsPool = sync.Pool{
 	},
 }
 
-func createMethodWrapper(vm *sobek.Runtime, methodVal reflect.Value, methodName string, visited map[uintptr]sobek.Value) func(sobek.FunctionCall) sobek.Value {
+func createMethodWrapper(vm *sobek.Runtime, methodVal reflect.Value, methodName string, opts *bindOptions) func(sobek.FunctionCall) sobek.Value {
 	methodType := methodVal.Type()
 	numIn := methodType.NumIn()
 
+	// A leading context.Context is supplied by the bridge, not by JS callers,
+	// unless they pass a go:context object first to bound the call
+	ctxFirst := numIn > 0 && methodType.In(0) == contextType
+	async := opts.isAsync(methodName, ctxFirst)
+	offset := 0
+	if ctxFirst {
+		offset = 1
+	}
+
 	return func(call sobek.FunctionCall) sobek.Value {
 		// Optimize: Use pool for common small argument lists
 		var goArgs []reflect.Value
 		var pGoArgs *[]reflect.Value
 
-		if numIn <= 8 {
+		if numIn <= 8 && !async {
 			pGoArgs = argsPool.Get().(*[]reflect.Value)
 			goArgs = (*pGoArgs)[:0:8]
 			goArgs = goArgs[:numIn]
@@ -226,14 +311,22 @@ func createMethodWrapper(vm *sobek.Runtime, methodVal reflect.Value, methodName
 			goArgs = make([]reflect.Value, numIn)
 		}
 
-		for j := 0; j < numIn; j++ {
+		args := call.Arguments
+		var parent context.Context
+		if ctxFirst {
+			if ctx, ok := ContextOf(call.Argument(0)); ok {
+				parent, args = ctx, args[1:]
+			}
+		}
+
+		for j := offset; j < numIn; j++ {
 			argType := methodType.In(j)
 
-			if j < len(call.Arguments) {
-				jsArg := call.Arguments[j]
-				goArg, err := convertJSToGo(vm, jsArg, argType)
+			if j-offset < len(args) {
+				jsArg := args[j-offset]
+				goArg, err := convertJSToGo(vm, jsArg, argType, opts)
 				if err != nil {
-					panic(vm.NewTypeError(fmt.Sprintf("Method %s: Argument %d: %v", methodName, j, err)))
+					panic(vm.NewTypeError(fmt.Sprintf("Method %s: Argument %d: %v", methodName, j-offset, err)))
 				}
 				goArgs[j] = goArg
 			} else {
@@ -241,107 +334,58 @@ func createMethodWrapper(vm *sobek.Runtime, methodVal reflect.Value, methodName
 			}
 		}
 
-		results := methodVal.Call(goArgs)
-
-		if len(results) == 0 {
-			return sobek.Undefined()
+		if async {
+			return callAsync(vm, opts, methodVal, goArgs, ctxFirst, parent)
 		}
 
-		// Handle (Value, error) pattern common in Go
-		if len(results) == 2 && methodType.Out(1).Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-			if !results[1].IsNil() {
-				err := results[1].Interface().(error)
-				panic(vm.NewGoError(err))
+		if ctxFirst {
+			if parent == nil {
+				parent = context.Background()
 			}
-			val, _ := bindValue(vm, results[0], visited)
-			return val
+			goArgs[0] = reflect.ValueOf(parent)
 		}
 
-		if len(results) == 1 {
-			val, _ := bindValue(vm, results[0], visited)
-			return val
-		}
-
-		// Multiple results: return as array
-		retVals := make([]interface{}, len(results))
-		for i, r := range results {
-			jsVal, _ := bindValue(vm, r, visited)
-			retVals[i] = jsVal
+		val, err := bindResults(vm, methodType, methodVal.Call(goArgs), opts)
+		if err != nil {
+			panic(NewGoError(vm, err))
 		}
-		return vm.NewArray(retVals...)
+		return val
 	}
 }
 
-func convertJSToGo(vm *sobek.Runtime, jsVal sobek.Value, goType reflect.Type) (reflect.Value, error) {
-	if goType.Kind() == reflect.Func {
-		callable, ok := sobek.AssertFunction(jsVal)
-		if !ok {
-			return reflect.Value{}, fmt.Errorf("expected function, got %T", jsVal.Export())
+// bindResults converts method results to JS. A trailing error result is
+// stripped and returned instead of bound, matching the generated typings.
+func bindResults(vm *sobek.Runtime, methodType reflect.Type, results []reflect.Value, opts *bindOptions) (sobek.Value, error) {
+	if n := len(results); n > 0 && methodType.Out(n-1) == errorType {
+		if !results[n-1].IsNil() {
+			return nil, results[n-1].Interface().(error)
 		}
-		return wrapJSCallback(vm, callable, goType), nil
+		results = results[:n-1]
 	}
 
-	exported := jsVal.Export()
-	if exported == nil {
-		return reflect.Zero(goType), nil
+	if len(results) == 0 {
+		return sobek.Undefined(), nil
 	}
 
-	goVal := reflect.ValueOf(exported)
-
-	if goVal.Type().AssignableTo(goType) {
-		return goVal, nil
+	if len(results) == 1 {
+		return bindValue(vm, results[0], newBindCtx(opts))
 	}
 
-	if goVal.Type().ConvertibleTo(goType) {
-		return goVal.Convert(goType), nil
+	// Multiple results: return as array
+	retVals := make([]interface{}, len(results))
+	for i, r := range results {
+		jsVal, _ := bindValue(vm, r, newBindCtx(opts))
+		retVals[i] = jsVal
 	}
-
-	return reflect.Value{}, fmt.Errorf("expected %s, got %T", goType, exported)
+	return vm.NewArray(retVals...), nil
 }
 
-func wrapJSCallback(vm *sobek.Runtime, callable sobek.Callable, goType reflect.Type) reflect.Value {
-	return reflect.MakeFunc(goType, func(args []reflect.Value) []reflect.Value {
-		jsArgs := make([]sobek.Value, len(args))
-		for i, arg := range args {
-			jsArgs[i] = vm.ToValue(arg.Interface())
-		}
-
-		result, err := callable(sobek.Undefined(), jsArgs...)
-		if err != nil {
-			// Return zero values on error
-			numOut := goType.NumOut()
-			results := make([]reflect.Value, numOut)
-			for i := 0; i < numOut; i++ {
-				results[i] = reflect.Zero(goType.Out(i))
-			}
-			return results
-		}
-
-		// Convert result back to Go
-		numOut := goType.NumOut()
-		if numOut == 0 {
-			return nil
-		}
-
-		results := make([]reflect.Value, numOut)
-		if numOut == 1 {
-			exported := result.Export()
-			if exported == nil {
-				results[0] = reflect.Zero(goType.Out(0))
-			} else {
-				results[0] = reflect.ValueOf(exported)
-			}
-		}
-		return results
-	})
-}
-
-func bindSlice(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Value) (sobek.Value, error) {
+func bindSlice(vm *sobek.Runtime, v reflect.Value, bc *bindCtx) (sobek.Value, error) {
 	// @optimized: Pre-allocate slice and use NewArray(vals...) to avoid repeated Set calls.
 	l := v.Len()
 	vals := make([]interface{}, l)
 	for i := 0; i < l; i++ {
-		elem, err := bindValue(vm, v.Index(i), visited)
+		elem, err := bindValue(vm, v.Index(i), bc)
 		if err != nil {
 			return nil, err
 		}
@@ -350,7 +394,12 @@ func bindSlice(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Val
 	return vm.NewArray(vals...), nil
 }
 
-func bindMap(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Value) (sobek.Value, error) {
+func bindMap(vm *sobek.Runtime, v reflect.Value, bc *bindCtx) (sobek.Value, error) {
+	// Keys that don't survive stringification become a JS Map keyed by bound values
+	if !isStringableKind(v.Type().Key().Kind()) {
+		return bindMapAsMap(vm, v, bc)
+	}
+
 	obj := vm.NewObject()
 	for _, key := range v.MapKeys() {
 		var keyStr string
@@ -361,7 +410,7 @@ func bindMap(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Value
 			keyStr = fmt.Sprint(key.Interface())
 		}
 
-		val, err := bindValue(vm, v.MapIndex(key), visited)
+		val, err := bindValue(vm, v.MapIndex(key), bc)
 		if err != nil {
 			return nil, err
 		}
@@ -369,3 +418,49 @@ func bindMap(vm *sobek.Runtime, v reflect.Value, visited map[uintptr]sobek.Value
 	}
 	return obj, nil
 }
+
+func bindMapAsMap(vm *sobek.Runtime, v reflect.Value, bc *bindCtx) (sobek.Value, error) {
+	m, err := vm.New(vm.Get("Map"))
+	if err != nil {
+		return nil, err
+	}
+	set, _ := sobek.AssertFunction(m.Get("set"))
+	for _, key := range v.MapKeys() {
+		k, err := bindValue(vm, key, bc)
+		if err != nil {
+			return nil, err
+		}
+		val, err := bindValue(vm, v.MapIndex(key), bc)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := set(m, k, val); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// bindBytes exposes byte slices as a Uint8Array. Slices share their backing
+// array with JS; fixed-size arrays are copied because they are values in Go.
+func bindBytes(vm *sobek.Runtime, v reflect.Value) sobek.Value {
+	var data []byte
+	if v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			return sobek.Null()
+		}
+		data = v.Bytes()
+	} else {
+		data = make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(data), v)
+	}
+	u8, _ := vm.New(vm.Get("Uint8Array"), vm.ToValue(vm.NewArrayBuffer(data)))
+	return u8
+}
+
+func timeToDate(vm *sobek.Runtime, t time.Time) (sobek.Value, error) {
+	if t.IsZero() {
+		return sobek.Null(), nil
+	}
+	return vm.New(vm.Get("Date"), vm.ToValue(t.UnixMilli()))
+}
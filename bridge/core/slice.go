@@ -0,0 +1,229 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/grafana/sobek"
+)
+
+const sliceProtoKey = "__typego_slice_proto__"
+
+// Slice is a Go slice exposed to JS as an array-like GoSlice. Elements live
+// in the Go backing array: slicing shares it, appending within capacity
+// writes to it, and Go functions taking []T receive the slice itself.
+// Struct elements are returned live.
+//
+// Like a Go slice variable, a GoSlice has a fixed length; append returns a
+// new GoSlice, except that setting the element at index length appends in
+// place so that push works.
+type Slice struct {
+	vm   *sobek.Runtime
+	v    reflect.Value
+	opts *bindOptions
+	obj  *sobek.Object
+}
+
+// NewSlice wraps an existing Go slice, e.g. one held by Go code.
+func NewSlice(vm *sobek.Runtime, slice interface{}, opts ...BindOption) (*Slice, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("expected a slice, got %T", slice)
+	}
+	return newSlice(vm, v, applyBindOptions(opts)), nil
+}
+
+// MakeSlice creates a slice of elem with the given length and capacity.
+func MakeSlice(vm *sobek.Runtime, elem reflect.Type, length, capacity int, opts ...BindOption) *Slice {
+	return newSlice(vm, reflect.MakeSlice(reflect.SliceOf(elem), length, capacity), applyBindOptions(opts))
+}
+
+func newSlice(vm *sobek.Runtime, v reflect.Value, opts *bindOptions) *Slice {
+	return &Slice{vm: vm, v: v, opts: opts}
+}
+
+// SliceOf returns the slice behind a GoSlice object.
+func SliceOf(v sobek.Value) (*Slice, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok || obj.ExportType() != slicePtrType {
+		return nil, false
+	}
+	s, ok := obj.Export().(*Slice)
+	return s, ok
+}
+
+var slicePtrType = reflect.TypeOf((*Slice)(nil))
+
+// Value returns the Go slice.
+func (s *Slice) Value() reflect.Value {
+	return s.v
+}
+
+// Object returns the JS object for the slice, creating it once.
+func (s *Slice) Object() *sobek.Object {
+	if s.obj == nil {
+		s.obj = s.vm.NewDynamicArray(s)
+		_ = s.obj.SetPrototype(sliceProto(s.vm))
+	}
+	return s.obj
+}
+
+// Len implements sobek.DynamicArray.
+func (s *Slice) Len() int {
+	return s.v.Len()
+}
+
+// Get implements sobek.DynamicArray.
+func (s *Slice) Get(i int) sobek.Value {
+	if i < 0 || i >= s.v.Len() {
+		return nil
+	}
+	elem := s.v.Index(i)
+	if elem.Kind() == reflect.Struct && elem.Type() != timeType {
+		return newLiveObject(s.vm, elem, s.opts)
+	}
+	val, err := bindValue(s.vm, elem, newBindCtx(s.opts))
+	if err != nil {
+		panic(s.vm.NewGoError(err))
+	}
+	return val
+}
+
+// Set implements sobek.DynamicArray. Setting index length appends.
+func (s *Slice) Set(i int, val sobek.Value) bool {
+	switch {
+	case i >= 0 && i < s.v.Len():
+		s.v.Index(i).Set(s.toGo(val))
+		return true
+	case i == s.v.Len():
+		s.v = reflect.Append(s.v, s.toGo(val))
+		return true
+	}
+	return false
+}
+
+// SetLen implements sobek.DynamicArray: a slice can be resliced up to its
+// capacity.
+func (s *Slice) SetLen(n int) bool {
+	if n < 0 || n > s.v.Cap() {
+		return false
+	}
+	s.v = s.v.Slice(0, n)
+	return true
+}
+
+func (s *Slice) toGo(val sobek.Value) reflect.Value {
+	goVal, err := convertJSToGo(s.vm, val, s.v.Type().Elem(), s.opts)
+	if err != nil {
+		panic(s.vm.NewTypeError(fmt.Sprintf("slice of %s: %v", s.v.Type().Elem(), err)))
+	}
+	return goVal
+}
+
+// Append returns a slice with vals appended, sharing the backing array when
+// its capacity allows, like Go's append. A single GoSlice of the same type
+// is appended element by element, as append(s, t...) is.
+func (s *Slice) Append(vals ...sobek.Value) *Slice {
+	if len(vals) == 1 {
+		if other, ok := SliceOf(vals[0]); ok && other.v.Type() == s.v.Type() {
+			return newSlice(s.vm, reflect.AppendSlice(s.v, other.v), s.opts)
+		}
+	}
+	v := s.v
+	for _, val := range vals {
+		v = reflect.Append(v, s.toGo(val))
+	}
+	return newSlice(s.vm, v, s.opts)
+}
+
+// Subslice returns s[lo:hi:max], sharing the backing array.
+func (s *Slice) Subslice(lo, hi, max int) (*Slice, error) {
+	if lo < 0 || hi < lo || max < hi || max > s.v.Cap() {
+		return nil, fmt.Errorf("slice bounds out of range [%d:%d:%d] with capacity %d", lo, hi, max, s.v.Cap())
+	}
+	return newSlice(s.vm, s.v.Slice3(lo, hi, max), s.opts), nil
+}
+
+// Copy copies src, a GoSlice or anything convertible to the slice's type,
+// into s and returns the number of elements copied.
+func (s *Slice) Copy(src sobek.Value) (int, error) {
+	if other, ok := SliceOf(src); ok && other.v.Type().Elem() == s.v.Type().Elem() {
+		return reflect.Copy(s.v, other.v), nil
+	}
+	v, err := convertJSToGo(s.vm, src, s.v.Type(), s.opts)
+	if err != nil {
+		return 0, err
+	}
+	return reflect.Copy(s.v, v), nil
+}
+
+// sliceProto returns the prototype of GoSlices in vm, which inherits
+// Array.prototype and overrides slice with Go's semantics.
+func sliceProto(vm *sobek.Runtime) *sobek.Object {
+	if proto, ok := vm.GlobalObject().Get(sliceProtoKey).(*sobek.Object); ok {
+		return proto
+	}
+
+	proto := vm.NewObject()
+	if ctor, ok := vm.Get("Array").(*sobek.Object); ok {
+		if arrayProto, ok := ctor.Get("prototype").(*sobek.Object); ok {
+			_ = proto.SetPrototype(arrayProto)
+		}
+	}
+	this := func(call sobek.FunctionCall) *Slice {
+		s, ok := SliceOf(call.This)
+		if !ok {
+			panic(vm.NewTypeError("not a GoSlice"))
+		}
+		return s
+	}
+	method := func(name string, fn func(s *Slice, call sobek.FunctionCall) sobek.Value) {
+		_ = proto.DefineDataProperty(name, vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+			return fn(this(call), call)
+		}), sobek.FLAG_TRUE, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	}
+
+	method("len", func(s *Slice, _ sobek.FunctionCall) sobek.Value { return vm.ToValue(s.v.Len()) })
+	method("cap", func(s *Slice, _ sobek.FunctionCall) sobek.Value { return vm.ToValue(s.v.Cap()) })
+	method("append", func(s *Slice, call sobek.FunctionCall) sobek.Value {
+		return s.Append(call.Arguments...).Object()
+	})
+	method("slice", func(s *Slice, call sobek.FunctionCall) sobek.Value {
+		bound := func(i int, def int) int {
+			if v := call.Argument(i); !sobek.IsUndefined(v) {
+				return int(v.ToInteger())
+			}
+			return def
+		}
+		lo := bound(0, 0)
+		hi := bound(1, s.v.Len())
+		sub, err := s.Subslice(lo, hi, bound(2, s.v.Cap()))
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return sub.Object()
+	})
+	method("toArray", func(s *Slice, _ sobek.FunctionCall) sobek.Value {
+		items := make([]interface{}, s.v.Len())
+		for i := range items {
+			items[i] = s.Get(i)
+		}
+		return vm.NewArray(items...)
+	})
+	_ = proto.DefineAccessorProperty("type", vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		return vm.ToValue(this(call).v.Type().Elem().String())
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+
+	_ = vm.GlobalObject().DefineDataProperty(sliceProtoKey, proto, sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+	return proto
+}
+
+// goTypes holds Go types registered for use by name from JS.
+var goTypes sync.Map
+
+// RegisterGoType makes the type of sample available by name to JS APIs
+// taking Go type names, such as make("[]Point", n).
+func RegisterGoType(name string, sample interface{}) {
+	goTypes.Store(name, reflect.TypeOf(sample))
+}
@@ -5,7 +5,8 @@
 //
 //   - bridge/core: Low-level binding primitives and shared types.
 //   - bridge/modules: Standard Go library bindings (go:fmt, go:os, go:net/http).
-//   - bridge/stdlib: TypeGo-specific standard library (typego:memory, typego:worker).
+//   - bridge/stdlib: TypeGo-specific standard library (typego:memory, typego:worker,
+//     typego:sync).
 //
 // # Internal Registration
 //
@@ -16,10 +17,33 @@
 // # Shared Memory
 //
 // TypeGo provides high-performance shared memory between the main thread and workers
-// via the typego:memory module.
+// via the typego:memory module. Its segments are SharedArrayBuffers over Go memory
+// or, on Linux, memory-mapped files, and the Atomics global operates on them with
+// sync/atomic.
+//
+// # Structured Concurrency
+//
+// go() returns a handle that can be awaited. The typego:sync module groups
+// such tasks with WaitGroup, errgroup-style Group, Once and Semaphore.
+// go.parallel runs a self-contained function on a pooled isolated runtime,
+// copying its arguments and result, for CPU parallelism without a Worker.
+//
+// # Console
+//
+// The console global formats its arguments like Node's util.format and
+// util.inspect, in bridge/core. Output goes to os.Stdout and os.Stderr, or
+// to writers or a slog.Handler the host sets on Engine.Console.
+//
+// # Streams
+//
+// The WHATWG streams globals are a JS shim over the event loop. Go readers
+// and writers become streams read and written off the loop, and
+// CompressionStream and DecompressionStream compress in Go.
 //
 // # Worker Support
 //
 // Background workers are supported via the typego:worker module, enabling
 // multi-threaded JavaScript execution with postMessage communication.
+// Messages are structured clones built by intrinsics.ExportTransferable;
+// typego:memory segments cross by reference.
 package bridge
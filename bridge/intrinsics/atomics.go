@@ -0,0 +1,518 @@
+package intrinsics
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/eventloop"
+)
+
+// SharedArrayBuffer and Atomics. A SharedArrayBuffer is an ArrayBuffer over
+// Go memory that crosses to workers by reference, so every runtime views the
+// same bytes. Atomics operate on that memory with sync/atomic, and wait and
+// notify park and wake goroutines by address, which works across runtimes.
+
+// SharedBuffer is the memory behind a SharedArrayBuffer.
+type SharedBuffer struct {
+	Data []byte
+}
+
+// Share returns a SharedArrayBuffer over the same memory in the runtime of el.
+func (b *SharedBuffer) Share(el *eventloop.EventLoop) sobek.Value {
+	return NewSharedArrayBuffer(el.VM, b.Data)
+}
+
+// NewSharedArrayBuffer returns a SharedArrayBuffer over data without copying
+// it.
+func NewSharedArrayBuffer(vm *sobek.Runtime, data []byte) *sobek.Object {
+	obj := vm.ToValue(vm.NewArrayBuffer(data)).(*sobek.Object)
+	if ctor, ok := vm.Get("SharedArrayBuffer").(*sobek.Object); ok {
+		if proto, ok := ctor.Get("prototype").(*sobek.Object); ok {
+			_ = obj.SetPrototype(proto)
+		}
+	}
+	MarkShared(vm, obj, &SharedBuffer{Data: data})
+	return obj
+}
+
+// SharedBytes allocates size zeroed bytes for shared memory, aligned to 8
+// bytes so that Atomics work on every view of them.
+func SharedBytes(size int) []byte {
+	if size == 0 {
+		return []byte{}
+	}
+	words := make([]uint64, (size+7)/8)
+	return unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+}
+
+// SharedOf returns the Shareable behind a value marked with MarkShared.
+func SharedOf(v sobek.Value) (Shareable, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	if mark := obj.GetSymbol(sharedSymbol); mark != nil {
+		s, ok := mark.Export().(Shareable)
+		return s, ok
+	}
+	return nil, false
+}
+
+// isShared reports whether obj is a SharedArrayBuffer.
+func isShared(obj *sobek.Object) bool {
+	s, _ := SharedOf(obj)
+	_, ok := s.(*SharedBuffer)
+	return ok
+}
+
+// EnableAtomics injects SharedArrayBuffer and Atomics.
+func (r *Registry) EnableAtomics() {
+	vm := r.vm
+
+	ctor := vm.ToValue(func(call sobek.ConstructorCall) *sobek.Object {
+		size := call.Argument(0).ToInteger()
+		if size < 0 || size > math.MaxInt32 {
+			panic(NewRangeError(vm, "invalid SharedArrayBuffer length %d", size))
+		}
+		return NewSharedArrayBuffer(vm, SharedBytes(int(size)))
+	}).(*sobek.Object)
+	proto := vm.NewObject()
+	if ab, ok := vm.Get("ArrayBuffer").(*sobek.Object); ok {
+		_ = proto.SetPrototype(ab.Get("prototype").ToObject(vm))
+	}
+	_ = proto.DefineDataProperty("constructor", ctor, sobek.FLAG_TRUE, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	_ = proto.DefineDataPropertySymbol(sobek.SymToStringTag, vm.ToValue("SharedArrayBuffer"), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	_ = ctor.DefineDataProperty("prototype", proto, sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+	_ = vm.Set("SharedArrayBuffer", ctor)
+
+	atomics := vm.NewObject()
+	rmw := map[string]func(old, v uint64) uint64{
+		"add":      func(old, v uint64) uint64 { return old + v },
+		"sub":      func(old, v uint64) uint64 { return old - v },
+		"and":      func(old, v uint64) uint64 { return old & v },
+		"or":       func(old, v uint64) uint64 { return old | v },
+		"xor":      func(old, v uint64) uint64 { return old ^ v },
+		"exchange": func(_, v uint64) uint64 { return v },
+	}
+	for name, op := range rmw {
+		op := op
+		_ = atomics.Set(name, func(call sobek.FunctionCall) sobek.Value {
+			c := r.atomicCell(call, false)
+			v := c.bits(vm, call.Argument(2))
+			return c.value(vm, c.update(func(old uint64) uint64 { return op(old, v) }))
+		})
+	}
+	_ = atomics.Set("load", func(call sobek.FunctionCall) sobek.Value {
+		c := r.atomicCell(call, false)
+		return c.value(vm, c.load())
+	})
+	_ = atomics.Set("store", func(call sobek.FunctionCall) sobek.Value {
+		c := r.atomicCell(call, false)
+		v := call.Argument(2)
+		if !c.big {
+			v = vm.ToValue(v.ToInteger())
+		}
+		bits := c.bits(vm, v)
+		c.update(func(uint64) uint64 { return bits })
+		return v
+	})
+	_ = atomics.Set("compareExchange", func(call sobek.FunctionCall) sobek.Value {
+		c := r.atomicCell(call, false)
+		expected, replacement := c.bits(vm, call.Argument(2)), c.bits(vm, call.Argument(3))
+		return c.value(vm, c.update(func(old uint64) uint64 {
+			if old == expected {
+				return replacement
+			}
+			return old
+		}))
+	})
+	_ = atomics.Set("isLockFree", func(call sobek.FunctionCall) sobek.Value {
+		switch call.Argument(0).ToInteger() {
+		case 1, 2, 4, 8:
+			return vm.ToValue(true)
+		}
+		return vm.ToValue(false)
+	})
+	_ = atomics.Set("wait", r.AtomicsWait)
+	_ = atomics.Set("waitAsync", r.AtomicsWaitAsync)
+	_ = atomics.Set("notify", r.AtomicsNotify)
+	_ = atomics.DefineDataPropertySymbol(sobek.SymToStringTag, vm.ToValue("Atomics"), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	_ = vm.Set("Atomics", atomics)
+}
+
+// AtomicsWait implements Atomics.wait(typedArray, index, value, timeout). It
+// blocks the calling thread until notified, returning "ok", "not-equal" or
+// "timed-out". Like channel operations, it yields VMLock while parked, so
+// go() tasks, one of which may be the notifier, keep running.
+func (r *Registry) AtomicsWait(call sobek.FunctionCall) sobek.Value {
+	c := r.atomicCell(call, true)
+	timeout := waitTimeout(call.Argument(3))
+	w, result := c.park(c.bits(r.vm, call.Argument(2)))
+	if w == nil {
+		return r.vm.ToValue(result)
+	}
+	resume := r.Yield()
+	result = w.wait(timeout, nil)
+	resume()
+	return r.vm.ToValue(result)
+}
+
+// AtomicsWaitAsync implements Atomics.waitAsync: it returns { async, value }
+// where value is a promise of the result when the wait does not end at once.
+func (r *Registry) AtomicsWaitAsync(call sobek.FunctionCall) sobek.Value {
+	vm := r.vm
+	c := r.atomicCell(call, true)
+	timeout := waitTimeout(call.Argument(3))
+	res := vm.NewObject()
+
+	w, result := c.park(c.bits(vm, call.Argument(2)))
+	if w == nil || timeout == 0 {
+		if w != nil {
+			result = w.wait(0, nil)
+		}
+		_ = res.Set("async", false)
+		_ = res.Set("value", result)
+		return res
+	}
+
+	promise, resolve, _ := vm.NewPromise()
+	el := r.el
+	el.WGAdd(1)
+	go func() {
+		result := w.wait(timeout, el.Context().Done())
+		if el.Context().Err() != nil {
+			el.WGDone()
+			return
+		}
+		el.RunOnLoop(func() {
+			defer el.WGDone()
+			_ = resolve(result)
+		})
+	}()
+	_ = res.Set("async", true)
+	_ = res.Set("value", promise)
+	return res
+}
+
+// AtomicsNotify implements Atomics.notify(typedArray, index, count), waking
+// up to count waiters, all by default, and returning how many woke.
+func (r *Registry) AtomicsNotify(call sobek.FunctionCall) sobek.Value {
+	c := r.atomicCell(call, true)
+	count := math.MaxInt
+	if arg := call.Argument(2); !sobek.IsUndefined(arg) {
+		if n := arg.ToFloat(); n < math.MaxInt32 {
+			count = int(math.Max(n, 0))
+		}
+	}
+	return r.vm.ToValue(notifyWaiters(uintptr(c.ptr), count))
+}
+
+// atomicCell is an element of an integer typed array.
+type atomicCell struct {
+	ptr    unsafe.Pointer
+	size   uintptr // Bytes: 1, 2, 4 or 8
+	signed bool
+	big    bool // BigInt64Array or BigUint64Array
+}
+
+// atomicCell resolves the typedArray and index arguments of an Atomics call.
+// With waitable, only Int32Array and BigInt64Array over a SharedArrayBuffer
+// are accepted.
+func (r *Registry) atomicCell(call sobek.FunctionCall, waitable bool) *atomicCell {
+	vm := r.vm
+	obj, ok := call.Argument(0).(*sobek.Object)
+	var buf *sobek.Object
+	if ok {
+		buf, _ = obj.Get("buffer").(*sobek.Object)
+	}
+	if buf == nil {
+		panic(vm.NewTypeError("Atomics operations require an integer typed array"))
+	}
+	if _, ok := buf.Export().(sobek.ArrayBuffer); !ok {
+		panic(vm.NewTypeError("Atomics operations require an integer typed array"))
+	}
+	if clamped, ok := vm.Get("Uint8ClampedArray").(*sobek.Object); ok && vm.InstanceOf(obj, clamped) {
+		panic(vm.NewTypeError("Atomics operations do not support Uint8ClampedArray"))
+	}
+
+	var c atomicCell
+	var n int
+	var base unsafe.Pointer
+	switch s := obj.Export().(type) {
+	case []int8:
+		c, n = atomicCell{size: 1, signed: true}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	case []uint8:
+		c, n = atomicCell{size: 1}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	case []int16:
+		c, n = atomicCell{size: 2, signed: true}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	case []uint16:
+		c, n = atomicCell{size: 2}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	case []int32:
+		c, n = atomicCell{size: 4, signed: true}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	case []uint32:
+		c, n = atomicCell{size: 4}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	case []int64:
+		c, n = atomicCell{size: 8, signed: true, big: true}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	case []uint64:
+		c, n = atomicCell{size: 8, big: true}, len(s)
+		if n > 0 {
+			base = unsafe.Pointer(&s[0])
+		}
+	default:
+		panic(vm.NewTypeError("Atomics operations require an integer typed array"))
+	}
+
+	if waitable {
+		if !c.signed || c.size < 4 {
+			panic(vm.NewTypeError("Atomics.wait and notify require an Int32Array or BigInt64Array"))
+		}
+		if !isShared(buf) {
+			panic(vm.NewTypeError("Atomics.wait and notify require a SharedArrayBuffer"))
+		}
+	}
+
+	index := call.Argument(1).ToInteger()
+	if index < 0 || index >= int64(n) {
+		panic(NewRangeError(vm, "index %d is out of range", index))
+	}
+	c.ptr = unsafe.Add(base, uintptr(index)*c.size)
+	return &c
+}
+
+// bits converts v to the cell's element type, as the typed array would.
+func (c *atomicCell) bits(vm *sobek.Runtime, v sobek.Value) uint64 {
+	if c.big {
+		b, ok := v.Export().(*big.Int)
+		if !ok {
+			panic(vm.NewTypeError("Cannot convert %s to a BigInt", v.String()))
+		}
+		if b.IsInt64() {
+			return uint64(b.Int64())
+		}
+		return new(big.Int).And(b, new(big.Int).SetUint64(math.MaxUint64)).Uint64()
+	}
+	f := v.ToFloat()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return uint64(int64(math.Mod(math.Trunc(f), 1<<32))) & c.mask()
+}
+
+func (c *atomicCell) mask() uint64 {
+	return math.MaxUint64 >> (64 - 8*c.size)
+}
+
+// value converts bits of the cell's element type to JS.
+func (c *atomicCell) value(vm *sobek.Runtime, bits uint64) sobek.Value {
+	shift := 64 - 8*c.size
+	switch {
+	case c.big && c.signed:
+		return vm.ToValue(big.NewInt(int64(bits)))
+	case c.big:
+		return vm.ToValue(new(big.Int).SetUint64(bits))
+	case c.signed:
+		return vm.ToValue(int64(bits<<shift) >> shift)
+	default:
+		return vm.ToValue(bits)
+	}
+}
+
+// littleEndian is the byte order of typed arrays, which is the host's.
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// word returns the aligned 32-bit word holding a 1 or 2 byte cell, and the
+// cell's bit offset in it.
+func (c *atomicCell) word() (*uint32, uint) {
+	addr := uintptr(c.ptr)
+	off := addr & 3
+	shift := off * 8
+	if !littleEndian {
+		shift = (4 - off - c.size) * 8
+	}
+	return (*uint32)(unsafe.Add(c.ptr, -int(off))), uint(shift)
+}
+
+func (c *atomicCell) load() uint64 {
+	switch c.size {
+	case 8:
+		return atomic.LoadUint64((*uint64)(c.ptr))
+	case 4:
+		return uint64(atomic.LoadUint32((*uint32)(c.ptr)))
+	default:
+		w, shift := c.word()
+		return uint64(atomic.LoadUint32(w)>>shift) & c.mask()
+	}
+}
+
+// update atomically replaces the cell's bits with f(old), returning old.
+func (c *atomicCell) update(f func(old uint64) uint64) uint64 {
+	switch c.size {
+	case 8:
+		p := (*uint64)(c.ptr)
+		for {
+			old := atomic.LoadUint64(p)
+			if atomic.CompareAndSwapUint64(p, old, f(old)) {
+				return old
+			}
+		}
+	case 4:
+		p := (*uint32)(c.ptr)
+		for {
+			old := atomic.LoadUint32(p)
+			if atomic.CompareAndSwapUint32(p, old, uint32(f(uint64(old)))) {
+				return uint64(old)
+			}
+		}
+	default:
+		w, shift := c.word()
+		mask := uint32(c.mask()) << shift
+		for {
+			word := atomic.LoadUint32(w)
+			old := uint64(word&mask) >> shift
+			next := word&^mask | uint32(f(old)&c.mask())<<shift
+			if atomic.CompareAndSwapUint32(w, word, next) {
+				return old
+			}
+		}
+	}
+}
+
+// LoadShared atomically reads the size byte integer at off in data, which
+// must be aligned to size.
+func LoadShared(data []byte, off, size int) uint64 {
+	c := atomicCell{ptr: unsafe.Pointer(&data[off]), size: uintptr(size)}
+	return c.load()
+}
+
+// StoreShared atomically writes bits as the size byte integer at off in data.
+func StoreShared(data []byte, off, size int, bits uint64) {
+	c := atomicCell{ptr: unsafe.Pointer(&data[off]), size: uintptr(size)}
+	c.update(func(uint64) uint64 { return bits & c.mask() })
+}
+
+// waiter is a thread parked by Atomics.wait.
+type waiter struct {
+	addr uintptr
+	wake chan struct{}
+}
+
+// waiters holds the parked threads of every runtime, by address.
+var waiters = struct {
+	sync.Mutex
+	byAddr map[uintptr][]*waiter
+}{byAddr: map[uintptr][]*waiter{}}
+
+// park registers a waiter on the cell if it still holds expected; otherwise
+// it returns "not-equal". Checking and parking under one lock means a
+// notify that follows a store cannot be missed.
+func (c *atomicCell) park(expected uint64) (*waiter, string) {
+	waiters.Lock()
+	defer waiters.Unlock()
+	if c.load() != expected&c.mask() {
+		return nil, "not-equal"
+	}
+	w := &waiter{addr: uintptr(c.ptr), wake: make(chan struct{})}
+	waiters.byAddr[w.addr] = append(waiters.byAddr[w.addr], w)
+	return w, ""
+}
+
+// wait blocks until w is notified, timeout passes or cancel is closed; a
+// negative timeout waits forever. Unless notified, w is unregistered.
+func (w *waiter) wait(timeout time.Duration, cancel <-chan struct{}) string {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-w.wake:
+		return "ok"
+	case <-expired:
+	case <-cancel:
+	}
+
+	waiters.Lock()
+	defer waiters.Unlock()
+	list := waiters.byAddr[w.addr]
+	for i, other := range list {
+		if other == w {
+			waiters.byAddr[w.addr] = append(list[:i:i], list[i+1:]...)
+			if len(waiters.byAddr[w.addr]) == 0 {
+				delete(waiters.byAddr, w.addr)
+			}
+			return "timed-out"
+		}
+	}
+	// Notified while timing out
+	return "ok"
+}
+
+// notifyWaiters wakes up to count waiters on addr, oldest first.
+func notifyWaiters(addr uintptr, count int) int {
+	waiters.Lock()
+	defer waiters.Unlock()
+	list := waiters.byAddr[addr]
+	n := 0
+	for n < len(list) && n < count {
+		close(list[n].wake)
+		n++
+	}
+	if n == len(list) {
+		delete(waiters.byAddr, addr)
+	} else {
+		waiters.byAddr[addr] = list[n:]
+	}
+	return n
+}
+
+// waitTimeout converts an Atomics.wait timeout in milliseconds; undefined,
+// NaN and Infinity wait forever.
+func waitTimeout(v sobek.Value) time.Duration {
+	if sobek.IsUndefined(v) {
+		return -1
+	}
+	ms := v.ToFloat()
+	switch {
+	case math.IsNaN(ms) || ms >= math.MaxInt64/float64(time.Millisecond):
+		return -1
+	case ms <= 0:
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// NewRangeError returns a RangeError with a formatted message.
+func NewRangeError(vm *sobek.Runtime, format string, args ...interface{}) *sobek.Object {
+	return (&ParallelError{Name: "RangeError", Message: fmt.Sprintf(format, args...)}).Object(vm)
+}
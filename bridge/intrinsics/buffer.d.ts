@@ -1,18 +1,127 @@
+type BufferEncoding = "utf8" | "utf-8" | "hex" | "base64" | "base64url" | "latin1" | "binary" | "ascii" | "utf16le" | "utf-16le" | "ucs2" | "ucs-2";
+
 /**
  * Buffer provides a way of handling binary data in TypeGo, mirrored from Node.js.
- * In TypeGo, Buffer is a subclass of Uint8Array with additional performance optimizations.
+ * In TypeGo, Buffer is a subclass of Uint8Array over Go-allocated memory;
+ * encoding, searching and comparing run in Go.
  */
-declare interface Buffer extends Uint8Array { }
+declare interface Buffer extends Uint8Array {
+    /**
+     * Decodes the bytes from start to end as a string (utf8 by default).
+     */
+    toString(encoding?: BufferEncoding, start?: number, end?: number): string;
+    toJSON(): { type: "Buffer"; data: number[] };
+    equals(other: Uint8Array): boolean;
+    compare(target: Uint8Array, targetStart?: number, targetEnd?: number, sourceStart?: number, sourceEnd?: number): -1 | 0 | 1;
+    indexOf(value: string | number | Uint8Array, byteOffset?: number | BufferEncoding, encoding?: BufferEncoding): number;
+    lastIndexOf(value: string | number | Uint8Array, byteOffset?: number | BufferEncoding, encoding?: BufferEncoding): number;
+    includes(value: string | number | Uint8Array, byteOffset?: number | BufferEncoding, encoding?: BufferEncoding): boolean;
+    /**
+     * Returns a Buffer over the same memory, like subarray.
+     */
+    slice(start?: number, end?: number): Buffer;
+    subarray(start?: number, end?: number): Buffer;
+    /**
+     * Writes string at offset, without splitting characters, returning the
+     * number of bytes written.
+     */
+    write(string: string, encoding?: BufferEncoding): number;
+    write(string: string, offset: number, encoding?: BufferEncoding): number;
+    write(string: string, offset: number, length: number, encoding?: BufferEncoding): number;
+    fill(value: string | number | Uint8Array, offset?: number, end?: number, encoding?: BufferEncoding): this;
+    fill(value: string, encoding: BufferEncoding): this;
+    copy(target: Uint8Array, targetStart?: number, sourceStart?: number, sourceEnd?: number): number;
+    swap16(): this;
+    swap32(): this;
+    swap64(): this;
+
+    readUInt8(offset?: number): number;
+    readUInt16LE(offset?: number): number;
+    readUInt16BE(offset?: number): number;
+    readUInt32LE(offset?: number): number;
+    readUInt32BE(offset?: number): number;
+    readInt8(offset?: number): number;
+    readInt16LE(offset?: number): number;
+    readInt16BE(offset?: number): number;
+    readInt32LE(offset?: number): number;
+    readInt32BE(offset?: number): number;
+    readFloatLE(offset?: number): number;
+    readFloatBE(offset?: number): number;
+    readDoubleLE(offset?: number): number;
+    readDoubleBE(offset?: number): number;
+    readBigUInt64LE(offset?: number): bigint;
+    readBigUInt64BE(offset?: number): bigint;
+    readBigInt64LE(offset?: number): bigint;
+    readBigInt64BE(offset?: number): bigint;
+    readUIntLE(offset: number, byteLength: number): number;
+    readUIntBE(offset: number, byteLength: number): number;
+    readIntLE(offset: number, byteLength: number): number;
+    readIntBE(offset: number, byteLength: number): number;
+    readUint8: Buffer["readUInt8"];
+    readUint16LE: Buffer["readUInt16LE"];
+    readUint16BE: Buffer["readUInt16BE"];
+    readUint32LE: Buffer["readUInt32LE"];
+    readUint32BE: Buffer["readUInt32BE"];
+    readBigUint64LE: Buffer["readBigUInt64LE"];
+    readBigUint64BE: Buffer["readBigUInt64BE"];
+    readUintLE: Buffer["readUIntLE"];
+    readUintBE: Buffer["readUIntBE"];
+
+    /** Writes value at offset, returning the offset after it. */
+    writeUInt8(value: number, offset?: number): number;
+    writeUInt16LE(value: number, offset?: number): number;
+    writeUInt16BE(value: number, offset?: number): number;
+    writeUInt32LE(value: number, offset?: number): number;
+    writeUInt32BE(value: number, offset?: number): number;
+    writeInt8(value: number, offset?: number): number;
+    writeInt16LE(value: number, offset?: number): number;
+    writeInt16BE(value: number, offset?: number): number;
+    writeInt32LE(value: number, offset?: number): number;
+    writeInt32BE(value: number, offset?: number): number;
+    writeFloatLE(value: number, offset?: number): number;
+    writeFloatBE(value: number, offset?: number): number;
+    writeDoubleLE(value: number, offset?: number): number;
+    writeDoubleBE(value: number, offset?: number): number;
+    writeBigUInt64LE(value: bigint, offset?: number): number;
+    writeBigUInt64BE(value: bigint, offset?: number): number;
+    writeBigInt64LE(value: bigint, offset?: number): number;
+    writeBigInt64BE(value: bigint, offset?: number): number;
+    writeUIntLE(value: number, offset: number, byteLength: number): number;
+    writeUIntBE(value: number, offset: number, byteLength: number): number;
+    writeIntLE(value: number, offset: number, byteLength: number): number;
+    writeIntBE(value: number, offset: number, byteLength: number): number;
+    writeUint8: Buffer["writeUInt8"];
+    writeUint16LE: Buffer["writeUInt16LE"];
+    writeUint16BE: Buffer["writeUInt16BE"];
+    writeUint32LE: Buffer["writeUInt32LE"];
+    writeUint32BE: Buffer["writeUInt32BE"];
+    writeBigUint64LE: Buffer["writeBigUInt64LE"];
+    writeBigUint64BE: Buffer["writeBigUInt64BE"];
+    writeUintLE: Buffer["writeUIntLE"];
+    writeUintBE: Buffer["writeUIntBE"];
+}
 
 declare var Buffer: {
+    prototype: Buffer;
+    readonly poolSize: number;
     /**
-     * Allocates a new Buffer of size bytes.
+     * Allocates a new zeroed Buffer of size bytes, optionally filled.
      * Backed by high-performance Go memory allocation.
      */
-    alloc(size: number): Buffer;
+    alloc(size: number, fill?: string | number | Uint8Array, encoding?: BufferEncoding): Buffer;
+    allocUnsafe(size: number): Buffer;
+    allocUnsafeSlow(size: number): Buffer;
     /**
      * Creates a new Buffer from the given data.
-     * If data is a string, it defaults to UTF-8 encoding.
+     * If data is a string, it defaults to UTF-8 encoding. A Buffer from an
+     * ArrayBuffer shares its memory; other data is copied.
      */
-    from(data: string | number[] | Uint8Array | ArrayBuffer, encoding?: string): Buffer;
+    from(data: string, encoding?: BufferEncoding): Buffer;
+    from(data: ArrayBuffer | SharedArrayBuffer, byteOffset?: number, length?: number): Buffer;
+    from(data: ArrayLike<number> | ArrayBufferView | { type: "Buffer"; data: number[] }): Buffer;
+    isBuffer(value: any): value is Buffer;
+    isEncoding(encoding: string): encoding is BufferEncoding;
+    byteLength(value: string | ArrayBufferView | ArrayBuffer, encoding?: BufferEncoding): number;
+    concat(list: readonly Uint8Array[], totalLength?: number): Buffer;
+    compare(a: Uint8Array, b: Uint8Array): -1 | 0 | 1;
 };
@@ -1,52 +1,268 @@
 package intrinsics
 
 import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/grafana/sobek"
 )
 
 // Buffer intrinsics provide high-performance byte array operations.
 
-// BufferAlloc implements Buffer.alloc(size)
+// BufferShimJS is the JavaScript implementation of the Buffer global, a
+// Uint8Array subclass mirroring Node's.
+//
+//go:embed buffer.js
+var BufferShimJS string
+
+// BufferAlloc implements __bufferAlloc(size): a zeroed ArrayBuffer of size
+// bytes allocated by Go.
 func (r *Registry) BufferAlloc(call sobek.FunctionCall) sobek.Value {
 	size := 0
 	if len(call.Arguments) > 0 {
 		size = int(call.Arguments[0].ToInteger())
 	}
+	if size < 0 {
+		panic(NewRangeError(r.vm, "invalid buffer size %d", size))
+	}
+	return r.vm.ToValue(r.vm.NewArrayBuffer(make([]byte, size)))
+}
 
-	bytes := make([]byte, size)
-	buf := r.vm.NewArrayBuffer(bytes)
-	u8 := r.vm.Get("Uint8Array").ToObject(r.vm)
-	tArray, _ := r.vm.New(u8, r.vm.ToValue(buf))
+// BufferFrom implements __bufferFrom(string, encoding): an ArrayBuffer of
+// the string encoded.
+func (r *Registry) BufferFrom(call sobek.FunctionCall) sobek.Value {
+	enc := r.bufferEncoding(call.Argument(1))
+	return r.vm.ToValue(r.vm.NewArrayBuffer(encodeString(jsString(call.Argument(0)), enc)))
+}
 
-	return tArray
+// BufferByteLength implements __bufferByteLength(string, encoding).
+func (r *Registry) BufferByteLength(call sobek.FunctionCall) sobek.Value {
+	s := jsString(call.Argument(0))
+	switch enc := r.bufferEncoding(call.Argument(1)); enc {
+	case "utf8":
+		n := 0
+		for i := 0; i < s.Length(); {
+			c, units := codePointAt(s, i)
+			n += utf8.RuneLen(c)
+			i += units
+		}
+		return r.vm.ToValue(n)
+	case "latin1", "ascii":
+		return r.vm.ToValue(s.Length())
+	case "utf16le":
+		return r.vm.ToValue(2 * s.Length())
+	default:
+		return r.vm.ToValue(len(encodeString(s, enc)))
+	}
 }
 
-// BufferFrom implements Buffer.from(data, encoding)
-func (r *Registry) BufferFrom(call sobek.FunctionCall) sobek.Value {
-	if len(call.Arguments) == 0 {
-		return r.vm.ToValue([]byte{})
+// BufferToString implements __bufferToString(view, encoding, start, end).
+func (r *Registry) BufferToString(call sobek.FunctionCall) sobek.Value {
+	data, ok := viewBytes(call.Argument(0))
+	if !ok {
+		panic(r.vm.NewTypeError("argument must be a buffer"))
 	}
+	enc := r.bufferEncoding(call.Argument(1))
+	start, end := clampRange(len(data), call.Argument(2), call.Argument(3))
+	return decodeBytes(r.vm, data[start:end], enc)
+}
 
-	arg := call.Arguments[0]
+// BufferWrite implements __bufferWrite(view, string, offset, length,
+// encoding): the string encoded into view at offset, up to length bytes,
+// returning the bytes written. Characters are not split.
+func (r *Registry) BufferWrite(call sobek.FunctionCall) sobek.Value {
+	data, ok := viewBytes(call.Argument(0))
+	if !ok {
+		panic(r.vm.NewTypeError("argument must be a buffer"))
+	}
+	enc := r.bufferEncoding(call.Argument(4))
+	offset, end := clampRange(len(data), call.Argument(2), sobek.Undefined())
+	if l := call.Argument(3); !sobek.IsUndefined(l) {
+		end = min(end, offset+int(max(l.ToInteger(), 0)))
+	}
+	encoded := encodeString(jsString(call.Argument(1)), enc)
+	n := min(len(encoded), end-offset)
+	switch enc {
+	case "utf8":
+		for n < len(encoded) && n > 0 && !utf8.RuneStart(encoded[n]) {
+			n--
+		}
+	case "utf16le":
+		n &^= 1
+	}
+	return r.vm.ToValue(copy(data[offset:], encoded[:n]))
+}
 
-	// If it's a string, we default to UTF-8
-	if arg.ExportType().String() == "string" {
-		str := arg.String()
-		bytes := []byte(str)
+// BufferCompare implements __bufferCompare(a, b): -1, 0 or 1 as the bytes
+// of a sort before, equal or after those of b.
+func (r *Registry) BufferCompare(call sobek.FunctionCall) sobek.Value {
+	a, ok1 := viewBytes(call.Argument(0))
+	b, ok2 := viewBytes(call.Argument(1))
+	if !ok1 || !ok2 {
+		panic(r.vm.NewTypeError("arguments must be buffers"))
+	}
+	return r.vm.ToValue(bytes.Compare(a, b))
+}
 
-		buf := r.vm.NewArrayBuffer(bytes)
-		u8 := r.vm.Get("Uint8Array").ToObject(r.vm)
-		tArray, _ := r.vm.New(u8, r.vm.ToValue(buf))
-		return tArray
+// BufferIndexOf implements __bufferIndexOf(haystack, needle, offset, last):
+// the first index at or after offset, or with last the last index at or
+// before it, where needle occurs in haystack, or -1. An empty needle is
+// found at offset, clamped to the haystack.
+func (r *Registry) BufferIndexOf(call sobek.FunctionCall) sobek.Value {
+	hay, ok1 := viewBytes(call.Argument(0))
+	needle, ok2 := viewBytes(call.Argument(1))
+	if !ok1 || !ok2 {
+		panic(r.vm.NewTypeError("arguments must be buffers"))
+	}
+	offset := int(call.Argument(2).ToInteger())
+	last := call.Argument(3).ToBoolean()
+	if offset < 0 {
+		if offset += len(hay); offset < 0 {
+			if last {
+				return r.vm.ToValue(-1)
+			}
+			offset = 0
+		}
 	}
+	if len(needle) == 0 {
+		return r.vm.ToValue(min(offset, len(hay)))
+	}
+	if last {
+		end := min(offset+len(needle), len(hay))
+		return r.vm.ToValue(bytes.LastIndex(hay[:end], needle))
+	}
+	if offset >= len(hay) {
+		return r.vm.ToValue(-1)
+	}
+	i := bytes.Index(hay[offset:], needle)
+	if i >= 0 {
+		i += offset
+	}
+	return r.vm.ToValue(i)
+}
 
-	// If it's already a TypedArray or ArrayBuffer, we return a new view
-	if _, ok := arg.(*sobek.Object); ok {
-		// New Uint8Array from existing buffer/array
-		u8 := r.vm.Get("Uint8Array").ToObject(r.vm)
-		tArray, _ := r.vm.New(u8, arg)
-		return tArray
+// bufferEncoding returns the canonical name of a Buffer encoding, utf8 when
+// undefined, throwing a TypeError for unknown encodings.
+func (r *Registry) bufferEncoding(v sobek.Value) string {
+	if sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return "utf8"
+	}
+	switch enc := strings.ToLower(v.String()); enc {
+	case "utf8", "hex", "base64", "base64url", "latin1", "ascii", "utf16le":
+		return enc
+	case "utf-8":
+		return "utf8"
+	case "binary":
+		return "latin1"
+	case "ucs2", "ucs-2", "utf-16le":
+		return "utf16le"
+	default:
+		panic(r.vm.NewTypeError("Unknown encoding: %s", v.String()))
 	}
+}
 
-	return r.vm.ToValue([]byte{})
+// encodeString returns s in a Buffer encoding. Like Node, hex stops at the
+// first invalid pair, base64 accepts either alphabet and skips other
+// characters, and latin1 and ascii keep the low byte of each code unit.
+func encodeString(s sobek.String, enc string) []byte {
+	switch enc {
+	case "hex":
+		str := s.String()
+		out := make([]byte, 0, len(str)/2)
+		for i := 0; i+1 < len(str); i += 2 {
+			b, err := hex.DecodeString(str[i : i+2])
+			if err != nil {
+				break
+			}
+			out = append(out, b[0])
+		}
+		return out
+	case "base64", "base64url":
+		str := s.String()
+		clean := make([]byte, 0, len(str))
+		for i := 0; i < len(str); i++ {
+			c := str[i]
+			switch {
+			case c == '=':
+				i = len(str)
+			case c == '-':
+				clean = append(clean, '+')
+			case c == '_':
+				clean = append(clean, '/')
+			case c == '+' || c == '/' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+				clean = append(clean, c)
+			}
+		}
+		if len(clean)%4 == 1 {
+			clean = clean[:len(clean)-1]
+		}
+		out := make([]byte, base64.RawStdEncoding.DecodedLen(len(clean)))
+		n, _ := base64.RawStdEncoding.Decode(out, clean)
+		return out[:n]
+	case "latin1", "ascii":
+		out := make([]byte, s.Length())
+		for i := range out {
+			out[i] = byte(s.CharAt(i))
+		}
+		return out
+	case "utf16le":
+		out := make([]byte, 2*s.Length())
+		for i := 0; i < s.Length(); i++ {
+			c := s.CharAt(i)
+			out[2*i], out[2*i+1] = byte(c), byte(c>>8)
+		}
+		return out
+	default:
+		return []byte(s.String())
+	}
+}
+
+// decodeBytes returns data decoded from a Buffer encoding.
+func decodeBytes(vm *sobek.Runtime, data []byte, enc string) sobek.Value {
+	switch enc {
+	case "hex":
+		return vm.ToValue(hex.EncodeToString(data))
+	case "base64":
+		return vm.ToValue(base64.StdEncoding.EncodeToString(data))
+	case "base64url":
+		return vm.ToValue(base64.RawURLEncoding.EncodeToString(data))
+	case "latin1", "ascii":
+		units := make([]uint16, len(data))
+		for i, b := range data {
+			if enc == "ascii" {
+				b &= 0x7f
+			}
+			units[i] = uint16(b)
+		}
+		return sobek.StringFromUTF16(units)
+	case "utf16le":
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		}
+		return sobek.StringFromUTF16(units)
+	default:
+		if utf8.Valid(data) {
+			return vm.ToValue(string(data))
+		}
+		units, _, _ := decodeUTF8(nil, data, true, false)
+		return sobek.StringFromUTF16(units)
+	}
+}
+
+// clampRange returns the start and end arguments as indexes into n bytes,
+// clamped, with end defaulting to n.
+func clampRange(n int, start, end sobek.Value) (int, int) {
+	s, e := 0, n
+	if !sobek.IsUndefined(start) {
+		s = int(min(max(start.ToInteger(), 0), int64(n)))
+	}
+	if !sobek.IsUndefined(end) {
+		e = int(min(max(end.ToInteger(), 0), int64(n)))
+	}
+	return s, max(s, e)
 }
@@ -0,0 +1,426 @@
+// Buffer: Node's Buffer, a Uint8Array subclass over Go-allocated memory.
+// Encoding, searching and comparing run in Go; the numeric read and write
+// methods use a DataView over the buffer's bytes.
+(function () {
+	'use strict';
+	if (typeof globalThis.Buffer !== 'undefined') return;
+
+	const kMaxLength = 2 ** 32;
+
+	function outOfRange(name, range, value) {
+		const e = new RangeError(`The value of "${name}" is out of range. It must be ${range}. Received ${value}`);
+		e.code = 'ERR_OUT_OF_RANGE';
+		return e;
+	}
+
+	function invalidType(name, expected, value) {
+		const e = new TypeError(`The "${name}" argument must be ${expected}. Received ${value === null ? 'null' : typeof value}`);
+		e.code = 'ERR_INVALID_ARG_TYPE';
+		return e;
+	}
+
+	function checkSize(size) {
+		if (typeof size !== 'number') throw invalidType('size', 'of type number', size);
+		if (!(size >= 0 && size <= kMaxLength)) throw outOfRange('size', `>= 0 && <= ${kMaxLength}`, size);
+	}
+
+	function isBytes(value) {
+		return value instanceof Uint8Array;
+	}
+
+	// checkOffset validates the offset of a read or write of size bytes.
+	function checkOffset(buf, offset, size) {
+		if (typeof offset !== 'number') throw invalidType('offset', 'of type number', offset);
+		if (!Number.isInteger(offset) || offset < 0 || offset + size > buf.length) {
+			if (buf.length - size < 0) {
+				const e = new RangeError('Attempt to access memory outside buffer bounds');
+				e.code = 'ERR_BUFFER_OUT_OF_BOUNDS';
+				throw e;
+			}
+			throw outOfRange('offset', `>= 0 and <= ${buf.length - size}`, offset);
+		}
+	}
+
+	function checkInt(value, min, max) {
+		if (typeof value !== 'number') throw invalidType('value', 'of type number', value);
+		if (!(value >= min && value <= max)) throw outOfRange('value', `>= ${min} and <= ${max}`, value);
+	}
+
+	function checkBigInt(value, min, max) {
+		if (typeof value !== 'bigint') throw invalidType('value', 'of type bigint', value);
+		if (value < min || value > max) throw outOfRange('value', `>= ${min}n and <= ${max}n`, value + 'n');
+	}
+
+	function view(buf) {
+		return new DataView(buf.buffer, buf.byteOffset, buf.byteLength);
+	}
+
+	// needleOf returns the bytes indexOf and friends search for.
+	function needleOf(value, encoding) {
+		if (typeof value === 'string') return Buffer.from(value, encoding);
+		if (typeof value === 'number') return Uint8Array.of(value & 0xff);
+		if (isBytes(value)) return value;
+		throw invalidType('value', 'one of type number or string or an instance of Buffer or Uint8Array', value);
+	}
+
+	function search(buf, value, byteOffset, encoding, last) {
+		if (typeof byteOffset === 'string') {
+			encoding = byteOffset;
+			byteOffset = undefined;
+		}
+		let offset = byteOffset === undefined ? NaN : +byteOffset;
+		if (Number.isNaN(offset)) offset = last ? buf.length : 0;
+		offset = Math.trunc(offset);
+		return __bufferIndexOf(buf, needleOf(value, encoding), offset, last);
+	}
+
+	class Buffer extends Uint8Array {
+		static alloc(size, fill, encoding) {
+			checkSize(size);
+			const buf = new Buffer(__bufferAlloc(size));
+			if (fill !== undefined && fill !== 0 && size > 0) buf.fill(fill, encoding);
+			return buf;
+		}
+
+		static allocUnsafe(size) {
+			checkSize(size);
+			return new Buffer(__bufferAlloc(size));
+		}
+
+		static allocUnsafeSlow(size) {
+			return Buffer.allocUnsafe(size);
+		}
+
+		// from copies strings, arrays and views into a new buffer, and shares
+		// the memory of an ArrayBuffer.
+		static from(value, encodingOrOffset, length) {
+			if (typeof value === 'string') return new Buffer(__bufferFrom(value, encodingOrOffset));
+			if (value instanceof ArrayBuffer || (typeof SharedArrayBuffer !== 'undefined' && value instanceof SharedArrayBuffer)) {
+				const offset = encodingOrOffset === undefined ? 0 : +encodingOrOffset || 0;
+				if (offset > value.byteLength) throw outOfRange('offset', `<= ${value.byteLength}`, offset);
+				return new Buffer(value, offset, length === undefined ? value.byteLength - offset : length);
+			}
+			if (ArrayBuffer.isView(value)) {
+				if (value instanceof DataView) return Buffer.from(new Uint8Array(value.buffer, value.byteOffset, value.byteLength));
+				const buf = Buffer.allocUnsafe(value.length);
+				buf.set(value);
+				return buf;
+			}
+			if (value !== null && typeof value === 'object') {
+				if (value.type === 'Buffer' && Array.isArray(value.data)) return Buffer.from(value.data);
+				if (typeof value.length === 'number' || Array.isArray(value)) {
+					const buf = Buffer.allocUnsafe(value.length >>> 0);
+					for (let i = 0; i < buf.length; i++) buf[i] = value[i] & 0xff;
+					return buf;
+				}
+				const prim = typeof value[Symbol.toPrimitive] === 'function' ? value[Symbol.toPrimitive]('string') : value.valueOf();
+				if (prim !== value && prim != null) return Buffer.from(prim, encodingOrOffset, length);
+			}
+			throw invalidType('first', 'of type string or an instance of Buffer, ArrayBuffer, or Array or an Array-like Object', value);
+		}
+
+		static isBuffer(value) {
+			return value instanceof Buffer;
+		}
+
+		static isEncoding(encoding) {
+			if (typeof encoding !== 'string' || encoding === '') return false;
+			try {
+				__bufferByteLength('', encoding);
+				return true;
+			} catch (e) {
+				return false;
+			}
+		}
+
+		static byteLength(value, encoding) {
+			if (ArrayBuffer.isView(value) || value instanceof ArrayBuffer ||
+				(typeof SharedArrayBuffer !== 'undefined' && value instanceof SharedArrayBuffer)) {
+				return value.byteLength;
+			}
+			if (typeof value !== 'string') throw invalidType('string', 'of type string or an instance of Buffer or ArrayBuffer', value);
+			return __bufferByteLength(value, encoding);
+		}
+
+		static concat(list, totalLength) {
+			if (!Array.isArray(list)) throw invalidType('list', 'an instance of Array', list);
+			list.forEach((item, i) => {
+				if (!isBytes(item)) throw invalidType(`list[${i}]`, 'an instance of Buffer or Uint8Array', item);
+			});
+			if (totalLength === undefined) totalLength = list.reduce((n, item) => n + item.length, 0);
+			const buf = Buffer.alloc(totalLength);
+			let pos = 0;
+			for (const item of list) {
+				if (pos >= totalLength) break;
+				const n = Math.min(item.length, totalLength - pos);
+				buf.set(n === item.length ? item : item.subarray(0, n), pos);
+				pos += n;
+			}
+			return buf;
+		}
+
+		static compare(a, b) {
+			if (!isBytes(a)) throw invalidType('buf1', 'an instance of Buffer or Uint8Array', a);
+			if (!isBytes(b)) throw invalidType('buf2', 'an instance of Buffer or Uint8Array', b);
+			return __bufferCompare(a, b);
+		}
+
+		toString(encoding, start, end) {
+			return __bufferToString(this, encoding, start, end);
+		}
+
+		toLocaleString(encoding, start, end) {
+			return this.toString(encoding, start, end);
+		}
+
+		toJSON() {
+			return { type: 'Buffer', data: Array.from(this) };
+		}
+
+		equals(other) {
+			if (!isBytes(other)) throw invalidType('otherBuffer', 'an instance of Buffer or Uint8Array', other);
+			return __bufferCompare(this, other) === 0;
+		}
+
+		compare(target, targetStart = 0, targetEnd = target.length, sourceStart = 0, sourceEnd = this.length) {
+			if (!isBytes(target)) throw invalidType('target', 'an instance of Buffer or Uint8Array', target);
+			if (targetStart < 0 || targetEnd > target.length || targetStart > targetEnd) {
+				throw outOfRange('targetStart', `>= 0 and <= ${target.length}`, targetStart);
+			}
+			if (sourceStart < 0 || sourceEnd > this.length || sourceStart > sourceEnd) {
+				throw outOfRange('sourceStart', `>= 0 and <= ${this.length}`, sourceStart);
+			}
+			const sub = Uint8Array.prototype.subarray;
+			return __bufferCompare(sub.call(this, sourceStart, sourceEnd), sub.call(target, targetStart, targetEnd));
+		}
+
+		indexOf(value, byteOffset, encoding) {
+			return search(this, value, byteOffset, encoding, false);
+		}
+
+		lastIndexOf(value, byteOffset, encoding) {
+			return search(this, value, byteOffset, encoding, true);
+		}
+
+		includes(value, byteOffset, encoding) {
+			return this.indexOf(value, byteOffset, encoding) !== -1;
+		}
+
+		// slice shares memory, as subarray does, unlike Uint8Array's slice.
+		slice(start, end) {
+			return this.subarray(start, end);
+		}
+
+		write(string, offset, length, encoding) {
+			if (typeof string !== 'string') throw invalidType('argument', 'of type string', string);
+			if (typeof offset === 'string') {
+				encoding = offset;
+				offset = length = undefined;
+			} else if (typeof length === 'string') {
+				encoding = length;
+				length = undefined;
+			}
+			offset = offset === undefined ? 0 : offset;
+			if (offset < 0 || offset > this.length) throw outOfRange('offset', `>= 0 && <= ${this.length}`, offset);
+			return __bufferWrite(this, string, offset, length, encoding);
+		}
+
+		// fill repeats a byte, the bytes of a string or those of a buffer.
+		fill(value, offset, end, encoding) {
+			if (typeof offset === 'string') {
+				encoding = offset;
+				offset = end = undefined;
+			} else if (typeof end === 'string') {
+				encoding = end;
+				end = undefined;
+			}
+			offset = offset === undefined ? 0 : offset;
+			end = end === undefined ? this.length : end;
+			if (offset < 0 || offset > this.length) throw outOfRange('offset', `>= 0 && <= ${this.length}`, offset);
+			if (end < 0 || end > this.length) throw outOfRange('end', `>= 0 && <= ${this.length}`, end);
+			if (end <= offset) return this;
+
+			let bytes;
+			if (typeof value === 'string') {
+				bytes = Buffer.from(value, encoding);
+				if (bytes.length === 0) {
+					if (value !== '') {
+						const e = new TypeError(`The argument 'value' is invalid. Received '${value}'`);
+						e.code = 'ERR_INVALID_ARG_VALUE';
+						throw e;
+					}
+					bytes = Uint8Array.of(0);
+				}
+			} else if (isBytes(value)) {
+				bytes = value;
+			} else {
+				Uint8Array.prototype.fill.call(this, Number(value) & 0xff, offset, end);
+				return this;
+			}
+			// Double the filled prefix until the range is full
+			let n = Math.min(bytes.length, end - offset);
+			Uint8Array.prototype.set.call(this, bytes.subarray(0, n), offset);
+			while (offset + n < end) {
+				const k = Math.min(n, end - offset - n);
+				this.copyWithin(offset + n, offset, offset + k);
+				n += k;
+			}
+			return this;
+		}
+
+		copy(target, targetStart = 0, sourceStart = 0, sourceEnd = this.length) {
+			if (!isBytes(target)) throw invalidType('target', 'an instance of Buffer or Uint8Array', target);
+			if (targetStart < 0) throw outOfRange('targetStart', '>= 0', targetStart);
+			if (sourceStart < 0 || sourceStart > this.length) throw outOfRange('sourceStart', `>= 0 && <= ${this.length}`, sourceStart);
+			if (sourceEnd < 0) throw outOfRange('sourceEnd', '>= 0', sourceEnd);
+			sourceEnd = Math.min(sourceEnd, this.length);
+			const n = Math.min(sourceEnd - sourceStart, target.length - targetStart);
+			if (n <= 0) return 0;
+			Uint8Array.prototype.set.call(target, Uint8Array.prototype.subarray.call(this, sourceStart, sourceStart + n), targetStart);
+			return n;
+		}
+
+		swap16() {
+			return swap(this, 2);
+		}
+
+		swap32() {
+			return swap(this, 4);
+		}
+
+		swap64() {
+			return swap(this, 8);
+		}
+
+		readUIntLE(offset, byteLength) {
+			return readVarInt(this, offset, byteLength, true, false);
+		}
+
+		readUIntBE(offset, byteLength) {
+			return readVarInt(this, offset, byteLength, false, false);
+		}
+
+		readIntLE(offset, byteLength) {
+			return readVarInt(this, offset, byteLength, true, true);
+		}
+
+		readIntBE(offset, byteLength) {
+			return readVarInt(this, offset, byteLength, false, true);
+		}
+
+		writeUIntLE(value, offset, byteLength) {
+			return writeVarInt(this, value, offset, byteLength, true, false);
+		}
+
+		writeUIntBE(value, offset, byteLength) {
+			return writeVarInt(this, value, offset, byteLength, false, false);
+		}
+
+		writeIntLE(value, offset, byteLength) {
+			return writeVarInt(this, value, offset, byteLength, true, true);
+		}
+
+		writeIntBE(value, offset, byteLength) {
+			return writeVarInt(this, value, offset, byteLength, false, true);
+		}
+	}
+
+	function swap(buf, size) {
+		if (buf.length % size !== 0) {
+			const e = new RangeError(`Buffer size must be a multiple of ${size * 8}-bits`);
+			e.code = 'ERR_INVALID_BUFFER_SIZE';
+			throw e;
+		}
+		for (let i = 0; i < buf.length; i += size) {
+			for (let a = i, b = i + size - 1; a < b; a++, b--) {
+				const t = buf[a];
+				buf[a] = buf[b];
+				buf[b] = t;
+			}
+		}
+		return buf;
+	}
+
+	function checkByteLength(byteLength) {
+		if (!Number.isInteger(byteLength) || byteLength < 1 || byteLength > 6) {
+			throw outOfRange('byteLength', '>= 1 and <= 6', byteLength);
+		}
+	}
+
+	// readVarInt reads an integer of 1 to 6 bytes, which fits a number.
+	function readVarInt(buf, offset, byteLength, littleEndian, signed) {
+		checkByteLength(byteLength);
+		checkOffset(buf, offset, byteLength);
+		let value = 0;
+		for (let i = 0; i < byteLength; i++) {
+			value = value * 256 + buf[offset + (littleEndian ? byteLength - 1 - i : i)];
+		}
+		const limit = 2 ** (8 * byteLength);
+		return signed && value >= limit / 2 ? value - limit : value;
+	}
+
+	function writeVarInt(buf, value, offset, byteLength, littleEndian, signed) {
+		checkByteLength(byteLength);
+		const limit = 2 ** (8 * byteLength);
+		if (signed) checkInt(value, -limit / 2, limit / 2 - 1);
+		else checkInt(value, 0, limit - 1);
+		checkOffset(buf, offset, byteLength);
+		let v = value < 0 ? value + limit : value;
+		for (let i = 0; i < byteLength; i++) {
+			buf[offset + (littleEndian ? i : byteLength - 1 - i)] = v % 256;
+			v = Math.floor(v / 256);
+		}
+		return offset + byteLength;
+	}
+
+	// The fixed-size read and write methods, as [name, size, DataView type,
+	// range] with the endianness appended to the name where it matters.
+	const numeric = [
+		['UInt8', 1, 'Uint8', [0, 0xff]],
+		['Int8', 1, 'Int8', [-0x80, 0x7f]],
+		['UInt16', 2, 'Uint16', [0, 0xffff]],
+		['Int16', 2, 'Int16', [-0x8000, 0x7fff]],
+		['UInt32', 4, 'Uint32', [0, 0xffffffff]],
+		['Int32', 4, 'Int32', [-0x80000000, 0x7fffffff]],
+		['Float', 4, 'Float32'],
+		['Double', 8, 'Float64'],
+		['BigUInt64', 8, 'BigUint64', [0n, 2n ** 64n - 1n]],
+		['BigInt64', 8, 'BigInt64', [-(2n ** 63n), 2n ** 63n - 1n]],
+	];
+	const proto = Buffer.prototype;
+	for (const [name, size, type, range] of numeric) {
+		const get = DataView.prototype['get' + type];
+		const set = DataView.prototype['set' + type];
+		const check = range === undefined ? (value) => {
+			if (typeof value !== 'number') throw invalidType('value', 'of type number', value);
+		} : typeof range[0] === 'bigint' ? (value) => checkBigInt(value, range[0], range[1])
+			: (value) => checkInt(value, range[0], range[1]);
+		const endians = size === 1 ? [['', false]] : [['LE', true], ['BE', false]];
+		for (const [suffix, littleEndian] of endians) {
+			const read = function (offset = 0) {
+				checkOffset(this, offset, size);
+				return get.call(view(this), offset, littleEndian);
+			};
+			const write = function (value, offset = 0) {
+				check(value);
+				checkOffset(this, offset, size);
+				set.call(view(this), offset, value, littleEndian);
+				return offset + size;
+			};
+			for (const alias of new Set([name, name.replace('UInt', 'Uint')])) {
+				proto['read' + alias + suffix] = read;
+				proto['write' + alias + suffix] = write;
+			}
+		}
+	}
+	for (const name of ['readUIntLE', 'readUIntBE', 'writeUIntLE', 'writeUIntBE']) {
+		proto[name.replace('UInt', 'Uint')] = proto[name];
+	}
+	// Defined by assignment above; make them non-enumerable like class methods.
+	for (const key of Object.keys(proto)) {
+		Object.defineProperty(proto, key, { enumerable: false });
+	}
+
+	Buffer.poolSize = 8192;
+	Object.defineProperty(globalThis, 'Buffer', { value: Buffer, writable: true, configurable: true, enumerable: false });
+})();
@@ -1,44 +1,128 @@
+/**
+ * Handle of a goroutine started with go(). Awaiting it yields the function's
+ * result, or rejects with the exception or panic that ended it. Awaited
+ * goroutines keep the program alive; others do not, as in Go.
+ */
+interface GoTask<T> extends PromiseLike<T> {
+    wait(): Promise<T>;
+    catch<R = never>(onrejected?: (reason: any) => R | PromiseLike<R>): Promise<T | R>;
+    readonly done: boolean;
+}
+
 /**
  * Launches a new goroutine to execute the provided function.
  * @param fn The function to execute in the background.
  * @param args Arguments to pass to the function.
  */
-declare function go<T extends (...args: any[]) => any>(fn: T, ...args: Parameters<T>): void;
+declare function go<T extends (...args: any[]) => any>(fn: T, ...args: Parameters<T>): GoTask<Awaited<ReturnType<T>>>;
+
+declare namespace go {
+    /**
+     * Runs fn on an isolated runtime from a pool, on its own OS thread, for
+     * true CPU parallelism. Only the function's source and its arguments
+     * cross over: fn cannot use closures or globals of the caller.
+     * Arguments and the result are copied like structured clone (primitives,
+     * arrays, plain objects, Dates, Errors, ArrayBuffers and typed arrays).
+     * @param fn A self-contained function.
+     * @param args Transferable arguments to pass to fn.
+     */
+    function parallel<T extends (...args: any[]) => any>(fn: T, ...args: Parameters<T>): Promise<Awaited<ReturnType<T>>>;
+}
+
+/**
+ * Go element types a channel can be created with.
+ */
+type GoElemType =
+    | "any" | "bool" | "string" | "error" | "[]byte"
+    | "int" | "int8" | "int16" | "int32" | "int64"
+    | "uint" | "uint8" | "uint16" | "uint32" | "uint64"
+    | "float32" | "float64"
+    | `[]${string}` | `map[string]${string}`;
 
 /**
  * A Go-style channel for synchronized communication.
+ * Iterate with `for await (const v of ch)` until the channel is closed.
  */
-interface Chan<T> {
+interface Chan<T> extends AsyncIterable<T> {
     /**
      * Sends a value into the channel. Blocks if the channel is full.
+     * Panics if the channel is closed.
      */
     send(val: T): void;
 
     /**
      * Receives a value from the channel. Blocks if the channel is empty.
+     * Returns undefined once the channel is closed and drained.
+     */
+    recv(): T | undefined;
+
+    /**
+     * Sends without blocking. Returns false if the send would block.
+     */
+    trySend(val: T): boolean;
+
+    /**
+     * Receives without blocking. The flag is false if no value was ready.
      */
-    recv(): T;
+    tryRecv(): [T | undefined, boolean];
 
     /**
-     * Closes the channel. Subsequent sends will panic.
+     * Receives without blocking the JS thread. Resolves with undefined once
+     * the channel is closed and drained.
+     */
+    recvAsync(): Promise<T | undefined>;
+
+    /**
+     * Closes the channel. Subsequent sends, and closing again, panic.
      */
     close(): void;
+
+    /** Number of buffered values. */
+    len(): number;
+
+    /** Buffer size. */
+    cap(): number;
+
+    /** True once the channel is known to be closed. */
+    readonly closed: boolean;
 }
 
 /**
  * Creates a new channel with an optional buffer size.
+ * With an element type the channel is a Go `chan T`, which can be passed to
+ * Go functions; values are converted on send. Without one it carries JS
+ * values as-is.
  * @param size The buffer size (0 for unbuffered).
+ * @param elemType The Go element type, e.g. "int" or "[]string".
+ */
+declare function makeChan<T>(size?: number, elemType?: GoElemType): Chan<T>;
+
+/**
+ * A case of select or selectAsync: a send or receive on a channel, a context
+ * cancellation, or a default making the select non-blocking.
  */
-declare function makeChan<T>(size?: number): Chan<T>;
+type SelectCase =
+    | { chan: Chan<any>; send: any; case?: () => void }
+    | { chan: Chan<any>; recv?: (val: any, ok: boolean) => void }
+    | { ctx: { Done(): Chan<void>; Err(): GoError | null }; done?: (err: GoError) => void }
+    | { default: () => void };
 
 /**
  * Multiplexes multiple channel operations.
- * Implements Go's select statement logic.
- */
-declare function select(cases: Array<{
-    chan?: Chan<any>;
-    send?: any;
-    recv?: (val: any) => void;
-    case?: () => void;
-    default?: () => void;
-}>): void;
+ * Implements Go's select statement logic. Blocks the JS thread until a case
+ * is ready and returns its index.
+ */
+declare function select(cases: SelectCase[]): number;
+
+/**
+ * Like select, but waits off the JS thread so timers and I/O keep running.
+ * The chosen callback runs on the event loop, then the promise resolves
+ * with the index of its case.
+ */
+declare function selectAsync(cases: SelectCase[]): Promise<number>;
+
+/**
+ * Returns a channel that receives the current time after ms milliseconds,
+ * like Go's time.After. Use it as a select timeout.
+ */
+declare function after(ms: number): Chan<Date>;
@@ -1,13 +1,17 @@
 package intrinsics
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
 )
 
-// Go implements the typego.go() intrinsic.
+// Go implements the typego.go() intrinsic. It returns the goroutine's handle,
+// a thenable settling with the function's result.
 func (r *Registry) Go(call sobek.FunctionCall) sobek.Value {
 	if len(call.Arguments) < 1 {
 		panic(r.vm.NewGoError(newPanicError("go requires a function to execute")))
@@ -18,166 +22,242 @@ func (r *Registry) Go(call sobek.FunctionCall) sobek.Value {
 		panic(r.vm.NewGoError(newPanicError("go argument must be a function")))
 	}
 
-	args := append([]sobek.Value{}, call.Arguments[1:]...)
-
-	go func() {
-		defer func() {
-			if rGo := recover(); rGo != nil {
-				fmt.Printf("[GOROUTINE PANIC] %v\n", rGo)
-			}
-		}()
-
-		// Execute the function in the VM
-		// NOTE: Sobek is NOT thread-safe for concurrent access to the SAME VM.
-		// We use the Registry's VMLock to ensure only one goroutine (or main thread) executes JS at a time.
-		r.VMLock.Lock()
-		_, err := fn(sobek.Undefined(), args...)
-		r.VMLock.Unlock()
-
-		if err != nil {
-			fmt.Printf("[GOROUTINE ERROR] %v\n", err)
-		}
-	}()
-
-	return sobek.Undefined()
+	// NOTE: Sobek is NOT thread-safe for concurrent access to the SAME VM.
+	// Tasks hold the Registry's VMLock while executing JS.
+	t := r.NewTask(fn, append([]sobek.Value{}, call.Arguments[1:]...))
+	t.Start()
+	return t.Object()
 }
 
-// Chan represents a bridged Go channel.
-type Chan struct {
-	ch chan sobek.Value
-}
-
-func (c *Chan) Send(call sobek.FunctionCall) sobek.Value {
-	if len(call.Arguments) < 1 {
-		return sobek.Undefined()
-	}
-	c.ch <- call.Arguments[0]
-	return sobek.Undefined()
-}
-
-func (c *Chan) Recv(call sobek.FunctionCall) sobek.Value {
-	val, ok := <-c.ch
-	if !ok {
-		return sobek.Undefined()
+// Yield releases VMLock around a blocking wait so go() goroutines can run JS,
+// and returns the function re-acquiring it. Loop jobs, tasks and Run hold the
+// lock; a caller that does not hold it has nothing to yield.
+func (r *Registry) Yield() (resume func()) {
+	if !r.VMLock.Owned() {
+		return func() {}
 	}
-	return val
+	r.VMLock.Unlock()
+	return r.VMLock.Lock
 }
 
-func (c *Chan) Close(call sobek.FunctionCall) sobek.Value {
-	close(c.ch)
-	return sobek.Undefined()
+// BindOptions are the options for values created by intrinsics.
+func (r *Registry) BindOptions() []core.BindOption {
+	return []core.BindOption{core.WithEventLoop(r.el), core.WithYield(r.Yield)}
 }
 
-// MakeChan implements makeChan(size)
+// MakeChan implements makeChan(size, elemType). Without an element type the
+// channel carries JS values as-is; with one ("int", "[]byte", ...) it is a
+// real chan T that Go functions accept.
 func (r *Registry) MakeChan(call sobek.FunctionCall) sobek.Value {
 	size := 0
 	if len(call.Arguments) > 0 {
 		size = int(call.Arguments[0].ToInteger())
 	}
+	if size < 0 {
+		panic(r.vm.NewGoError(newPanicError("makechan: size out of range")))
+	}
 
-	c := &Chan{ch: make(chan sobek.Value, size)}
-
-	obj := r.vm.NewObject()
-	_ = obj.Set("send", c.Send)
-	_ = obj.Set("recv", c.Recv)
-	_ = obj.Set("close", c.Close)
+	var elem reflect.Type
+	if name := call.Argument(1); !sobek.IsUndefined(name) {
+		t, ok := core.LookupGoType(name.String())
+		if !ok {
+			panic(r.vm.NewTypeError(fmt.Sprintf("makeChan: unknown element type %q", name.String())))
+		}
+		elem = t
+	}
 
-	// Tag the object so Select can identify the channel
-	_ = obj.Set("__chan", c)
+	return core.MakeChannel(r.vm, elem, size, r.BindOptions()...).Object()
+}
 
-	return obj
+// selectSet is a parsed select argument. Cases the runtime skips (no channel)
+// are dropped, so every slice is indexed by reflect case.
+type selectSet struct {
+	cases      []reflect.SelectCase
+	objs       []*sobek.Object
+	index      []int           // Position in the JS array
+	chans      []*core.Channel // nil for default and ctx cases
+	hasDefault bool
 }
 
-// Select implements select([{ chan, send, recv, default }])
-func (r *Registry) Select(call sobek.FunctionCall) sobek.Value {
-	if len(call.Arguments) < 1 {
-		return sobek.Undefined()
+// parseSelect reads [{ chan, send?, recv?, case? } | { ctx, done? } | { default }].
+// Channels may be makeChan/go:sync channels, after() timers, a context's
+// Done() or raw Go channels returned by hyperlinked functions.
+func (r *Registry) parseSelect(arg sobek.Value, fn string) *selectSet {
+	set := &selectSet{}
+	if sobek.IsUndefined(arg) || sobek.IsNull(arg) {
+		return set
 	}
 
-	casesArr := call.Arguments[0].ToObject(r.vm)
+	casesArr := arg.ToObject(r.vm)
 	n := int(casesArr.Get("length").ToInteger())
 
-	var selectCases []reflect.SelectCase
-	var caseObjs []*sobek.Object
-
 	for i := 0; i < n; i++ {
 		caseObj := casesArr.Get(fmt.Sprintf("%d", i)).ToObject(r.vm)
-		caseObjs = append(caseObjs, caseObj)
+		add := func(sc reflect.SelectCase, c *core.Channel) {
+			set.cases = append(set.cases, sc)
+			set.objs = append(set.objs, caseObj)
+			set.index = append(set.index, i)
+			set.chans = append(set.chans, c)
+		}
 
 		// 1. Default case
 		if defValue := caseObj.Get("default"); defValue != nil && !sobek.IsUndefined(defValue) {
-			selectCases = append(selectCases, reflect.SelectCase{
-				Dir: reflect.SelectDefault,
-			})
+			set.hasDefault = true
+			add(reflect.SelectCase{Dir: reflect.SelectDefault}, nil)
 			continue
 		}
 
-		// 2. Channel operation
-		chWrapper := caseObj.Get("chan")
-		if chWrapper == nil || sobek.IsUndefined(chWrapper) {
+		// 2. Context cancellation
+		if ctxVal := caseObj.Get("ctx"); ctxVal != nil && !sobek.IsUndefined(ctxVal) {
+			ctx, ok := core.ContextOf(ctxVal)
+			if !ok {
+				panic(r.vm.NewTypeError(fmt.Sprintf("%s: case %d: ctx is not a context", fn, i)))
+			}
+			add(reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}, nil)
 			continue
 		}
 
-		chObj := chWrapper.ToObject(r.vm)
-		rawChan := chObj.Get("__chan")
-		if rawChan == nil {
+		// 3. Channel operation
+		chWrapper := caseObj.Get("chan")
+		if chWrapper == nil || sobek.IsUndefined(chWrapper) {
 			continue
 		}
 
-		c, ok := rawChan.Export().(*Chan)
+		c, ok := core.ToChannel(r.vm, chWrapper, r.BindOptions()...)
 		if !ok {
-			continue
+			panic(r.vm.NewTypeError(fmt.Sprintf("%s: case %d: chan is not a channel", fn, i)))
 		}
 
-		chValue := reflect.ValueOf(c.ch)
-
 		// Determine Direction
 		if sendVal := caseObj.Get("send"); sendVal != nil && !sobek.IsUndefined(sendVal) {
-			selectCases = append(selectCases, reflect.SelectCase{
-				Dir:  reflect.SelectSend,
-				Chan: chValue,
-				Send: reflect.ValueOf(sendVal),
-			})
+			goVal, err := c.ToGo(sendVal)
+			if err != nil {
+				panic(r.vm.NewTypeError(fmt.Sprintf("%s: case %d: %v", fn, i, err)))
+			}
+			add(reflect.SelectCase{Dir: reflect.SelectSend, Chan: c.Chan(), Send: goVal}, c)
 		} else {
-			selectCases = append(selectCases, reflect.SelectCase{
-				Dir:  reflect.SelectRecv,
-				Chan: chValue,
-			})
+			add(reflect.SelectCase{Dir: reflect.SelectRecv, Chan: c.Chan()}, c)
+		}
+	}
+	return set
+}
+
+// dispatch runs the callback of the chosen case on the JS thread.
+func (r *Registry) dispatch(set *selectSet, chosen int, recv reflect.Value, recvOk bool) error {
+	caseObj := set.objs[chosen]
+	call := func(name string, args ...sobek.Value) error {
+		if cb, ok := sobek.AssertFunction(caseObj.Get(name)); ok {
+			_, err := cb(sobek.Undefined(), args...)
+			return err
 		}
+		return nil
+	}
+
+	switch {
+	case set.cases[chosen].Dir == reflect.SelectDefault:
+		return call("default")
+	case set.cases[chosen].Dir == reflect.SelectSend:
+		return call("case")
+	case set.chans[chosen] == nil:
+		ctx, _ := core.ContextOf(caseObj.Get("ctx"))
+		return call("done", core.NewGoError(r.vm, ctx.Err()))
+	default:
+		recvVal := set.chans[chosen].ToJS(recv, recvOk)
+		return call("recv", recvVal, r.vm.ToValue(recvOk))
 	}
+}
 
-	if len(selectCases) == 0 {
+// Select implements select([{ chan, send, recv, default }])
+func (r *Registry) Select(call sobek.FunctionCall) sobek.Value {
+	set := r.parseSelect(call.Argument(0), "select")
+	if len(set.cases) == 0 {
 		return sobek.Undefined()
 	}
 
 	// YIELD THE LOCK: Allow goroutines to execute JS while we wait on channels
-	r.VMLock.Unlock()
-	chosen, recv, recvOk := reflect.Select(selectCases)
-	r.VMLock.Lock()
+	resume := r.Yield()
+	chosen, recv, recvOk, panicked := trySelect(set.cases)
+	resume()
+	if panicked != nil {
+		panic(core.NewGoError(r.vm, fmt.Errorf("panic: %v", panicked)))
+	}
 
-	chosenObj := caseObjs[chosen]
+	if err := r.dispatch(set, chosen, recv, recvOk); err != nil {
+		panic(err)
+	}
+	return r.vm.ToValue(set.index[chosen])
+}
 
-	// Handle Result
-	if selectCases[chosen].Dir == reflect.SelectRecv {
-		recvVal := recv.Interface().(sobek.Value)
-		if recvCb := chosenObj.Get("recv"); recvCb != nil && !sobek.IsUndefined(recvCb) {
-			if cb, ok := sobek.AssertFunction(recvCb); ok {
-				_, _ = cb(sobek.Undefined(), recvVal, r.vm.ToValue(recvOk))
-			}
-		}
-	} else if selectCases[chosen].Dir == reflect.SelectSend {
-		if caseCb := chosenObj.Get("case"); caseCb != nil && !sobek.IsUndefined(caseCb) {
-			if cb, ok := sobek.AssertFunction(caseCb); ok {
-				_, _ = cb(sobek.Undefined())
-			}
+// SelectAsync implements selectAsync(cases): select without blocking the event
+// loop. The wait happens on a goroutine; the chosen callback runs on the loop
+// and the promise resolves with the index of its case. With a default case
+// it settles immediately.
+func (r *Registry) SelectAsync(call sobek.FunctionCall) sobek.Value {
+	set := r.parseSelect(call.Argument(0), "selectAsync")
+	promise, resolve, reject := r.vm.NewPromise()
+
+	settle := func(chosen int, recv reflect.Value, recvOk bool, panicked interface{}) {
+		if panicked != nil {
+			_ = reject(core.NewGoError(r.vm, fmt.Errorf("panic: %v", panicked)))
+			return
 		}
-	} else if selectCases[chosen].Dir == reflect.SelectDefault {
-		if defCb := chosenObj.Get("default"); defCb != nil && !sobek.IsUndefined(defCb) {
-			if cb, ok := sobek.AssertFunction(defCb); ok {
-				_, _ = cb(sobek.Undefined())
+		if err := r.dispatch(set, chosen, recv, recvOk); err != nil {
+			var ex *sobek.Exception
+			if errors.As(err, &ex) {
+				_ = reject(ex.Value())
+			} else {
+				_ = reject(core.NewGoError(r.vm, err))
 			}
+			return
 		}
+		_ = resolve(set.index[chosen])
+	}
+
+	switch {
+	case len(set.cases) == 0:
+		_ = resolve(sobek.Undefined())
+		return r.vm.ToValue(promise)
+	case set.hasDefault:
+		settle(trySelect(set.cases))
+		return r.vm.ToValue(promise)
 	}
 
-	return r.vm.ToValue(chosen)
+	el := r.el
+	el.WGAdd(1)
+	go func() {
+		// Engine shutdown abandons the select
+		cases := append(set.cases[:len(set.cases):len(set.cases)], reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(el.Context().Done()),
+		})
+		chosen, recv, recvOk, panicked := trySelect(cases)
+		if panicked == nil && chosen == len(set.cases) {
+			el.WGDone()
+			return
+		}
+		el.RunOnLoop(func() {
+			defer el.WGDone()
+			settle(chosen, recv, recvOk, panicked)
+		})
+	}()
+
+	return r.vm.ToValue(promise)
+}
+
+// After implements after(ms): a channel that receives the current time once
+// the duration has elapsed, like time.After.
+func (r *Registry) After(call sobek.FunctionCall) sobek.Value {
+	d := time.Duration(call.Argument(0).ToFloat() * float64(time.Millisecond))
+	c, _ := core.NewChannel(r.vm, time.After(d), r.BindOptions()...)
+	return c.Object()
+}
+
+// trySelect runs reflect.Select, returning a send-on-closed panic instead of
+// unwinding with the lock released.
+func trySelect(cases []reflect.SelectCase) (chosen int, recv reflect.Value, recvOK bool, panicked interface{}) {
+	defer func() {
+		panicked = recover()
+	}()
+	chosen, recv, recvOK = reflect.Select(cases)
+	return
 }
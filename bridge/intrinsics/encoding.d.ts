@@ -7,6 +7,11 @@ interface TextEncoder {
      * Encodes the given string into a Uint8Array.
      */
     encode(input?: string): Uint8Array;
+    /**
+     * Encodes whole characters of source into destination, returning the
+     * UTF-16 code units read and the bytes written.
+     */
+    encodeInto(source: string, destination: Uint8Array): { read: number; written: number };
 }
 
 declare var TextEncoder: {
@@ -16,23 +21,28 @@ declare var TextEncoder: {
 
 /**
  * TextDecoder represents a decoder for a specific text encoding, such as UTF-8.
+ * Labels are those of the Encoding Standard, so "latin1" is windows-1252.
  */
 interface TextDecoder {
     /**
-     * The encoding used by this decoder.
+     * The canonical name of the encoding used by this decoder.
      */
     readonly encoding: string;
     /**
-     * Decodes the given input buffer into a string.
+     * Whether invalid input throws a TypeError instead of decoding as U+FFFD.
+     */
+    readonly fatal: boolean;
+    /**
+     * Whether a leading byte order mark is kept in the output.
+     */
+    readonly ignoreBOM: boolean;
+    /**
+     * Decodes the given input buffer into a string. With stream set, an
+     * incomplete character at the end is kept for the next call.
      */
-    decode(input?: ArrayBufferView | ArrayBuffer): string;
+    decode(input?: ArrayBufferView | ArrayBuffer, options?: { stream?: boolean }): string;
 }
 
-declare var TextDecoder: {
-    prototype: TextDecoder;
-    new(encoding?: string): TextDecoder;
-};
-
 declare var TextDecoder: {
     prototype: TextDecoder;
     new(label?: string, options?: { fatal?: boolean; ignoreBOM?: boolean }): TextDecoder;
@@ -1,14 +1,26 @@
 package intrinsics
 
 import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"github.com/grafana/sobek"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
 )
 
 // Encoding intrinsics provide high-performance string/byte conversion.
 
-// Encode implements TextEncoder.prototype.encode
+// EncodingShimJS provides the TextEncoder and TextDecoder globals.
+//
+//go:embed encoding.js
+var EncodingShimJS string
+
+// Encode implements TextEncoder.prototype.encode. Lone surrogates are
+// encoded as U+FFFD.
 func (r *Registry) Encode(call sobek.FunctionCall) sobek.Value {
 	var bytes []byte
 	if len(call.Arguments) > 0 {
@@ -26,41 +38,343 @@ func (r *Registry) Encode(call sobek.FunctionCall) sobek.Value {
 	return tArray
 }
 
-// Decode implements TextDecoder.prototype.decode
+// EncodeInto implements TextEncoder.prototype.encodeInto(source, dest),
+// returning [read, written]: the UTF-16 code units of source encoded and
+// the bytes written. Only whole characters are written.
+func (r *Registry) EncodeInto(call sobek.FunctionCall) sobek.Value {
+	src := jsString(call.Argument(0))
+	dst, ok := viewBytes(call.Argument(1))
+	if !ok {
+		panic(r.vm.NewTypeError("encodeInto requires a Uint8Array"))
+	}
+	read, written := 0, 0
+	for read < src.Length() {
+		c, units := codePointAt(src, read)
+		n := utf8.RuneLen(c)
+		if written+n > len(dst) {
+			break
+		}
+		utf8.EncodeRune(dst[written:], c)
+		read += units
+		written += n
+	}
+	return r.vm.NewArray(read, written)
+}
+
+// jsString returns v converted to a string, keeping its UTF-16 code units.
+func jsString(v sobek.Value) sobek.String {
+	s, _ := v.ToString().(sobek.String)
+	return s
+}
+
+// codePointAt returns the code point at index i of s and the number of
+// code units it takes, with lone surrogates as U+FFFD.
+func codePointAt(s sobek.String, i int) (rune, int) {
+	c := s.CharAt(i)
+	if utf16.IsSurrogate(rune(c)) {
+		if c < 0xdc00 && i+1 < s.Length() {
+			if d := s.CharAt(i + 1); d >= 0xdc00 && d <= 0xdfff {
+				return utf16.DecodeRune(rune(c), rune(d)), 2
+			}
+		}
+		return utf8.RuneError, 1
+	}
+	return rune(c), 1
+}
+
+// Decode implements __decode(input): input decoded as UTF-8, with invalid
+// sequences replaced by U+FFFD.
 func (r *Registry) Decode(call sobek.FunctionCall) sobek.Value {
-	if len(call.Arguments) == 0 {
+	bytes, ok := viewBytes(call.Argument(0))
+	if !ok {
 		return r.vm.ToValue("")
 	}
+	units, _, _ := decodeUTF8(nil, bytes, true, false)
+	return sobek.StringFromUTF16(units)
+}
+
+// NewDecoder implements __decoder(label, fatal, ignoreBOM): the state of a
+// TextDecoder, as an object with the canonical encoding name and
+// decode(input, stream).
+func (r *Registry) NewDecoder(call sobek.FunctionCall) sobek.Value {
+	vm := r.vm
+	d, err := newTextDecoder(call.Argument(0).String(), call.Argument(1).ToBoolean(), call.Argument(2).ToBoolean())
+	if err != nil {
+		panic(NewRangeError(vm, "The encoding label provided (%q) is invalid", call.Argument(0).String()))
+	}
+	obj := vm.NewObject()
+	_ = obj.Set("encoding", d.name)
+	_ = obj.Set("decode", func(call sobek.FunctionCall) sobek.Value {
+		var input []byte
+		if arg := call.Argument(0); !sobek.IsUndefined(arg) {
+			var ok bool
+			if input, ok = viewBytes(arg); !ok {
+				panic(vm.NewTypeError("The provided value is not of type '(ArrayBuffer or ArrayBufferView)'"))
+			}
+		}
+		units, err := d.decode(input, call.Argument(1).ToBoolean())
+		if err != nil {
+			panic(vm.NewTypeError(err.Error()))
+		}
+		return sobek.StringFromUTF16(units)
+	})
+	return obj
+}
 
-	arg := call.Arguments[0]
-	exported := arg.Export()
+// textDecoder is the state of a TextDecoder between streaming calls.
+type textDecoder struct {
+	name      string // Canonical WHATWG name
+	fatal     bool
+	ignoreBOM bool
+	started   bool   // Output was produced, so a BOM is no longer stripped
+	pending   []byte // Bytes of an incomplete sequence
+	legacy    transform.Transformer
+}
 
-	var bytes []byte
-	switch v := exported.(type) {
-	case []byte:
-		bytes = v
-	case sobek.ArrayBuffer:
+func newTextDecoder(label string, fatal, ignoreBOM bool) (*textDecoder, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", label)
+	}
+	name, err := htmlindex.Name(enc)
+	if err != nil {
+		name = strings.ToLower(strings.TrimSpace(label))
+	}
+	if name == "replacement" {
+		return nil, fmt.Errorf("unknown encoding %q", label)
+	}
+	d := &textDecoder{name: name, fatal: fatal, ignoreBOM: ignoreBOM}
+	switch name {
+	case "utf-8", "utf-16le", "utf-16be":
+	default:
+		d.legacy = enc.NewDecoder()
+	}
+	return d, nil
+}
 
-		bytes = v.Bytes()
+// decode decodes the pending bytes and input as UTF-16. Unless stream is
+// set, an incomplete sequence at the end is an error, and the decoder is
+// reset for a new stream.
+func (d *textDecoder) decode(input []byte, stream bool) ([]uint16, error) {
+	src := input
+	if len(d.pending) > 0 {
+		src = append(d.pending, input...)
+	}
+	var units []uint16
+	var rest []byte
+	var err error
+	switch d.name {
+	case "utf-8":
+		units, rest, err = decodeUTF8(nil, src, !stream, d.fatal)
+	case "utf-16le", "utf-16be":
+		units, rest, err = decodeUTF16(nil, src, d.name == "utf-16be", !stream, d.fatal)
 	default:
-		// Fallback for other typed arrays or objects
-		if obj, ok := arg.(*sobek.Object); ok {
-			if buffer := obj.Get("buffer"); buffer != nil {
-				if ab, ok := buffer.Export().(sobek.ArrayBuffer); ok {
-					bytes = ab.Bytes()
+		units, rest, err = d.decodeLegacy(src, !stream)
+	}
+	if err != nil {
+		d.reset()
+		return nil, err
+	}
+	d.pending = append([]byte(nil), rest...)
+
+	if !d.started && len(units) > 0 {
+		d.started = true
+		if !d.ignoreBOM && d.legacy == nil && units[0] == 0xfeff {
+			units = units[1:]
+		}
+	}
+	if !stream {
+		d.reset()
+	}
+	return units, nil
+}
+
+func (d *textDecoder) reset() {
+	d.started = false
+	d.pending = nil
+	if d.legacy != nil {
+		d.legacy.Reset()
+	}
+}
+
+// decodeLegacy decodes src with a golang.org/x/text decoder. Bytes it does
+// not map decode as U+FFFD, which is an error when fatal.
+func (d *textDecoder) decodeLegacy(src []byte, flush bool) ([]uint16, []byte, error) {
+	out := make([]byte, 0, len(src)*2)
+	buf := make([]byte, 4096)
+	for done := false; !done; {
+		nDst, nSrc, err := d.legacy.Transform(buf, src, flush)
+		out = append(out, buf[:nDst]...)
+		src = src[nSrc:]
+		switch err {
+		case nil, transform.ErrShortSrc: // The rest is an incomplete sequence
+			done = true
+		case transform.ErrShortDst:
+		default:
+			return nil, nil, err
+		}
+	}
+	s := string(out)
+	if d.fatal && strings.ContainsRune(s, utf8.RuneError) {
+		return nil, nil, invalidData(d.name)
+	}
+	return utf16.Encode([]rune(s)), src, nil
+}
+
+func invalidData(name string) error {
+	return fmt.Errorf("The encoded data was not valid for encoding %s", name)
+}
+
+// decodeUTF8 appends the UTF-16 of p to dst, replacing invalid sequences
+// by U+FFFD as the Encoding Standard does, or failing when fatal. An
+// incomplete sequence at the end is returned as rest unless flush is set.
+func decodeUTF8(dst []uint16, p []byte, flush, fatal bool) (units []uint16, rest []byte, err error) {
+	if dst == nil {
+		dst = make([]uint16, 0, len(p))
+	}
+	var cp rune
+	needed, seen, start := 0, 0, 0
+	lower, upper := byte(0x80), byte(0xbf)
+	for i := 0; i < len(p); i++ {
+		b := p[i]
+		if needed == 0 {
+			switch {
+			case b < 0x80:
+				dst = append(dst, uint16(b))
+			case b >= 0xc2 && b <= 0xdf:
+				needed, cp = 1, rune(b&0x1f)
+			case b >= 0xe0 && b <= 0xef:
+				if b == 0xe0 {
+					lower = 0xa0
+				} else if b == 0xed {
+					upper = 0x9f
 				}
+				needed, cp = 2, rune(b&0x0f)
+			case b >= 0xf0 && b <= 0xf4:
+				if b == 0xf0 {
+					lower = 0x90
+				} else if b == 0xf4 {
+					upper = 0x8f
+				}
+				needed, cp = 3, rune(b&0x07)
+			default:
+				if fatal {
+					return nil, nil, invalidData("utf-8")
+				}
+				dst = append(dst, 0xfffd)
 			}
+			start = i
+			continue
+		}
+		if b < lower || b > upper {
+			// The sequence ends early; b starts the next one.
+			if fatal {
+				return nil, nil, invalidData("utf-8")
+			}
+			dst = append(dst, 0xfffd)
+			cp, needed, seen, lower, upper = 0, 0, 0, 0x80, 0xbf
+			i--
+			continue
+		}
+		lower, upper = 0x80, 0xbf
+		cp = cp<<6 | rune(b&0x3f)
+		if seen++; seen == needed {
+			dst = utf16.AppendRune(dst, cp)
+			cp, needed, seen = 0, 0, 0
 		}
 	}
-
-	if bytes == nil {
-		return r.vm.ToValue("")
+	if needed > 0 {
+		if !flush {
+			return dst, p[start:], nil
+		}
+		if fatal {
+			return nil, nil, invalidData("utf-8")
+		}
+		dst = append(dst, 0xfffd)
 	}
+	return dst, nil, nil
+}
 
-	if !utf8.Valid(bytes) {
-		// Handle non-UTF8? For now just try to convert.
-		return r.vm.ToValue(string(bytes))
+// decodeUTF16 appends the code units of p, little or big endian, to dst.
+// Lone surrogates are replaced by U+FFFD, or fail when fatal. An odd byte
+// or a lead surrogate at the end is returned as rest unless flush is set.
+func decodeUTF16(dst []uint16, p []byte, bigEndian, flush, fatal bool) (units []uint16, rest []byte, err error) {
+	if dst == nil {
+		dst = make([]uint16, 0, len(p)/2)
+	}
+	unit := func(i int) uint16 {
+		if bigEndian {
+			return uint16(p[i])<<8 | uint16(p[i+1])
+		}
+		return uint16(p[i+1])<<8 | uint16(p[i])
+	}
+	replace := func() error {
+		if fatal {
+			return invalidData("utf-16")
+		}
+		dst = append(dst, 0xfffd)
+		return nil
+	}
+	i := 0
+	for ; i+1 < len(p); i += 2 {
+		c := unit(i)
+		switch {
+		case c < 0xd800 || c > 0xdfff:
+			dst = append(dst, c)
+		case c >= 0xdc00:
+			if err := replace(); err != nil {
+				return nil, nil, err
+			}
+		case i+3 < len(p):
+			if d := unit(i + 2); d >= 0xdc00 && d <= 0xdfff {
+				dst = append(dst, c, d)
+				i += 2
+			} else if err := replace(); err != nil {
+				return nil, nil, err
+			}
+		case !flush:
+			return dst, p[i:], nil
+		default:
+			if err := replace(); err != nil {
+				return nil, nil, err
+			}
+		}
 	}
+	if i < len(p) {
+		if !flush {
+			return dst, p[i:], nil
+		}
+		if err := replace(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return dst, nil, nil
+}
 
-	return r.vm.ToValue(string(bytes))
+// viewBytes returns the bytes of an ArrayBuffer, a typed array or a
+// DataView, sharing their memory.
+func viewBytes(v sobek.Value) ([]byte, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	if ab, ok := obj.Export().(sobek.ArrayBuffer); ok {
+		return ab.Bytes(), true
+	}
+	buf, ok := obj.Get("buffer").(*sobek.Object)
+	if !ok {
+		b, ok := obj.Export().([]byte) // A bound Go []byte
+		return b, ok
+	}
+	ab, ok := buf.Export().(sobek.ArrayBuffer)
+	if !ok {
+		return nil, false
+	}
+	off := obj.Get("byteOffset").ToInteger()
+	n := obj.Get("byteLength").ToInteger()
+	data := ab.Bytes()
+	if off < 0 || n < 0 || off+n > int64(len(data)) {
+		return nil, false
+	}
+	return data[off : off+n : off+n], true
 }
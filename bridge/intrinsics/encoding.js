@@ -0,0 +1,61 @@
+// TextEncoder and TextDecoder. Decoding follows the Encoding Standard in Go:
+// UTF-8 and UTF-16 natively, other labels through golang.org/x/text.
+(function () {
+	'use strict';
+
+	const DECODER = Symbol('decoder');
+
+	class TextEncoder {
+		get encoding() {
+			return 'utf-8';
+		}
+
+		encode(input = '') {
+			return __encode(String(input));
+		}
+
+		// encodeInto writes whole characters of source into destination,
+		// returning the code units read and the bytes written.
+		encodeInto(source, destination) {
+			if (!(destination instanceof Uint8Array)) throw new TypeError('encodeInto requires a Uint8Array destination');
+			const [read, written] = __encodeInto(String(source), destination);
+			return { read, written };
+		}
+	}
+
+	class TextDecoder {
+		constructor(label = 'utf-8', options = {}) {
+			options = options == null ? {} : options;
+			const fatal = Boolean(options.fatal);
+			const ignoreBOM = Boolean(options.ignoreBOM);
+			Object.defineProperty(this, DECODER, {
+				value: { state: __decoder(String(label), fatal, ignoreBOM), fatal, ignoreBOM },
+			});
+		}
+
+		get encoding() {
+			return this[DECODER].state.encoding;
+		}
+
+		get fatal() {
+			return this[DECODER].fatal;
+		}
+
+		get ignoreBOM() {
+			return this[DECODER].ignoreBOM;
+		}
+
+		// decode decodes input; with { stream: true } an incomplete sequence
+		// at its end is kept for the next call.
+		decode(input, options = {}) {
+			const stream = options != null && Boolean(options.stream);
+			return this[DECODER].state.decode(input, stream);
+		}
+	}
+
+	const globals = { TextEncoder, TextDecoder };
+	for (const name of Object.keys(globals)) {
+		if (typeof globalThis[name] !== 'undefined') continue;
+		Object.defineProperty(globalThis, name, { value: globals[name], writable: true, configurable: true, enumerable: false });
+	}
+})();
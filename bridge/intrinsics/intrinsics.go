@@ -1,8 +1,6 @@
 package intrinsics
 
 import (
-	"sync"
-
 	"github.com/grafana/sobek"
 	"github.com/repyh/typego/eventloop"
 )
@@ -11,13 +9,21 @@ import (
 type Registry struct {
 	vm           *sobek.Runtime
 	currentScope *scopeState
-	VMLock       sync.Mutex
+	VMLock       eventloop.Lock
 	el           *eventloop.EventLoop
+	parallel     ParallelRunner
+	refs         refTable
+
+	stdio          stdio
+	env            *processEnv
+	externalMemory func() uint64
+	exitCode       *int // Set by process.exit
 }
 
 // Enable registers all global intrinsics (panic, sizeof, defer/scope)
 func Enable(vm *sobek.Runtime, el *eventloop.EventLoop) *Registry {
 	r := &Registry{vm: vm, el: el}
+	el.SetLocker(&r.VMLock)
 
 	_ = vm.Set("panic", r.Panic)
 	_ = vm.Set("sizeof", r.Sizeof)
@@ -25,19 +31,33 @@ func Enable(vm *sobek.Runtime, el *eventloop.EventLoop) *Registry {
 	_ = vm.Set("deref", r.Deref)
 	_ = vm.Set("recover", r.Recover)
 	_ = vm.Set("go", r.Go)
+	_ = vm.Get("go").ToObject(vm).Set("parallel", r.Parallel)
 	_ = vm.Set("makeChan", r.MakeChan)
 	_ = vm.Set("select", r.Select)
+	_ = vm.Set("selectAsync", r.SelectAsync)
+	_ = vm.Set("after", r.After)
 	_ = vm.Set("cap", r.Cap)
 	_ = vm.Set("make", r.Make)
 	_ = vm.Set("copy", r.Copy)
+	_ = vm.Set("append", r.Append)
 	_ = vm.Set("wrapReader", r.WrapReader)
 	_ = vm.Set("wrapWriter", r.WrapWriter)
 
 	// Background backup for polyfills
 	_ = vm.Set("__encode", r.Encode)
+	_ = vm.Set("__encodeInto", r.EncodeInto)
 	_ = vm.Set("__decode", r.Decode)
+	_ = vm.Set("__decoder", r.NewDecoder)
 	_ = vm.Set("__bufferAlloc", r.BufferAlloc)
 	_ = vm.Set("__bufferFrom", r.BufferFrom)
+	_ = vm.Set("__bufferByteLength", r.BufferByteLength)
+	_ = vm.Set("__bufferToString", r.BufferToString)
+	_ = vm.Set("__bufferWrite", r.BufferWrite)
+	_ = vm.Set("__bufferCompare", r.BufferCompare)
+	_ = vm.Set("__bufferIndexOf", r.BufferIndexOf)
+	_ = vm.Set("__readerSource", r.ReaderSource)
+	_ = vm.Set("__writerSink", r.WriterSink)
+	_ = vm.Set("__codec", r.Codec)
 
 	// Scope needs the VM to create the defer callback
 
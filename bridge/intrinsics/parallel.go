@@ -0,0 +1,88 @@
+package intrinsics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
+)
+
+// ParallelRunner calls the function with source src on an isolated runtime,
+// passing args imported with ImportTransferable, and returns its exported
+// result once any returned promise settles. Thrown exceptions come back as
+// *ParallelError.
+type ParallelRunner func(ctx context.Context, src string, args []interface{}) (interface{}, error)
+
+// SetParallelRunner installs the runner behind go.parallel. The engine sets
+// it to its pool of isolated runtimes.
+func (r *Registry) SetParallelRunner(run ParallelRunner) {
+	r.parallel = run
+}
+
+// Parallel implements go.parallel(fn, ...args). Unlike go(), which shares
+// this runtime and VMLock, fn runs on a separate runtime and OS thread, so
+// it executes truly in parallel. It cannot see the caller's closures or
+// globals: only its source and transferable arguments cross over, and the
+// result is copied back the same way.
+func (r *Registry) Parallel(call sobek.FunctionCall) sobek.Value {
+	if _, ok := sobek.AssertFunction(call.Argument(0)); !ok {
+		panic(r.vm.NewTypeError("go.parallel argument must be a function"))
+	}
+	if r.parallel == nil {
+		panic(r.vm.NewGoError(errors.New("go.parallel is not available in this runtime")))
+	}
+
+	src := FunctionSource(call.Argument(0))
+	args := make([]interface{}, 0, len(call.Arguments)-1)
+	for i, arg := range call.Arguments[1:] {
+		v, err := ExportTransferable(r.vm, arg, nil)
+		if err != nil {
+			panic(r.vm.NewTypeError(fmt.Sprintf("go.parallel: argument %d: %v", i+1, err)))
+		}
+		args = append(args, v)
+	}
+
+	promise, resolve, reject := r.vm.NewPromise()
+	el := r.el
+	el.WGAdd(1)
+	go func() {
+		res, err := r.parallel(el.Context(), src, args)
+		if el.Context().Err() != nil {
+			el.WGDone()
+			return
+		}
+		el.RunOnLoop(func() {
+			defer el.WGDone()
+			var val sobek.Value
+			if err == nil {
+				val, err = ImportTransferable(el, res)
+			}
+			var pe *ParallelError
+			switch {
+			case errors.As(err, &pe):
+				_ = reject(pe.Object(r.vm))
+			case err != nil:
+				_ = reject(core.NewGoError(r.vm, err))
+			default:
+				_ = resolve(val)
+			}
+		})
+	}()
+
+	return r.vm.ToValue(promise)
+}
+
+// FunctionSource returns fn's source as an expression. Sobek drops the
+// closing parenthesis of arrow functions with a parenthesized body, as in
+// (x) => ({ x }); restore it when the text does not parse as is.
+func FunctionSource(fn sobek.Value) string {
+	src := fn.String()
+	if _, err := sobek.Compile("", "("+src+")", false); err != nil {
+		if _, err := sobek.Compile("", "("+src+"))", false); err == nil {
+			return src + ")"
+		}
+	}
+	return src
+}
@@ -1,16 +1,44 @@
+/**
+ * A pointer to a Go variable. Two refs to the same variable are the same
+ * object.
+ */
+interface Ref<T> {
+    /** Reads or writes the variable; a struct is returned live. */
+    value: T;
+    /** Address of the variable. */
+    readonly ptr: number;
+    /** Go type of the variable. */
+    readonly type: string;
+    /** Returns a copy of the variable. */
+    load(): T;
+    store(val: T): void;
+    /** Sets the variable and returns its previous value. */
+    swap(val: T): T;
+    /** Sets the variable to val if it equals old; numbers are compared atomically. */
+    compareAndSwap(old: T, val: T): boolean;
+}
+
 /**
  * Creates a reference (pointer) to a value on the Go heap.
  * This allows passing primitives by reference and manual memory management.
+ * Passed to a Go function taking *T, the function receives the pointer.
  * 
  * @param val The value to reference.
  * @returns A Ref object with 'value' (get/set) and 'ptr' (uintptr).
  */
-declare function ref<T>(val: T): { value: T; readonly ptr: number };
+declare function ref<T>(val: T): Ref<T>;
+
+/**
+ * Creates a reference to a field or element of Go memory: path walks struct
+ * fields by name and slice or array elements by index, starting from a
+ * live-bound struct, a Ref, a Go slice or a typed array.
+ */
+declare function ref<T = any>(target: object, ...path: (string | number)[]): Ref<T>;
 
 /**
  * Dereferences a raw pointer or a Ref object.
  * 
- * @param ptr The raw uintptr or Ref object.
+ * @param ptr The ptr of a live Ref, or a Ref object.
  * @returns The value stored at that address.
  */
-declare function deref<T>(ptr: number | { readonly ptr: number }): T;
+declare function deref<T>(ptr: number | Ref<T>): T;
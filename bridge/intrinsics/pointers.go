@@ -3,70 +3,124 @@ package intrinsics
 import (
 	"reflect"
 	"runtime"
+	"sync"
+	"weak"
 
 	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
 )
 
 // Pointers implements ref() and deref() intrinsics with handle support.
 
+// refKey identifies a variable: refs to the same address and type are the
+// same JS object, so they compare equal with ===.
+type refKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// refTable holds the live refs of a runtime, dropped once collected.
+type refTable struct {
+	mu   sync.Mutex
+	refs map[refKey]weak.Pointer[core.Ref]
+}
+
+// Ref implements ref(value) and ref(target, ...path). A plain value is boxed
+// on the Go heap; a ref or live struct target, followed by field names and
+// indexes, refers to a Go variable in place, as does a typed array or Go
+// slice with an index.
 func (r *Registry) Ref(call sobek.FunctionCall) sobek.Value {
 	if len(call.Arguments) == 0 {
 		return sobek.Undefined()
 	}
 
-	val := call.Arguments[0]
-	exported := val.Export()
-
-	// Allocate a new box on the Go heap
-	ptr := reflect.New(reflect.TypeOf(exported))
-	ptr.Elem().Set(reflect.ValueOf(exported))
-
-	obj := r.vm.NewObject()
-	_ = obj.Set("ptr", r.vm.ToValue(ptr.Pointer()))
+	target := call.Arguments[0]
+	ptr, ok, err := core.RefTarget(target, call.Arguments[1:])
+	if err != nil {
+		panic(r.vm.NewTypeError(err.Error()))
+	}
+	if !ok {
+		// Allocate a new box on the Go heap
+		exported := target.Export()
+		if exported == nil {
+			ptr = reflect.New(reflect.TypeOf((*interface{})(nil)).Elem())
+		} else {
+			ptr = reflect.New(reflect.TypeOf(exported))
+			ptr.Elem().Set(reflect.ValueOf(exported))
+		}
+	}
+	return r.refFor(ptr)
+}
 
-	// Use an accessor to allow direct mutation
-	_ = obj.DefineAccessorProperty("value",
-		r.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
-			return r.vm.ToValue(ptr.Elem().Interface())
-		}),
-		r.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
-			newVal := call.Argument(0).Export()
-			if newVal == nil {
-				ptr.Elem().Set(reflect.Zero(ptr.Elem().Type()))
-			} else {
-				// Type-safe set if possible
-				v := reflect.ValueOf(newVal)
-				if v.Type().AssignableTo(ptr.Elem().Type()) {
-					ptr.Elem().Set(v)
-				}
-			}
-			return sobek.Undefined()
-		}),
-		sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+// refFor returns the ref object of the variable ptr points to, reusing the
+// live one if any.
+func (r *Registry) refFor(ptr reflect.Value) sobek.Value {
+	key := refKey{addr: ptr.Pointer(), typ: ptr.Type()}
+	t := &r.refs
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	// Finalizer for the handle
-	runtime.SetFinalizer(obj, func(o *sobek.Object) {
-		// Optional: Log or track cleanup
-	})
+	if wp, ok := t.refs[key]; ok {
+		if ref := wp.Value(); ref != nil {
+			return ref.Object()
+		}
+	}
 
-	return obj
+	ref, err := core.NewRef(r.vm, ptr, r.BindOptions()...)
+	if err != nil {
+		panic(r.vm.NewTypeError(err.Error()))
+	}
+	if t.refs == nil {
+		t.refs = make(map[refKey]weak.Pointer[core.Ref])
+	}
+	wp := weak.Make(ref)
+	t.refs[key] = wp
+	runtime.AddCleanup(ref, func(key refKey) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.refs[key] == wp {
+			delete(t.refs, key)
+		}
+	}, key)
+	return ref.Object()
 }
 
+// Deref returns the value of a ref, or of the live ref whose ptr is the
+// given address.
 func (r *Registry) Deref(call sobek.FunctionCall) sobek.Value {
 	if len(call.Arguments) == 0 {
 		return sobek.Undefined()
 	}
 
 	val := call.Arguments[0]
-	
-	// If it's a number, it's a raw pointer (unsafe)
-	if ptr, ok := val.Export().(int64); ok {
-		// UNIMPLEMENTED: Raw pointer dereference requires unsafe.Pointer
-		// For now, only Ref objects are supported.
-		_ = ptr
+	if sobek.IsUndefined(val) || sobek.IsNull(val) {
+		return sobek.Undefined()
+	}
+	if ref, ok := core.RefOf(val); ok {
+		return ref.Object().Get("value")
+	}
+
+	if _, ok := val.(*sobek.Object); !ok {
+		// Refs to a struct and to its first field share an address: the
+		// struct wins.
+		addr := uintptr(val.ToInteger())
+		r.refs.mu.Lock()
+		var found *core.Ref
+		for key, wp := range r.refs.refs {
+			if ref := wp.Value(); key.addr == addr && ref != nil {
+				if found == nil || key.typ.Elem().Size() > found.Pointer().Type().Elem().Size() {
+					found = ref
+				}
+			}
+		}
+		r.refs.mu.Unlock()
+		if found == nil {
+			panic(r.vm.NewTypeError("deref: no live ref at address %d", addr))
+		}
+		return found.Object().Get("value")
 	}
 
-	// If it's a Ref object
+	// Any other object with a value, as before refs were Go variables
 	if obj := val.ToObject(r.vm); obj != nil {
 		if v := obj.Get("value"); v != nil {
 			return v
@@ -1,19 +1,44 @@
+/**
+ * A standard output stream: a WritableStream over the Go writer that also
+ * has Node's synchronous write.
+ */
+declare interface ProcessWriteStream extends WritableStream<Uint8Array | string> {
+    readonly fd: number;
+    /**
+     * Whether the stream is a terminal. Undefined when it is not a file.
+     */
+    readonly isTTY?: boolean;
+    /**
+     * Writes chunk immediately, returning false if the write failed.
+     * callback runs on the next tick with the error or null.
+     */
+    write(chunk: string | Uint8Array | ArrayBuffer, callback?: (err: Error | null) => void): boolean;
+    write(chunk: string, encoding: BufferEncoding, callback?: (err: Error | null) => void): boolean;
+}
+
 /**
  * Direct access to the current Go process environment and metadata.
  * Mimics a subset of the Node.js process API.
  */
 declare const process: {
     /**
-     * Environment variables. Only variables whitelisted or prefixed with TYPEGO_ 
-     * are accessible for security.
+     * Environment variables, read from and written to the Go process
+     * environment. Only variables whitelisted (PATH, LANG, PWD, HOSTNAME,
+     * USER), prefixed with TYPEGO_ or set by the script are visible. In a
+     * worker, the entries of its env option are local to it.
      */
-    env: Record<string, string>;
+    env: Record<string, string | undefined>;
 
     /**
      * Operating system platform (e.g., 'windows', 'linux', 'darwin').
      */
     platform: string;
 
+    /**
+     * CPU architecture (e.g., 'amd64', 'arm64').
+     */
+    arch: string;
+
     /**
      * Returns the current working directory.
      */
@@ -28,4 +53,55 @@ declare const process: {
      * Go runtime version.
      */
     version: string;
+
+    pid: number;
+    ppid: number;
+
+    /**
+     * The code the process exits with when the script ends or calls
+     * exit() without a code.
+     */
+    exitCode: number | undefined;
+
+    /**
+     * Ends the script at once: pending timers and I/O are dropped and the
+     * process exits with code, or exitCode.
+     */
+    exit(code?: number): never;
+
+    /**
+     * Seconds since the process started.
+     */
+    uptime(): number;
+
+    /**
+     * High-resolution time as [seconds, nanoseconds], relative to an
+     * arbitrary time or to previous.
+     */
+    hrtime: {
+        (previous?: [number, number]): [number, number];
+        /** Nanoseconds as a bigint. */
+        bigint(): bigint;
+    };
+
+    /**
+     * Memory statistics in bytes, from the Go runtime. external and
+     * arrayBuffers count memory held outside the JS heap.
+     */
+    memoryUsage(): {
+        rss: number;
+        heapTotal: number;
+        heapUsed: number;
+        external: number;
+        arrayBuffers: number;
+    };
+
+    /**
+     * Runs callback once the current job finishes, before timers and I/O.
+     */
+    nextTick<A extends any[]>(callback: (...args: A) => void, ...args: A): void;
+
+    readonly stdin: ReadableStream<Uint8Array>;
+    readonly stdout: ProcessWriteStream;
+    readonly stderr: ProcessWriteStream;
 };
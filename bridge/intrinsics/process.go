@@ -1,49 +1,103 @@
 package intrinsics
 
 import (
+	"fmt"
+	"io"
+	"math/big"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/grafana/sobek"
 )
 
+// processStart is when the process started, for uptime and hrtime.
+var processStart = time.Now()
+
+// ExitError is the interruption of a script that called process.exit.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("process exited with code %d", e.Code)
+}
+
+// stdio are the streams behind process.stdin, stdout and stderr.
+type stdio struct {
+	in       io.Reader
+	out, err io.Writer
+}
+
+// SetStdio replaces the reader and writers behind process.stdin, stdout and
+// stderr, os.Stdin, os.Stdout and os.Stderr by default. It takes effect for
+// streams not yet used by the script.
+func (r *Registry) SetStdio(stdin io.Reader, stdout, stderr io.Writer) {
+	r.stdio = stdio{in: stdin, out: stdout, err: stderr}
+}
+
+// SetExternalMemory installs the source of memoryUsage().external, the
+// bytes held outside the JS heap. The engine sets it to its memory factory.
+func (r *Registry) SetExternalMemory(f func() uint64) {
+	r.externalMemory = f
+}
+
+// Exit ends the script with code, as process.exit does: the loop stops and
+// the running JS is interrupted with an *ExitError. Only the first exit
+// counts.
+func (r *Registry) Exit(code int) {
+	if r.exitCode == nil {
+		r.exitCode = &code
+	}
+	r.el.Stop()
+	r.vm.Interrupt(&ExitError{Code: *r.exitCode})
+}
+
+// ExitCode returns the code the script exits with: the one it passed to
+// process.exit, else process.exitCode, else 0. It must be called on the JS
+// thread or after the loop stopped.
+func (r *Registry) ExitCode() int {
+	if r.exitCode != nil {
+		return *r.exitCode
+	}
+	return exitCodeOf(r.vm, sobek.Undefined())
+}
+
+// exitCodeOf returns the exit code given to process.exit: code, or
+// process.exitCode when it is undefined.
+func exitCodeOf(vm *sobek.Runtime, code sobek.Value) int {
+	if sobek.IsUndefined(code) || sobek.IsNull(code) {
+		if proc, ok := vm.Get("process").(*sobek.Object); ok {
+			code = proc.Get("exitCode")
+		}
+	}
+	if code == nil || sobek.IsUndefined(code) || sobek.IsNull(code) {
+		return 0
+	}
+	return int(code.ToInteger())
+}
+
 // EnableProcess injects the Node.js `process` global
 func (r *Registry) EnableProcess() {
-	proc := r.vm.NewObject()
-
-	env := r.vm.NewObject()
-	whitelist := map[string]bool{
-		"PATH":     true,
-		"LANG":     true,
-		"PWD":      true,
-		"HOSTNAME": true,
-		"USER":     true,
-	}
-
-	for _, e := range os.Environ() {
-		parts := strings.SplitN(e, "=", 2)
-		if len(parts) == 2 {
-			key := parts[0]
-			upperKey := strings.ToUpper(key)
-			// Allow whitelisted vars or anything prefixed with TYPEGO_
-			if whitelist[upperKey] || strings.HasPrefix(upperKey, "TYPEGO_") {
-				_ = env.Set(key, parts[1])
-			}
-		}
+	vm := r.vm
+	proc := vm.NewObject()
+	if r.stdio.in == nil {
+		r.stdio = stdio{in: os.Stdin, out: os.Stdout, err: os.Stderr}
 	}
 
-	// Force color
-	_ = env.Set("FORCE_COLOR", "1")
-	_ = proc.Set("env", env)
+	r.env = &processEnv{vm: vm, local: map[string]string{}, written: map[string]bool{}}
+	_ = proc.Set("env", vm.NewDynamicObject(r.env))
 
 	// process.platform
 	_ = proc.Set("platform", runtime.GOOS)
+	_ = proc.Set("arch", runtime.GOARCH)
 
 	// process.cwd()
 	_ = proc.Set("cwd", func(call sobek.FunctionCall) sobek.Value {
 		wd, _ := os.Getwd()
-		return r.vm.ToValue(wd)
+		return vm.ToValue(wd)
 	})
 
 	// process.argv
@@ -52,5 +106,238 @@ func (r *Registry) EnableProcess() {
 	// process.version
 	_ = proc.Set("version", runtime.Version())
 
-	_ = r.vm.Set("process", proc)
+	_ = proc.Set("pid", os.Getpid())
+	_ = proc.Set("ppid", os.Getppid())
+	_ = proc.Set("exitCode", sobek.Undefined())
+	_ = proc.Set("exit", func(call sobek.FunctionCall) sobek.Value {
+		r.Exit(exitCodeOf(vm, call.Argument(0)))
+		return sobek.Undefined()
+	})
+
+	_ = proc.Set("uptime", func(sobek.FunctionCall) sobek.Value {
+		return vm.ToValue(time.Since(processStart).Seconds())
+	})
+	hrtime := vm.ToValue(r.hrtime).ToObject(vm)
+	_ = hrtime.Set("bigint", func(sobek.FunctionCall) sobek.Value {
+		return vm.ToValue(big.NewInt(int64(time.Since(processStart))))
+	})
+	_ = proc.Set("hrtime", hrtime)
+	_ = proc.Set("memoryUsage", r.memoryUsage)
+	_ = proc.Set("nextTick", r.nextTick)
+
+	// The standard streams are made on first use.
+	r.defineStream(proc, "stdin", func() (sobek.Value, error) {
+		return r.ReadableStream(struct{ io.Reader }{r.stdio.in}, 0) // Hides Close
+	})
+	r.defineStream(proc, "stdout", func() (sobek.Value, error) { return r.stdWritable(r.stdio.out, 1) })
+	r.defineStream(proc, "stderr", func() (sobek.Value, error) { return r.stdWritable(r.stdio.err, 2) })
+
+	_ = vm.Set("process", proc)
+}
+
+// hrtime implements process.hrtime(previous): [seconds, nanoseconds] since
+// an arbitrary time, or since previous.
+func (r *Registry) hrtime(call sobek.FunctionCall) sobek.Value {
+	d := time.Since(processStart)
+	if prev, ok := call.Argument(0).(*sobek.Object); ok {
+		sec, nsec := prev.Get("0"), prev.Get("1")
+		if sec == nil || nsec == nil {
+			panic(r.vm.NewTypeError("The \"time\" argument must be an array of two numbers"))
+		}
+		d -= time.Duration(sec.ToInteger())*time.Second + time.Duration(nsec.ToInteger())
+	}
+	return r.vm.NewArray(int64(d/time.Second), int64(d%time.Second))
+}
+
+// memoryUsage implements process.memoryUsage() from the Go runtime's
+// statistics: the Go heap stands for the JS heap, which lives in it.
+func (r *Registry) memoryUsage(sobek.FunctionCall) sobek.Value {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	var external uint64
+	if r.externalMemory != nil {
+		external = r.externalMemory()
+	}
+	obj := r.vm.NewObject()
+	_ = obj.Set("rss", ms.Sys)
+	_ = obj.Set("heapTotal", ms.HeapSys)
+	_ = obj.Set("heapUsed", ms.HeapAlloc)
+	_ = obj.Set("external", external)
+	_ = obj.Set("arrayBuffers", external)
+	return obj
+}
+
+// nextTick implements process.nextTick(fn, ...args): fn runs once the
+// current job finishes, before timers and I/O.
+func (r *Registry) nextTick(call sobek.FunctionCall) sobek.Value {
+	vm := r.vm
+	fn, ok := sobek.AssertFunction(call.Argument(0))
+	if !ok {
+		panic(vm.NewTypeError("The \"callback\" argument must be of type function"))
+	}
+	args := append([]sobek.Value(nil), call.Arguments[1:]...)
+	promise, resolve, _ := vm.NewPromise()
+	_ = resolve(sobek.Undefined())
+	_ = callMethod(vm.ToValue(promise).ToObject(vm), "then", vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+		if _, err := fn(sobek.Undefined(), args...); err != nil {
+			panic(err)
+		}
+		return sobek.Undefined()
+	}))
+	return sobek.Undefined()
+}
+
+// defineStream defines a lazy, cached property of proc.
+func (r *Registry) defineStream(proc *sobek.Object, name string, open func() (sobek.Value, error)) {
+	vm := r.vm
+	var stream sobek.Value
+	getter := vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+		if stream == nil {
+			s, err := open()
+			if err != nil {
+				panic(vm.NewGoError(err))
+			}
+			stream = s
+		}
+		return stream
+	})
+	_ = proc.DefineAccessorProperty(name, getter, nil, sobek.FLAG_TRUE, sobek.FLAG_TRUE)
+}
+
+// stdWritable returns a WritableStream to w that leaves w open, with
+// Node's synchronous write(chunk, encoding?, callback?) and fd.
+func (r *Registry) stdWritable(w io.Writer, fd int) (sobek.Value, error) {
+	vm := r.vm
+	v, err := r.WritableStream(keepOpen{w})
+	if err != nil {
+		return nil, err
+	}
+	obj := v.ToObject(vm)
+	_ = obj.Set("fd", fd)
+	if f, ok := w.(*os.File); ok {
+		info, err := f.Stat()
+		_ = obj.Set("isTTY", err == nil && info.Mode()&os.ModeCharDevice != 0)
+	}
+	_ = obj.Set("write", func(call sobek.FunctionCall) sobek.Value {
+		var data []byte
+		if _, ok := call.Argument(0).Export().(string); ok {
+			enc := call.Argument(1)
+			if _, isCallback := sobek.AssertFunction(enc); isCallback {
+				enc = sobek.Undefined()
+			}
+			data = encodeString(jsString(call.Argument(0)), r.bufferEncoding(enc))
+		} else {
+			var err error
+			if data, err = chunkBytes(vm, call.Argument(0)); err != nil {
+				panic(vm.NewTypeError(err.Error()))
+			}
+		}
+		_, werr := w.Write(data)
+		for _, arg := range call.Arguments[1:] {
+			if cb, ok := sobek.AssertFunction(arg); ok {
+				var cbArg sobek.Value = sobek.Null()
+				if werr != nil {
+					cbArg = vm.NewGoError(werr)
+				}
+				r.nextTick(sobek.FunctionCall{Arguments: []sobek.Value{vm.ToValue(cb), cbArg}})
+			}
+		}
+		return vm.ToValue(werr == nil)
+	})
+	return obj, nil
+}
+
+// keepOpen hides the Close of a writer, for streams over the standard
+// streams, which outlive the script.
+type keepOpen struct {
+	io.Writer
+}
+
+// processEnv is process.env: the real environment, read and written
+// through. Only variables in envWhitelist, those prefixed with TYPEGO_ and
+// those the script set are visible. Entries given to a worker are local to
+// its runtime.
+type processEnv struct {
+	vm      *sobek.Runtime
+	local   map[string]string
+	written map[string]bool
+}
+
+var envWhitelist = map[string]bool{
+	"PATH":     true,
+	"LANG":     true,
+	"PWD":      true,
+	"HOSTNAME": true,
+	"USER":     true,
+}
+
+// SetLocalEnv adds entries to process.env that are not written to the
+// environment, such as the env option of a Worker.
+func (r *Registry) SetLocalEnv(env map[string]string) {
+	for k, v := range env {
+		r.env.local[k] = v
+	}
+}
+
+func (e *processEnv) visible(key string) bool {
+	upper := strings.ToUpper(key)
+	return envWhitelist[upper] || strings.HasPrefix(upper, "TYPEGO_") || e.written[key]
+}
+
+func (e *processEnv) Get(key string) sobek.Value {
+	if v, ok := e.local[key]; ok {
+		return e.vm.ToValue(v)
+	}
+	if !e.visible(key) {
+		return nil
+	}
+	if v, ok := os.LookupEnv(key); ok {
+		return e.vm.ToValue(v)
+	}
+	return nil
+}
+
+func (e *processEnv) Set(key string, val sobek.Value) bool {
+	if _, ok := e.local[key]; ok {
+		e.local[key] = val.String()
+		return true
+	}
+	if os.Setenv(key, val.String()) != nil {
+		return false
+	}
+	e.written[key] = true
+	return true
+}
+
+func (e *processEnv) Has(key string) bool {
+	return e.Get(key) != nil
+}
+
+func (e *processEnv) Delete(key string) bool {
+	if _, ok := e.local[key]; ok {
+		delete(e.local, key)
+		return true
+	}
+	if e.visible(key) {
+		_ = os.Unsetenv(key)
+	}
+	return true
+}
+
+func (e *processEnv) Keys() []string {
+	seen := map[string]bool{}
+	var keys []string
+	for k := range e.local {
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	for _, kv := range os.Environ() {
+		k, _, ok := strings.Cut(kv, "=")
+		if ok && !seen[k] && e.visible(k) {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -1,9 +1,11 @@
 /**
- * Estimates the memory size of an object in bytes.
- * This is a TypeGo intrinsic that performs shallow or deep inspection 
- * based on the underlying Go representation.
+ * Returns the bytes a value retains, counting memory it reaches more than
+ * once only the first time. Go values (live structs, refs, GoSlices and
+ * other bound Go values) are measured by Go's layout rules, including
+ * alignment padding and headers, plus the data they reference; JS objects
+ * are estimated from the engine's representation.
  * 
- * @param obj The object to measure.
- * @returns Estimated size in bytes.
+ * @param obj The value to measure.
+ * @returns Size in bytes.
  */
 declare function sizeof(obj: any): number;
@@ -2,67 +2,594 @@ package intrinsics
 
 import (
 	"reflect"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+	"unsafe"
 
 	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
 )
 
-// Sizeof implements the global sizeof() function.
-// Usage: sizeof(obj) -> returns estimated bytes in memory
+// Sizes of sobek's representations on 64-bit platforms, used to estimate
+// the memory JS values hold. Go values are measured by their layout.
+const (
+	jsObjectSize   = 144 // Object and baseObject, with an empty property map
+	jsPropertySize = 48  // A property map slot and its entry in propNames
+	jsAccessorSize = 32  // The getter and setter of an accessor property
+	jsElementSize  = 16  // An array element, a Value interface
+	jsArraySize    = 64  // Extra fields of arrays: length and the values slice
+	jsFunctionSize = 96  // Extra fields of functions: code, scope and realm
+	jsEntrySize    = 64  // A Map or Set entry: list node and hash slot
+	jsSymbolSize   = 32
+
+	goMapHeader  = 48 // The map header
+	goChanHeader = 96 // hchan
+)
+
+// Sizeof implements the global sizeof() function: the bytes a value holds,
+// counting memory shared within it once. Go values, including live structs,
+// refs and GoSlices, are measured by Go's layout rules; JS objects by
+// estimates of the engine's representation.
 func (r *Registry) Sizeof(call sobek.FunctionCall) sobek.Value {
 	if len(call.Arguments) == 0 {
 		return sobek.Undefined()
 	}
+	s := NewSizer(r.vm)
+	if obj, ok := call.Arguments[0].(*sobek.Object); ok {
+		if v, ok := GoValueOf(obj); ok {
+			return r.vm.ToValue(s.GoRetained(v))
+		}
+	}
+	return r.vm.ToValue(s.Retained(call.Arguments[0]))
+}
 
-	val := call.Arguments[0]
-	size := estimateSize(val)
+// Sizer measures values, counting memory reached more than once only the
+// first time, across calls.
+type Sizer struct {
+	vm       *sobek.Runtime
+	jsSeen   map[*sobek.Object]bool
+	goSeen   map[goKey]bool
+	describe sobek.Callable
+}
 
-	// Sobek automatically converts Go int64 to JS number
-	return r.vm.ToValue(size)
+// goKey identifies a Go allocation: the address and type of its contents.
+type goKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// NewSizer returns a sizer for values of vm.
+func NewSizer(vm *sobek.Runtime) *Sizer {
+	s := &Sizer{vm: vm, jsSeen: map[*sobek.Object]bool{}, goSeen: map[goKey]bool{}}
+	if ctor, ok := vm.Get("Object").(*sobek.Object); ok {
+		s.describe, _ = sobek.AssertFunction(ctor.Get("getOwnPropertyDescriptor"))
+	}
+	return s
 }
 
-func estimateSize(val sobek.Value) int64 {
-	if val == nil {
+// Retained returns the bytes held by v and everything it reaches, apart
+// from prototypes and memory already counted.
+func (s *Sizer) Retained(v sobek.Value) int64 {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return PrimitiveSize(v)
+	}
+	if s.jsSeen[obj] {
 		return 0
 	}
+	s.jsSeen[obj] = true
+	size, children := s.Object(obj)
+	for _, c := range children {
+		size += s.Retained(c.Value)
+	}
+	return size
+}
+
+// Edge is an object reachable from another by a property.
+type Edge struct {
+	Name  string
+	Value *sobek.Object
+}
 
-	// 1. Export to Go value to analyze
-	export := val.Export()
-	if export == nil {
-		return 0 // null/undefined
+// Object returns the bytes obj holds itself, including primitive property
+// values and any Go value behind it, and the objects it references.
+// Accessors are not called.
+func (s *Sizer) Object(obj *sobek.Object) (int64, []Edge) {
+	if v, ok := GoValueOf(obj); ok {
+		return jsObjectSize + s.GoRetained(v), nil
+	}
+	switch {
+	case obj.ExportType() == arrayBufferType:
+		return jsObjectSize + s.bytes(obj.Export().(sobek.ArrayBuffer).Bytes()), nil
+	case IsProxy(obj):
+		// Looking into a proxy would fire its traps; follow its target and
+		// handler instead
+		var edges []Edge
+		p := obj.Export().(sobek.Proxy)
+		if target := p.Target(); target != nil {
+			edges = append(edges, Edge{Name: "target", Value: target})
+		}
+		if handler := p.Handler(); handler != nil {
+			edges = append(edges, Edge{Name: "handler", Value: handler})
+		}
+		return jsObjectSize, edges
+	}
+	if isTypedArray(obj) {
+		size := int64(jsObjectSize + jsArraySize)
+		if buf, ok := obj.Get("buffer").(*sobek.Object); ok {
+			return size, []Edge{{Name: "buffer", Value: buf}}
+		}
+		return size, nil
 	}
 
-	// 2. Use reflect for Go types
-	v := reflect.ValueOf(export)
-	switch v.Kind() {
-	case reflect.Bool:
+	size := int64(jsObjectSize)
+	class := obj.ClassName()
+	switch class {
+	case "Array":
+		size += jsArraySize
+	case "Function":
+		size += jsFunctionSize
+	}
+
+	var edges []Edge
+	for _, name := range obj.GetOwnPropertyNames() {
+		if class == "Array" && isIndex(name) {
+			size += jsElementSize
+		} else {
+			size += jsPropertySize
+		}
+		desc, ok := s.descriptor(obj, name)
+		if !ok {
+			continue
+		}
+		if value := desc.Get("value"); value != nil {
+			edges = s.add(edges, &size, name, value)
+			continue
+		}
+		size += jsAccessorSize
+		for _, kind := range []string{"get", "set"} {
+			if fn, ok := desc.Get(kind).(*sobek.Object); ok {
+				edges = append(edges, Edge{Name: kind + " " + name, Value: fn})
+			}
+		}
+	}
+	size += int64(len(obj.Symbols())) * jsPropertySize
+
+	if class == "Map" || class == "Set" {
+		if forEach, ok := sobek.AssertFunction(obj.Get("forEach")); ok {
+			_, _ = forEach(obj, s.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+				size += jsEntrySize
+				key := call.Argument(1)
+				name := "[" + key.String() + "]"
+				if class == "Map" {
+					edges = s.add(edges, &size, name+" key", key)
+					edges = s.add(edges, &size, name, call.Argument(0))
+				} else {
+					edges = s.add(edges, &size, name, key)
+				}
+				return sobek.Undefined()
+			}))
+		}
+	}
+	return size, edges
+}
+
+// add counts a primitive value in size, or adds an edge to an object.
+func (s *Sizer) add(edges []Edge, size *int64, name string, v sobek.Value) []Edge {
+	if obj, ok := v.(*sobek.Object); ok {
+		return append(edges, Edge{Name: name, Value: obj})
+	}
+	*size += PrimitiveSize(v)
+	return edges
+}
+
+func (s *Sizer) descriptor(obj *sobek.Object, name string) (*sobek.Object, bool) {
+	if s.describe == nil {
+		return nil, false
+	}
+	desc, err := s.describe(sobek.Undefined(), obj, s.vm.ToValue(name))
+	if err != nil {
+		return nil, false
+	}
+	d, ok := desc.(*sobek.Object)
+	return d, ok
+}
+
+// isTypedArray reports whether obj is a typed array, which exports as a Go
+// slice over its buffer.
+func isTypedArray(obj *sobek.Object) bool {
+	t := obj.ExportType()
+	if t == nil || t.Kind() != reflect.Slice || t.Elem().Kind() < reflect.Int || t.Elem().Kind() > reflect.Float64 {
+		return false
+	}
+	bpe := obj.Get("BYTES_PER_ELEMENT")
+	return bpe != nil && !sobek.IsUndefined(bpe)
+}
+
+// IsProxy reports whether obj is a Proxy, whose properties, prototype and
+// class must not be looked up: that fires its traps, or throws once revoked.
+func IsProxy(obj *sobek.Object) bool {
+	return obj.ExportType() == proxyType
+}
+
+// bytes counts a backing array once.
+func (s *Sizer) bytes(b []byte) int64 {
+	if cap(b) == 0 {
+		return 0
+	}
+	key := goKey{addr: uintptr(unsafe.Pointer(unsafe.SliceData(b))), typ: reflect.TypeOf(b)}
+	if s.goSeen[key] {
+		return 0
+	}
+	s.goSeen[key] = true
+	return int64(cap(b))
+}
+
+func isIndex(name string) bool {
+	_, err := strconv.ParseUint(name, 10, 32)
+	return err == nil
+}
+
+// PrimitiveSize returns the bytes a JS primitive holds: numbers 8, booleans
+// 1, and strings their data and header, two bytes a character for strings
+// that are not ASCII, as the engine stores them in UTF-16.
+func PrimitiveSize(v sobek.Value) int64 {
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return 0
+	}
+	switch exported := v.Export().(type) {
+	case bool:
 		return 1
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Float32, reflect.Float64:
-		return 8 // Standardize word size
+	case string:
+		if isASCII(exported) {
+			return 16 + int64(len(exported))
+		}
+		return 24 + 2*int64(utf16Len(exported)+1)
+	case int64, float64:
+		return 8
+	case *sobek.Symbol:
+		return jsSymbolSize
+	}
+	if sobek.IsBigInt(v) {
+		return 32 + int64(len(v.String())/19+1)*8
+	}
+	return 8
+}
+
+// typeOf returns the typeof of a primitive.
+func typeOf(v sobek.Value) string {
+	switch {
+	case v == nil || sobek.IsUndefined(v):
+		return "undefined"
+	case sobek.IsNull(v):
+		return "null"
+	case sobek.IsString(v):
+		return "string"
+	case sobek.IsNumber(v):
+		return "number"
+	case sobek.IsBigInt(v):
+		return "bigint"
+	}
+	if _, ok := v.Export().(bool); ok {
+		return "boolean"
+	}
+	return "symbol"
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n++
+		}
+		n++
+	}
+	return n
+}
+
+// jsExported are the types JS objects export as, not Go values.
+var jsExported = map[reflect.Type]bool{
+	reflect.TypeOf(map[string]interface{}{}): true,
+	reflect.TypeOf([]interface{}{}):          true,
+	proxyType:                                true,
+}
+
+var (
+	arrayBufferType = reflect.TypeOf(sobek.ArrayBuffer{})
+	proxyType       = reflect.TypeOf(sobek.Proxy{})
+)
+
+// jsClasses are the classes of JS objects that export as Go types, such as
+// Date as time.Time.
+var jsClasses = map[string]bool{
+	"Function": true, "Date": true, "RegExp": true, "Error": true, "Map": true, "Set": true,
+	"WeakMap": true, "WeakSet": true, "Promise": true, "Boolean": true, "Number": true,
+	"String": true, "Symbol": true, "Arguments": true, "Array": true,
+}
+
+// GoValueOf returns the Go value behind a bridged object: a pointer to the
+// variable of a ref or live struct, the slice of a GoSlice, or the value of
+// a wrapped Go object.
+func GoValueOf(obj *sobek.Object) (reflect.Value, bool) {
+	if slice, ok := core.SliceOf(obj); ok {
+		return slice.Value(), true
+	}
+	if ptr, ok, _ := core.RefTarget(obj, nil); ok {
+		return ptr, true
+	}
+	// The class of a revoked proxy is an error, so check the type first
+	t := obj.ExportType()
+	if t == nil || jsExported[t] || t == arrayBufferType {
+		return reflect.Value{}, false
+	}
+	if jsClasses[obj.ClassName()] || isTypedArray(obj) {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(obj.Export())
+	return v, v.IsValid()
+}
+
+// GoRetained returns the bytes held by a Go value and the memory it
+// references: its own size per unsafe.Sizeof, string and slice data, map
+// and channel storage, and whatever pointers and interfaces point to. The
+// pointer of a ref or live struct counts as the variable it points to.
+func (s *Sizer) GoRetained(v reflect.Value) int64 {
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		return s.pointee(v)
+	}
+	return int64(v.Type().Size()) + s.goIndirect(v)
+}
+
+// pointee counts the variable ptr points to once.
+func (s *Sizer) pointee(ptr reflect.Value) int64 {
+	if opaque(ptr.Type().Elem()) {
+		return 0
+	}
+	key := goKey{addr: ptr.Pointer(), typ: ptr.Type().Elem()}
+	if s.goSeen[key] {
+		return 0
+	}
+	s.goSeen[key] = true
+	return int64(key.typ.Size()) + s.goIndirect(ptr.Elem())
+}
+
+// goIndirect returns the bytes v references beyond its own size.
+func (s *Sizer) goIndirect(v reflect.Value) int64 {
+	if !v.IsValid() || !hasIndirect(v.Type()) {
+		return 0
+	}
+	switch v.Kind() {
 	case reflect.String:
-		return int64(len(v.String()) + 16) // Content + Header overhead
+		if v.Len() == 0 {
+			return 0
+		}
+		key := goKey{addr: uintptr(unsafe.Pointer(unsafe.StringData(v.String()))), typ: v.Type()}
+		if s.goSeen[key] {
+			return 0
+		}
+		s.goSeen[key] = true
+		return int64(v.Len())
 	case reflect.Slice:
-		// Header + (Len * ElemSize)
-		// Deep inspection is expensive, doing shallow for now + capacity
-		elem := v.Type().Elem()
-		return int64(v.Cap()) * int64(elem.Size())
+		if v.IsNil() || v.Cap() == 0 {
+			return 0
+		}
+		key := goKey{addr: v.Pointer(), typ: v.Type()}
+		if s.goSeen[key] {
+			return 0
+		}
+		s.goSeen[key] = true
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		if hasIndirect(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				size += s.goIndirect(v.Index(i))
+			}
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += s.goIndirect(v.Index(i))
+		}
+		return size
 	case reflect.Struct:
-		return int64(v.Type().Size())
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += s.goIndirect(v.Field(i))
+		}
+		return size
 	case reflect.Ptr:
 		if v.IsNil() {
-			return 8
+			return 0
+		}
+		return s.pointee(v)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		switch elem.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			// Stored in the interface itself
+			return s.goIndirect(elem)
+		}
+		if opaque(elem.Type()) {
+			return 0
+		}
+		return int64(elem.Type().Size()) + s.goIndirect(elem)
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		key := goKey{addr: v.Pointer(), typ: v.Type()}
+		if s.goSeen[key] {
+			return 0
+		}
+		s.goSeen[key] = true
+		size := mapStorage(v.Type(), v.Len())
+		if hasIndirect(v.Type().Key()) || hasIndirect(v.Type().Elem()) {
+			iter := v.MapRange()
+			for iter.Next() {
+				size += s.goIndirect(iter.Key()) + s.goIndirect(iter.Value())
+			}
+		}
+		return size
+	case reflect.Chan:
+		if v.IsNil() {
+			return 0
+		}
+		key := goKey{addr: v.Pointer(), typ: v.Type()}
+		if s.goSeen[key] {
+			return 0
 		}
-		return 8 + estimateReflectSize(v.Elem())
-	default:
-		// Fallback for strict Sobek types (Object, Array, etc) not fully exported
-		return 64 // Rough estimate for an object reference
+		s.goSeen[key] = true
+		return goChanHeader + int64(v.Cap())*int64(v.Type().Elem().Size())
 	}
+	return 0
 }
 
-func estimateReflectSize(v reflect.Value) int64 {
-	if !v.IsValid() {
-		return 0
+// mapStorage estimates the header and groups of a Go map of n entries:
+// groups of eight slots and a control word, at most 7/8 full.
+func mapStorage(t reflect.Type, n int) int64 {
+	slots := 8
+	for slots*7/8 < n {
+		slots *= 2
+	}
+	slot := alignUp(t.Key().Size(), uintptr(t.Elem().Align())) + t.Elem().Size()
+	slot = alignUp(slot, uintptr(max(t.Key().Align(), t.Elem().Align())))
+	return goMapHeader + int64(slots/8)*int64(8+8*slot)
+}
+
+func alignUp(n, align uintptr) uintptr {
+	if align == 0 {
+		return n
+	}
+	return (n + align - 1) &^ (align - 1)
+}
+
+// hasIndirect reports whether values of t may reference other memory.
+func hasIndirect(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Ptr, reflect.Interface, reflect.Map, reflect.Chan:
+		return !opaque(t)
+	case reflect.Array:
+		return t.Len() > 0 && hasIndirect(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasIndirect(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var sobekPkg = reflect.TypeOf(sobek.Runtime{}).PkgPath()
+
+// opaque reports whether memory behind t is the engine's own, such as the
+// runtime or a JS object held by a Go value, and not followed.
+func opaque(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.PkgPath() == sobekPkg || t == reflect.TypeOf(reflect.Value{})
+}
+
+// Field is the layout of a struct field.
+type Field struct {
+	Name    string
+	Type    string
+	Offset  int64
+	Size    int64
+	Align   int64
+	Padding int64 // Bytes of padding after the field
+}
+
+// Layout returns the fields of struct type t with their offsets and the
+// padding the alignment of the next field, or of t, requires.
+func Layout(t reflect.Type) []Field {
+	fields := make([]Field, t.NumField())
+	for i := range fields {
+		f := t.Field(i)
+		end := t.Size()
+		if i+1 < len(fields) {
+			end = t.Field(i + 1).Offset
+		}
+		fields[i] = Field{
+			Name:    f.Name,
+			Type:    f.Type.String(),
+			Offset:  int64(f.Offset),
+			Size:    int64(f.Type.Size()),
+			Align:   int64(f.Type.Align()),
+			Padding: int64(end - f.Offset - f.Type.Size()),
+		}
+	}
+	return fields
+}
+
+// Profile describes the memory of a value, as memory.profile returns it.
+type Profile struct {
+	Kind     string // "go", "js" or "primitive"
+	Type     string
+	Size     int64 // Bytes of the value itself
+	Retained int64 // Bytes of the value and all it reaches
+	Align    int64
+	Padding  int64
+	Fields   []Field
+	Children []Child // What each property retains, largest first
+}
+
+// Child is what a property retains, beyond what earlier properties did.
+type Child struct {
+	Name     string
+	Retained int64
+}
+
+// NewProfile profiles v.
+func NewProfile(vm *sobek.Runtime, v sobek.Value) *Profile {
+	s := NewSizer(vm)
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		size := PrimitiveSize(v)
+		return &Profile{Kind: "primitive", Type: typeOf(v), Size: size, Retained: size}
+	}
+
+	if gv, ok := GoValueOf(obj); ok {
+		t := gv.Type()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		p := &Profile{Kind: "go", Type: t.String(), Size: int64(t.Size()), Align: int64(t.Align())}
+		if t.Kind() == reflect.Struct {
+			p.Fields = Layout(t)
+			for _, f := range p.Fields {
+				p.Padding += f.Padding
+			}
+		}
+		p.Retained = s.GoRetained(gv)
+		return p
+	}
+
+	s.jsSeen[obj] = true
+	size, edges := s.Object(obj)
+	p := &Profile{Kind: "js", Type: "Proxy", Size: size, Retained: size}
+	if !IsProxy(obj) {
+		p.Type = obj.ClassName()
+	}
+	for _, e := range edges {
+		retained := s.Retained(e.Value)
+		p.Retained += retained
+		p.Children = append(p.Children, Child{Name: e.Name, Retained: retained})
 	}
-	return int64(v.Type().Size())
+	sort.SliceStable(p.Children, func(i, j int) bool { return p.Children[i].Retained > p.Children[j].Retained })
+	return p
 }
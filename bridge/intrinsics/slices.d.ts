@@ -1,9 +1,30 @@
+/** Go element type names accepted by make. */
+type GoElementType = "int" | "int8" | "int16" | "int32" | "int64" | "uint" | "uint8" | "uint16" | "uint32" | "uint64" |
+    "float32" | "float64" | "bool" | "string" | "any";
+
+/**
+ * A Go slice. Its elements live in a Go backing array, which slice() and
+ * append() share like their Go counterparts, and Go functions taking []T
+ * receive the slice itself. Struct elements are live objects.
+ */
+interface GoSlice<T> extends Array<T> {
+    /** Go type of the elements. */
+    readonly type: string;
+    len(): number;
+    cap(): number;
+    /** Returns s with vals appended, reusing the backing array when cap allows. */
+    append(...vals: T[]): GoSlice<T>;
+    /** s[lo:hi:max]; max defaults to cap(). Shares the backing array. */
+    slice(lo?: number, hi?: number, max?: number): GoSlice<T>;
+    toArray(): T[];
+}
+
 /**
  * Returns the capacity of a typed array, array, or buffer.
  * For JS Arrays, this is the same as length.
  * For TypedArrays, this is the allocated size of the underlying buffer.
  */
-declare function cap(v: any[] | ArrayBuffer | SharedArrayBuffer | { length: number } | { byteLength: number }): number;
+declare function cap(v: GoSlice<any> | Chan<any> | any[] | ArrayBuffer | SharedArrayBuffer | { length: number } | { byteLength: number }): number;
 
 /**
  * Creates a new slice (TypedArray) with a specified length and optional capacity.
@@ -13,8 +34,17 @@ declare function cap(v: any[] | ArrayBuffer | SharedArrayBuffer | { length: numb
  */
 declare function make<T extends { new(len: number): any }>(Type: T, len: number, cap?: number): InstanceType<T>;
 
+/**
+ * Creates a Go slice of the named element type, e.g. "int32", "[]string",
+ * or a struct type registered by the host with core.RegisterGoType.
+ */
+declare function make<T = any>(type: GoElementType | `[]${string}` | string, len: number, cap?: number): GoSlice<T>;
+
+/** Go's append: returns s with vals appended. */
+declare function append<T>(s: GoSlice<T>, ...vals: T[]): GoSlice<T>;
+
 /**
  * Copies elements from a source to a destination.
  * Returns the number of elements copied.
  */
-declare function copy(dst: { set(src: any): void; length: number }, src: { length: number }): number;
+declare function copy(dst: GoSlice<any> | { set(src: any): void; length: number }, src: { length: number }): number;
@@ -1,7 +1,10 @@
 package intrinsics
 
 import (
+	"strings"
+
 	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
 )
 
 // Cap implements cap(v)
@@ -11,6 +14,12 @@ func (r *Registry) Cap(call sobek.FunctionCall) sobek.Value {
 	}
 
 	val := call.Arguments[0]
+	if c, ok := core.ChannelOf(val); ok {
+		return r.vm.ToValue(c.Chan().Cap())
+	}
+	if s, ok := core.SliceOf(val); ok {
+		return r.vm.ToValue(s.Value().Cap())
+	}
 	if obj, ok := val.(*sobek.Object); ok {
 		// For TypedArrays (Uint8Array, etc.)
 		if buffer := obj.Get("buffer"); buffer != nil {
@@ -32,7 +41,9 @@ func (r *Registry) Cap(call sobek.FunctionCall) sobek.Value {
 	return r.vm.ToValue(0)
 }
 
-// Make implements make(Type, len, cap)
+// Make implements make(Type, len, cap). Type is a TypedArray constructor,
+// or a Go element type name such as "int32", "string" or a type registered
+// with core.RegisterGoType, which makes a GoSlice.
 func (r *Registry) Make(call sobek.FunctionCall) sobek.Value {
 	if len(call.Arguments) < 2 {
 		panic(r.vm.NewTypeError("make requires at least a type and a length"))
@@ -66,15 +77,47 @@ func (r *Registry) Make(call sobek.FunctionCall) sobek.Value {
 		return arr
 	}
 
+	if name := typ.String(); !sobek.IsUndefined(typ) {
+		elem, ok := core.LookupGoType(strings.TrimPrefix(name, "[]"))
+		if !ok {
+			panic(r.vm.NewTypeError("make: unknown Go type %q", name))
+		}
+		if length < 0 {
+			panic(r.vm.NewTypeError("length cannot be negative"))
+		}
+		return core.MakeSlice(r.vm, elem, length, capacity, r.BindOptions()...).Object()
+	}
+
 	return sobek.Undefined()
 }
 
+// Append implements append(slice, ...vals) for GoSlices.
+func (r *Registry) Append(call sobek.FunctionCall) sobek.Value {
+	s, ok := core.SliceOf(call.Argument(0))
+	if !ok {
+		panic(r.vm.NewTypeError("append requires a GoSlice made with make"))
+	}
+	var vals []sobek.Value
+	if len(call.Arguments) > 1 {
+		vals = call.Arguments[1:]
+	}
+	return s.Append(vals...).Object()
+}
+
 // Copy implements copy(dst, src)
 func (r *Registry) Copy(call sobek.FunctionCall) sobek.Value {
 	if len(call.Arguments) < 2 {
 		return r.vm.ToValue(0)
 	}
 
+	if s, ok := core.SliceOf(call.Arguments[0]); ok {
+		n, err := s.Copy(call.Arguments[1])
+		if err != nil {
+			panic(r.vm.NewTypeError("copy: %v", err))
+		}
+		return r.vm.ToValue(n)
+	}
+
 	dst := call.Arguments[0].ToObject(r.vm)
 	src := call.Arguments[1].ToObject(r.vm)
 
@@ -97,7 +140,10 @@ func (r *Registry) Copy(call sobek.FunctionCall) sobek.Value {
 
 	var finalSrc sobek.Value = src
 	if srcLen > n {
-		sub, _ := sobek.AssertFunction(src.Get("subarray"))
+		sub, ok := sobek.AssertFunction(src.Get("subarray"))
+		if !ok {
+			sub, _ = sobek.AssertFunction(src.Get("slice")) // GoSlices and Arrays
+		}
 		finalSrc, _ = sub(src, r.vm.ToValue(0), r.vm.ToValue(n))
 	}
 
@@ -0,0 +1,208 @@
+/**
+ * A queuing strategy sizes chunks and sets how much a stream buffers
+ * before it applies backpressure.
+ */
+interface QueuingStrategy<T = any> {
+    highWaterMark?: number;
+    size?(chunk: T): number;
+}
+
+/**
+ * Counts each chunk as 1.
+ */
+declare class CountQueuingStrategy implements QueuingStrategy<any> {
+    constructor(init: { highWaterMark: number });
+    readonly highWaterMark: number;
+    readonly size: (chunk: any) => 1;
+}
+
+/**
+ * Sizes chunks by their byteLength.
+ */
+declare class ByteLengthQueuingStrategy implements QueuingStrategy<ArrayBufferView> {
+    constructor(init: { highWaterMark: number });
+    readonly highWaterMark: number;
+    readonly size: (chunk: ArrayBufferView) => number;
+}
+
+interface ReadableStreamReadResult<T> {
+    done: boolean;
+    value: T | undefined;
+}
+
+interface ReadableStreamDefaultController<R = any> {
+    readonly desiredSize: number | null;
+    enqueue(chunk: R): void;
+    close(): void;
+    error(reason?: any): void;
+}
+
+interface UnderlyingSource<R = any> {
+    start?(controller: ReadableStreamDefaultController<R>): any;
+    pull?(controller: ReadableStreamDefaultController<R>): void | PromiseLike<void>;
+    cancel?(reason?: any): void | PromiseLike<void>;
+}
+
+interface ReadableStreamDefaultReader<R = any> {
+    readonly closed: Promise<undefined>;
+    read(): Promise<ReadableStreamReadResult<R>>;
+    cancel(reason?: any): Promise<void>;
+    releaseLock(): void;
+}
+
+/**
+ * What pipeTo needs of an abort signal.
+ */
+interface StreamAbortSignal {
+    readonly aborted: boolean;
+    readonly reason: any;
+    addEventListener(type: "abort", listener: () => void): void;
+    removeEventListener(type: "abort", listener: () => void): void;
+}
+
+interface StreamPipeOptions {
+    preventClose?: boolean;
+    preventAbort?: boolean;
+    preventCancel?: boolean;
+    signal?: StreamAbortSignal;
+}
+
+/**
+ * A source of chunks, read on demand with backpressure. Byte streams and
+ * BYOB readers are not supported.
+ */
+declare class ReadableStream<R = any> {
+    constructor(source?: UnderlyingSource<R>, strategy?: QueuingStrategy<R>);
+    /**
+     * A stream of the values of an iterable or async iterable, or of the
+     * bytes of a Go io.Reader.
+     */
+    static from<R>(source: Iterable<R> | AsyncIterable<R> | any): ReadableStream<R>;
+    readonly locked: boolean;
+    cancel(reason?: any): Promise<void>;
+    getReader(): ReadableStreamDefaultReader<R>;
+    pipeThrough<T>(transform: { writable: WritableStream<R>; readable: ReadableStream<T> }, options?: StreamPipeOptions): ReadableStream<T>;
+    pipeTo(destination: WritableStream<R>, options?: StreamPipeOptions): Promise<void>;
+    tee(): [ReadableStream<R>, ReadableStream<R>];
+    values(options?: { preventCancel?: boolean }): AsyncIterableIterator<R>;
+    [Symbol.asyncIterator](options?: { preventCancel?: boolean }): AsyncIterableIterator<R>;
+}
+
+interface WritableStreamDefaultController {
+    readonly signal: StreamAbortSignal;
+    error(reason?: any): void;
+}
+
+interface UnderlyingSink<W = any> {
+    start?(controller: WritableStreamDefaultController): any;
+    write?(chunk: W, controller: WritableStreamDefaultController): void | PromiseLike<void>;
+    close?(): void | PromiseLike<void>;
+    abort?(reason?: any): void | PromiseLike<void>;
+}
+
+interface WritableStreamDefaultWriter<W = any> {
+    readonly closed: Promise<undefined>;
+    readonly desiredSize: number | null;
+    readonly ready: Promise<undefined>;
+    abort(reason?: any): Promise<void>;
+    close(): Promise<void>;
+    releaseLock(): void;
+    write(chunk: W): Promise<void>;
+}
+
+/**
+ * A destination for chunks, written one at a time in order.
+ */
+declare class WritableStream<W = any> {
+    constructor(sink?: UnderlyingSink<W>, strategy?: QueuingStrategy<W>);
+    readonly locked: boolean;
+    abort(reason?: any): Promise<void>;
+    close(): Promise<void>;
+    getWriter(): WritableStreamDefaultWriter<W>;
+}
+
+interface TransformStreamDefaultController<O = any> {
+    readonly desiredSize: number | null;
+    enqueue(chunk: O): void;
+    error(reason?: any): void;
+    terminate(): void;
+}
+
+interface Transformer<I = any, O = any> {
+    start?(controller: TransformStreamDefaultController<O>): any;
+    transform?(chunk: I, controller: TransformStreamDefaultController<O>): void | PromiseLike<void>;
+    flush?(controller: TransformStreamDefaultController<O>): void | PromiseLike<void>;
+    cancel?(reason?: any): void | PromiseLike<void>;
+}
+
+/**
+ * A writable and a readable side, with chunks written to one transformed
+ * into chunks read from the other.
+ */
+declare class TransformStream<I = any, O = any> {
+    constructor(transformer?: Transformer<I, O>, writableStrategy?: QueuingStrategy<I>, readableStrategy?: QueuingStrategy<O>);
+    readonly readable: ReadableStream<O>;
+    readonly writable: WritableStream<I>;
+}
+
+/**
+ * Decodes a stream of bytes to strings, carrying characters split across chunks.
+ */
+declare class TextDecoderStream {
+    constructor(label?: string, options?: { fatal?: boolean; ignoreBOM?: boolean });
+    readonly encoding: string;
+    readonly fatal: boolean;
+    readonly ignoreBOM: boolean;
+    readonly readable: ReadableStream<string>;
+    readonly writable: WritableStream<ArrayBufferView | ArrayBuffer>;
+}
+
+/**
+ * Encodes a stream of strings as UTF-8.
+ */
+declare class TextEncoderStream {
+    constructor();
+    readonly encoding: "utf-8";
+    readonly readable: ReadableStream<Uint8Array>;
+    readonly writable: WritableStream<string>;
+}
+
+type CompressionFormat = "gzip" | "deflate" | "deflate-raw";
+
+/**
+ * Compresses a stream of bytes, in Go.
+ */
+declare class CompressionStream {
+    constructor(format: CompressionFormat);
+    readonly readable: ReadableStream<Uint8Array>;
+    readonly writable: WritableStream<ArrayBufferView | ArrayBuffer>;
+}
+
+/**
+ * Decompresses a stream of bytes, in Go.
+ */
+declare class DecompressionStream {
+    constructor(format: CompressionFormat);
+    readonly readable: ReadableStream<Uint8Array>;
+    readonly writable: WritableStream<ArrayBufferView | ArrayBuffer>;
+}
+
+/**
+ * Returns a ReadableStream of the bytes of a Go io.Reader, read off the
+ * event loop as the stream is pulled. A reader that is an io.Closer is
+ * closed at the end of the stream and when it is cancelled.
+ *
+ * @param reader A Go io.Reader.
+ * @param options chunkSize is the most read at once (64 KiB); highWaterMark is in chunks (0).
+ */
+declare function toReadableStream(reader: any, options?: { chunkSize?: number; highWaterMark?: number }): ReadableStream<Uint8Array>;
+
+/**
+ * Returns a WritableStream writing bytes, or strings as UTF-8, to a Go
+ * io.Writer off the event loop. The writer is flushed and closed, when it
+ * can be, as the stream is closed.
+ *
+ * @param writer A Go io.Writer.
+ * @param options highWaterMark is in chunks (1).
+ */
+declare function toWritableStream(writer: any, options?: { highWaterMark?: number }): WritableStream<Uint8Array | string>;
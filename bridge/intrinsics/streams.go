@@ -0,0 +1,347 @@
+package intrinsics
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/grafana/sobek"
+)
+
+// StreamsJS implements the WHATWG streams globals over the event loop.
+//
+//go:embed streams.js
+var StreamsJS string
+
+// defaultChunkSize is the most a Go reader source reads per chunk.
+const defaultChunkSize = 64 * 1024
+
+// ReadableStream returns a JS ReadableStream of the bytes of reader, read
+// on demand. A reader that is an io.Closer is closed at EOF, on error and
+// when the stream is cancelled.
+func (r *Registry) ReadableStream(reader io.Reader, chunkSize int) (sobek.Value, error) {
+	ctor, ok := r.vm.Get("ReadableStream").(*sobek.Object)
+	if !ok {
+		return nil, errors.New("ReadableStream is not available")
+	}
+	strategy := r.vm.NewObject()
+	_ = strategy.Set("highWaterMark", 0)
+	return r.vm.New(ctor, r.readerSource(reader, chunkSize), strategy)
+}
+
+// WritableStream returns a JS WritableStream writing to w. A writer that is
+// an io.Closer is closed when the stream is closed or aborted.
+func (r *Registry) WritableStream(w io.Writer) (sobek.Value, error) {
+	ctor, ok := r.vm.Get("WritableStream").(*sobek.Object)
+	if !ok {
+		return nil, errors.New("WritableStream is not available")
+	}
+	return r.vm.New(ctor, r.writerSink(w))
+}
+
+// ReaderSource implements __readerSource(reader, chunkSize): the underlying
+// source of a stream of a Go io.Reader, or undefined for other values.
+func (r *Registry) ReaderSource(call sobek.FunctionCall) sobek.Value {
+	reader, ok := call.Argument(0).Export().(io.Reader)
+	if !ok {
+		return sobek.Undefined()
+	}
+	return r.readerSource(reader, int(call.Argument(1).ToInteger()))
+}
+
+// WriterSink implements __writerSink(writer): the underlying sink of a
+// stream to a Go io.Writer, or undefined for other values.
+func (r *Registry) WriterSink(call sobek.FunctionCall) sobek.Value {
+	w, ok := call.Argument(0).Export().(io.Writer)
+	if !ok {
+		return sobek.Undefined()
+	}
+	return r.writerSink(w)
+}
+
+// goAsync runs f off the loop and settles the returned promise on the loop
+// with then's result, keeping the loop alive meanwhile.
+func (r *Registry) goAsync(f func() func() error) sobek.Value {
+	promise, resolve, reject := r.vm.NewPromise()
+	el := r.el
+	el.WGAdd(1)
+	go func() {
+		then := f()
+		if el.Context().Err() != nil {
+			el.WGDone()
+			return
+		}
+		el.RunOnLoop(func() {
+			defer el.WGDone()
+			if err := then(); err != nil {
+				_ = reject(r.vm.NewGoError(err))
+				return
+			}
+			_ = resolve(sobek.Undefined())
+		})
+	}()
+	return r.vm.ToValue(promise)
+}
+
+func (r *Registry) readerSource(reader io.Reader, chunkSize int) *sobek.Object {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	vm := r.vm
+	var done atomic.Bool
+	closeReader := func() {
+		if done.CompareAndSwap(false, true) {
+			if c, ok := reader.(io.Closer); ok {
+				_ = c.Close()
+			}
+		}
+	}
+
+	src := vm.NewObject()
+	_ = src.Set("pull", func(call sobek.FunctionCall) sobek.Value {
+		controller := call.Argument(0).ToObject(vm)
+		return r.goAsync(func() func() error {
+			buf := make([]byte, chunkSize)
+			var n int
+			var err error
+			for n == 0 && err == nil {
+				n, err = reader.Read(buf)
+			}
+			return func() error {
+				if done.Load() {
+					return nil // Cancelled meanwhile
+				}
+				if n > 0 {
+					if err := callMethod(controller, "enqueue", r.newUint8Array(buf[:n])); err != nil {
+						return err
+					}
+				}
+				switch {
+				case err == io.EOF:
+					closeReader()
+					return callMethod(controller, "close")
+				case err != nil:
+					closeReader()
+					return err
+				}
+				return nil
+			}
+		})
+	})
+	_ = src.Set("cancel", func(sobek.FunctionCall) sobek.Value {
+		closeReader()
+		return sobek.Undefined()
+	})
+	return src
+}
+
+func (r *Registry) writerSink(w io.Writer) *sobek.Object {
+	vm := r.vm
+	closeWriter := func(reason error) func() error {
+		return func() error {
+			if f, ok := w.(interface{ Flush() error }); ok && reason == nil {
+				if err := f.Flush(); err != nil {
+					return err
+				}
+			}
+			if c, ok := w.(interface{ CloseWithError(error) error }); ok && reason != nil {
+				return c.CloseWithError(reason)
+			}
+			if c, ok := w.(io.Closer); ok {
+				return c.Close()
+			}
+			return nil
+		}
+	}
+
+	sink := vm.NewObject()
+	_ = sink.Set("write", func(call sobek.FunctionCall) sobek.Value {
+		data, err := chunkBytes(vm, call.Argument(0))
+		if err != nil {
+			panic(vm.NewTypeError(err.Error()))
+		}
+		return r.goAsync(func() func() error {
+			_, err := w.Write(data)
+			return func() error { return err }
+		})
+	})
+	_ = sink.Set("close", func(sobek.FunctionCall) sobek.Value {
+		return r.goAsync(func() func() error {
+			err := closeWriter(nil)()
+			return func() error { return err }
+		})
+	})
+	_ = sink.Set("abort", func(call sobek.FunctionCall) sobek.Value {
+		reason := fmt.Errorf("stream aborted: %s", call.Argument(0).String())
+		return r.goAsync(func() func() error {
+			_ = closeWriter(reason)()
+			return func() error { return nil }
+		})
+	})
+	return sink
+}
+
+// Codec implements __codec(format, compress): a transformer compressing or
+// decompressing in Go, for CompressionStream and DecompressionStream.
+func (r *Registry) Codec(call sobek.FunctionCall) sobek.Value {
+	vm := r.vm
+	run, err := codecFunc(call.Argument(0).String(), call.Argument(1).ToBoolean())
+	if err != nil {
+		panic(vm.NewTypeError(err.Error()))
+	}
+
+	// Input goes through a pipe to a goroutine running the codec; output
+	// is enqueued on the loop in order, before flush resolves.
+	var pw *io.PipeWriter
+	finished := make(chan error, 1)
+	transformer := vm.NewObject()
+	_ = transformer.Set("start", func(call sobek.FunctionCall) sobek.Value {
+		controller := call.Argument(0).ToObject(vm)
+		pr, w := io.Pipe()
+		pw = w
+		out := &loopWriter{r: r, controller: controller}
+		go func() {
+			err := run(pr, out)
+			pr.CloseWithError(err) // Later writes fail
+			finished <- err
+		}()
+		return sobek.Undefined()
+	})
+	_ = transformer.Set("transform", func(call sobek.FunctionCall) sobek.Value {
+		data, err := chunkBytes(vm, call.Argument(0))
+		if err != nil {
+			panic(vm.NewTypeError(err.Error()))
+		}
+		return r.goAsync(func() func() error {
+			_, err := pw.Write(data)
+			return func() error { return err }
+		})
+	})
+	_ = transformer.Set("flush", func(sobek.FunctionCall) sobek.Value {
+		return r.goAsync(func() func() error {
+			_ = pw.Close()
+			err := <-finished
+			return func() error { return err }
+		})
+	})
+	_ = transformer.Set("cancel", func(call sobek.FunctionCall) sobek.Value {
+		pw.CloseWithError(fmt.Errorf("stream cancelled: %s", call.Argument(0).String()))
+		return sobek.Undefined()
+	})
+	return transformer
+}
+
+// codecFunc returns the function copying in to out through the codec of a
+// CompressionStream format.
+func codecFunc(format string, compress bool) (func(in io.Reader, out io.Writer) error, error) {
+	var newWriter func(io.Writer) io.WriteCloser
+	var newReader func(io.Reader) (io.Reader, error)
+	switch format {
+	case "gzip":
+		newWriter = func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+		newReader = func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+	case "deflate":
+		newWriter = func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+		newReader = func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }
+	case "deflate-raw":
+		newWriter = func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		}
+		newReader = func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+
+	if compress {
+		return func(in io.Reader, out io.Writer) error {
+			w := newWriter(out)
+			if _, err := io.Copy(w, in); err != nil {
+				return err
+			}
+			return w.Close()
+		}, nil
+	}
+	return func(in io.Reader, out io.Writer) error {
+		zr, err := newReader(in)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, zr); err != nil {
+			return err
+		}
+		// Trailing data after the compressed stream is an error
+		var b [1]byte
+		if n, _ := in.Read(b[:]); n > 0 {
+			return errors.New("trailing data after the compressed stream")
+		}
+		return nil
+	}, nil
+}
+
+// loopWriter enqueues what is written to it as Uint8Arrays on a stream
+// controller, on the loop.
+type loopWriter struct {
+	r          *Registry
+	controller *sobek.Object
+}
+
+func (w *loopWriter) Write(p []byte) (int, error) {
+	data := append([]byte(nil), p...)
+	w.r.el.RunOnLoop(func() {
+		_ = callMethod(w.controller, "enqueue", w.r.newUint8Array(data))
+	})
+	return len(p), nil
+}
+
+// callMethod calls obj[name](args...), returning any exception as an error.
+func callMethod(obj *sobek.Object, name string, args ...sobek.Value) error {
+	fn, ok := sobek.AssertFunction(obj.Get(name))
+	if !ok {
+		return fmt.Errorf("%s is not a function", name)
+	}
+	_, err := fn(obj, args...)
+	return err
+}
+
+// newUint8Array returns a Uint8Array over data.
+func (r *Registry) newUint8Array(data []byte) sobek.Value {
+	u8 := r.vm.Get("Uint8Array").ToObject(r.vm)
+	arr, _ := r.vm.New(u8, r.vm.ToValue(r.vm.NewArrayBuffer(data)))
+	return arr
+}
+
+// chunkBytes returns a copy of the bytes of a stream chunk: an ArrayBuffer,
+// a typed array or DataView, or a string as UTF-8.
+func chunkBytes(vm *sobek.Runtime, v sobek.Value) ([]byte, error) {
+	if s, ok := v.Export().(string); ok {
+		return []byte(s), nil
+	}
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, fmt.Errorf("chunk must be bytes or a string, got %s", v.String())
+	}
+	if ab, ok := obj.Export().(sobek.ArrayBuffer); ok {
+		return append([]byte(nil), ab.Bytes()...), nil
+	}
+	buf, ok := obj.Get("buffer").(*sobek.Object)
+	if !ok {
+		return nil, fmt.Errorf("chunk must be bytes or a string")
+	}
+	ab, ok := buf.Export().(sobek.ArrayBuffer)
+	if !ok {
+		return nil, fmt.Errorf("chunk must be bytes or a string")
+	}
+	off := obj.Get("byteOffset").ToInteger()
+	n := obj.Get("byteLength").ToInteger()
+	data := ab.Bytes()
+	if off < 0 || n < 0 || off+n > int64(len(data)) {
+		return nil, fmt.Errorf("chunk is out of bounds of its buffer")
+	}
+	return append([]byte(nil), data[off:off+n]...), nil
+}
@@ -0,0 +1,1322 @@
+// WHATWG Streams: ReadableStream, WritableStream and TransformStream, with
+// queuing strategies, text and compression streams, and converters from Go
+// readers and writers. Byte streams (BYOB readers) are not implemented.
+(function () {
+	'use strict';
+	if (typeof globalThis.ReadableStream !== 'undefined') return;
+
+	// Internal slots, hidden from enumeration and JSON
+	const S = Symbol('slots');
+	const CLOSE = Symbol('close');
+
+	function deferred() {
+		const d = { settled: false };
+		d.promise = new Promise((resolve, reject) => {
+			d.resolve = (v) => { d.settled = true; resolve(v); };
+			d.reject = (e) => { d.settled = true; reject(e); };
+		});
+		return d;
+	}
+
+	function settledDeferred(ok, value) {
+		const d = deferred();
+		if (ok) d.resolve(value); else { d.reject(value); handled(d.promise); }
+		return d;
+	}
+
+	function handled(promise) {
+		promise.then(undefined, () => {});
+		return promise;
+	}
+
+	// promiseCall calls an optional user method, turning results and
+	// exceptions into a promise.
+	function promiseCall(fn, thisArg, args) {
+		if (fn === undefined) return Promise.resolve();
+		try {
+			return Promise.resolve(fn.apply(thisArg, args));
+		} catch (e) {
+			return Promise.reject(e);
+		}
+	}
+
+	function method(obj, name) {
+		const fn = obj[name];
+		if (fn !== undefined && typeof fn !== 'function') throw new TypeError(name + ' must be a function');
+		return fn;
+	}
+
+	function extractStrategy(strategy, defaultHWM) {
+		strategy = strategy == null ? {} : strategy;
+		const hwm = strategy.highWaterMark === undefined ? defaultHWM : Number(strategy.highWaterMark);
+		if (Number.isNaN(hwm) || hwm < 0) throw new RangeError('highWaterMark must be a non-negative number');
+		const size = strategy.size;
+		if (size !== undefined && typeof size !== 'function') throw new TypeError('size must be a function');
+		return { hwm, size: size === undefined ? () => 1 : (chunk) => size(chunk) };
+	}
+
+	function illegal() {
+		throw new TypeError('Illegal constructor');
+	}
+
+	function slots(obj, type) {
+		if (obj === null || typeof obj !== 'object' || !(S in obj) || !(obj instanceof type)) {
+			throw new TypeError('Illegal invocation: not a ' + type.name);
+		}
+		return obj[S];
+	}
+
+	// Abort signals passed to pipeTo are duck-typed: { aborted, reason,
+	// addEventListener, removeEventListener }. Writable controllers expose a
+	// minimal one.
+	function newSignal() {
+		const listeners = [];
+		const signal = {
+			aborted: false,
+			reason: undefined,
+			addEventListener(type, fn) { if (type === 'abort') listeners.push(fn); },
+			removeEventListener(type, fn) {
+				const i = listeners.indexOf(fn);
+				if (i >= 0) listeners.splice(i, 1);
+			},
+		};
+		const abort = (reason) => {
+			if (signal.aborted) return;
+			signal.aborted = true;
+			signal.reason = reason;
+			for (const fn of listeners.slice()) fn.call(signal, { type: 'abort' });
+		};
+		return { signal, abort };
+	}
+
+	function abortError(signal) {
+		if (signal.reason !== undefined) return signal.reason;
+		const e = new Error('The operation was aborted');
+		e.name = 'AbortError';
+		return e;
+	}
+
+	// ReadableStream
+
+	class ReadableStream {
+		constructor(source, strategy) {
+			if (source === undefined) source = {};
+			if (source === null || typeof source !== 'object') throw new TypeError('source must be an object');
+			if (source.type !== undefined) throw new RangeError('unsupported stream type: ' + source.type);
+			const { hwm, size } = extractStrategy(strategy, 1);
+			this[S] = { state: 'readable', reader: null, storedError: undefined, disturbed: false, controller: null };
+			setUpReadableController(this, source, hwm, size);
+		}
+
+		get locked() {
+			return slots(this, ReadableStream).reader !== null;
+		}
+
+		cancel(reason) {
+			if (!(this instanceof ReadableStream)) return Promise.reject(new TypeError('not a ReadableStream'));
+			if (this.locked) return Promise.reject(new TypeError('cannot cancel a locked stream'));
+			return readableCancel(this, reason);
+		}
+
+		getReader(options) {
+			slots(this, ReadableStream);
+			if (options != null && options.mode !== undefined) {
+				if (String(options.mode) === 'byob') throw new TypeError('BYOB readers are not supported');
+				throw new RangeError('invalid reader mode: ' + options.mode);
+			}
+			return new ReadableStreamDefaultReader(this);
+		}
+
+		pipeThrough(transform, options) {
+			slots(this, ReadableStream);
+			if (transform == null || !(transform.readable instanceof ReadableStream) || !(transform.writable instanceof WritableStream)) {
+				throw new TypeError('pipeThrough requires a { readable, writable } pair');
+			}
+			if (this.locked) throw new TypeError('cannot pipe a locked stream');
+			if (transform.writable.locked) throw new TypeError('cannot pipe to a locked stream');
+			handled(readablePipeTo(this, transform.writable, options));
+			return transform.readable;
+		}
+
+		pipeTo(dest, options) {
+			if (!(this instanceof ReadableStream)) return Promise.reject(new TypeError('not a ReadableStream'));
+			if (!(dest instanceof WritableStream)) return Promise.reject(new TypeError('pipeTo requires a WritableStream'));
+			if (this.locked) return Promise.reject(new TypeError('cannot pipe a locked stream'));
+			if (dest.locked) return Promise.reject(new TypeError('cannot pipe to a locked stream'));
+			return readablePipeTo(this, dest, options);
+		}
+
+		tee() {
+			slots(this, ReadableStream);
+			return readableTee(this);
+		}
+
+		values(options) {
+			slots(this, ReadableStream);
+			const preventCancel = options != null && !!options.preventCancel;
+			const reader = this.getReader();
+			let done = false;
+			const finish = () => {
+				done = true;
+				reader.releaseLock();
+			};
+			const iterator = Object.create(AsyncIteratorPrototype);
+			iterator.next = () => {
+				if (done) return Promise.resolve({ value: undefined, done: true });
+				return reader.read().then((r) => {
+					if (r.done) finish();
+					return r;
+				}, (e) => {
+					finish();
+					throw e;
+				});
+			};
+			iterator.return = (value) => {
+				if (done) return Promise.resolve({ value, done: true });
+				if (preventCancel) {
+					finish();
+					return Promise.resolve({ value, done: true });
+				}
+				const cancelled = reader.cancel(value);
+				finish();
+				return cancelled.then(() => ({ value, done: true }));
+			};
+			return iterator;
+		}
+
+		// from returns a stream of the chunks of an async or sync iterable, or
+		// of a Go io.Reader.
+		static from(source) {
+			const goSource = __readerSource(source);
+			if (goSource !== undefined) return new ReadableStream(goSource, { highWaterMark: 0 });
+
+			let iterator;
+			if (source != null && typeof source[Symbol.asyncIterator] === 'function') {
+				iterator = source[Symbol.asyncIterator]();
+			} else if (source != null && typeof source[Symbol.iterator] === 'function') {
+				iterator = source[Symbol.iterator]();
+			} else {
+				throw new TypeError('ReadableStream.from requires an iterable or a Go io.Reader');
+			}
+			return new ReadableStream({
+				pull(controller) {
+					return Promise.resolve(iterator.next()).then((r) => {
+						if (r.done) {
+							controller.close();
+							return;
+						}
+						return Promise.resolve(r.value).then((v) => controller.enqueue(v));
+					});
+				},
+				cancel(reason) {
+					if (typeof iterator.return === 'function') return Promise.resolve(iterator.return(reason)).then(() => undefined);
+				},
+			}, { highWaterMark: 0 });
+		}
+	}
+
+	const AsyncIteratorPrototype = {
+		[Symbol.asyncIterator]() {
+			return this;
+		},
+	};
+	ReadableStream.prototype[Symbol.asyncIterator] = ReadableStream.prototype.values;
+
+	class ReadableStreamDefaultController {
+		constructor() {
+			illegal();
+		}
+
+		get desiredSize() {
+			return readableDesiredSize(slots(this, ReadableStreamDefaultController));
+		}
+
+		close() {
+			const c = slots(this, ReadableStreamDefaultController);
+			if (!canCloseOrEnqueue(c)) throw new TypeError('the stream is not readable');
+			readableControllerClose(c);
+		}
+
+		enqueue(chunk) {
+			const c = slots(this, ReadableStreamDefaultController);
+			if (!canCloseOrEnqueue(c)) throw new TypeError('the stream is not readable');
+			readableControllerEnqueue(c, chunk);
+		}
+
+		error(e) {
+			readableControllerError(slots(this, ReadableStreamDefaultController), e);
+		}
+	}
+
+	function setUpReadableController(stream, source, hwm, size) {
+		const controller = Object.create(ReadableStreamDefaultController.prototype);
+		const c = {
+			controller, stream, source, hwm, size,
+			pull: method(source, 'pull'),
+			cancel: method(source, 'cancel'),
+			queue: [], queueTotal: 0,
+			started: false, closeRequested: false, pulling: false, pullAgain: false,
+		};
+		controller[S] = c;
+		stream[S].controller = c;
+
+		const start = method(source, 'start');
+		const started = start === undefined ? undefined : start.call(source, controller);
+		Promise.resolve(started).then(() => {
+			c.started = true;
+			readableCallPullIfNeeded(c);
+		}, (e) => readableControllerError(c, e));
+	}
+
+	function canCloseOrEnqueue(c) {
+		return !c.closeRequested && c.stream[S].state === 'readable';
+	}
+
+	function readableDesiredSize(c) {
+		const state = c.stream[S].state;
+		if (state === 'errored') return null;
+		if (state === 'closed') return 0;
+		return c.hwm - c.queueTotal;
+	}
+
+	function readableShouldCallPull(c) {
+		if (!canCloseOrEnqueue(c) || !c.started) return false;
+		const reader = c.stream[S].reader;
+		if (reader !== null && reader[S].readRequests.length > 0) return true;
+		return readableDesiredSize(c) > 0;
+	}
+
+	function readableCallPullIfNeeded(c) {
+		if (!readableShouldCallPull(c)) return;
+		if (c.pulling) {
+			c.pullAgain = true;
+			return;
+		}
+		c.pulling = true;
+		promiseCall(c.pull, c.source, [c.controller]).then(() => {
+			c.pulling = false;
+			if (c.pullAgain) {
+				c.pullAgain = false;
+				readableCallPullIfNeeded(c);
+			}
+		}, (e) => readableControllerError(c, e));
+	}
+
+	function clearReadableAlgorithms(c) {
+		c.pull = undefined;
+		c.cancel = undefined;
+		c.size = () => 1;
+	}
+
+	function readableControllerEnqueue(c, chunk) {
+		const reader = c.stream[S].reader;
+		if (reader !== null && reader[S].readRequests.length > 0) {
+			reader[S].readRequests.shift().chunk(chunk);
+		} else {
+			let size;
+			try {
+				size = c.size(chunk);
+				if (typeof size !== 'number' || !(size >= 0) || size === Infinity) {
+					throw new RangeError('chunk size must be a finite, non-negative number');
+				}
+			} catch (e) {
+				readableControllerError(c, e);
+				throw e;
+			}
+			c.queue.push({ value: chunk, size });
+			c.queueTotal += size;
+		}
+		readableCallPullIfNeeded(c);
+	}
+
+	function readableControllerClose(c) {
+		c.closeRequested = true;
+		if (c.queue.length === 0) {
+			clearReadableAlgorithms(c);
+			readableClose(c.stream);
+		}
+	}
+
+	function readableControllerError(c, e) {
+		if (c.stream[S].state !== 'readable') return;
+		c.queue = [];
+		c.queueTotal = 0;
+		clearReadableAlgorithms(c);
+		readableError(c.stream, e);
+	}
+
+	// readablePullSteps serves a read request from the queue, or parks it.
+	function readablePullSteps(c, request) {
+		if (c.queue.length > 0) {
+			const { value, size } = c.queue.shift();
+			c.queueTotal = c.queue.length === 0 ? 0 : c.queueTotal - size;
+			if (c.closeRequested && c.queue.length === 0) {
+				clearReadableAlgorithms(c);
+				readableClose(c.stream);
+			} else {
+				readableCallPullIfNeeded(c);
+			}
+			request.chunk(value);
+			return;
+		}
+		c.stream[S].reader[S].readRequests.push(request);
+		readableCallPullIfNeeded(c);
+	}
+
+	function readableClose(stream) {
+		const st = stream[S];
+		st.state = 'closed';
+		const reader = st.reader;
+		if (reader === null) return;
+		reader[S].closed.resolve();
+		const requests = reader[S].readRequests;
+		reader[S].readRequests = [];
+		for (const r of requests) r.close();
+	}
+
+	function readableError(stream, e) {
+		const st = stream[S];
+		st.state = 'errored';
+		st.storedError = e;
+		const reader = st.reader;
+		if (reader === null) return;
+		reader[S].closed.reject(e);
+		handled(reader[S].closed.promise);
+		const requests = reader[S].readRequests;
+		reader[S].readRequests = [];
+		for (const r of requests) r.error(e);
+	}
+
+	function readableCancel(stream, reason) {
+		const st = stream[S];
+		st.disturbed = true;
+		if (st.state === 'closed') return Promise.resolve();
+		if (st.state === 'errored') return Promise.reject(st.storedError);
+		readableClose(stream);
+		const c = st.controller;
+		c.queue = [];
+		c.queueTotal = 0;
+		const cancelled = promiseCall(c.cancel, c.source, [reason]);
+		clearReadableAlgorithms(c);
+		return cancelled.then(() => undefined);
+	}
+
+	class ReadableStreamDefaultReader {
+		constructor(stream) {
+			if (!(stream instanceof ReadableStream)) throw new TypeError('ReadableStreamDefaultReader requires a ReadableStream');
+			if (stream.locked) throw new TypeError('the stream is locked to another reader');
+			const st = stream[S];
+			this[S] = { stream, readRequests: [], closed: null };
+			st.reader = this;
+			if (st.state === 'readable') this[S].closed = deferred();
+			else if (st.state === 'closed') this[S].closed = settledDeferred(true);
+			else this[S].closed = settledDeferred(false, st.storedError);
+		}
+
+		get closed() {
+			return slots(this, ReadableStreamDefaultReader).closed.promise;
+		}
+
+		read() {
+			if (!(this instanceof ReadableStreamDefaultReader)) return Promise.reject(new TypeError('not a reader'));
+			const stream = this[S].stream;
+			if (stream === null) return Promise.reject(new TypeError('the reader has been released'));
+			const st = stream[S];
+			st.disturbed = true;
+			if (st.state === 'closed') return Promise.resolve({ value: undefined, done: true });
+			if (st.state === 'errored') return Promise.reject(st.storedError);
+			const d = deferred();
+			readablePullSteps(st.controller, {
+				chunk: (value) => d.resolve({ value, done: false }),
+				close: () => d.resolve({ value: undefined, done: true }),
+				error: (e) => d.reject(e),
+			});
+			return d.promise;
+		}
+
+		cancel(reason) {
+			if (!(this instanceof ReadableStreamDefaultReader)) return Promise.reject(new TypeError('not a reader'));
+			const stream = this[S].stream;
+			if (stream === null) return Promise.reject(new TypeError('the reader has been released'));
+			return readableCancel(stream, reason);
+		}
+
+		releaseLock() {
+			const r = slots(this, ReadableStreamDefaultReader);
+			const stream = r.stream;
+			if (stream === null) return;
+			const e = new TypeError('the reader has been released');
+			if (stream[S].state === 'readable' && !r.closed.settled) r.closed.reject(e);
+			else r.closed = settledDeferred(false, e);
+			handled(r.closed.promise);
+			const requests = r.readRequests;
+			r.readRequests = [];
+			for (const req of requests) req.error(e);
+			stream[S].reader = null;
+			r.stream = null;
+		}
+	}
+
+	function readableTee(stream) {
+		const reader = stream.getReader();
+		let reading = false;
+		let readAgain = false;
+		const canceled = [false, false];
+		const reasons = [undefined, undefined];
+		const controllers = [];
+		const cancelled = deferred();
+
+		function pull() {
+			if (reading) {
+				readAgain = true;
+				return Promise.resolve();
+			}
+			reading = true;
+			reader.read().then(({ value, done }) => {
+				reading = false;
+				for (let i = 0; i < 2; i++) {
+					if (canceled[i] || !canCloseOrEnqueue(controllers[i])) continue;
+					if (done) readableControllerClose(controllers[i]);
+					else readableControllerEnqueue(controllers[i], value);
+				}
+				if (done) {
+					if (!canceled[0] || !canceled[1]) cancelled.resolve();
+					return;
+				}
+				if (readAgain) {
+					readAgain = false;
+					pull();
+				}
+			}, () => {
+				reading = false;
+			});
+			return Promise.resolve();
+		}
+
+		const branch = (i) => new ReadableStream({
+			start(controller) {
+				controllers[i] = controller[S];
+			},
+			pull,
+			cancel(reason) {
+				canceled[i] = true;
+				reasons[i] = reason;
+				if (canceled[1 - i]) cancelled.resolve(readableCancel(stream, reasons));
+				return cancelled.promise;
+			},
+		});
+		const branches = [branch(0), branch(1)];
+		reader.closed.then(undefined, (e) => {
+			readableControllerError(controllers[0], e);
+			readableControllerError(controllers[1], e);
+			if (!canceled[0] || !canceled[1]) cancelled.resolve();
+		});
+		return branches;
+	}
+
+	function readablePipeTo(source, dest, options) {
+		options = options == null ? {} : options;
+		const preventClose = !!options.preventClose;
+		const preventAbort = !!options.preventAbort;
+		const preventCancel = !!options.preventCancel;
+		const signal = options.signal;
+
+		const reader = source.getReader();
+		const writer = dest.getWriter();
+		source[S].disturbed = true;
+
+		return new Promise((resolve, reject) => {
+			let shuttingDown = false;
+			let currentWrite = Promise.resolve();
+
+			const finalize = (isError, error) => {
+				writer.releaseLock();
+				reader.releaseLock();
+				if (signal !== undefined) signal.removeEventListener('abort', onAbort);
+				if (isError) reject(error);
+				else resolve();
+			};
+
+			// shutdown waits for pending writes while dest is writable, runs
+			// action, and settles the pipe.
+			const shutdown = (action, isError, error) => {
+				if (shuttingDown) return;
+				shuttingDown = true;
+				const wait = dest[S].state === 'writable' && !writableCloseQueuedOrInFlight(dest)
+					? currentWrite.then(() => {}, () => {})
+					: Promise.resolve();
+				wait.then(() => (action ? action() : undefined)).then(
+					() => finalize(isError, error),
+					(e) => finalize(true, e));
+			};
+
+			function onAbort() {
+				const error = abortError(signal);
+				const actions = [];
+				if (!preventAbort) actions.push(() => (dest[S].state === 'writable' ? writableAbort(dest, error) : Promise.resolve()));
+				if (!preventCancel) actions.push(() => (source[S].state === 'readable' ? readableCancel(source, error) : Promise.resolve()));
+				shutdown(() => Promise.all(actions.map((a) => a())), true, error);
+			}
+
+			if (signal !== undefined) {
+				if (signal.aborted) {
+					onAbort();
+					return;
+				}
+				signal.addEventListener('abort', onAbort);
+			}
+
+			// Errors and closing of either end
+			reader.closed.then(undefined, (e) => {
+				if (preventAbort) shutdown(null, true, e);
+				else shutdown(() => writableAbort(dest, e), true, e);
+			});
+			writer.closed.then(() => {
+				if (source[S].state === 'closed') return;
+				const e = new TypeError('the destination stream closed');
+				if (preventCancel) shutdown(null, true, e);
+				else shutdown(() => readableCancel(source, e), true, e);
+			}, (e) => {
+				if (preventCancel) shutdown(null, true, e);
+				else shutdown(() => readableCancel(source, e), true, e);
+			});
+
+			const pump = () => {
+				if (shuttingDown) return;
+				writer.ready.then(() => {
+					if (shuttingDown) return undefined;
+					return reader.read().then(({ value, done }) => {
+						if (done) {
+							if (preventClose) shutdown(null, false);
+							else shutdown(() => writableCloseWithErrorPropagation(dest), false);
+							return;
+						}
+						currentWrite = handled(writer.write(value));
+						pump();
+					});
+				}).then(undefined, () => {});
+			};
+			pump();
+		});
+	}
+
+	// WritableStream
+
+	class WritableStream {
+		constructor(sink, strategy) {
+			if (sink === undefined) sink = {};
+			if (sink === null || typeof sink !== 'object') throw new TypeError('sink must be an object');
+			if (sink.type !== undefined) throw new RangeError('invalid sink type');
+			const { hwm, size } = extractStrategy(strategy, 1);
+			this[S] = {
+				state: 'writable', storedError: undefined, writer: null, controller: null,
+				writeRequests: [], inFlightWrite: null, closeRequest: null, inFlightClose: null,
+				pendingAbort: null, backpressure: false,
+			};
+			setUpWritableController(this, sink, hwm, size);
+		}
+
+		get locked() {
+			return slots(this, WritableStream).writer !== null;
+		}
+
+		abort(reason) {
+			if (!(this instanceof WritableStream)) return Promise.reject(new TypeError('not a WritableStream'));
+			if (this.locked) return Promise.reject(new TypeError('cannot abort a locked stream'));
+			return writableAbort(this, reason);
+		}
+
+		close() {
+			if (!(this instanceof WritableStream)) return Promise.reject(new TypeError('not a WritableStream'));
+			if (this.locked) return Promise.reject(new TypeError('cannot close a locked stream'));
+			if (writableCloseQueuedOrInFlight(this)) return Promise.reject(new TypeError('the stream is already closing'));
+			return writableClose(this);
+		}
+
+		getWriter() {
+			slots(this, WritableStream);
+			return new WritableStreamDefaultWriter(this);
+		}
+	}
+
+	function writableAbort(stream, reason) {
+		const st = stream[S];
+		if (st.state === 'closed' || st.state === 'errored') return Promise.resolve();
+		st.controller.abortSignal(reason);
+		if (st.state === 'closed' || st.state === 'errored') return Promise.resolve();
+		if (st.pendingAbort !== null) return st.pendingAbort.d.promise;
+		const wasAlreadyErroring = st.state === 'erroring';
+		const d = deferred();
+		st.pendingAbort = { d, reason: wasAlreadyErroring ? undefined : reason, wasAlreadyErroring };
+		if (!wasAlreadyErroring) writableStartErroring(stream, reason);
+		return d.promise;
+	}
+
+	function writableClose(stream) {
+		const st = stream[S];
+		if (st.state === 'closed' || st.state === 'errored') {
+			return Promise.reject(new TypeError('the stream is closed or errored'));
+		}
+		const d = deferred();
+		st.closeRequest = d;
+		if (st.writer !== null && st.backpressure && st.state === 'writable') st.writer[S].ready.resolve();
+		const c = st.controller;
+		c.queue.push({ value: CLOSE, size: 0 });
+		writableAdvanceQueueIfNeeded(c);
+		return d.promise;
+	}
+
+	function writableCloseWithErrorPropagation(stream) {
+		const st = stream[S];
+		if (writableCloseQueuedOrInFlight(stream) || st.state === 'closed') return Promise.resolve();
+		if (st.state === 'errored') return Promise.reject(st.storedError);
+		return writableClose(stream);
+	}
+
+	function writableCloseQueuedOrInFlight(stream) {
+		const st = stream[S];
+		return st.closeRequest !== null || st.inFlightClose !== null;
+	}
+
+	function writableStartErroring(stream, reason) {
+		const st = stream[S];
+		st.state = 'erroring';
+		st.storedError = reason;
+		if (st.writer !== null) writerEnsureReadyRejected(st.writer, reason);
+		if (st.inFlightWrite === null && st.inFlightClose === null && st.controller.started) {
+			writableFinishErroring(stream);
+		}
+	}
+
+	function writableFinishErroring(stream) {
+		const st = stream[S];
+		st.state = 'errored';
+		const c = st.controller;
+		c.queue = [];
+		c.queueTotal = 0;
+		for (const d of st.writeRequests) d.reject(st.storedError);
+		st.writeRequests = [];
+		if (st.pendingAbort === null) {
+			writableRejectCloseAndClosed(stream);
+			return;
+		}
+		const abort = st.pendingAbort;
+		st.pendingAbort = null;
+		if (abort.wasAlreadyErroring) {
+			abort.d.reject(st.storedError);
+			writableRejectCloseAndClosed(stream);
+			return;
+		}
+		const aborted = promiseCall(c.abort, c.sink, [abort.reason]);
+		clearWritableAlgorithms(c);
+		aborted.then(() => {
+			abort.d.resolve();
+			writableRejectCloseAndClosed(stream);
+		}, (e) => {
+			abort.d.reject(e);
+			writableRejectCloseAndClosed(stream);
+		});
+	}
+
+	function writableDealWithRejection(stream, e) {
+		if (stream[S].state === 'writable') writableStartErroring(stream, e);
+		else writableFinishErroring(stream);
+	}
+
+	function writableRejectCloseAndClosed(stream) {
+		const st = stream[S];
+		if (st.closeRequest !== null) {
+			st.closeRequest.reject(st.storedError);
+			st.closeRequest = null;
+		}
+		if (st.writer !== null) {
+			st.writer[S].closed.reject(st.storedError);
+			handled(st.writer[S].closed.promise);
+		}
+	}
+
+	function writableUpdateBackpressure(stream, backpressure) {
+		const st = stream[S];
+		if (st.writer !== null && backpressure !== st.backpressure) {
+			if (backpressure) st.writer[S].ready = deferred();
+			else st.writer[S].ready.resolve();
+		}
+		st.backpressure = backpressure;
+	}
+
+	class WritableStreamDefaultController {
+		constructor() {
+			illegal();
+		}
+
+		get signal() {
+			return slots(this, WritableStreamDefaultController).signal;
+		}
+
+		error(e) {
+			const c = slots(this, WritableStreamDefaultController);
+			if (c.stream[S].state !== 'writable') return;
+			writableControllerError(c, e);
+		}
+	}
+
+	function setUpWritableController(stream, sink, hwm, size) {
+		const controller = Object.create(WritableStreamDefaultController.prototype);
+		const { signal, abort } = newSignal();
+		const c = {
+			controller, stream, sink, hwm, size, signal,
+			abortSignal: abort,
+			write: method(sink, 'write'),
+			close: method(sink, 'close'),
+			abort: method(sink, 'abort'),
+			queue: [], queueTotal: 0, started: false,
+		};
+		controller[S] = c;
+		stream[S].controller = c;
+		writableUpdateBackpressure(stream, c.hwm - c.queueTotal <= 0);
+
+		const start = method(sink, 'start');
+		const started = start === undefined ? undefined : start.call(sink, controller);
+		Promise.resolve(started).then(() => {
+			c.started = true;
+			writableAdvanceQueueIfNeeded(c);
+		}, (e) => {
+			c.started = true;
+			writableDealWithRejection(stream, e);
+		});
+	}
+
+	function clearWritableAlgorithms(c) {
+		c.write = undefined;
+		c.close = undefined;
+		c.abort = undefined;
+		c.size = () => 1;
+	}
+
+	function writableDesiredSize(c) {
+		return c.hwm - c.queueTotal;
+	}
+
+	function writableControllerError(c, e) {
+		clearWritableAlgorithms(c);
+		writableStartErroring(c.stream, e);
+	}
+
+	function writableControllerErrorIfNeeded(c, e) {
+		if (c.stream[S].state === 'writable') writableControllerError(c, e);
+	}
+
+	function writableChunkSize(c, chunk) {
+		try {
+			return c.size(chunk);
+		} catch (e) {
+			writableControllerErrorIfNeeded(c, e);
+			return 1;
+		}
+	}
+
+	function writableControllerWrite(c, chunk, size) {
+		if (typeof size !== 'number' || !(size >= 0) || size === Infinity) {
+			writableControllerErrorIfNeeded(c, new RangeError('chunk size must be a finite, non-negative number'));
+			return;
+		}
+		c.queue.push({ value: chunk, size });
+		c.queueTotal += size;
+		const stream = c.stream;
+		if (!writableCloseQueuedOrInFlight(stream) && stream[S].state === 'writable') {
+			writableUpdateBackpressure(stream, writableDesiredSize(c) <= 0);
+		}
+		writableAdvanceQueueIfNeeded(c);
+	}
+
+	function writableAdvanceQueueIfNeeded(c) {
+		const stream = c.stream;
+		const st = stream[S];
+		if (!c.started || st.inFlightWrite !== null) return;
+		if (st.state === 'erroring') {
+			writableFinishErroring(stream);
+			return;
+		}
+		if (c.queue.length === 0) return;
+		if (c.queue[0].value === CLOSE) writableProcessClose(c);
+		else writableProcessWrite(c, c.queue[0].value);
+	}
+
+	function writableProcessClose(c) {
+		const stream = c.stream;
+		const st = stream[S];
+		st.inFlightClose = st.closeRequest;
+		st.closeRequest = null;
+		c.queue.shift();
+		c.queueTotal = 0;
+		const closed = promiseCall(c.close, c.sink, []);
+		clearWritableAlgorithms(c);
+		closed.then(() => {
+			st.inFlightClose.resolve();
+			st.inFlightClose = null;
+			if (st.state === 'erroring') {
+				st.storedError = undefined;
+				if (st.pendingAbort !== null) {
+					st.pendingAbort.d.resolve();
+					st.pendingAbort = null;
+				}
+			}
+			st.state = 'closed';
+			if (st.writer !== null) st.writer[S].closed.resolve();
+		}, (e) => {
+			st.inFlightClose.reject(e);
+			st.inFlightClose = null;
+			if (st.pendingAbort !== null) {
+				st.pendingAbort.d.reject(e);
+				st.pendingAbort = null;
+			}
+			writableDealWithRejection(stream, e);
+		});
+	}
+
+	function writableProcessWrite(c, chunk) {
+		const stream = c.stream;
+		const st = stream[S];
+		st.inFlightWrite = st.writeRequests.shift();
+		promiseCall(c.write, c.sink, [chunk, c.controller]).then(() => {
+			st.inFlightWrite.resolve();
+			st.inFlightWrite = null;
+			const { size } = c.queue.shift();
+			c.queueTotal = c.queue.length === 0 ? 0 : c.queueTotal - size;
+			if (!writableCloseQueuedOrInFlight(stream) && st.state === 'writable') {
+				writableUpdateBackpressure(stream, writableDesiredSize(c) <= 0);
+			}
+			writableAdvanceQueueIfNeeded(c);
+		}, (e) => {
+			if (st.state === 'writable') clearWritableAlgorithms(c);
+			st.inFlightWrite.reject(e);
+			st.inFlightWrite = null;
+			writableDealWithRejection(stream, e);
+		});
+	}
+
+	class WritableStreamDefaultWriter {
+		constructor(stream) {
+			if (!(stream instanceof WritableStream)) throw new TypeError('WritableStreamDefaultWriter requires a WritableStream');
+			if (stream.locked) throw new TypeError('the stream is locked to another writer');
+			const st = stream[S];
+			const w = { stream, ready: null, closed: null };
+			this[S] = w;
+			st.writer = this;
+			switch (st.state) {
+			case 'writable':
+				w.ready = !writableCloseQueuedOrInFlight(stream) && st.backpressure ? deferred() : settledDeferred(true);
+				w.closed = deferred();
+				break;
+			case 'erroring':
+				w.ready = settledDeferred(false, st.storedError);
+				w.closed = deferred();
+				break;
+			case 'closed':
+				w.ready = settledDeferred(true);
+				w.closed = settledDeferred(true);
+				break;
+			default:
+				w.ready = settledDeferred(false, st.storedError);
+				w.closed = settledDeferred(false, st.storedError);
+			}
+		}
+
+		get closed() {
+			return slots(this, WritableStreamDefaultWriter).closed.promise;
+		}
+
+		get ready() {
+			return slots(this, WritableStreamDefaultWriter).ready.promise;
+		}
+
+		get desiredSize() {
+			const stream = slots(this, WritableStreamDefaultWriter).stream;
+			if (stream === null) throw new TypeError('the writer has been released');
+			const state = stream[S].state;
+			if (state === 'errored' || state === 'erroring') return null;
+			if (state === 'closed') return 0;
+			return writableDesiredSize(stream[S].controller);
+		}
+
+		abort(reason) {
+			if (!(this instanceof WritableStreamDefaultWriter)) return Promise.reject(new TypeError('not a writer'));
+			const stream = this[S].stream;
+			if (stream === null) return Promise.reject(new TypeError('the writer has been released'));
+			return writableAbort(stream, reason);
+		}
+
+		close() {
+			if (!(this instanceof WritableStreamDefaultWriter)) return Promise.reject(new TypeError('not a writer'));
+			const stream = this[S].stream;
+			if (stream === null) return Promise.reject(new TypeError('the writer has been released'));
+			if (writableCloseQueuedOrInFlight(stream)) return Promise.reject(new TypeError('the stream is already closing'));
+			return writableClose(stream);
+		}
+
+		releaseLock() {
+			const w = slots(this, WritableStreamDefaultWriter);
+			const stream = w.stream;
+			if (stream === null) return;
+			const e = new TypeError('the writer has been released');
+			writerEnsureReadyRejected(this, e);
+			if (!w.closed.settled) w.closed.reject(e);
+			else w.closed = settledDeferred(false, e);
+			handled(w.closed.promise);
+			stream[S].writer = null;
+			w.stream = null;
+		}
+
+		write(chunk) {
+			if (!(this instanceof WritableStreamDefaultWriter)) return Promise.reject(new TypeError('not a writer'));
+			const stream = this[S].stream;
+			if (stream === null) return Promise.reject(new TypeError('the writer has been released'));
+			const st = stream[S];
+			const c = st.controller;
+			const size = writableChunkSize(c, chunk);
+			if (st.state === 'errored' || st.state === 'erroring') return Promise.reject(st.storedError);
+			if (writableCloseQueuedOrInFlight(stream) || st.state === 'closed') {
+				return Promise.reject(new TypeError('the stream is closing or closed'));
+			}
+			const d = deferred();
+			st.writeRequests.push(d);
+			writableControllerWrite(c, chunk, size);
+			return d.promise;
+		}
+	}
+
+	function writerEnsureReadyRejected(writer, e) {
+		const w = writer[S];
+		if (!w.ready.settled) w.ready.reject(e);
+		else w.ready = settledDeferred(false, e);
+		handled(w.ready.promise);
+	}
+
+	// TransformStream
+
+	class TransformStream {
+		constructor(transformer, writableStrategy, readableStrategy) {
+			if (transformer === undefined) transformer = {};
+			if (transformer === null || typeof transformer !== 'object') throw new TypeError('transformer must be an object');
+			if (transformer.readableType !== undefined || transformer.writableType !== undefined) {
+				throw new RangeError('transformer types are not supported');
+			}
+			const ws = extractStrategy(writableStrategy, 1);
+			const rs = extractStrategy(readableStrategy, 0);
+			const t = { backpressure: undefined, backpressureChange: null, finish: null, readable: null, writable: null, readableController: null };
+			this[S] = t;
+			const started = deferred();
+
+			t.writable = new WritableStream({
+				start: () => started.promise,
+				write: (chunk) => transformSinkWrite(t, chunk),
+				abort: (reason) => transformSinkAbort(t, reason),
+				close: () => transformSinkClose(t),
+			}, { highWaterMark: ws.hwm, size: ws.size });
+			t.readable = new ReadableStream({
+				start: (controller) => {
+					t.readableController = controller[S];
+					return started.promise;
+				},
+				pull: () => transformSourcePull(t),
+				cancel: (reason) => transformSourceCancel(t, reason),
+			}, { highWaterMark: rs.hwm, size: rs.size });
+			transformSetBackpressure(t, true);
+
+			const controller = Object.create(TransformStreamDefaultController.prototype);
+			t.controller = controller;
+			controller[S] = t;
+			const transform = method(transformer, 'transform');
+			t.transform = transform === undefined
+				? (chunk) => { transformEnqueue(t, chunk); return Promise.resolve(); }
+				: (chunk) => promiseCall(transform, transformer, [chunk, controller]);
+			t.flush = (() => { const fn = method(transformer, 'flush'); return () => promiseCall(fn, transformer, [controller]); })();
+			t.cancel = (() => { const fn = method(transformer, 'cancel'); return (reason) => promiseCall(fn, transformer, [reason]); })();
+
+			const start = method(transformer, 'start');
+			started.resolve(start === undefined ? undefined : start.call(transformer, controller));
+		}
+
+		get readable() {
+			return slots(this, TransformStream).readable;
+		}
+
+		get writable() {
+			return slots(this, TransformStream).writable;
+		}
+	}
+
+	class TransformStreamDefaultController {
+		constructor() {
+			illegal();
+		}
+
+		get desiredSize() {
+			return readableDesiredSize(slots(this, TransformStreamDefaultController).readableController);
+		}
+
+		enqueue(chunk) {
+			transformEnqueue(slots(this, TransformStreamDefaultController), chunk);
+		}
+
+		error(e) {
+			transformError(slots(this, TransformStreamDefaultController), e);
+		}
+
+		terminate() {
+			const t = slots(this, TransformStreamDefaultController);
+			if (canCloseOrEnqueue(t.readableController)) readableControllerClose(t.readableController);
+			transformErrorWritableAndUnblockWrite(t, new TypeError('the transform stream was terminated'));
+		}
+	}
+
+	function clearTransformAlgorithms(t) {
+		t.transform = undefined;
+		t.flush = undefined;
+		t.cancel = undefined;
+	}
+
+	function transformEnqueue(t, chunk) {
+		const rc = t.readableController;
+		if (!canCloseOrEnqueue(rc)) throw new TypeError('the readable side is not accepting chunks');
+		try {
+			readableControllerEnqueue(rc, chunk);
+		} catch (e) {
+			transformErrorWritableAndUnblockWrite(t, e);
+			throw t.readable[S].storedError;
+		}
+		if (!readableShouldCallPull(rc) && t.backpressure !== true) transformSetBackpressure(t, true);
+	}
+
+	function transformError(t, e) {
+		readableControllerError(t.readableController, e);
+		transformErrorWritableAndUnblockWrite(t, e);
+	}
+
+	function transformErrorWritableAndUnblockWrite(t, e) {
+		clearTransformAlgorithms(t);
+		writableControllerErrorIfNeeded(t.writable[S].controller, e);
+		if (t.backpressure) transformSetBackpressure(t, false);
+	}
+
+	function transformSetBackpressure(t, backpressure) {
+		if (t.backpressureChange !== null) t.backpressureChange.resolve();
+		t.backpressureChange = deferred();
+		t.backpressure = backpressure;
+	}
+
+	function transformPerform(t, chunk) {
+		if (t.transform === undefined) return Promise.resolve();
+		return t.transform(chunk).then(undefined, (e) => {
+			transformError(t, e);
+			throw e;
+		});
+	}
+
+	function transformSinkWrite(t, chunk) {
+		if (t.backpressure) {
+			return t.backpressureChange.promise.then(() => {
+				const ws = t.writable[S];
+				if (ws.state === 'erroring') throw ws.storedError;
+				return transformPerform(t, chunk);
+			});
+		}
+		return transformPerform(t, chunk);
+	}
+
+	// transformFinish settles the shared promise of flush and cancel.
+	function transformFinish(t, run) {
+		if (t.finish !== null) return t.finish.promise;
+		const d = deferred();
+		t.finish = d;
+		run(d);
+		return d.promise;
+	}
+
+	function transformSinkAbort(t, reason) {
+		return transformFinish(t, (d) => {
+			const cancelled = t.cancel === undefined ? Promise.resolve() : t.cancel(reason);
+			clearTransformAlgorithms(t);
+			cancelled.then(() => {
+				const rs = t.readable[S];
+				if (rs.state === 'errored') {
+					d.reject(rs.storedError);
+					return;
+				}
+				readableControllerError(t.readableController, reason);
+				d.resolve();
+			}, (e) => {
+				readableControllerError(t.readableController, e);
+				d.reject(e);
+			});
+		});
+	}
+
+	function transformSinkClose(t) {
+		return transformFinish(t, (d) => {
+			const flushed = t.flush === undefined ? Promise.resolve() : t.flush();
+			clearTransformAlgorithms(t);
+			flushed.then(() => {
+				const rs = t.readable[S];
+				if (rs.state === 'errored') {
+					d.reject(rs.storedError);
+					return;
+				}
+				if (canCloseOrEnqueue(t.readableController)) readableControllerClose(t.readableController);
+				d.resolve();
+			}, (e) => {
+				readableControllerError(t.readableController, e);
+				d.reject(e);
+			});
+		});
+	}
+
+	function transformSourcePull(t) {
+		transformSetBackpressure(t, false);
+		return t.backpressureChange.promise;
+	}
+
+	function transformSourceCancel(t, reason) {
+		return transformFinish(t, (d) => {
+			const cancelled = t.cancel === undefined ? Promise.resolve() : t.cancel(reason);
+			clearTransformAlgorithms(t);
+			const unblock = (e) => {
+				writableControllerErrorIfNeeded(t.writable[S].controller, e);
+				if (t.backpressure) transformSetBackpressure(t, false);
+			};
+			cancelled.then(() => {
+				const ws = t.writable[S];
+				if (ws.state === 'errored') {
+					d.reject(ws.storedError);
+					return;
+				}
+				unblock(reason);
+				d.resolve();
+			}, (e) => {
+				unblock(e);
+				d.reject(e);
+			});
+		});
+	}
+
+	// Queuing strategies
+
+	class CountQueuingStrategy {
+		constructor(init) {
+			if (init == null || init.highWaterMark === undefined) throw new TypeError('highWaterMark is required');
+			this.highWaterMark = Number(init.highWaterMark);
+		}
+
+		size() {
+			return 1;
+		}
+	}
+
+	class ByteLengthQueuingStrategy {
+		constructor(init) {
+			if (init == null || init.highWaterMark === undefined) throw new TypeError('highWaterMark is required');
+			this.highWaterMark = Number(init.highWaterMark);
+		}
+
+		size(chunk) {
+			return chunk.byteLength;
+		}
+	}
+
+	// Text and compression streams
+
+	class TextDecoderStream {
+		constructor(label, options) {
+			const decoder = new TextDecoder(label, options);
+			const transform = new TransformStream({
+				transform(chunk, controller) {
+					const text = decoder.decode(chunk, { stream: true });
+					if (text.length > 0) controller.enqueue(text);
+				},
+				flush(controller) {
+					const text = decoder.decode();
+					if (text.length > 0) controller.enqueue(text);
+				},
+			});
+			this.encoding = decoder.encoding;
+			this.fatal = decoder.fatal;
+			this.ignoreBOM = decoder.ignoreBOM;
+			this.readable = transform.readable;
+			this.writable = transform.writable;
+		}
+	}
+
+	class TextEncoderStream {
+		constructor() {
+			const encoder = new TextEncoder();
+			let pending = '';
+			const transform = new TransformStream({
+				transform(chunk, controller) {
+					let text = pending + String(chunk);
+					pending = '';
+					const last = text.charCodeAt(text.length - 1);
+					if (last >= 0xd800 && last <= 0xdbff) {
+						// A surrogate pair split across chunks
+						pending = text.slice(-1);
+						text = text.slice(0, -1);
+					}
+					if (text.length > 0) controller.enqueue(encoder.encode(text));
+				},
+				flush(controller) {
+					if (pending.length > 0) controller.enqueue(encoder.encode(pending));
+				},
+			});
+			this.encoding = 'utf-8';
+			this.readable = transform.readable;
+			this.writable = transform.writable;
+		}
+	}
+
+	// Compression runs in Go: "gzip", "deflate" (zlib) and "deflate-raw".
+	class CompressionStream {
+		constructor(format) {
+			const transform = new TransformStream(__codec(String(format), true));
+			this.readable = transform.readable;
+			this.writable = transform.writable;
+		}
+	}
+
+	class DecompressionStream {
+		constructor(format) {
+			const transform = new TransformStream(__codec(String(format), false));
+			this.readable = transform.readable;
+			this.writable = transform.writable;
+		}
+	}
+
+	// Converters from Go
+
+	// toReadableStream streams a Go io.Reader in chunks of up to chunkSize
+	// bytes. With the default highWaterMark of 0 it reads only on demand.
+	function toReadableStream(reader, options) {
+		options = options == null ? {} : options;
+		const source = __readerSource(reader, options.chunkSize === undefined ? 0 : Number(options.chunkSize));
+		if (source === undefined) throw new TypeError('toReadableStream requires a Go io.Reader');
+		return new ReadableStream(source, { highWaterMark: options.highWaterMark === undefined ? 0 : options.highWaterMark });
+	}
+
+	// toWritableStream writes chunks, bytes or strings, to a Go io.Writer.
+	function toWritableStream(writer, options) {
+		options = options == null ? {} : options;
+		const sink = __writerSink(writer);
+		if (sink === undefined) throw new TypeError('toWritableStream requires a Go io.Writer');
+		return new WritableStream(sink, { highWaterMark: options.highWaterMark === undefined ? 1 : options.highWaterMark });
+	}
+
+	const globals = {
+		ReadableStream, ReadableStreamDefaultReader, ReadableStreamDefaultController,
+		WritableStream, WritableStreamDefaultWriter, WritableStreamDefaultController,
+		TransformStream, TransformStreamDefaultController,
+		CountQueuingStrategy, ByteLengthQueuingStrategy,
+		TextDecoderStream, TextEncoderStream, CompressionStream, DecompressionStream,
+		toReadableStream, toWritableStream,
+	};
+	for (const name of Object.keys(globals)) {
+		Object.defineProperty(globalThis, name, { value: globals[name], writable: true, configurable: true, enumerable: false });
+	}
+})();
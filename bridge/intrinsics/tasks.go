@@ -0,0 +1,229 @@
+package intrinsics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
+)
+
+// Task is a goroutine started by go(). Its handle settles with the function's
+// result, awaiting a returned promise, or rejects with the exception or
+// panic that ended it.
+//
+// Tasks do not keep the event loop alive on their own, matching Go where
+// main does not wait for goroutines; awaiting the handle does.
+type Task struct {
+	r    *Registry
+	fn   sobek.Callable
+	args []sobek.Value
+	obj  *sobek.Object
+
+	promise         *sobek.Promise
+	resolve, reject func(interface{}) error
+
+	mu       sync.Mutex
+	settled  bool
+	observed bool
+	holding  bool // loop kept alive for an observer
+	owned    bool // failure reported by an owner, e.g. a group
+}
+
+// TaskError is returned by Run for a failed task. Reason is the thrown value
+// (nil for Go panics), so groups can reject with the original exception.
+type TaskError struct {
+	Err      error
+	Reason   sobek.Value
+	Panicked bool
+}
+
+func (e *TaskError) Error() string { return e.Err.Error() }
+func (e *TaskError) Unwrap() error { return e.Err }
+
+type taskOutcome struct {
+	val sobek.Value
+	err *TaskError
+}
+
+// NewTask prepares fn(...args) to run on a goroutine. It must be called on
+// the JS thread; start it with Start or call Run from a goroutine.
+func (r *Registry) NewTask(fn sobek.Callable, args []sobek.Value) *Task {
+	p, resolve, reject := r.vm.NewPromise()
+	return &Task{
+		r:       r,
+		fn:      fn,
+		args:    args,
+		promise: p,
+		resolve: resolve,
+		reject:  reject,
+	}
+}
+
+// Own marks the task's failure as reported by its owner (e.g. an errgroup's
+// wait), so it is not also reported as unhandled.
+func (t *Task) Own() {
+	t.mu.Lock()
+	t.owned = true
+	t.mu.Unlock()
+}
+
+// Start runs the task on a new goroutine.
+func (t *Task) Start() {
+	go func() { _ = t.Run() }()
+}
+
+// Run executes the task on the calling goroutine and blocks until it finishes,
+// including settlement of a returned promise. The JS handle is settled on the
+// event loop.
+func (t *Task) Run() error {
+	out, pending := t.invoke()
+	if pending != nil {
+		select {
+		case out = <-pending:
+		case <-t.r.el.Context().Done():
+			out = taskOutcome{err: &TaskError{Err: t.r.el.Context().Err()}}
+		}
+	}
+
+	t.r.el.RunOnLoop(func() { t.settle(out) })
+	if out.err != nil {
+		return out.err
+	}
+	return nil
+}
+
+// invoke calls the function holding VMLock, like every goroutine running JS.
+func (t *Task) invoke() (out taskOutcome, pending chan taskOutcome) {
+	t.r.VMLock.Lock()
+	defer t.r.VMLock.Unlock()
+	defer func() {
+		if p := recover(); p != nil {
+			out, pending = taskOutcome{err: &TaskError{Err: fmt.Errorf("panic: %v", p), Panicked: true}}, nil
+		}
+	}()
+
+	val, err := t.fn(sobek.Undefined(), t.args...)
+	if err != nil {
+		return taskOutcome{err: thrown(err)}, nil
+	}
+
+	p, ok := val.Export().(*sobek.Promise)
+	if !ok {
+		return taskOutcome{val: val}, nil
+	}
+	switch p.State() {
+	case sobek.PromiseStateFulfilled:
+		return taskOutcome{val: p.Result()}, nil
+	case sobek.PromiseStateRejected:
+		return taskOutcome{err: rejected(p.Result())}, nil
+	}
+
+	pending = make(chan taskOutcome, 1)
+	obj := val.ToObject(t.r.vm)
+	then, _ := sobek.AssertFunction(obj.Get("then"))
+	onFulfilled := t.r.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		pending <- taskOutcome{val: call.Argument(0)}
+		return sobek.Undefined()
+	})
+	onRejected := t.r.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		pending <- taskOutcome{err: rejected(call.Argument(0))}
+		return sobek.Undefined()
+	})
+	if _, err := then(obj, onFulfilled, onRejected); err != nil {
+		return taskOutcome{err: thrown(err)}, nil
+	}
+	return taskOutcome{}, pending
+}
+
+func thrown(err error) *TaskError {
+	if ex, ok := err.(*sobek.Exception); ok {
+		return rejected(ex.Value())
+	}
+	return &TaskError{Err: err}
+}
+
+func rejected(reason sobek.Value) *TaskError {
+	return &TaskError{Err: core.ValueError(reason), Reason: reason}
+}
+
+// reason returns the JS value a failed task rejects with. It must be called
+// on the JS thread.
+func (e *TaskError) reason(vm *sobek.Runtime) sobek.Value {
+	if e.Reason != nil {
+		return e.Reason
+	}
+	return core.NewGoError(vm, e.Err)
+}
+
+func (t *Task) settle(out taskOutcome) {
+	t.mu.Lock()
+	t.settled = true
+	observed, holding := t.observed || t.owned, t.holding
+	t.mu.Unlock()
+
+	if holding {
+		defer t.r.el.WGDone()
+	}
+	if out.err == nil {
+		_ = t.resolve(out.val)
+		return
+	}
+	_ = t.reject(out.err.reason(t.r.vm))
+
+	// Nobody is waiting on the handle; do not drop the failure silently.
+	if !observed && t.r.el.OnUnhandledRejection != nil {
+		t.r.el.OnUnhandledRejection(out.err)
+	}
+}
+
+// observe marks the handle as awaited, keeping the loop alive until it settles.
+func (t *Task) observe() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.observed {
+		return
+	}
+	t.observed = true
+	if !t.settled {
+		t.holding = true
+		t.r.el.WGAdd(1)
+	}
+}
+
+// Object returns the JS handle: a thenable with wait() and a done flag.
+func (t *Task) Object() *sobek.Object {
+	if t.obj != nil {
+		return t.obj
+	}
+
+	vm := t.r.vm
+	promise := vm.ToValue(t.promise).ToObject(vm)
+	delegate := func(method string) func(sobek.FunctionCall) sobek.Value {
+		return func(call sobek.FunctionCall) sobek.Value {
+			t.observe()
+			fn, _ := sobek.AssertFunction(promise.Get(method))
+			res, err := fn(promise, call.Arguments...)
+			if err != nil {
+				panic(err)
+			}
+			return res
+		}
+	}
+
+	obj := vm.NewObject()
+	_ = obj.Set("wait", func(sobek.FunctionCall) sobek.Value {
+		t.observe()
+		return promise
+	})
+	_ = obj.Set("then", delegate("then"))
+	_ = obj.Set("catch", delegate("catch"))
+	_ = obj.DefineAccessorProperty("done", vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return vm.ToValue(t.settled)
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+
+	t.obj = obj
+	return obj
+}
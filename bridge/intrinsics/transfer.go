@@ -0,0 +1,481 @@
+package intrinsics
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/eventloop"
+)
+
+// Transferable values cross between isolated runtimes as plain Go data,
+// following structured clone: primitives, arrays, plain objects, Maps, Sets,
+// Dates, Errors, ArrayBuffers and typed arrays are copied, keeping shared
+// references and cycles; functions and symbols are rejected. Prototypes are
+// not preserved. ArrayBuffers in a transfer list are moved instead of copied,
+// and Shareable values, such as SharedArrayBuffers, cross by reference.
+
+type (
+	transferUndefined struct{}
+
+	transferArray struct{ items []interface{} }
+
+	// transferObject keeps key order, which Go maps would lose.
+	transferObject struct {
+		keys   []string
+		values []interface{}
+	}
+
+	transferMap struct{ keys, values []interface{} }
+	transferSet struct{ items []interface{} }
+
+	transferBuffer struct{ data []byte }
+
+	// transferView is a typed array or DataView over a transferred buffer,
+	// or over a SharedArrayBuffer.
+	transferView struct {
+		kind   string      // Constructor name, e.g. "Float64Array"
+		buf    interface{} // *transferBuffer or *transferShared
+		offset int
+		length int // Elements for typed arrays, bytes for DataView
+	}
+
+	transferShared struct{ s Shareable }
+)
+
+// Shareable is implemented by Go values that cross runtimes by reference
+// rather than by copy, such as typego:memory segments. Share returns the
+// value's JS form in the runtime of el, on its JS thread.
+type Shareable interface {
+	Share(el *eventloop.EventLoop) sobek.Value
+}
+
+var sharedSymbol = sobek.NewSymbol("typego.shared")
+
+// MarkShared makes obj transfer as s.
+func MarkShared(vm *sobek.Runtime, obj *sobek.Object, s Shareable) {
+	_ = obj.DefineDataPropertySymbol(sharedSymbol, vm.ToValue(s), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+}
+
+// DataCloneError reports a value that cannot be transferred.
+type DataCloneError struct {
+	msg string
+}
+
+func (e *DataCloneError) Error() string { return e.msg }
+
+func dataCloneError(format string, args ...interface{}) error {
+	return &DataCloneError{msg: fmt.Sprintf(format, args...)}
+}
+
+type exporter struct {
+	vm       *sobek.Runtime
+	seen     map[*sobek.Object]interface{}
+	transfer map[*sobek.Object]bool
+}
+
+// ExportTransferable copies v out of vm so it can be imported into another
+// runtime, moving the ArrayBuffers listed in transfer. Moved buffers are
+// detached only once the whole value exported. It must be called on vm's JS
+// thread.
+func ExportTransferable(vm *sobek.Runtime, v sobek.Value, transfer []sobek.Value) (interface{}, error) {
+	ex := &exporter{vm: vm, seen: map[*sobek.Object]interface{}{}, transfer: map[*sobek.Object]bool{}}
+	var moved []sobek.ArrayBuffer
+	for _, t := range transfer {
+		obj, ok := t.(*sobek.Object)
+		if !ok {
+			return nil, dataCloneError("only ArrayBuffers can be transferred")
+		}
+		buf, ok := obj.Export().(sobek.ArrayBuffer)
+		switch {
+		case !ok:
+			return nil, dataCloneError("only ArrayBuffers can be transferred")
+		case isShared(obj):
+			return nil, dataCloneError("SharedArrayBuffers cannot be transferred")
+		case ex.transfer[obj]:
+			return nil, dataCloneError("ArrayBuffer listed twice in the transfer list")
+		case buf.Detached():
+			return nil, dataCloneError("ArrayBuffer is detached")
+		}
+		ex.transfer[obj] = true
+		moved = append(moved, buf)
+	}
+
+	out, err := ex.export(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, buf := range moved {
+		buf.Detach()
+	}
+	return out, nil
+}
+
+func (ex *exporter) export(v sobek.Value) (interface{}, error) {
+	switch {
+	case v == nil || sobek.IsUndefined(v):
+		return transferUndefined{}, nil
+	case sobek.IsNull(v):
+		return nil, nil
+	}
+
+	obj, isObj := v.(*sobek.Object)
+	if !isObj {
+		switch x := v.Export().(type) {
+		case bool, string, int64, float64:
+			return x, nil
+		case *big.Int:
+			return new(big.Int).Set(x), nil
+		default:
+			return nil, dataCloneError("%s is not transferable", v.String())
+		}
+	}
+
+	if node, ok := ex.seen[obj]; ok {
+		return node, nil
+	}
+	if _, ok := sobek.AssertFunction(obj); ok {
+		return nil, dataCloneError("functions are not transferable")
+	}
+	if mark := obj.GetSymbol(sharedSymbol); mark != nil {
+		if s, ok := mark.Export().(Shareable); ok {
+			node := &transferShared{s: s}
+			ex.seen[obj] = node
+			return node, nil
+		}
+	}
+
+	switch x := obj.Export().(type) {
+	case time.Time:
+		return x, nil
+	case sobek.ArrayBuffer:
+		if x.Detached() {
+			return nil, dataCloneError("ArrayBuffer is detached")
+		}
+		node := &transferBuffer{data: x.Bytes()}
+		if !ex.transfer[obj] {
+			node.data = append([]byte(nil), node.data...)
+		}
+		ex.seen[obj] = node
+		return node, nil
+	}
+	if view, ok, err := ex.exportView(obj); ok || err != nil {
+		return view, err
+	}
+
+	switch class := obj.ClassName(); class {
+	case "Array":
+		node := &transferArray{}
+		ex.seen[obj] = node
+		n := int(obj.Get("length").ToInteger())
+		for i := 0; i < n; i++ {
+			el, err := ex.export(obj.Get(fmt.Sprintf("%d", i)))
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, el)
+		}
+		return node, nil
+	case "Error":
+		return exportError(obj), nil
+	case "Object":
+		for _, class := range []string{"Map", "Set"} {
+			if ctor, ok := ex.vm.Get(class).(*sobek.Object); ok && ex.vm.InstanceOf(obj, ctor) {
+				return ex.exportCollection(obj, class)
+			}
+		}
+		node := &transferObject{}
+		ex.seen[obj] = node
+		for _, key := range obj.Keys() {
+			el, err := ex.export(obj.Get(key))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			node.keys = append(node.keys, key)
+			node.values = append(node.values, el)
+		}
+		return node, nil
+	default:
+		return nil, dataCloneError("%s objects are not transferable", class)
+	}
+}
+
+// exportView exports a typed array or DataView along with its buffer.
+func (ex *exporter) exportView(obj *sobek.Object) (*transferView, bool, error) {
+	bufVal, ok := obj.Get("buffer").(*sobek.Object)
+	if !ok {
+		return nil, false, nil
+	}
+	if _, ok := bufVal.Export().(sobek.ArrayBuffer); !ok {
+		return nil, false, nil
+	}
+	ctor, ok := obj.Get("constructor").(*sobek.Object)
+	if !ok {
+		return nil, false, nil
+	}
+
+	node := &transferView{
+		kind:   ctor.Get("name").String(),
+		offset: int(obj.Get("byteOffset").ToInteger()),
+		length: int(obj.Get("byteLength").ToInteger()),
+	}
+	if node.kind != "DataView" {
+		node.length = int(obj.Get("length").ToInteger())
+	}
+	ex.seen[obj] = node
+
+	buf, err := ex.export(bufVal)
+	if err != nil {
+		return nil, true, err
+	}
+	node.buf = buf
+	return node, true, nil
+}
+
+// exportCollection exports the entries of a Map or Set, iterating with
+// forEach so that nested values keep their JS form.
+func (ex *exporter) exportCollection(obj *sobek.Object, class string) (interface{}, error) {
+	m, s := &transferMap{}, &transferSet{}
+	var node interface{} = s
+	if class == "Map" {
+		node = m
+	}
+	ex.seen[obj] = node
+
+	forEach, ok := sobek.AssertFunction(obj.Get("forEach"))
+	if !ok {
+		return nil, dataCloneError("%s is not transferable", class)
+	}
+	var failed error
+	_, err := forEach(obj, ex.vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		if failed != nil {
+			return sobek.Undefined()
+		}
+		val, err := ex.export(call.Argument(0))
+		if err != nil {
+			failed = err
+			return sobek.Undefined()
+		}
+		if class == "Set" {
+			s.items = append(s.items, val)
+			return sobek.Undefined()
+		}
+		key, err := ex.export(call.Argument(1))
+		if err != nil {
+			failed = err
+			return sobek.Undefined()
+		}
+		m.keys = append(m.keys, key)
+		m.values = append(m.values, val)
+		return sobek.Undefined()
+	}))
+	if err == nil {
+		err = failed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func exportError(obj *sobek.Object) *ParallelError {
+	str := func(key string) string {
+		if v := obj.Get(key); v != nil && !sobek.IsUndefined(v) {
+			return v.String()
+		}
+		return ""
+	}
+	name := str("name")
+	if name == "" {
+		name = "Error"
+	}
+	return &ParallelError{Name: name, Message: str("message"), Stack: str("stack")}
+}
+
+type importer struct {
+	el   *eventloop.EventLoop
+	vm   *sobek.Runtime
+	seen map[interface{}]sobek.Value
+}
+
+// ImportTransferable recreates a value produced by ExportTransferable in the
+// runtime of el. It must be called on el's JS thread.
+func ImportTransferable(el *eventloop.EventLoop, v interface{}) (sobek.Value, error) {
+	im := &importer{el: el, vm: el.VM, seen: map[interface{}]sobek.Value{}}
+	return im.value(v)
+}
+
+func (im *importer) value(v interface{}) (sobek.Value, error) {
+	switch v.(type) {
+	case *transferArray, *transferObject, *transferMap, *transferSet, *transferBuffer, *transferView, *transferShared:
+		if val, ok := im.seen[v]; ok {
+			return val, nil
+		}
+	}
+
+	vm := im.vm
+	switch x := v.(type) {
+	case nil:
+		return sobek.Null(), nil
+	case transferUndefined:
+		return sobek.Undefined(), nil
+	case *transferArray:
+		arr := vm.NewArray()
+		im.seen[x] = arr
+		for i, item := range x.items {
+			val, err := im.value(item)
+			if err != nil {
+				return nil, err
+			}
+			_ = arr.Set(fmt.Sprintf("%d", i), val)
+		}
+		return arr, nil
+	case *transferObject:
+		obj := vm.NewObject()
+		im.seen[x] = obj
+		for i, key := range x.keys {
+			val, err := im.value(x.values[i])
+			if err != nil {
+				return nil, err
+			}
+			_ = obj.Set(key, val)
+		}
+		return obj, nil
+	case *transferMap:
+		return im.collection(x, "Map", "set", x.keys, x.values)
+	case *transferSet:
+		return im.collection(x, "Set", "add", nil, x.items)
+	case *transferBuffer:
+		buf := vm.ToValue(vm.NewArrayBuffer(x.data))
+		im.seen[x] = buf
+		return buf, nil
+	case *transferView:
+		ctor, ok := sobek.AssertConstructor(vm.Get(x.kind))
+		if !ok {
+			return nil, dataCloneError("%s is not available in this runtime", x.kind)
+		}
+		buf, err := im.value(x.buf)
+		if err != nil {
+			return nil, err
+		}
+		view, err := ctor(nil, buf, vm.ToValue(x.offset), vm.ToValue(x.length))
+		if err != nil {
+			return nil, err
+		}
+		im.seen[x] = view
+		return view, nil
+	case *transferShared:
+		val := x.s.Share(im.el)
+		im.seen[x] = val
+		return val, nil
+	case time.Time:
+		return vm.New(vm.Get("Date"), vm.ToValue(x.UnixMilli()))
+	case *ParallelError:
+		return x.Object(vm), nil
+	default:
+		return vm.ToValue(x), nil
+	}
+}
+
+func (im *importer) collection(node interface{}, class, method string, keys, values []interface{}) (sobek.Value, error) {
+	obj, err := im.vm.New(im.vm.Get(class))
+	if err != nil {
+		return nil, err
+	}
+	im.seen[node] = obj
+	add, _ := sobek.AssertFunction(obj.Get(method))
+	for i, v := range values {
+		val, err := im.value(v)
+		if err != nil {
+			return nil, err
+		}
+		args := []sobek.Value{val}
+		if keys != nil {
+			key, err := im.value(keys[i])
+			if err != nil {
+				return nil, err
+			}
+			args = []sobek.Value{key, val}
+		}
+		if _, err := add(obj, args...); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// ParallelError is an exception thrown in another runtime. It crosses back
+// by name, message and stack, and is rethrown as an error of the same class
+// when one exists.
+type ParallelError struct {
+	Name    string
+	Message string
+	Stack   string
+}
+
+func (e *ParallelError) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// NewParallelError converts an error returned by a call in another runtime.
+// It must be called on that runtime's JS thread.
+func NewParallelError(err error) error {
+	var dce *DataCloneError
+	if errors.As(err, &dce) {
+		return &ParallelError{Name: "DataCloneError", Message: dce.msg}
+	}
+	ex, ok := err.(*sobek.Exception)
+	if !ok {
+		return err
+	}
+	pe := ParallelErrorOf(ex.Value())
+	if pe.Stack == "" {
+		pe.Stack = ex.String()
+	}
+	return pe
+}
+
+// ParallelErrorOf converts a thrown or rejected value.
+func ParallelErrorOf(reason sobek.Value) *ParallelError {
+	if obj, ok := reason.(*sobek.Object); ok && obj.ClassName() == "Error" {
+		return exportError(obj)
+	}
+	return &ParallelError{Name: "Error", Message: reason.String()}
+}
+
+// errorConstructors are the globals an error's name may select. Names come
+// from another runtime, so any other global, such as Function or Worker,
+// must not be called with its message.
+var errorConstructors = map[string]bool{
+	"Error": true, "TypeError": true, "RangeError": true, "SyntaxError": true,
+	"ReferenceError": true, "EvalError": true, "URIError": true,
+	"AggregateError": true, "GoError": true,
+}
+
+// Object returns the error as a JS error in vm. Names without a standard
+// constructor make an Error carrying the name.
+func (e *ParallelError) Object(vm *sobek.Runtime) *sobek.Object {
+	name := e.Name
+	if !errorConstructors[name] {
+		name = "Error"
+	}
+	ctor, ok := sobek.AssertConstructor(vm.Get(name))
+	if !ok {
+		ctor, _ = sobek.AssertConstructor(vm.Get("Error"))
+	}
+	obj, err := ctor(nil, vm.ToValue(e.Message))
+	if err != nil {
+		obj = vm.NewObject()
+		_ = obj.Set("message", e.Message)
+	}
+	_ = obj.Set("name", e.Name)
+	if e.Stack != "" {
+		_ = obj.Set("stack", e.Stack)
+	}
+	return obj
+}
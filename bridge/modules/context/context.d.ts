@@ -0,0 +1,22 @@
+// MODULE: go:context
+declare module "go:context" {
+    /** A Go context.Context. Pass it to Go functions or select on Done(). */
+    export interface Context {
+        /** Closed when the context is cancelled or times out. */
+        Done(): Chan<void>;
+        /** Canceled or DeadlineExceeded once done, otherwise null. */
+        Err(): GoError | null;
+        Deadline(): Date | null;
+    }
+    export type CancelFunc = () => void;
+
+    /** The engine's root context, cancelled when the engine shuts down. */
+    export function Background(): Context;
+    export function WithCancel(parent?: Context): [Context, CancelFunc];
+    export function WithTimeout(parent: Context | undefined, ms: number): [Context, CancelFunc];
+    export function WithDeadline(parent: Context | undefined, deadline: Date): [Context, CancelFunc];
+
+    export const Canceled: GoError;
+    export const DeadlineExceeded: GoError;
+}
+// END: go:context
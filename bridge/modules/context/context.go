@@ -0,0 +1,74 @@
+// Package context provides bindings for Go's context package. Contexts are
+// passed to Go functions as-is, and their Done() channel works in select.
+package context
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
+	"github.com/repyh/typego/eventloop"
+)
+
+func init() {
+	core.RegisterModule(&contextModule{})
+}
+
+type contextModule struct{}
+
+func (m *contextModule) Name() string {
+	return "go:context"
+}
+
+func (m *contextModule) Register(vm *sobek.Runtime, el *eventloop.EventLoop) {
+	Register(vm, el)
+}
+
+func Register(vm *sobek.Runtime, el *eventloop.EventLoop) {
+	opts := []core.BindOption{core.WithEventLoop(el)}
+	wrap := func(ctx context.Context) sobek.Value {
+		return core.ContextObject(vm, ctx, opts...)
+	}
+
+	// The root context is the engine's, so derived contexts end with it.
+	parentOf := func(v sobek.Value) context.Context {
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			return el.Context()
+		}
+		ctx, ok := core.ContextOf(v)
+		if !ok {
+			panic(vm.NewTypeError("expected a context"))
+		}
+		return ctx
+	}
+	withCancel := func(ctx context.Context, cancel context.CancelFunc) sobek.Value {
+		return vm.NewArray(wrap(ctx), vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+			cancel()
+			return sobek.Undefined()
+		}))
+	}
+
+	obj := vm.NewObject()
+	_ = obj.Set("Background", func(sobek.FunctionCall) sobek.Value {
+		return wrap(el.Context())
+	})
+	_ = obj.Set("WithCancel", func(call sobek.FunctionCall) sobek.Value {
+		return withCancel(context.WithCancel(parentOf(call.Argument(0))))
+	})
+	_ = obj.Set("WithTimeout", func(call sobek.FunctionCall) sobek.Value {
+		d := time.Duration(call.Argument(1).ToFloat() * float64(time.Millisecond))
+		return withCancel(context.WithTimeout(parentOf(call.Argument(0)), d))
+	})
+	_ = obj.Set("WithDeadline", func(call sobek.FunctionCall) sobek.Value {
+		var deadline time.Time
+		if err := vm.ExportTo(call.Argument(1), &deadline); err != nil {
+			panic(vm.NewTypeError("WithDeadline expects a Date"))
+		}
+		return withCancel(context.WithDeadline(parentOf(call.Argument(0)), deadline))
+	})
+	_ = obj.Set("Canceled", core.NewGoError(vm, context.Canceled))
+	_ = obj.Set("DeadlineExceeded", core.NewGoError(vm, context.DeadlineExceeded))
+
+	_ = vm.Set("__go_context__", obj)
+}
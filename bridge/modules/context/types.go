@@ -0,0 +1,6 @@
+package context
+
+import _ "embed"
+
+//go:embed context.d.ts
+var Types []byte
@@ -0,0 +1,20 @@
+// MODULE: go:errors
+declare module "go:errors" {
+    /** Reports whether any error in err's chain matches target (errors.Is). */
+    export function is(err: unknown, target: unknown): boolean;
+    /**
+     * Finds the first error in err's chain whose Go type matches, e.g.
+     * "*fs.PathError" or "PathError" (errors.As). Returns null if none does.
+     */
+    export function as<K extends keyof GoErrorTypes>(err: unknown, goType: K): (GoErrorTypes[K] & GoError) | null;
+    export function as(err: unknown, goType: string): GoError | null;
+    /** Returns the error wrapped by err, or null. */
+    export function unwrap(err: unknown): GoError | null;
+
+    export const Is: typeof is;
+    export const As: typeof as;
+    export const Unwrap: typeof unwrap;
+    export function New(message: string): GoError;
+    export function Join(...errs: unknown[]): GoError | null;
+}
+// END: go:errors
@@ -0,0 +1,89 @@
+// Package errors provides bindings for Go's errors package. Errors are
+// matched against the Go value carried by a GoError, so sentinel identity
+// survives the trip through JS.
+package errors
+
+import (
+	"errors"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
+	"github.com/repyh/typego/eventloop"
+)
+
+func init() {
+	core.RegisterModule(&errorsModule{})
+}
+
+type errorsModule struct{}
+
+func (m *errorsModule) Name() string {
+	return "go:errors"
+}
+
+func (m *errorsModule) Register(vm *sobek.Runtime, el *eventloop.EventLoop) {
+	Register(vm)
+}
+
+func Register(vm *sobek.Runtime) {
+	obj := vm.NewObject()
+
+	is := func(call sobek.FunctionCall) sobek.Value {
+		err, ok := core.GoErrorOf(call.Argument(0))
+		if !ok {
+			return vm.ToValue(false)
+		}
+		target, ok := core.GoErrorOf(call.Argument(1))
+		return vm.ToValue(ok && errors.Is(err, target))
+	}
+
+	as := func(call sobek.FunctionCall) sobek.Value {
+		err, ok := core.GoErrorOf(call.Argument(0))
+		if !ok {
+			return sobek.Null()
+		}
+		match, ok := core.ErrorAs(err, call.Argument(1).String())
+		if !ok {
+			return sobek.Null()
+		}
+		return core.NewGoError(vm, match)
+	}
+
+	unwrap := func(call sobek.FunctionCall) sobek.Value {
+		err, ok := core.GoErrorOf(call.Argument(0))
+		if !ok {
+			return sobek.Null()
+		}
+		if inner := errors.Unwrap(err); inner != nil {
+			return core.NewGoError(vm, inner)
+		}
+		return sobek.Null()
+	}
+
+	_ = obj.Set("is", is)
+	_ = obj.Set("as", as)
+	_ = obj.Set("unwrap", unwrap)
+	_ = obj.Set("Is", is)         // Go spelling
+	_ = obj.Set("As", as)         // Go spelling
+	_ = obj.Set("Unwrap", unwrap) // Go spelling
+
+	_ = obj.Set("New", func(call sobek.FunctionCall) sobek.Value {
+		return core.NewGoError(vm, errors.New(call.Argument(0).String()))
+	})
+
+	_ = obj.Set("Join", func(call sobek.FunctionCall) sobek.Value {
+		var errs []error
+		for _, arg := range call.Arguments {
+			if err, ok := core.GoErrorOf(arg); ok {
+				errs = append(errs, err)
+			}
+		}
+		joined := errors.Join(errs...)
+		if joined == nil {
+			return sobek.Null()
+		}
+		return core.NewGoError(vm, joined)
+	})
+
+	_ = vm.Set("__go_errors__", obj)
+}
@@ -0,0 +1,6 @@
+package errors
+
+import _ "embed"
+
+//go:embed errors.d.ts
+var Types []byte
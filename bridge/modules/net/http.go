@@ -16,16 +16,15 @@ func init() {
 	core.RegisterModule(&httpModule{})
 }
 
-type httpModule struct {
-	el *eventloop.EventLoop
-}
+// httpModule is shared by every engine, which may be created concurrently
+// (workers), so it keeps no per-engine state.
+type httpModule struct{}
 
 func (m *httpModule) Name() string {
 	return "go:net/http"
 }
 
 func (m *httpModule) Register(vm *sobek.Runtime, el *eventloop.EventLoop) {
-	m.el = el
 	Register(vm, el)
 }
 
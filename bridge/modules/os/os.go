@@ -71,12 +71,12 @@ func (m *Module) WriteFile(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Val
 
 		safePath, err := m.sanitizePath(path)
 		if err != nil {
-			panic(vm.NewTypeError(fmt.Sprintf("sandbox violation: %v", err)))
+			panic(core.NewGoError(vm, fmt.Errorf("sandbox violation: %w", err)))
 		}
 
 		err = os.WriteFile(safePath, []byte(data), 0644)
 		if err != nil {
-			panic(vm.NewTypeError(fmt.Sprintf("os.WriteFile error: %v", err)))
+			panic(core.NewGoError(vm, fmt.Errorf("os.WriteFile error: %w", err)))
 		}
 
 		return sobek.Undefined()
@@ -89,12 +89,12 @@ func (m *Module) ReadFile(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Valu
 
 		safePath, err := m.sanitizePath(path)
 		if err != nil {
-			panic(vm.NewTypeError(fmt.Sprintf("sandbox violation: %v", err)))
+			panic(core.NewGoError(vm, fmt.Errorf("sandbox violation: %w", err)))
 		}
 
 		data, err := os.ReadFile(safePath)
 		if err != nil {
-			panic(vm.NewTypeError(fmt.Sprintf("os.ReadFile error: %v", err)))
+			panic(core.NewGoError(vm, fmt.Errorf("os.ReadFile error: %w", err)))
 		}
 
 		return vm.ToValue(string(data))
@@ -132,10 +132,16 @@ func Register(vm *sobek.Runtime) {
 
 	_ = obj.Set("Args", vm.ToValue(os.Args))
 
+	// Sentinels are bound by value so errors.is compares them by identity.
+	_ = obj.Set("ErrNotExist", os.ErrNotExist)
+	_ = obj.Set("ErrExist", os.ErrExist)
+	_ = obj.Set("ErrPermission", os.ErrPermission)
+	_ = obj.Set("ErrClosed", os.ErrClosed)
+
 	_ = obj.Set("Cwd", func(call sobek.FunctionCall) sobek.Value {
 		wd, err := os.Getwd()
 		if err != nil {
-			panic(vm.NewGoError(err))
+			panic(core.NewGoError(vm, err))
 		}
 		return vm.ToValue(wd)
 	})
@@ -143,7 +149,7 @@ func Register(vm *sobek.Runtime) {
 	_ = obj.Set("Mkdir", func(call sobek.FunctionCall) sobek.Value {
 		path := call.Argument(0).String()
 		if err := os.Mkdir(path, 0755); err != nil {
-			panic(vm.NewGoError(err))
+			panic(core.NewGoError(vm, err))
 		}
 		return sobek.Undefined()
 	})
@@ -151,7 +157,7 @@ func Register(vm *sobek.Runtime) {
 	_ = obj.Set("MkdirAll", func(call sobek.FunctionCall) sobek.Value {
 		path := call.Argument(0).String()
 		if err := os.MkdirAll(path, 0755); err != nil {
-			panic(vm.NewGoError(err))
+			panic(core.NewGoError(vm, err))
 		}
 		return sobek.Undefined()
 	})
@@ -160,10 +166,10 @@ func Register(vm *sobek.Runtime) {
 		path := call.Argument(0).String()
 		safePath, err := m.sanitizePath(path)
 		if err != nil {
-			panic(vm.NewTypeError(fmt.Sprintf("sandbox violation: %v", err)))
+			panic(core.NewGoError(vm, fmt.Errorf("sandbox violation: %w", err)))
 		}
 		if err := os.Remove(safePath); err != nil {
-			panic(vm.NewGoError(err))
+			panic(core.NewGoError(vm, err))
 		}
 		return sobek.Undefined()
 	})
@@ -172,10 +178,10 @@ func Register(vm *sobek.Runtime) {
 		path := call.Argument(0).String()
 		safePath, err := m.sanitizePath(path)
 		if err != nil {
-			panic(vm.NewTypeError(fmt.Sprintf("sandbox violation: %v", err)))
+			panic(core.NewGoError(vm, fmt.Errorf("sandbox violation: %w", err)))
 		}
 		if err := os.RemoveAll(safePath); err != nil {
-			panic(vm.NewGoError(err))
+			panic(core.NewGoError(vm, err))
 		}
 		return sobek.Undefined()
 	})
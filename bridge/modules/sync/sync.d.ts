@@ -2,11 +2,15 @@
 declare module "go:sync" {
     export function Spawn(fn: () => void): void;
     export function Sleep(ms: number): Promise<void>;
-    export function Chan<T = any>(buffer?: number): {
+    /** A buffered channel (100 by default) that also works with select and selectAsync. */
+    export function Chan<T = any>(buffer?: number): Chan<T> & {
         Send(val: T): void;
-        Recv(): T;
-        TryRecv(): [T, boolean];
+        Recv(): Promise<T | undefined>;
+        TrySend(val: T): boolean;
+        TryRecv(): [T | undefined, boolean];
         Close(): void;
+        Len(): number;
+        Cap(): number;
     };
 }
 // END: go:sync
@@ -80,18 +80,20 @@ func Register(vm *sobek.Runtime, el *eventloop.EventLoop) {
 	_ = obj.Set("Spawn", m.Spawn)
 	_ = obj.Set("Sleep", m.Sleep)
 
+	// Chan is a bridge channel with Go-spelled methods; Recv returns a promise
+	// so it never blocks the loop. Buffered by 100 unless a size is given.
 	_ = vm.Set("Chan", func(call sobek.ConstructorCall) *sobek.Object {
-		ch := make(chan sobek.Value, 100)
-		res := vm.NewObject()
-		_ = res.Set("send", func(c sobek.FunctionCall) sobek.Value {
-			ch <- c.Argument(0)
-			return sobek.Undefined()
-		})
-		_ = res.Set("recv", func(c sobek.FunctionCall) sobek.Value {
-			p, resolve, _ := el.CreatePromise()
-			go func() { resolve(<-ch) }()
-			return p
-		})
+		size := 100
+		if arg := call.Argument(0); !sobek.IsUndefined(arg) {
+			size = int(arg.ToInteger())
+		}
+		res := core.MakeChannel(vm, nil, size, core.WithEventLoop(el)).Object()
+		for goName, name := range map[string]string{
+			"Send": "send", "Recv": "recvAsync", "TrySend": "trySend", "TryRecv": "tryRecv",
+			"Close": "close", "Len": "len", "Cap": "cap",
+		} {
+			_ = res.Set(goName, res.Get(name))
+		}
 		return res
 	})
 
@@ -0,0 +1,303 @@
+package memory
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
+	"github.com/repyh/typego/eventloop"
+)
+
+// valueType is how a value is stored in shared memory: a scalar such as
+// "u32", or a Layout. Types hold no JS values, so structures built on them
+// work from any runtime.
+type valueType interface {
+	size() int
+	align() int
+	read(vm *sobek.Runtime, data []byte, off int) sobek.Value
+	write(vm *sobek.Runtime, data []byte, off int, v sobek.Value)
+}
+
+type scalarKind int
+
+const (
+	kindInt scalarKind = iota
+	kindUint
+	kindFloat
+	kindBigInt
+	kindBigUint
+	kindBool
+)
+
+// scalar is a number, BigInt or boolean field. Fields are naturally aligned
+// and accessed atomically, so a field written by one worker is never seen
+// torn by another.
+type scalar struct {
+	name  string
+	bytes int
+	kind  scalarKind
+}
+
+var scalars = map[string]*scalar{
+	"i8":   {"i8", 1, kindInt},
+	"u8":   {"u8", 1, kindUint},
+	"i16":  {"i16", 2, kindInt},
+	"u16":  {"u16", 2, kindUint},
+	"i32":  {"i32", 4, kindInt},
+	"u32":  {"u32", 4, kindUint},
+	"i64":  {"i64", 8, kindBigInt},
+	"u64":  {"u64", 8, kindBigUint},
+	"f32":  {"f32", 4, kindFloat},
+	"f64":  {"f64", 8, kindFloat},
+	"bool": {"bool", 1, kindBool},
+}
+
+func (s *scalar) size() int  { return s.bytes }
+func (s *scalar) align() int { return s.bytes }
+
+func (s *scalar) read(vm *sobek.Runtime, data []byte, off int) sobek.Value {
+	bits := intrinsics.LoadShared(data, off, s.bytes)
+	shift := 64 - 8*uint(s.bytes)
+	switch s.kind {
+	case kindInt:
+		return vm.ToValue(int64(bits<<shift) >> shift)
+	case kindFloat:
+		if s.bytes == 4 {
+			return vm.ToValue(float64(math.Float32frombits(uint32(bits))))
+		}
+		return vm.ToValue(math.Float64frombits(bits))
+	case kindBigInt:
+		return vm.ToValue(big.NewInt(int64(bits)))
+	case kindBigUint:
+		return vm.ToValue(new(big.Int).SetUint64(bits))
+	case kindBool:
+		return vm.ToValue(bits != 0)
+	default:
+		return vm.ToValue(bits)
+	}
+}
+
+func (s *scalar) write(vm *sobek.Runtime, data []byte, off int, v sobek.Value) {
+	var bits uint64
+	switch s.kind {
+	case kindFloat:
+		if s.bytes == 4 {
+			bits = uint64(math.Float32bits(float32(v.ToFloat())))
+		} else {
+			bits = math.Float64bits(v.ToFloat())
+		}
+	case kindBigInt, kindBigUint:
+		if b, ok := v.Export().(*big.Int); ok {
+			if b.IsInt64() {
+				bits = uint64(b.Int64())
+			} else {
+				bits = new(big.Int).And(b, new(big.Int).SetUint64(math.MaxUint64)).Uint64()
+			}
+		} else {
+			bits = uint64(v.ToInteger())
+		}
+	case kindBool:
+		if v.ToBoolean() {
+			bits = 1
+		}
+	default:
+		if f := v.ToFloat(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			bits = uint64(int64(math.Mod(math.Trunc(f), 1<<32)))
+		}
+	}
+	intrinsics.StoreShared(data, off, s.bytes, bits)
+}
+
+// Layout is a struct type defined with defineLayout: named fields at fixed,
+// naturally aligned offsets, like a C struct.
+type Layout struct {
+	fields    []layoutField
+	bytes     int
+	alignment int
+}
+
+type layoutField struct {
+	name   string
+	typ    valueType
+	offset int
+}
+
+func (l *Layout) size() int  { return l.bytes }
+func (l *Layout) align() int { return l.alignment }
+
+// read copies the struct at off into a plain object.
+func (l *Layout) read(vm *sobek.Runtime, data []byte, off int) sobek.Value {
+	obj := vm.NewObject()
+	for _, f := range l.fields {
+		_ = obj.Set(f.name, f.typ.read(vm, data, off+f.offset))
+	}
+	return obj
+}
+
+// write stores the fields of v present on it; missing ones are left as is.
+func (l *Layout) write(vm *sobek.Runtime, data []byte, off int, v sobek.Value) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		panic(vm.NewTypeError("expected an object for a layout value"))
+	}
+	for _, f := range l.fields {
+		if fv := obj.Get(f.name); fv != nil && !sobek.IsUndefined(fv) {
+			f.typ.write(vm, data, off+f.offset, fv)
+		}
+	}
+}
+
+func (l *Layout) field(name string) (layoutField, bool) {
+	for _, f := range l.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return layoutField{}, false
+}
+
+func alignUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+// newLayout lays out fields in the order given.
+func newLayout(vm *sobek.Runtime, spec *sobek.Object) *Layout {
+	l := &Layout{alignment: 1}
+	for _, name := range spec.Keys() {
+		typ := parseType(vm, spec.Get(name))
+		offset := alignUp(l.bytes, typ.align())
+		l.fields = append(l.fields, layoutField{name: name, typ: typ, offset: offset})
+		l.bytes = offset + typ.size()
+		if typ.align() > l.alignment {
+			l.alignment = typ.align()
+		}
+	}
+	if len(l.fields) == 0 {
+		panic(vm.NewTypeError("defineLayout requires at least one field"))
+	}
+	l.bytes = alignUp(l.bytes, l.alignment)
+	return l
+}
+
+// parseType resolves a scalar name or a layout.
+func parseType(vm *sobek.Runtime, v sobek.Value) valueType {
+	if s, ok := intrinsics.SharedOf(v); ok {
+		if l, ok := s.(*Layout); ok {
+			return l
+		}
+	}
+	if s, ok := scalars[v.String()]; ok && !sobek.IsUndefined(v) {
+		return s
+	}
+	panic(vm.NewTypeError("unknown shared memory type %q", v.String()))
+}
+
+// Share returns the layout's JS form: { size, align, fields, view, read,
+// write }. Layouts cross to workers by reference.
+func (l *Layout) Share(el *eventloop.EventLoop) sobek.Value {
+	vm := el.VM
+	obj := vm.NewObject()
+	fields := vm.NewObject()
+	for _, f := range l.fields {
+		desc := vm.NewObject()
+		_ = desc.Set("offset", f.offset)
+		_ = desc.Set("size", f.typ.size())
+		if s, ok := f.typ.(*scalar); ok {
+			_ = desc.Set("type", s.name)
+		} else {
+			_ = desc.Set("type", "layout")
+		}
+		_ = fields.Set(f.name, desc)
+	}
+	_ = obj.Set("size", l.bytes)
+	_ = obj.Set("align", l.alignment)
+	_ = obj.Set("fields", fields)
+
+	// at resolves (target, index) to the bytes and offset of a struct.
+	at := func(call sobek.FunctionCall) ([]byte, int) {
+		data := bytesOf(vm, call.Argument(0))
+		off := int(call.Argument(1).ToInteger()) * l.bytes
+		if off < 0 || off+l.bytes > len(data) {
+			panic(intrinsics.NewRangeError(vm, "struct at index %d is outside the %d byte buffer", call.Argument(1).ToInteger(), len(data)))
+		}
+		return data, off
+	}
+	_ = obj.Set("view", func(call sobek.FunctionCall) sobek.Value {
+		data, off := at(call)
+		return vm.NewDynamicObject(&layoutView{vm: vm, l: l, data: data, off: off})
+	})
+	_ = obj.Set("read", func(call sobek.FunctionCall) sobek.Value {
+		data, off := at(call)
+		return l.read(vm, data, off)
+	})
+	_ = obj.Set("write", func(call sobek.FunctionCall) sobek.Value {
+		data, off := at(call)
+		l.write(vm, data, off, call.Argument(2))
+		return sobek.Undefined()
+	})
+	intrinsics.MarkShared(vm, obj, l)
+	return obj
+}
+
+// layoutView is a live struct: reading a field loads it from memory, setting
+// it stores it.
+type layoutView struct {
+	vm   *sobek.Runtime
+	l    *Layout
+	data []byte
+	off  int
+}
+
+func (v *layoutView) Get(key string) sobek.Value {
+	f, ok := v.l.field(key)
+	if !ok {
+		return nil
+	}
+	if l, ok := f.typ.(*Layout); ok {
+		return v.vm.NewDynamicObject(&layoutView{vm: v.vm, l: l, data: v.data, off: v.off + f.offset})
+	}
+	return f.typ.read(v.vm, v.data, v.off+f.offset)
+}
+
+func (v *layoutView) Set(key string, val sobek.Value) bool {
+	f, ok := v.l.field(key)
+	if !ok {
+		return false
+	}
+	f.typ.write(v.vm, v.data, v.off+f.offset, val)
+	return true
+}
+
+func (v *layoutView) Has(key string) bool {
+	_, ok := v.l.field(key)
+	return ok
+}
+
+func (v *layoutView) Delete(string) bool { return false }
+
+func (v *layoutView) Keys() []string {
+	keys := make([]string, len(v.l.fields))
+	for i, f := range v.l.fields {
+		keys[i] = f.name
+	}
+	return keys
+}
+
+// bytesOf returns the memory of a segment, SharedArrayBuffer or ArrayBuffer.
+func bytesOf(vm *sobek.Runtime, v sobek.Value) []byte {
+	if s, ok := intrinsics.SharedOf(v); ok {
+		switch s := s.(type) {
+		case *SharedSegment:
+			return s.Bytes()
+		case *intrinsics.SharedBuffer:
+			return s.Data
+		}
+	}
+	if obj, ok := v.(*sobek.Object); ok {
+		if buf, ok := obj.Export().(sobek.ArrayBuffer); ok {
+			return buf.Bytes()
+		}
+	}
+	panic(vm.NewTypeError("expected a shared segment or an ArrayBuffer"))
+}
@@ -6,8 +6,236 @@ declare module "go:memory" {
 
 // MODULE: typego:memory
 declare module "typego:memory" {
-    export function makeShared(name: string, size: number): { buffer: ArrayBuffer; mutex: any };
-    export function stats(): { alloc: number; totalAlloc: number; sys: number; numGC: number };
+    export interface SegmentOptions {
+        /**
+         * Maps this file instead of allocating Go memory (Linux). The file
+         * is created or grown to size; a size of 0 maps the whole file.
+         */
+        file?: string;
+        /** Maps the file copy-on-write: writes never reach it. */
+        readOnly?: boolean;
+    }
+
+    /**
+     * A named block of shared memory. Each segment object holds a
+     * reference; the segment is freed, and its file unmapped, when the last
+     * one is released.
+     */
+    export interface Segment {
+        /** SharedArrayBuffer over the segment, usable with Atomics. */
+        readonly buffer: SharedArrayBuffer;
+        readonly size: number;
+        readonly name: string;
+        /** Absolute path of the mapped file. */
+        readonly file?: string;
+        readonly readOnly: boolean;
+        mutex: any;
+        /** Grows a file-backed segment; buffer then covers the new size. */
+        resize(size: number): void;
+        /** Writes changes to the file, returning once they are on disk. */
+        sync(): void;
+        /** Schedules changes to be written to the file. */
+        flush(): void;
+        /** Gives back this reference and detaches buffer. */
+        release(): void;
+    }
+
+    export interface SegmentInfo {
+        name: string;
+        size: number;
+        /** Segment objects not yet released. */
+        holders: number;
+        /** State of the segment's mutex when listed. */
+        locked: false | "read" | "write";
+        file?: string;
+        readOnly: boolean;
+    }
+
+    /**
+     * Gets or creates a named segment of Go memory. Posting the segment or
+     * its buffer to a worker shares the same memory. Opening an existing
+     * segment with another size throws; a size of 0 opens it whatever its
+     * size. Heap segments count against the engine's memory limit.
+     */
+    export function makeShared(name: string, size?: number, options?: SegmentOptions): Segment;
+    /** Frees a segment whatever its holders; false when there is none. */
+    export function free(name: string): boolean;
+    /** Lists the open segments, to track down leaks. */
+    export function segments(): SegmentInfo[];
+    export function stats(): {
+        alloc: number;
+        totalAlloc: number;
+        sys: number;
+        numGC: number;
+        /**
+         * Bytes of the heap segments this runtime and its workers created,
+         * and the limit on them (0 for none).
+         */
+        segmentBytes: number;
+        quota: number;
+        segments: SegmentInfo[];
+    };
     export function ptr(val: any): any;
+
+    /** The layout of a Go struct field. */
+    export interface FieldLayout {
+        name: string;
+        type: string;
+        offset: number;
+        size: number;
+        align: number;
+        /** Bytes of padding after the field. */
+        padding: number;
+    }
+
+    export interface Profile {
+        kind: "go" | "js" | "primitive";
+        /** The Go type, or the JS class or typeof. */
+        type: string;
+        /** Bytes of the value itself: unsafe.Sizeof for Go values. */
+        size: number;
+        /** Bytes of the value and all it reaches. */
+        retained: number;
+        /** Go values: alignment and total padding. */
+        align?: number;
+        padding?: number;
+        /** Go structs: the layout of each field. */
+        fields?: FieldLayout[];
+        /** JS objects: what each property retains, largest first. */
+        children?: { name: string; retained: number }[];
+    }
+
+    /** Measures a value: Go layout for Go values, estimates for JS objects. */
+    export function profile(value: any): Profile;
+
+    export interface SnapshotNode {
+        /** First path found to the object, e.g. globalThis.cache.entries[0]. */
+        path: string;
+        type: string;
+        /** Bytes of the object and its primitive properties. */
+        size: number;
+    }
+
+    export interface TypeStats {
+        count: number;
+        bytes: number;
+    }
+
+    export interface HeapSnapshot {
+        format: "typego-heap-snapshot";
+        version: 1;
+        time: string;
+        root: string;
+        go: { alloc: number; sys: number; heapObjects: number; numGC: number };
+        segments: SegmentInfo[];
+        bytes: number;
+        types: Record<string, TypeStats>;
+        /** Sorted by path. */
+        nodes: SnapshotNode[];
+    }
+
+    export interface SnapshotDiff {
+        bytes: number;
+        nodes: number;
+        /** Differences, for the types that changed. */
+        types: Record<string, TypeStats>;
+        added: SnapshotNode[];
+        removed: SnapshotNode[];
+        changed: { path: string; type: string; before: number; after: number }[];
+    }
+
+    /** Snapshots the objects reachable from root, or globalThis. */
+    export function snapshot(root?: object): HeapSnapshot;
+    /** Writes a snapshot to a file as JSON that diffs line by line. */
+    export function writeSnapshot(path: string, root?: object): void;
+    /** Compares two snapshots, as objects or JSON text, by path. */
+    export function diff(before: HeapSnapshot | string, after: HeapSnapshot | string): SnapshotDiff;
+
+    /** Field types of shared structures; i64 and u64 are BigInts. */
+    export type ScalarType = "i8" | "u8" | "i16" | "u16" | "i32" | "u32" | "i64" | "u64" | "f32" | "f64" | "bool";
+    export type FieldType = ScalarType | Layout<any>;
+
+    type ScalarValue<T> = T extends "i64" | "u64" ? bigint : T extends "bool" ? boolean : number;
+    export type ValueOf<T> = T extends Layout<infer F> ? { [K in keyof F]: ValueOf<F[K]> } : ScalarValue<T>;
+
+    /**
+     * A struct type: named fields at fixed, naturally aligned offsets. Field
+     * accesses are atomic, so workers never see them torn.
+     */
+    export interface Layout<F extends Record<string, FieldType>> {
+        /** Bytes per struct, padded to its alignment. */
+        readonly size: number;
+        readonly align: number;
+        readonly fields: { readonly [K in keyof F]: { offset: number; size: number; type: string } };
+        /** A live struct at index: its properties read and write memory. */
+        view(target: Segment | ArrayBuffer | SharedArrayBuffer, index?: number): ValueOf<Layout<F>>;
+        /** Copies the struct at index into a plain object. */
+        read(target: Segment | ArrayBuffer | SharedArrayBuffer, index?: number): ValueOf<Layout<F>>;
+        /** Stores the fields present on value. */
+        write(target: Segment | ArrayBuffer | SharedArrayBuffer, index: number, value: Partial<ValueOf<Layout<F>>>): void;
+    }
+    export function defineLayout<F extends Record<string, FieldType>>(fields: F): Layout<F>;
+
+    export interface StructureOptions<T extends FieldType> {
+        capacity: number;
+        /** Element type; defaults to "f64". */
+        type?: T;
+    }
+
+    /**
+     * Shared structures keep their state in a segment, so another runtime
+     * opening the same segment with the same options, or receiving the
+     * structure in a message, works on the same data.
+     */
+    interface StructureFactory<O, S> {
+        (segment: Segment, options: O): S;
+        /** Segment size needed for these options. */
+        bytes(options: O): number;
+    }
+
+    /** Fixed-capacity FIFO; pushing to a full ring overwrites the oldest element. */
+    export interface RingBuffer<V> {
+        readonly capacity: number;
+        readonly length: number;
+        /** Returns true when the oldest element was overwritten. */
+        push(value: V): boolean;
+        shift(): V | undefined;
+        peek(): V | undefined;
+        toArray(): V[];
+        clear(): void;
+    }
+    export const ringBuffer: StructureFactory<StructureOptions<FieldType>, RingBuffer<any>> &
+        (<T extends FieldType = "f64">(segment: Segment, options: StructureOptions<T>) => RingBuffer<ValueOf<T>>);
+
+    /** Lock-free bounded queue for one producer and one consumer. */
+    export interface SPSCQueue<V> {
+        readonly capacity: number;
+        readonly size: number;
+        /** Returns false when the queue is full. */
+        push(value: V): boolean;
+        pop(): V | undefined;
+    }
+    export const spscQueue: StructureFactory<StructureOptions<FieldType>, SPSCQueue<any>> &
+        (<T extends FieldType = "f64">(segment: Segment, options: StructureOptions<T>) => SPSCQueue<ValueOf<T>>);
+
+    export interface SharedMapOptions<T extends FieldType> extends StructureOptions<T> {
+        /** Longest key in bytes; defaults to 32. */
+        keySize?: number;
+    }
+
+    /** Fixed-capacity hash map from strings; set throws a RangeError when full. */
+    export interface SharedMap<V> {
+        readonly capacity: number;
+        readonly size: number;
+        get(key: string): V | undefined;
+        has(key: string): boolean;
+        set(key: string, value: V): this;
+        delete(key: string): boolean;
+        clear(): void;
+        keys(): string[];
+        entries(): [string, V][];
+    }
+    export const sharedMap: StructureFactory<SharedMapOptions<FieldType>, SharedMap<any>> &
+        (<T extends FieldType = "f64">(segment: Segment, options: SharedMapOptions<T>) => SharedMap<ValueOf<T>>);
 }
 // END: typego:memory
@@ -2,45 +2,155 @@
 package memory
 
 import (
+	"errors"
+	"os"
 	"runtime"
 	"sync" // standard sync
 
 	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
 	modulesync "github.com/repyh/typego/bridge/modules/sync" // TypeGo sync module
 	"github.com/repyh/typego/eventloop"
 )
 
-// SharedSegment represents a named block of memory shared between Go and JS.
+// SharedSegment represents a named block of memory shared between Go and JS,
+// held in the Go heap or mapped from a file. Segments are reference counted:
+// each JS form holds a reference, and the segment is closed when the last
+// one is released.
 type SharedSegment struct {
+	// Data is the segment's memory as it was opened. It stays valid, at
+	// that size, after Resize, until a file-backed segment is released.
+	//
+	// Deprecated: Use Bytes, which returns the current mapping.
 	Data []byte
+
 	Mu   sync.RWMutex
+	lock sync.Mutex // Guards the structures built on the segment, apart from Mu
+
+	name    string
+	factory *Factory
+	refs    int // Guarded by factory.mu
+
+	mapMu    sync.RWMutex // Guards the fields below
+	data     []byte
+	file     *os.File
+	path     string
+	readOnly bool
+	mappings [][]byte // Every mapping of file, kept until the segment is closed
+	closed   bool
+}
+
+// Share returns a new JS form of the segment in the runtime of el, holding
+// its own reference. Posting a form to a worker passes the segment by
+// reference.
+func (s *SharedSegment) Share(el *eventloop.EventLoop) sobek.Value {
+	s.Acquire()
+	return s.form(el)
+}
+
+// form returns the segment's JS form, taking over a reference the caller
+// holds: a SharedArrayBuffer over its bytes, a mutex guarding them, and
+// methods to resize, sync and release it. Releasing the form detaches its
+// buffer.
+func (s *SharedSegment) form(el *eventloop.EventLoop) sobek.Value {
+	vm := el.VM
+	res := vm.NewObject()
+
+	var (
+		buffer   *sobek.Object
+		mapped   []byte
+		released bool
+	)
+	live := func(method string) {
+		if released {
+			panic(vm.NewTypeError("%s: segment %q has been released", method, s.name))
+		}
+	}
+	check := func(err error) sobek.Value {
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return sobek.Undefined()
+	}
+	// The buffer follows the segment to its latest mapping after a resize.
+	getter := func(f func(sobek.FunctionCall) sobek.Value) sobek.Value { return vm.ToValue(f) }
+	_ = res.DefineAccessorProperty("buffer", getter(func(sobek.FunctionCall) sobek.Value {
+		if released {
+			return buffer
+		}
+		if data := s.Bytes(); buffer == nil || len(data) != len(mapped) || (len(data) > 0 && &data[0] != &mapped[0]) {
+			buffer, mapped = intrinsics.NewSharedArrayBuffer(vm, data), data
+		}
+		return buffer
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	_ = res.DefineAccessorProperty("size", getter(func(sobek.FunctionCall) sobek.Value {
+		return vm.ToValue(len(s.Bytes()))
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	_ = res.Set("name", s.name)
+	_ = res.Set("readOnly", s.readOnly)
+	if s.path != "" {
+		_ = res.Set("file", s.path)
+	}
+	// Reuse the production-ready BindMutex from sync module
+	_ = res.Set("mutex", modulesync.BindMutex(vm, &s.Mu, el))
+
+	_ = res.Set("resize", func(call sobek.FunctionCall) sobek.Value {
+		live("resize")
+		return check(s.Resize(int(call.Argument(0).ToInteger())))
+	})
+	_ = res.Set("sync", func(sobek.FunctionCall) sobek.Value {
+		live("sync")
+		return check(s.Sync(false))
+	})
+	_ = res.Set("flush", func(sobek.FunctionCall) sobek.Value {
+		live("flush")
+		return check(s.Sync(true))
+	})
+	_ = res.Set("release", func(sobek.FunctionCall) sobek.Value {
+		if released {
+			return sobek.Undefined()
+		}
+		released = true
+		if buffer == nil {
+			buffer = intrinsics.NewSharedArrayBuffer(vm, nil)
+		}
+		if ab, ok := buffer.Export().(sobek.ArrayBuffer); ok {
+			ab.Detach()
+		}
+		return check(s.Release())
+	})
+	intrinsics.MarkShared(vm, res, s)
+	return res
 }
 
 // Factory manages shared memory segments for an engine instance.
 type Factory struct {
-	segments map[string]*SharedSegment
-	mu       sync.Mutex
+	segments map[string]*SharedSegment // Shared with scopes of the factory
+	mu       *sync.Mutex
+	parent   *Factory // The factory a scope belongs to
+	used     uint64   // Bytes of the heap segments created through f
+	quota    uint64
 }
 
 // NewFactory creates a new memory factory.
 func NewFactory() *Factory {
 	return &Factory{
 		segments: make(map[string]*SharedSegment),
+		mu:       new(sync.Mutex),
 	}
 }
 
-// MakeShared gets or creates a shared memory segment.
-func (f *Factory) MakeShared(name string, size int) *SharedSegment {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-
-	if s, ok := f.segments[name]; ok {
-		return s
-	}
+// Scope returns a factory for another engine, such as a worker, that opens
+// the same segments as f. The heap segments it creates count against its
+// own quota, which starts as quota, as well as against f's.
+func (f *Factory) Scope(quota uint64) *Factory {
+	return &Factory{segments: f.segments, mu: f.mu, parent: f, quota: quota}
+}
 
-	s := &SharedSegment{Data: make([]byte, size)}
-	f.segments[name] = s
-	return s
+// MakeShared gets or creates a shared memory segment in the Go heap. The
+// reference it takes is never released, so the segment lives until freed.
+func (f *Factory) MakeShared(name string, size int) (*SharedSegment, error) {
+	return f.Open(name, size, SegmentOptions{})
 }
 
 // Module implements the typego:memory module.
@@ -60,10 +170,103 @@ func (m *Module) GetStats(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Valu
 		_ = obj.Set("sys", ms.Sys)
 		_ = obj.Set("numGC", ms.NumGC)
 
+		used, quota := m.Factory.Usage()
+		_ = obj.Set("segmentBytes", used)
+		_ = obj.Set("quota", quota)
+		_ = obj.Set("segments", m.listSegments(vm))
+
 		return obj
 	}
 }
 
+// GetSegments returns the open segments to JS: { name, size, holders,
+// locked, file, readOnly } each.
+func (m *Module) GetSegments(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		return m.listSegments(vm)
+	}
+}
+
+func (m *Module) listSegments(vm *sobek.Runtime) sobek.Value {
+	infos := m.Factory.Segments()
+	segments := make([]interface{}, len(infos))
+	for i, info := range infos {
+		seg := vm.NewObject()
+		_ = seg.Set("name", info.Name)
+		_ = seg.Set("size", info.Size)
+		_ = seg.Set("holders", info.Holders)
+		if info.Locked != "" {
+			_ = seg.Set("locked", info.Locked)
+		} else {
+			_ = seg.Set("locked", false)
+		}
+		if info.File != "" {
+			_ = seg.Set("file", info.File)
+		}
+		_ = seg.Set("readOnly", info.ReadOnly)
+		segments[i] = seg
+	}
+	return vm.NewArray(segments...)
+}
+
+// Free closes a segment by name, returning false when there is none.
+func (m *Module) Free(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		err := m.Factory.Free(call.Argument(0).String())
+		switch {
+		case errors.Is(err, ErrNoSegment):
+			return vm.ToValue(false)
+		case err != nil:
+			panic(vm.NewGoError(err))
+		}
+		return vm.ToValue(true)
+	}
+}
+
+// Profile returns the memory profile of a value to JS: its Go layout for
+// bridged Go values, and what each property retains for JS objects.
+func (m *Module) Profile(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		return profileObject(vm, intrinsics.NewProfile(vm, call.Argument(0)))
+	}
+}
+
+// Snapshot returns a heap snapshot of the objects reachable from the given
+// root, or globalThis, to JS.
+func (m *Module) Snapshot(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		root, _ := call.Argument(0).(*sobek.Object)
+		return toJS(vm, m.TakeSnapshot(vm, root))
+	}
+}
+
+// WriteSnapshot writes a heap snapshot to a file, as snapshot(root) would
+// return it.
+func (m *Module) WriteSnapshot(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		root, _ := call.Argument(1).(*sobek.Object)
+		if err := WriteSnapshot(call.Argument(0).String(), m.TakeSnapshot(vm, root)); err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return sobek.Undefined()
+	}
+}
+
+// Diff compares two heap snapshots, objects or JSON text.
+func (m *Module) Diff(vm *sobek.Runtime) func(sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		before, err := snapshotOf(call.Argument(0))
+		if err != nil {
+			panic(vm.NewTypeError(err.Error()))
+		}
+		after, err := snapshotOf(call.Argument(1))
+		if err != nil {
+			panic(vm.NewTypeError(err.Error()))
+		}
+		return toJS(vm, DiffSnapshots(before, after))
+	}
+}
+
 // Register injects the typego:memory module into the runtime.
 func Register(vm *sobek.Runtime, el *eventloop.EventLoop, f *Factory) {
 	if f == nil {
@@ -73,27 +276,75 @@ func Register(vm *sobek.Runtime, el *eventloop.EventLoop, f *Factory) {
 
 	obj := vm.NewObject()
 	_ = obj.Set("stats", m.GetStats(vm))
+	_ = obj.Set("segments", m.GetSegments(vm))
+	_ = obj.Set("free", m.Free(vm))
+	_ = obj.Set("profile", m.Profile(vm))
+	_ = obj.Set("snapshot", m.Snapshot(vm))
+	_ = obj.Set("writeSnapshot", m.WriteSnapshot(vm))
+	_ = obj.Set("diff", m.Diff(vm))
 
 	_ = obj.Set("makeShared", func(call sobek.FunctionCall) sobek.Value {
 		name := call.Argument(0).String()
 		size := int(call.Argument(1).ToInteger())
 
-		if name == "" || size <= 0 {
+		var opts SegmentOptions
+		if o, ok := call.Argument(2).(*sobek.Object); ok {
+			if v := o.Get("file"); v != nil && !sobek.IsUndefined(v) {
+				opts.File = v.String()
+			}
+			if v := o.Get("readOnly"); v != nil {
+				opts.ReadOnly = v.ToBoolean()
+			}
+		}
+
+		// A size of 0 opens an existing segment, or maps a whole file.
+		if name == "" || size < 0 {
 			panic(vm.NewTypeError("makeShared requires a name and positive size"))
 		}
 
-		segment := f.MakeShared(name, size)
+		seg, err := f.Open(name, size, opts)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return seg.form(el)
+	})
+
+	_ = obj.Set("defineLayout", func(call sobek.FunctionCall) sobek.Value {
+		spec, ok := call.Argument(0).(*sobek.Object)
+		if !ok {
+			panic(vm.NewTypeError("defineLayout requires an object of field types"))
+		}
+		return newLayout(vm, spec).Share(el)
+	})
+
+	// Each structure constructor has a bytes(options) method giving the
+	// segment size it needs.
+	structure := func(name string, create func(call sobek.FunctionCall) sobek.Value, elem func(typ valueType, opts *sobek.Object) int) {
+		fn := vm.ToValue(create).(*sobek.Object)
+		_ = fn.Set("bytes", func(call sobek.FunctionCall) sobek.Value {
+			capacity, typ, opts := parseStructureOptions(vm, call.Argument(0))
+			return vm.ToValue(headerSize + capacity*elem(typ, opts))
+		})
+		_ = obj.Set(name, fn)
+	}
+	queueElem := func(typ valueType, _ *sobek.Object) int { return elemSize(typ) }
 
-		buf := vm.NewArrayBuffer(segment.Data)
-		u8 := vm.Get("Uint8Array").ToObject(vm)
-		tArray, _ := vm.New(u8, vm.ToValue(buf))
+	structure("ringBuffer", func(call sobek.FunctionCall) sobek.Value {
+		seg, capacity, typ, _ := parseStructure(vm, call)
+		return newRingBuffer(vm, seg, capacity, typ).Share(el)
+	}, queueElem)
 
-		res := vm.NewObject()
-		_ = res.Set("buffer", tArray)
-		// Reuse the production-ready BindMutex from sync module
-		_ = res.Set("mutex", modulesync.BindMutex(vm, &segment.Mu, el))
+	structure("spscQueue", func(call sobek.FunctionCall) sobek.Value {
+		seg, capacity, typ, _ := parseStructure(vm, call)
+		return newSPSCQueue(vm, seg, capacity, typ).Share(el)
+	}, queueElem)
 
-		return res
+	structure("sharedMap", func(call sobek.FunctionCall) sobek.Value {
+		seg, capacity, typ, opts := parseStructure(vm, call)
+		return newSharedMap(vm, seg, capacity, parseKeySize(vm, opts), typ).Share(el)
+	}, func(typ valueType, opts *sobek.Object) int {
+		_, slot := mapSlot(parseKeySize(vm, opts), typ)
+		return slot
 	})
 
 	// Ptr factory for referencing values
@@ -0,0 +1,329 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/repyh/typego/bridge/intrinsics"
+)
+
+// SegmentOptions configures a segment opened with Factory.Open.
+type SegmentOptions struct {
+	// File backs the segment with a memory-mapped file instead of Go heap
+	// memory. The file is created, or grown, to the segment size; a size of
+	// 0 maps the whole existing file.
+	File string
+	// ReadOnly maps File copy-on-write: the segment can still be written,
+	// but writes stay in memory and never reach the file.
+	ReadOnly bool
+}
+
+// SegmentInfo describes an open segment, as listed by Factory.Segments.
+type SegmentInfo struct {
+	Name     string `json:"name"`
+	Size     int    `json:"size"`
+	Holders  int    `json:"holders"`          // References not yet released
+	Locked   string `json:"locked,omitempty"` // State of the segment's mutex: "", "read" or "write"
+	File     string `json:"file,omitempty"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
+var (
+	// ErrFileSegments is returned by Open for file-backed segments on
+	// platforms without mmap support.
+	ErrFileSegments = errors.New("file-backed segments are not supported on this platform")
+	// ErrQuotaExceeded is returned when a segment would take the factory's
+	// heap segments past its quota.
+	ErrQuotaExceeded = errors.New("shared memory quota exceeded")
+	// ErrNoSegment is returned for a name no open segment has.
+	ErrNoSegment = errors.New("no such segment")
+)
+
+// Open gets or creates the segment name, acquiring a reference the caller
+// gives back with Release. An existing segment is returned when size is 0
+// or its size, and opts names no other file than the one it maps.
+func (f *Factory) Open(name string, size int, opts SegmentOptions) (*SharedSegment, error) {
+	path := opts.File
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		path = abs
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if s, ok := f.segments[name]; ok {
+		if path != "" && path != s.path {
+			return nil, fmt.Errorf("segment %q is already open and not mapped from %s", name, opts.File)
+		}
+		if current := len(s.Bytes()); size != 0 && size != current {
+			return nil, fmt.Errorf("segment %q already exists with %d bytes, not %d", name, current, size)
+		}
+		s.refs++
+		return s, nil
+	}
+
+	s := &SharedSegment{name: name, factory: f, refs: 1}
+	if path == "" {
+		switch {
+		case size == 0:
+			return nil, fmt.Errorf("%w %q", ErrNoSegment, name)
+		case size < 0:
+			return nil, fmt.Errorf("segment %q needs a positive size", name)
+		}
+		for g := f; g != nil; g = g.parent {
+			if g.quota > 0 && g.used+uint64(size) > g.quota {
+				return nil, fmt.Errorf("%w: segment %q needs %d bytes, %d of %d in use", ErrQuotaExceeded, name, size, g.used, g.quota)
+			}
+		}
+		s.data = intrinsics.SharedBytes(size)
+		for g := f; g != nil; g = g.parent {
+			g.used += uint64(size)
+		}
+	} else {
+		file, data, err := mapFile(path, size, opts.ReadOnly)
+		if err != nil {
+			return nil, err
+		}
+		s.file, s.path, s.readOnly = file, path, opts.ReadOnly
+		s.data = data
+		s.mappings = [][]byte{data}
+	}
+	s.Data = s.data
+	f.segments[name] = s
+	return s, nil
+}
+
+// Free closes the segment name whatever references remain. Views of a
+// freed segment keep their memory, but a file-backed one no longer maps
+// the file.
+func (f *Factory) Free(name string) error {
+	f.mu.Lock()
+	s, ok := f.segments[name]
+	if ok {
+		f.drop(s)
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w %q", ErrNoSegment, name)
+	}
+	return s.close()
+}
+
+// drop removes s from the factory, crediting the factory that created it
+// and that factory's parents. It must be called with f.mu held.
+func (f *Factory) drop(s *SharedSegment) {
+	if f.segments[s.name] != s {
+		return
+	}
+	delete(f.segments, s.name)
+	if s.file == nil {
+		for g := s.factory; g != nil; g = g.parent {
+			g.used -= uint64(len(s.data))
+		}
+	}
+}
+
+// SetQuota limits the bytes of the heap segments created through f, and
+// through its scopes; 0 removes the limit. File-backed segments live in
+// the page cache and are not counted.
+func (f *Factory) SetQuota(bytes uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.quota = bytes
+}
+
+// Usage returns the bytes of the heap segments created through f and its
+// scopes, and its quota.
+func (f *Factory) Usage() (used, quota uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.used, f.quota
+}
+
+// Segments lists the open segments by name.
+func (f *Factory) Segments() []SegmentInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	infos := make([]SegmentInfo, 0, len(f.segments))
+	for _, s := range f.segments {
+		infos = append(infos, SegmentInfo{
+			Name:     s.name,
+			Size:     len(s.Bytes()),
+			Holders:  s.refs,
+			Locked:   s.lockState(),
+			File:     s.path,
+			ReadOnly: s.readOnly,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// lockState probes Mu. It is a snapshot for debugging: a read lock with a
+// writer waiting reads as "write".
+func (s *SharedSegment) lockState() string {
+	if s.Mu.TryLock() {
+		s.Mu.Unlock()
+		return ""
+	}
+	if s.Mu.TryRLock() {
+		s.Mu.RUnlock()
+		return "read"
+	}
+	return "write"
+}
+
+// Name returns the name the segment was opened with.
+func (s *SharedSegment) Name() string { return s.name }
+
+// Bytes returns the segment's memory. Resize replaces it with a larger
+// mapping, so hold on to the slice only as long as its size is enough.
+func (s *SharedSegment) Bytes() []byte {
+	s.mapMu.RLock()
+	defer s.mapMu.RUnlock()
+	return s.data
+}
+
+// Acquire takes another reference to the segment.
+func (s *SharedSegment) Acquire() {
+	s.factory.mu.Lock()
+	defer s.factory.mu.Unlock()
+	s.refs++
+}
+
+// Release gives back a reference. Releasing the last one frees the
+// segment: it leaves its factory and, when file-backed, is flushed and
+// unmapped and its file closed.
+func (s *SharedSegment) Release() error {
+	f := s.factory
+	f.mu.Lock()
+	if s.refs == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	s.refs--
+	last := s.refs == 0
+	if last {
+		f.drop(s)
+	}
+	f.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	return s.close()
+}
+
+func (s *SharedSegment) close() error {
+	s.mapMu.Lock()
+	defer s.mapMu.Unlock()
+	if s.closed || s.file == nil {
+		s.closed = true
+		return nil
+	}
+	s.closed = true
+
+	var err error
+	if !s.readOnly {
+		err = msync(s.data, false)
+	}
+	unmap(s.mappings)
+	s.mappings, s.data = nil, nil
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Resize grows a file-backed segment to size bytes, growing its file.
+// Earlier mappings stay valid until the segment is released, so views and
+// structures built on them keep working.
+func (s *SharedSegment) Resize(size int) error {
+	s.mapMu.Lock()
+	defer s.mapMu.Unlock()
+
+	switch {
+	case s.closed:
+		return fmt.Errorf("segment %q has been released", s.name)
+	case s.file == nil:
+		return fmt.Errorf("segment %q is not file-backed and cannot be resized", s.name)
+	case s.readOnly:
+		return fmt.Errorf("segment %q is read-only and cannot be resized", s.name)
+	case size < len(s.data):
+		return fmt.Errorf("segment %q can only grow, from %d bytes", s.name, len(s.data))
+	case size == len(s.data):
+		return nil
+	}
+
+	if err := s.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+	data, err := mmapFile(s.file, size, false)
+	if err != nil {
+		return err
+	}
+	s.mappings = append(s.mappings, data)
+	s.data = data
+	return nil
+}
+
+// Sync writes the changes of a file-backed segment to its file, returning
+// once they are on disk, or at once when async is true. It does nothing
+// for other segments.
+func (s *SharedSegment) Sync(async bool) error {
+	s.mapMu.RLock()
+	defer s.mapMu.RUnlock()
+	if s.closed || s.file == nil || s.readOnly {
+		return nil
+	}
+	return msync(s.data, async)
+}
+
+// mapFile opens path and maps its first size bytes, growing the file when
+// it is smaller. A read-only file must already be large enough.
+func mapFile(path string, size int, readOnly bool) (*os.File, []byte, error) {
+	flag := os.O_RDWR | os.O_CREATE
+	if readOnly {
+		flag = os.O_RDONLY
+	}
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	switch {
+	case size == 0 && info.Size() == 0:
+		err = fmt.Errorf("cannot map empty file %s without a size", path)
+	case size == 0:
+		size = int(info.Size())
+	case int64(size) > info.Size() && readOnly:
+		err = fmt.Errorf("read-only file %s is smaller than %d bytes", path, size)
+	case int64(size) > info.Size():
+		err = file.Truncate(int64(size))
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	data, err := mmapFile(file, size, readOnly)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, data, nil
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package memory
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapFile maps the first size bytes of file. Read-only mappings are
+// private, so writes through them are copy-on-write instead of faulting.
+func mmapFile(file *os.File, size int, readOnly bool) ([]byte, error) {
+	flags := unix.MAP_SHARED
+	if readOnly {
+		flags = unix.MAP_PRIVATE
+	}
+	return unix.Mmap(int(file.Fd()), 0, size, unix.PROT_READ|unix.PROT_WRITE, flags)
+}
+
+func msync(data []byte, async bool) error {
+	if len(data) == 0 {
+		return nil
+	}
+	flags := unix.MS_SYNC
+	if async {
+		flags = unix.MS_ASYNC
+	}
+	return unix.Msync(data, flags)
+}
+
+// unmap releases the file pages of mappings. Structures and views in other
+// runtimes may still hold them, so rather than unmapping, each range is
+// replaced by zeroed anonymous memory: stale accesses read zeros instead of
+// crashing the process.
+func unmap(mappings [][]byte) {
+	for _, m := range mappings {
+		_, _ = unix.MmapPtr(-1, 0, unsafe.Pointer(&m[0]), uintptr(len(m)),
+			unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS|unix.MAP_FIXED)
+	}
+}
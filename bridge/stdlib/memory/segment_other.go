@@ -0,0 +1,11 @@
+//go:build !linux
+
+package memory
+
+import "os"
+
+func mmapFile(*os.File, int, bool) ([]byte, error) { return nil, ErrFileSegments }
+
+func msync([]byte, bool) error { return nil }
+
+func unmap([][]byte) {}
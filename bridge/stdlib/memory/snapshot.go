@@ -0,0 +1,314 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
+)
+
+// SnapshotFormat identifies heap snapshot files.
+const SnapshotFormat = "typego-heap-snapshot"
+
+// Snapshot is a heap snapshot: every object reachable from a root, each
+// under the first path found to it, breadth first, so that the same
+// program state gives the same nodes. Nodes are sorted by path, and
+// snapshots written as JSON diff line by line.
+type Snapshot struct {
+	Format   string                `json:"format"`
+	Version  int                   `json:"version"`
+	Time     time.Time             `json:"time"`
+	Root     string                `json:"root"`
+	Go       GoStats               `json:"go"`
+	Segments []SegmentInfo         `json:"segments"`
+	Bytes    int64                 `json:"bytes"`
+	Types    map[string]*TypeStats `json:"types"`
+	Nodes    []Node                `json:"nodes"`
+}
+
+// GoStats are the Go runtime's memory statistics at a snapshot.
+type GoStats struct {
+	Alloc       uint64 `json:"alloc"`
+	Sys         uint64 `json:"sys"`
+	HeapObjects uint64 `json:"heapObjects"`
+	NumGC       uint32 `json:"numGC"`
+}
+
+// TypeStats totals the nodes of a type.
+type TypeStats struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Node is an object in a snapshot. Size counts its primitive properties
+// and, for Go values, all the Go memory they reach; other objects it
+// references are nodes of their own.
+type Node struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+}
+
+// TakeSnapshot walks the objects reachable from root, globalThis when nil.
+func (m *Module) TakeSnapshot(vm *sobek.Runtime, root *sobek.Object) *Snapshot {
+	name := "root"
+	if root == nil {
+		root, name = vm.GlobalObject(), "globalThis"
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	snap := &Snapshot{
+		Format:   SnapshotFormat,
+		Version:  1,
+		Time:     time.Now().UTC(),
+		Root:     name,
+		Go:       GoStats{Alloc: ms.Alloc, Sys: ms.Sys, HeapObjects: ms.HeapObjects, NumGC: ms.NumGC},
+		Segments: m.Factory.Segments(),
+		Types:    map[string]*TypeStats{},
+	}
+
+	type entry struct {
+		path string
+		obj  *sobek.Object
+	}
+	s := intrinsics.NewSizer(vm)
+	seen := map[*sobek.Object]bool{root: true}
+	queue := []entry{{name, root}}
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+
+		size, edges := s.Object(e.obj)
+		typ := typeName(vm, e.obj)
+		snap.Nodes = append(snap.Nodes, Node{Path: e.path, Type: typ, Size: size})
+		snap.Bytes += size
+		stats := snap.Types[typ]
+		if stats == nil {
+			stats = &TypeStats{}
+			snap.Types[typ] = stats
+		}
+		stats.Count++
+		stats.Bytes += size
+
+		for _, edge := range edges {
+			if !seen[edge.Value] {
+				seen[edge.Value] = true
+				queue = append(queue, entry{childPath(e.path, edge.Name), edge.Value})
+			}
+		}
+	}
+	sort.Slice(snap.Nodes, func(i, j int) bool { return snap.Nodes[i].Path < snap.Nodes[j].Path })
+	return snap
+}
+
+// typeName names the type of a node: the Go type of a bridged value, the
+// constructor of a class instance, or the object's class.
+func typeName(vm *sobek.Runtime, obj *sobek.Object) string {
+	if v, ok := intrinsics.GoValueOf(obj); ok {
+		t := v.Type()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return t.String()
+	}
+	if intrinsics.IsProxy(obj) {
+		return "Proxy"
+	}
+	class := obj.ClassName()
+	proto := obj.Prototype()
+	if class != "Object" || proto == nil {
+		return class
+	}
+	if ctor, ok := vm.Get("Object").(*sobek.Object); ok && proto.SameAs(ctor.Get("prototype")) {
+		return class
+	}
+	// The constructor property of a class prototype is a data property.
+	if ctor, ok := proto.Get("constructor").(*sobek.Object); ok {
+		if name := ctor.Get("name"); name != nil && name.String() != "" {
+			return name.String()
+		}
+	}
+	return class
+}
+
+func childPath(parent, name string) string {
+	switch {
+	case len(name) > 0 && name[0] == '[':
+		return parent + name
+	case isIdentifier(name):
+		return parent + "." + name
+	}
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return parent + "[" + name + "]"
+	}
+	return parent + "[" + strconv.Quote(name) + "]"
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		letter := c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// SnapshotDiff is what changed between two snapshots.
+type SnapshotDiff struct {
+	Bytes   int64                `json:"bytes"`
+	Nodes   int                  `json:"nodes"`
+	Types   map[string]TypeStats `json:"types"` // Differences, for types that changed
+	Added   []Node               `json:"added"`
+	Removed []Node               `json:"removed"`
+	Changed []NodeChange         `json:"changed"`
+}
+
+// NodeChange is a node whose type or size changed.
+type NodeChange struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+}
+
+// DiffSnapshots compares two snapshots by path.
+func DiffSnapshots(before, after *Snapshot) *SnapshotDiff {
+	d := &SnapshotDiff{
+		Bytes:   after.Bytes - before.Bytes,
+		Nodes:   len(after.Nodes) - len(before.Nodes),
+		Types:   map[string]TypeStats{},
+		Added:   []Node{},
+		Removed: []Node{},
+		Changed: []NodeChange{},
+	}
+	for typ, a := range after.Types {
+		b := before.Types[typ]
+		if b == nil {
+			b = &TypeStats{}
+		}
+		if a.Count != b.Count || a.Bytes != b.Bytes {
+			d.Types[typ] = TypeStats{Count: a.Count - b.Count, Bytes: a.Bytes - b.Bytes}
+		}
+	}
+	for typ, b := range before.Types {
+		if _, ok := after.Types[typ]; !ok {
+			d.Types[typ] = TypeStats{Count: -b.Count, Bytes: -b.Bytes}
+		}
+	}
+
+	old := make(map[string]Node, len(before.Nodes))
+	for _, n := range before.Nodes {
+		old[n.Path] = n
+	}
+	for _, n := range after.Nodes {
+		o, ok := old[n.Path]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, n)
+		case o.Type != n.Type || o.Size != n.Size:
+			d.Changed = append(d.Changed, NodeChange{Path: n.Path, Type: n.Type, Before: o.Size, After: n.Size})
+		}
+		delete(old, n.Path)
+	}
+	for _, n := range before.Nodes {
+		if _, ok := old[n.Path]; ok {
+			d.Removed = append(d.Removed, n)
+		}
+	}
+	return d
+}
+
+// snapshotOf reads a snapshot from JS: a snapshot object, as snapshot() or
+// JSON.parse returns it, or the JSON text of one.
+func snapshotOf(v sobek.Value) (*Snapshot, error) {
+	var data []byte
+	if s, ok := v.Export().(string); ok {
+		data = []byte(s)
+	} else {
+		var err error
+		if data, err = json.Marshal(v.Export()); err != nil {
+			return nil, err
+		}
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	if snap.Format != SnapshotFormat {
+		return nil, fmt.Errorf("not a heap snapshot: format %q", snap.Format)
+	}
+	return &snap, nil
+}
+
+// WriteSnapshot writes snap to path as indented JSON.
+func WriteSnapshot(path string, snap *Snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// toJS returns v as plain JS objects, through its JSON form.
+func toJS(vm *sobek.Runtime, v interface{}) sobek.Value {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(vm.NewGoError(err))
+	}
+	parse, _ := sobek.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+	res, err := parse(sobek.Undefined(), vm.ToValue(string(data)))
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// profileObject returns a profile to JS.
+func profileObject(vm *sobek.Runtime, p *intrinsics.Profile) sobek.Value {
+	obj := vm.NewObject()
+	_ = obj.Set("kind", p.Kind)
+	_ = obj.Set("type", p.Type)
+	_ = obj.Set("size", p.Size)
+	_ = obj.Set("retained", p.Retained)
+	if p.Kind == "go" {
+		_ = obj.Set("align", p.Align)
+		_ = obj.Set("padding", p.Padding)
+	}
+	if p.Fields != nil {
+		fields := make([]interface{}, len(p.Fields))
+		for i, f := range p.Fields {
+			field := vm.NewObject()
+			_ = field.Set("name", f.Name)
+			_ = field.Set("type", f.Type)
+			_ = field.Set("offset", f.Offset)
+			_ = field.Set("size", f.Size)
+			_ = field.Set("align", f.Align)
+			_ = field.Set("padding", f.Padding)
+			fields[i] = field
+		}
+		_ = obj.Set("fields", vm.NewArray(fields...))
+	}
+	if p.Kind == "js" {
+		children := make([]interface{}, len(p.Children))
+		for i, c := range p.Children {
+			child := vm.NewObject()
+			_ = child.Set("name", c.Name)
+			_ = child.Set("retained", c.Retained)
+			children[i] = child
+		}
+		_ = obj.Set("children", vm.NewArray(children...))
+	}
+	return obj
+}
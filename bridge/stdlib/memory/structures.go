@@ -0,0 +1,403 @@
+package memory
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
+	"github.com/repyh/typego/eventloop"
+)
+
+// Shared structures keep all their state in a segment's bytes, behind a
+// header that records what the segment holds:
+//
+//	0  magic     u32
+//	4  capacity  u32
+//	8  elemSize  u32
+//	12 two u32 words of structure state
+//	24 elements
+//
+// Attaching to a segment that already holds the same structure reuses its
+// contents, so a worker can open a queue by segment name. Ring buffers and
+// maps lock the segment while they work; the SPSC queue only uses atomics.
+
+const (
+	headerSize = 24
+
+	magicRing uint32 = 0x474e4952 // "RING"
+	magicSPSC uint32 = 0x43535053 // "SPSC"
+	magicMap  uint32 = 0x50414d48 // "HMAP"
+)
+
+// structure is the part common to the shared structures.
+type structure struct {
+	seg      *SharedSegment
+	typ      valueType
+	capacity int
+	elemSize int    // Bytes per element, including any key
+	data     []byte // The segment's mapping when attached, valid after it grows
+}
+
+func (s *structure) word(off int) *uint32 {
+	return (*uint32)(unsafe.Pointer(&s.data[off]))
+}
+
+func (s *structure) elem(i int) int {
+	return headerSize + i*s.elemSize
+}
+
+// attach initializes the header, or checks that the segment already holds
+// the same structure. A segment too small throws a RangeError giving the
+// bytes needed.
+func (s *structure) attach(vm *sobek.Runtime, magic uint32, what string) {
+	s.data = s.seg.Bytes()
+	need := headerSize + s.capacity*s.elemSize
+	if len(s.data) < need {
+		panic(intrinsics.NewRangeError(vm, "%s needs a segment of %d bytes, got %d", what, need, len(s.data)))
+	}
+
+	s.seg.lock.Lock()
+	defer s.seg.lock.Unlock()
+	header := s.data[:headerSize]
+	switch binary.NativeEndian.Uint32(header) {
+	case 0:
+		binary.NativeEndian.PutUint32(header[4:], uint32(s.capacity))
+		binary.NativeEndian.PutUint32(header[8:], uint32(s.elemSize))
+		atomic.StoreUint32(s.word(0), magic)
+	case magic:
+		if binary.NativeEndian.Uint32(header[4:]) != uint32(s.capacity) || binary.NativeEndian.Uint32(header[8:]) != uint32(s.elemSize) {
+			panic(vm.NewTypeError("segment holds a %s of another capacity or type", what))
+		}
+	default:
+		panic(vm.NewTypeError("segment already holds another structure"))
+	}
+}
+
+// parseStructure reads (segment, { capacity, type }) arguments.
+func parseStructure(vm *sobek.Runtime, call sobek.FunctionCall) (*SharedSegment, int, valueType, *sobek.Object) {
+	s, _ := intrinsics.SharedOf(call.Argument(0))
+	seg, ok := s.(*SharedSegment)
+	if !ok {
+		panic(vm.NewTypeError("expected a segment from makeShared"))
+	}
+	capacity, typ, opts := parseStructureOptions(vm, call.Argument(1))
+	return seg, capacity, typ, opts
+}
+
+// parseStructureOptions reads { capacity, type }; type defaults to "f64".
+func parseStructureOptions(vm *sobek.Runtime, arg sobek.Value) (int, valueType, *sobek.Object) {
+	opts, ok := arg.(*sobek.Object)
+	if !ok {
+		panic(vm.NewTypeError("expected options with capacity and type"))
+	}
+	capacity := opts.Get("capacity")
+	if capacity == nil || capacity.ToInteger() < 1 || capacity.ToInteger() > 1<<30 {
+		panic(intrinsics.NewRangeError(vm, "capacity must be a positive integer"))
+	}
+	typ := valueType(scalars["f64"])
+	if v := opts.Get("type"); v != nil && !sobek.IsUndefined(v) {
+		typ = parseType(vm, v)
+	}
+	return int(capacity.ToInteger()), typ, opts
+}
+
+// parseKeySize reads the keySize option of a shared map, 32 by default.
+func parseKeySize(vm *sobek.Runtime, opts *sobek.Object) int {
+	keySize := 32
+	if v := opts.Get("keySize"); v != nil && !sobek.IsUndefined(v) {
+		keySize = int(v.ToInteger())
+	}
+	if keySize < 1 || keySize > 0xffff {
+		panic(intrinsics.NewRangeError(vm, "keySize must be between 1 and 65535"))
+	}
+	return keySize
+}
+
+// elemSize is the bytes per element of ring buffers and queues.
+func elemSize(typ valueType) int {
+	return alignUp(typ.size(), 8)
+}
+
+// mapSlot returns the offset of the value in a map slot and the slot size.
+func mapSlot(keySize int, typ valueType) (int, int) {
+	valueOff := alignUp(4+keySize, typ.align())
+	return valueOff, alignUp(valueOff+typ.size(), 8)
+}
+
+// RingBuffer is a fixed-capacity FIFO in a segment. Pushing to a full ring
+// overwrites its oldest element. State: head (oldest index) and length.
+type RingBuffer struct {
+	structure
+}
+
+func newRingBuffer(vm *sobek.Runtime, seg *SharedSegment, capacity int, typ valueType) *RingBuffer {
+	r := &RingBuffer{structure{seg: seg, typ: typ, capacity: capacity, elemSize: elemSize(typ)}}
+	r.attach(vm, magicRing, "ring buffer")
+	return r
+}
+
+// Share returns the ring buffer's JS form.
+func (r *RingBuffer) Share(el *eventloop.EventLoop) sobek.Value {
+	vm := el.VM
+	head, length := r.word(12), r.word(16)
+	locked := func(f func() sobek.Value) sobek.Value {
+		r.seg.lock.Lock()
+		defer r.seg.lock.Unlock()
+		return f()
+	}
+
+	obj := vm.NewObject()
+	_ = obj.Set("capacity", r.capacity)
+	_ = obj.Set("push", func(call sobek.FunctionCall) sobek.Value {
+		return locked(func() sobek.Value {
+			overwrote := *length == uint32(r.capacity)
+			i := (int(*head) + int(*length)) % r.capacity
+			r.typ.write(vm, r.data, r.elem(i), call.Argument(0))
+			if overwrote {
+				*head = uint32((int(*head) + 1) % r.capacity)
+			} else {
+				*length++
+			}
+			return vm.ToValue(overwrote)
+		})
+	})
+	_ = obj.Set("shift", func(sobek.FunctionCall) sobek.Value {
+		return locked(func() sobek.Value {
+			if *length == 0 {
+				return sobek.Undefined()
+			}
+			v := r.typ.read(vm, r.data, r.elem(int(*head)))
+			*head = uint32((int(*head) + 1) % r.capacity)
+			*length--
+			return v
+		})
+	})
+	_ = obj.Set("peek", func(sobek.FunctionCall) sobek.Value {
+		return locked(func() sobek.Value {
+			if *length == 0 {
+				return sobek.Undefined()
+			}
+			return r.typ.read(vm, r.data, r.elem(int(*head)))
+		})
+	})
+	_ = obj.Set("toArray", func(sobek.FunctionCall) sobek.Value {
+		return locked(func() sobek.Value {
+			items := make([]interface{}, *length)
+			for i := range items {
+				items[i] = r.typ.read(vm, r.data, r.elem((int(*head)+i)%r.capacity))
+			}
+			return vm.NewArray(items...)
+		})
+	})
+	_ = obj.Set("clear", func(sobek.FunctionCall) sobek.Value {
+		return locked(func() sobek.Value {
+			*head, *length = 0, 0
+			return sobek.Undefined()
+		})
+	})
+	_ = obj.DefineAccessorProperty("length", vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+		return locked(func() sobek.Value { return vm.ToValue(*length) })
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	intrinsics.MarkShared(vm, obj, r)
+	return obj
+}
+
+// SPSCQueue is a bounded lock-free queue for one producer and one consumer,
+// possibly in different workers. State: head and tail, free-running counters
+// owned by the consumer and the producer.
+type SPSCQueue struct {
+	structure
+}
+
+func newSPSCQueue(vm *sobek.Runtime, seg *SharedSegment, capacity int, typ valueType) *SPSCQueue {
+	q := &SPSCQueue{structure{seg: seg, typ: typ, capacity: capacity, elemSize: elemSize(typ)}}
+	q.attach(vm, magicSPSC, "SPSC queue")
+	return q
+}
+
+// Share returns the queue's JS form.
+func (q *SPSCQueue) Share(el *eventloop.EventLoop) sobek.Value {
+	vm := el.VM
+	head, tail := q.word(12), q.word(16)
+
+	obj := vm.NewObject()
+	_ = obj.Set("capacity", q.capacity)
+	_ = obj.Set("push", func(call sobek.FunctionCall) sobek.Value {
+		t := atomic.LoadUint32(tail)
+		if t-atomic.LoadUint32(head) == uint32(q.capacity) {
+			return vm.ToValue(false)
+		}
+		q.typ.write(vm, q.data, q.elem(int(t%uint32(q.capacity))), call.Argument(0))
+		atomic.StoreUint32(tail, t+1)
+		return vm.ToValue(true)
+	})
+	_ = obj.Set("pop", func(sobek.FunctionCall) sobek.Value {
+		h := atomic.LoadUint32(head)
+		if atomic.LoadUint32(tail) == h {
+			return sobek.Undefined()
+		}
+		v := q.typ.read(vm, q.data, q.elem(int(h%uint32(q.capacity))))
+		atomic.StoreUint32(head, h+1)
+		return v
+	})
+	_ = obj.DefineAccessorProperty("size", vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+		return vm.ToValue(atomic.LoadUint32(tail) - atomic.LoadUint32(head))
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	intrinsics.MarkShared(vm, obj, q)
+	return obj
+}
+
+// Slot states of a SharedMap.
+const (
+	slotEmpty = iota
+	slotUsed
+	slotDeleted
+)
+
+// SharedMap is a fixed-capacity hash map from strings of at most keySize
+// bytes to values, with open addressing. State: count. Each slot holds
+//
+//	0 state u8, 2 key length u16, 4 key bytes, then the value, aligned.
+type SharedMap struct {
+	structure
+	keySize  int
+	valueOff int
+}
+
+func newSharedMap(vm *sobek.Runtime, seg *SharedSegment, capacity, keySize int, typ valueType) *SharedMap {
+	valueOff, slotSize := mapSlot(keySize, typ)
+	m := &SharedMap{
+		structure: structure{seg: seg, typ: typ, capacity: capacity, elemSize: slotSize},
+		keySize:   keySize,
+		valueOff:  valueOff,
+	}
+	m.attach(vm, magicMap, "shared map")
+	return m
+}
+
+// find returns the slot holding key, or -1 and the first slot it could be
+// inserted in, or -1 when the map is full. It must be called with the lock.
+func (m *SharedMap) find(key string) (int, int) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	free := -1
+	for n, i := 0, int(h.Sum32()%uint32(m.capacity)); n < m.capacity; n, i = n+1, (i+1)%m.capacity {
+		slot := m.data[m.elem(i):]
+		switch slot[0] {
+		case slotEmpty:
+			if free < 0 {
+				free = i
+			}
+			return -1, free
+		case slotDeleted:
+			if free < 0 {
+				free = i
+			}
+		case slotUsed:
+			if keyLen := int(binary.NativeEndian.Uint16(slot[2:])); string(slot[4:4+keyLen]) == key {
+				return i, -1
+			}
+		}
+	}
+	return -1, free
+}
+
+func (m *SharedMap) key(i int) string {
+	slot := m.data[m.elem(i):]
+	return string(slot[4 : 4+int(binary.NativeEndian.Uint16(slot[2:]))])
+}
+
+// Share returns the map's JS form, mirroring the Map methods.
+func (m *SharedMap) Share(el *eventloop.EventLoop) sobek.Value {
+	vm := el.VM
+	count := m.word(12)
+	mu := &m.seg.lock
+
+	obj := vm.NewObject()
+	_ = obj.Set("capacity", m.capacity)
+	_ = obj.Set("get", func(call sobek.FunctionCall) sobek.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		if i, _ := m.find(call.Argument(0).String()); i >= 0 {
+			return m.typ.read(vm, m.data, m.elem(i)+m.valueOff)
+		}
+		return sobek.Undefined()
+	})
+	_ = obj.Set("has", func(call sobek.FunctionCall) sobek.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		i, _ := m.find(call.Argument(0).String())
+		return vm.ToValue(i >= 0)
+	})
+	_ = obj.Set("set", func(call sobek.FunctionCall) sobek.Value {
+		key := call.Argument(0).String()
+		if len(key) > m.keySize {
+			panic(intrinsics.NewRangeError(vm, "key %q is longer than %d bytes", key, m.keySize))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		i, free := m.find(key)
+		if i < 0 {
+			if free < 0 {
+				panic(intrinsics.NewRangeError(vm, "shared map is full (%d entries)", m.capacity))
+			}
+			i = free
+			slot := m.data[m.elem(i):]
+			slot[0] = slotUsed
+			binary.NativeEndian.PutUint16(slot[2:], uint16(len(key)))
+			copy(slot[4:], key)
+			*count++
+		}
+		m.typ.write(vm, m.data, m.elem(i)+m.valueOff, call.Argument(1))
+		return obj
+	})
+	_ = obj.Set("delete", func(call sobek.FunctionCall) sobek.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		i, _ := m.find(call.Argument(0).String())
+		if i < 0 {
+			return vm.ToValue(false)
+		}
+		m.data[m.elem(i)] = slotDeleted
+		*count--
+		return vm.ToValue(true)
+	})
+	_ = obj.Set("clear", func(sobek.FunctionCall) sobek.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 0; i < m.capacity; i++ {
+			m.data[m.elem(i)] = slotEmpty
+		}
+		*count = 0
+		return sobek.Undefined()
+	})
+	entries := func(f func(i int) interface{}) sobek.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		var out []interface{}
+		for i := 0; i < m.capacity; i++ {
+			if m.data[m.elem(i)] == slotUsed {
+				out = append(out, f(i))
+			}
+		}
+		return vm.NewArray(out...)
+	}
+	_ = obj.Set("keys", func(sobek.FunctionCall) sobek.Value {
+		return entries(func(i int) interface{} { return m.key(i) })
+	})
+	_ = obj.Set("entries", func(sobek.FunctionCall) sobek.Value {
+		return entries(func(i int) interface{} {
+			return vm.NewArray(m.key(i), m.typ.read(vm, m.data, m.elem(i)+m.valueOff))
+		})
+	})
+	_ = obj.DefineAccessorProperty("size", vm.ToValue(func(sobek.FunctionCall) sobek.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		return vm.ToValue(*count)
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	intrinsics.MarkShared(vm, obj, m)
+	return obj
+}
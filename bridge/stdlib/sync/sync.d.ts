@@ -0,0 +1,52 @@
+// MODULE: typego:sync
+declare module "typego:sync" {
+    import type { Context } from "go:context";
+
+    /** A goroutine started by go(), WaitGroup.go or Group.go. */
+    export type Task<T> = GoTask<T>;
+
+    /** Waits for a collection of tasks to finish, like sync.WaitGroup. */
+    export class WaitGroup {
+        add(delta?: number): void;
+        done(): void;
+        /** Resolves once the counter drops to zero. */
+        wait(): Promise<void>;
+        /** Runs fn on a goroutine tracked by the group. */
+        go<A extends any[], R>(fn: (...args: A) => R, ...args: A): GoTask<Awaited<R>>;
+    }
+
+    /**
+     * A group of tasks where the first failure is reported by wait(), like
+     * errgroup.Group.
+     */
+    export class Group {
+        /** Caps the number of active tasks; extra tasks queue. Call before go(). */
+        setLimit(n: number): void;
+        go<A extends any[], R>(fn: (...args: A) => R, ...args: A): GoTask<Awaited<R>>;
+        /** Starts fn only if under the limit, otherwise returns null. */
+        tryGo<A extends any[], R>(fn: (...args: A) => R, ...args: A): GoTask<Awaited<R>> | null;
+        /** Resolves when all tasks finish, or rejects with the first error. */
+        wait(): Promise<void>;
+    }
+
+    /**
+     * Returns a Group and a context cancelled when a task fails or wait()
+     * returns, like errgroup.WithContext.
+     */
+    export function withContext(parent?: Context): [Group, Context];
+
+    /** Runs a function at most once, like sync.Once. */
+    export class Once {
+        do(fn: () => void): void;
+    }
+
+    /** A weighted semaphore, like semaphore.Weighted. */
+    export class Semaphore {
+        constructor(size: number);
+        /** Resolves once n (default 1) is acquired; rejects if ctx ends first. */
+        acquire(n?: number, ctx?: Context): Promise<void>;
+        tryAcquire(n?: number): boolean;
+        release(n?: number): void;
+    }
+}
+// END: typego:sync
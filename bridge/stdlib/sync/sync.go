@@ -0,0 +1,215 @@
+// Package sync provides the typego:sync module: structured concurrency for
+// go() tasks modeled on sync.WaitGroup, errgroup.Group, sync.Once and
+// semaphore.Weighted. Waiting never blocks the JS thread; it returns a
+// promise settled on the event loop.
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
+	"github.com/repyh/typego/bridge/intrinsics"
+	"github.com/repyh/typego/eventloop"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// Module implements the typego:sync module.
+type Module struct {
+	vm  *sobek.Runtime
+	el  *eventloop.EventLoop
+	reg *intrinsics.Registry
+}
+
+func Register(vm *sobek.Runtime, el *eventloop.EventLoop, reg *intrinsics.Registry) {
+	m := &Module{vm: vm, el: el, reg: reg}
+
+	obj := vm.NewObject()
+	_ = obj.Set("WaitGroup", m.newWaitGroup)
+	_ = obj.Set("Group", func(call sobek.ConstructorCall) *sobek.Object {
+		return m.group(&errgroup.Group{})
+	})
+	_ = obj.Set("withContext", func(call sobek.FunctionCall) sobek.Value {
+		g, ctx := errgroup.WithContext(m.context(call.Argument(0)))
+		return vm.NewArray(m.group(g), core.ContextObject(vm, ctx, core.WithEventLoop(el)))
+	})
+	_ = obj.Set("Once", m.newOnce)
+	_ = obj.Set("Semaphore", m.newSemaphore)
+
+	_ = vm.Set("__typego_sync__", obj)
+}
+
+// task creates a go() task from fn and the remaining arguments.
+func (m *Module) task(call sobek.FunctionCall, method string) *intrinsics.Task {
+	fn, ok := sobek.AssertFunction(call.Argument(0))
+	if !ok {
+		panic(m.vm.NewTypeError(method + " expects a function"))
+	}
+	var args []sobek.Value
+	if len(call.Arguments) > 1 {
+		args = append(args, call.Arguments[1:]...)
+	}
+	return m.reg.NewTask(fn, args)
+}
+
+// await runs wait on a goroutine and settles the returned promise on the loop.
+func (m *Module) await(wait func() error) sobek.Value {
+	p, resolve, reject := m.vm.NewPromise()
+	m.el.WGAdd(1)
+	go func() {
+		err := wait()
+		m.el.RunOnLoop(func() {
+			defer m.el.WGDone()
+			if err != nil {
+				_ = reject(m.reason(err))
+				return
+			}
+			_ = resolve(sobek.Undefined())
+		})
+	}()
+	return m.vm.ToValue(p)
+}
+
+// reason rejects with the exception a task threw rather than a copy of it.
+func (m *Module) reason(err error) sobek.Value {
+	var te *intrinsics.TaskError
+	if errors.As(err, &te) && te.Reason != nil {
+		return te.Reason
+	}
+	return core.NewGoError(m.vm, err)
+}
+
+// guard rethrows Go panics (negative counters, over-release) as GoErrors.
+func (m *Module) guard(op func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			panic(core.NewGoError(m.vm, fmt.Errorf("panic: %v", p)))
+		}
+	}()
+	op()
+}
+
+func (m *Module) context(v sobek.Value) context.Context {
+	if sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return m.el.Context()
+	}
+	ctx, ok := core.ContextOf(v)
+	if !ok {
+		panic(m.vm.NewTypeError("expected a context"))
+	}
+	return ctx
+}
+
+func (m *Module) weight(v sobek.Value) int64 {
+	if sobek.IsUndefined(v) {
+		return 1
+	}
+	return v.ToInteger()
+}
+
+func (m *Module) newWaitGroup(call sobek.ConstructorCall) *sobek.Object {
+	var wg sync.WaitGroup
+	obj := call.This
+
+	_ = obj.Set("add", func(c sobek.FunctionCall) sobek.Value {
+		m.guard(func() { wg.Add(int(m.weight(c.Argument(0)))) })
+		return sobek.Undefined()
+	})
+	_ = obj.Set("done", func(sobek.FunctionCall) sobek.Value {
+		m.guard(wg.Done)
+		return sobek.Undefined()
+	})
+	_ = obj.Set("wait", func(sobek.FunctionCall) sobek.Value {
+		return m.await(func() error {
+			wg.Wait()
+			return nil
+		})
+	})
+	// go(fn, ...args) is add(1), go() and done() in one, like wg.Go.
+	_ = obj.Set("go", func(c sobek.FunctionCall) sobek.Value {
+		t := m.task(c, "WaitGroup.go")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = t.Run()
+		}()
+		return t.Object()
+	})
+	return nil
+}
+
+// group wraps an errgroup.Group. Tasks past the limit are queued on their own
+// goroutine instead of blocking the JS thread as g.Go would.
+func (m *Module) group(g *errgroup.Group) *sobek.Object {
+	var launching sync.WaitGroup
+	obj := m.vm.NewObject()
+
+	_ = obj.Set("setLimit", func(c sobek.FunctionCall) sobek.Value {
+		m.guard(func() { g.SetLimit(int(c.Argument(0).ToInteger())) })
+		return sobek.Undefined()
+	})
+	_ = obj.Set("go", func(c sobek.FunctionCall) sobek.Value {
+		t := m.task(c, "Group.go")
+		t.Own()
+		launching.Add(1)
+		go func() {
+			g.Go(t.Run)
+			launching.Done()
+		}()
+		return t.Object()
+	})
+	_ = obj.Set("tryGo", func(c sobek.FunctionCall) sobek.Value {
+		t := m.task(c, "Group.tryGo")
+		t.Own()
+		if !g.TryGo(t.Run) {
+			return sobek.Null()
+		}
+		return t.Object()
+	})
+	_ = obj.Set("wait", func(sobek.FunctionCall) sobek.Value {
+		return m.await(func() error {
+			launching.Wait()
+			return g.Wait()
+		})
+	})
+	return obj
+}
+
+func (m *Module) newOnce(call sobek.ConstructorCall) *sobek.Object {
+	var once sync.Once
+	_ = call.This.Set("do", func(c sobek.FunctionCall) sobek.Value {
+		fn, ok := sobek.AssertFunction(c.Argument(0))
+		if !ok {
+			panic(m.vm.NewTypeError("Once.do expects a function"))
+		}
+		var err error
+		once.Do(func() { _, err = fn(sobek.Undefined()) })
+		if err != nil {
+			panic(err)
+		}
+		return sobek.Undefined()
+	})
+	return nil
+}
+
+func (m *Module) newSemaphore(call sobek.ConstructorCall) *sobek.Object {
+	sem := semaphore.NewWeighted(m.weight(call.Argument(0)))
+	obj := call.This
+
+	_ = obj.Set("acquire", func(c sobek.FunctionCall) sobek.Value {
+		n, ctx := m.weight(c.Argument(0)), m.context(c.Argument(1))
+		return m.await(func() error { return sem.Acquire(ctx, n) })
+	})
+	_ = obj.Set("tryAcquire", func(c sobek.FunctionCall) sobek.Value {
+		return m.vm.ToValue(sem.TryAcquire(m.weight(c.Argument(0))))
+	})
+	_ = obj.Set("release", func(c sobek.FunctionCall) sobek.Value {
+		m.guard(func() { sem.Release(m.weight(c.Argument(0))) })
+		return sobek.Undefined()
+	})
+	return nil
+}
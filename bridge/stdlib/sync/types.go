@@ -0,0 +1,6 @@
+package sync
+
+import _ "embed"
+
+//go:embed sync.d.ts
+var Types []byte
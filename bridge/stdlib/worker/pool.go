@@ -0,0 +1,474 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
+	"github.com/repyh/typego/eventloop"
+)
+
+// task is a WorkerPool task in flight to a worker.
+type task struct {
+	id      uint64
+	payload interface{}
+}
+
+// taskResult is a worker's answer to a task.
+type taskResult struct {
+	id    uint64
+	value interface{}
+	err   error
+}
+
+// runTask calls self.ontask with the task's payload and reports its result,
+// once settled, to the parent.
+func (s *Self) runTask(t *task) {
+	vm := s.el.VM
+	reply := func(v sobek.Value, err error) {
+		res := &taskResult{id: t.id}
+		if err == nil {
+			res.value, err = intrinsics.ExportTransferable(vm, v, nil)
+		}
+		if err != nil {
+			res.err = intrinsics.NewParallelError(err)
+		}
+		s.parent.OnMessage(res)
+	}
+
+	fn, ok := sobek.AssertFunction(vm.GlobalObject().Get("ontask"))
+	if !ok {
+		reply(nil, errors.New("worker has no self.ontask handler"))
+		return
+	}
+	arg, err := intrinsics.ImportTransferable(s.el, t.payload)
+	if err != nil {
+		reply(nil, err)
+		return
+	}
+	val, err := fn(sobek.Undefined(), arg)
+	if errors.Is(err, exitSignal) {
+		return
+	}
+	if err != nil {
+		reply(nil, err)
+		return
+	}
+
+	if _, ok := val.Export().(*sobek.Promise); !ok {
+		reply(val, nil)
+		return
+	}
+	obj := val.ToObject(vm)
+	then, _ := sobek.AssertFunction(obj.Get("then"))
+	onFulfilled := vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		reply(call.Argument(0), nil)
+		return sobek.Undefined()
+	})
+	onRejected := vm.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		reply(nil, intrinsics.ParallelErrorOf(call.Argument(0)))
+		return sobek.Undefined()
+	})
+	if _, err := then(obj, onFulfilled, onRejected); err != nil {
+		reply(nil, err)
+	}
+}
+
+// Pool is a WorkerPool: a fixed set of supervised workers serving run(task)
+// calls in order, each worker handling one task at a time. Tasks beyond a
+// full queue wait for space in it, or with the "reject" overflow policy fail
+// at once. Its state is only touched on the parent's loop.
+type Pool struct {
+	vm     *sobek.Runtime
+	el     *eventloop.EventLoop
+	obj    *sobek.Object
+	spawn  func(Parent) (Handle, error)
+	policy Supervision
+
+	size       int
+	maxQueue   int           // -1 for unbounded
+	rejectFull bool          // Reject tasks beyond a full queue rather than make them wait
+	timeout    time.Duration // Default per-task timeout, 0 for none
+
+	workers   []*poolWorker
+	queue     []*poolTask
+	waiting   []*poolTask // Tasks waiting for space in the queue
+	nextID    uint64
+	respawns  int
+	completed int
+	failed    int
+	closed    error
+}
+
+type poolWorker struct {
+	sup  *supervisor
+	task *poolTask
+	err  error // Last error the worker reported, failing its task if it exits
+}
+
+type poolTask struct {
+	id      uint64
+	payload interface{}
+	timer   *time.Timer
+	worker  *poolWorker
+	done    bool
+
+	resolve, reject func(interface{}) error
+}
+
+func newPool(vm *sobek.Runtime, el *eventloop.EventLoop, spawner Spawner, call sobek.ConstructorCall) *Pool {
+	script := parseScript(vm, call.Argument(0))
+	if script.Code != "" {
+		script.Code = "self.ontask = (" + intrinsics.FunctionSource(call.Argument(0)) + ");"
+	}
+	opts := parseOptions(vm, call.Argument(1))
+
+	p := &Pool{
+		vm:       vm,
+		el:       el,
+		policy:   opts.Supervise,
+		size:     runtime.NumCPU(),
+		maxQueue: -1,
+		spawn: func(parent Parent) (Handle, error) {
+			return spawner(script, opts, parent)
+		},
+	}
+	if o, ok := call.Argument(1).(*sobek.Object); ok {
+		if v := o.Get("size"); v != nil && !sobek.IsUndefined(v) {
+			p.size = int(v.ToInteger())
+		}
+		if v := o.Get("maxQueue"); v != nil && !sobek.IsUndefined(v) && !math.IsInf(v.ToFloat(), 1) {
+			p.maxQueue = int(v.ToInteger())
+		}
+		if v := o.Get("overflow"); v != nil && !sobek.IsUndefined(v) {
+			switch v.String() {
+			case "wait":
+			case "reject":
+				p.rejectFull = true
+			default:
+				panic(vm.NewTypeError("unknown overflow policy %q", v.String()))
+			}
+		}
+		if v := o.Get("timeout"); v != nil && !sobek.IsUndefined(v) {
+			p.timeout = time.Duration(v.ToFloat() * float64(time.Millisecond))
+		}
+	}
+	if p.size < 1 {
+		panic(vm.NewTypeError("WorkerPool size must be at least 1"))
+	}
+	if p.maxQueue < 0 {
+		p.maxQueue = -1
+	}
+
+	for i := 0; i < p.size; i++ {
+		w := p.newWorker()
+		p.workers = append(p.workers, w)
+		if err := w.sup.start(); err != nil {
+			p.terminate(err)
+			panic(vm.NewGoError(err))
+		}
+	}
+	return p
+}
+
+func (p *Pool) newWorker() *poolWorker {
+	w := &poolWorker{}
+	w.sup = newSupervisor(p.el, p.policy, p.spawn, supervisorHooks{
+		message: func(msg interface{}) { p.onMessage(w, msg) },
+		error:   func(ev *ErrorEvent) { p.onError(w, ev) },
+		exit:    func(code int, restarting bool) { p.onExit(w, code, restarting) },
+		restart: func(int, time.Duration, int) {
+			p.respawns++
+			p.next(w)
+		},
+	})
+	return w
+}
+
+// Object returns the JS WorkerPool.
+func (p *Pool) Object() *sobek.Object {
+	if p.obj != nil {
+		return p.obj
+	}
+	obj := p.vm.NewObject()
+	_ = obj.Set("run", p.run)
+	_ = obj.Set("stats", func(sobek.FunctionCall) sobek.Value { return p.stats() })
+	_ = obj.Set("terminate", func(sobek.FunctionCall) sobek.Value {
+		p.terminate(errors.New("worker pool terminated"))
+		return sobek.Undefined()
+	})
+	_ = obj.Set("size", p.size)
+	p.obj = obj
+	return obj
+}
+
+// run implements pool.run(task, { timeout, transfer }).
+func (p *Pool) run(call sobek.FunctionCall) sobek.Value {
+	var transfer []sobek.Value
+	timeout := p.timeout
+	if o, ok := call.Argument(1).(*sobek.Object); ok {
+		if v := o.Get("timeout"); v != nil && !sobek.IsUndefined(v) {
+			timeout = time.Duration(v.ToFloat() * float64(time.Millisecond))
+		}
+		if list, ok := o.Get("transfer").(*sobek.Object); ok {
+			n := int(list.Get("length").ToInteger())
+			for i := 0; i < n; i++ {
+				transfer = append(transfer, list.Get(fmt.Sprintf("%d", i)))
+			}
+		}
+	}
+
+	payload, err := intrinsics.ExportTransferable(p.vm, call.Argument(0), transfer)
+	if err != nil {
+		panic(errorObject(p.vm, err))
+	}
+
+	promise, resolve, reject := p.vm.NewPromise()
+	switch {
+	case p.closed != nil:
+		_ = reject(errorObject(p.vm, p.closed))
+		return p.vm.ToValue(promise)
+	case p.rejectFull && p.idle() == nil && p.queueFull():
+		full := &intrinsics.ParallelError{Name: "QueueFullError", Message: fmt.Sprintf("worker pool queue is full (%d tasks)", len(p.queue))}
+		_ = reject(full.Object(p.vm))
+		return p.vm.ToValue(promise)
+	}
+
+	p.nextID++
+	t := &poolTask{id: p.nextID, payload: payload, resolve: resolve, reject: reject}
+	p.el.WGAdd(1)
+	if timeout > 0 {
+		t.timer = time.AfterFunc(timeout, func() {
+			p.el.RunOnLoop(func() { p.expire(t, timeout) })
+		})
+	}
+
+	if w := p.idle(); w != nil {
+		p.assign(w, t)
+	} else if p.queueFull() {
+		p.waiting = append(p.waiting, t)
+	} else {
+		p.queue = append(p.queue, t)
+	}
+	return p.vm.ToValue(promise)
+}
+
+func (p *Pool) queueFull() bool {
+	return p.maxQueue >= 0 && len(p.queue) >= p.maxQueue
+}
+
+// admit moves waiting tasks into the queue while it has space.
+func (p *Pool) admit() {
+	for len(p.waiting) > 0 && !p.queueFull() {
+		p.queue = append(p.queue, p.waiting[0])
+		p.waiting = p.waiting[1:]
+	}
+}
+
+func (p *Pool) idle() *poolWorker {
+	for _, w := range p.workers {
+		if w.task == nil && w.sup.up() {
+			return w
+		}
+	}
+	return nil
+}
+
+func (p *Pool) assign(w *poolWorker, t *poolTask) {
+	w.task, t.worker = t, w
+	w.sup.postMessage(&task{id: t.id, payload: t.payload})
+}
+
+// next hands the oldest queued task to w, if it is still idle, making room
+// in the queue for a waiting one. With a maxQueue of 0 waiting tasks go to
+// workers directly.
+func (p *Pool) next(w *poolWorker) {
+	if p.closed != nil || w.task != nil || !w.sup.up() {
+		return
+	}
+	var t *poolTask
+	switch {
+	case len(p.queue) > 0:
+		t, p.queue = p.queue[0], p.queue[1:]
+	case len(p.waiting) > 0:
+		t, p.waiting = p.waiting[0], p.waiting[1:]
+	default:
+		return
+	}
+	p.assign(w, t)
+	p.admit()
+}
+
+// settle resolves t with val, or rejects it with err, exactly once.
+func (p *Pool) settle(t *poolTask, val sobek.Value, err error) {
+	if t.done {
+		return
+	}
+	t.done = true
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	defer p.el.WGDone()
+	if err != nil {
+		p.failed++
+		_ = t.reject(errorObject(p.vm, err))
+		return
+	}
+	p.completed++
+	_ = t.resolve(val)
+}
+
+func (p *Pool) onMessage(w *poolWorker, msg interface{}) {
+	res, ok := msg.(*taskResult)
+	if !ok {
+		// Plain postMessage from a pool worker
+		if err := deliver(p.el, p.Object(), msg); err != nil {
+			reportUnhandled(p.el, fmt.Errorf("worker pool onmessage: %w", err))
+		}
+		return
+	}
+	t := w.task
+	if t == nil || t.id != res.id {
+		return
+	}
+	w.task = nil
+
+	err := res.err
+	var val sobek.Value
+	if err == nil {
+		val, err = intrinsics.ImportTransferable(p.el, res.value)
+	}
+	p.settle(t, val, err)
+	p.next(w)
+}
+
+// onError dispatches an error a worker reported. Should the worker exit, the
+// error fails the task it was running.
+func (p *Pool) onError(w *poolWorker, ev *ErrorEvent) {
+	w.err = ev.Err
+	if handled, err := dispatch(p.vm, p.Object(), "onerror", errorEventObject(p.vm, ev)); err != nil {
+		reportUnhandled(p.el, fmt.Errorf("worker pool onerror: %w", err))
+	} else if !handled && w.task == nil {
+		reportUnhandled(p.el, fmt.Errorf("worker %s: %s", ev.Filename, ev.Message))
+	}
+}
+
+// onExit fails the task of a worker that exited. With the one-for-all
+// strategy a crash restarts the other workers too. Once no worker is left
+// the pool closes.
+func (p *Pool) onExit(w *poolWorker, code int, restarting bool) {
+	err := w.err
+	if err == nil {
+		err = fmt.Errorf("worker exited with code %d", code)
+	}
+	w.err = nil
+	if t := w.task; t != nil {
+		w.task = nil
+		p.settle(t, nil, err)
+	}
+
+	if restarting && code != 0 && p.policy.Strategy == OneForAll {
+		for _, other := range p.workers {
+			if other != w && other.sup.up() {
+				p.replace(other, err)
+			}
+		}
+	}
+
+	for _, other := range p.workers {
+		if !other.sup.stopped {
+			return
+		}
+	}
+	p.terminate(fmt.Errorf("worker pool has no workers left: %w", err))
+}
+
+// expire fails t after its timeout. A running task cannot be interrupted,
+// so its worker is replaced.
+func (p *Pool) expire(t *poolTask, timeout time.Duration) {
+	if t.done {
+		return
+	}
+	err := &intrinsics.ParallelError{Name: "TimeoutError", Message: fmt.Sprintf("task timed out after %v", timeout)}
+	if t.worker != nil {
+		p.replace(t.worker, err)
+		return
+	}
+	p.queue = removeTask(p.queue, t)
+	p.waiting = removeTask(p.waiting, t)
+	p.admit()
+	p.settle(t, nil, err)
+}
+
+func removeTask(tasks []*poolTask, t *poolTask) []*poolTask {
+	for i, q := range tasks {
+		if q == t {
+			return append(tasks[:i], tasks[i+1:]...)
+		}
+	}
+	return tasks
+}
+
+// replace restarts w at once, failing its task with err.
+func (p *Pool) replace(w *poolWorker, err error) {
+	t := w.task
+	w.task, w.err = nil, nil
+	if p.closed == nil {
+		if err := w.sup.replace(); err != nil {
+			p.terminate(err)
+		} else {
+			p.respawns++
+		}
+	}
+	if t != nil {
+		p.settle(t, nil, err)
+	}
+	p.next(w)
+}
+
+// terminate stops every worker and fails running and queued tasks with err.
+func (p *Pool) terminate(err error) {
+	if p.closed != nil {
+		return
+	}
+	p.closed = err
+	for _, w := range p.workers {
+		if t := w.task; t != nil {
+			p.settle(t, nil, err)
+		}
+		w.task = nil
+		w.sup.terminate()
+	}
+	for _, t := range append(p.queue, p.waiting...) {
+		p.settle(t, nil, err)
+	}
+	p.queue, p.waiting = nil, nil
+}
+
+func (p *Pool) stats() sobek.Value {
+	busy, idle := 0, 0
+	if p.closed == nil {
+		for _, w := range p.workers {
+			switch {
+			case w.task != nil:
+				busy++
+			case w.sup.up():
+				idle++
+			}
+		}
+	}
+	return p.vm.ToValue(map[string]interface{}{
+		"size":      p.size,
+		"busy":      busy,
+		"idle":      idle,
+		"queued":    len(p.queue),
+		"waiting":   len(p.waiting),
+		"respawns":  p.respawns,
+		"completed": p.completed,
+		"failed":    p.failed,
+	})
+}
@@ -0,0 +1,268 @@
+package worker
+
+import (
+	"math"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/eventloop"
+)
+
+// RestartPolicy decides which exits of a worker restart it.
+type RestartPolicy string
+
+const (
+	Permanent RestartPolicy = "permanent" // Restarted whatever its exit code
+	Transient RestartPolicy = "transient" // Restarted after a non-zero exit
+	Temporary RestartPolicy = "temporary" // Never restarted
+)
+
+// Strategy decides which workers of a WorkerPool restart when one crashes.
+type Strategy string
+
+const (
+	OneForOne Strategy = "one-for-one" // Only the crashed worker
+	OneForAll Strategy = "one-for-all" // Every worker of the pool
+)
+
+// Supervision configures how a worker is restarted after it exits.
+type Supervision struct {
+	Restart     RestartPolicy
+	Strategy    Strategy
+	MaxRestarts int           // Restarts allowed within Window before giving up
+	Window      time.Duration // Period over which restarts are counted
+	Backoff     time.Duration // Delay of the first restart, doubled for each recent one
+	MaxBackoff  time.Duration
+}
+
+// DefaultSupervision restarts crashed workers, at most 5 times a minute,
+// waiting from 100ms up to 30s.
+func DefaultSupervision() Supervision {
+	return Supervision{
+		Restart:     Transient,
+		Strategy:    OneForOne,
+		MaxRestarts: 5,
+		Window:      time.Minute,
+		Backoff:     100 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+// parseSupervision reads the supervise option: { restart, strategy,
+// maxRestarts, window, backoff, maxBackoff } with durations in milliseconds.
+func parseSupervision(vm *sobek.Runtime, obj *sobek.Object) Supervision {
+	sup := DefaultSupervision()
+	if v := obj.Get("restart"); v != nil && !sobek.IsUndefined(v) {
+		switch p := RestartPolicy(v.String()); p {
+		case Permanent, Transient, Temporary:
+			sup.Restart = p
+		default:
+			panic(vm.NewTypeError("unknown restart policy %q", v.String()))
+		}
+	}
+	if v := obj.Get("strategy"); v != nil && !sobek.IsUndefined(v) {
+		switch s := Strategy(v.String()); s {
+		case OneForOne, OneForAll:
+			sup.Strategy = s
+		default:
+			panic(vm.NewTypeError("unknown restart strategy %q", v.String()))
+		}
+	}
+	if v := obj.Get("maxRestarts"); v != nil && !sobek.IsUndefined(v) {
+		if n := v.ToFloat(); n >= math.MaxInt32 {
+			sup.MaxRestarts = math.MaxInt32
+		} else if n > 0 {
+			sup.MaxRestarts = int(n)
+		} else {
+			sup.MaxRestarts = 0
+		}
+	}
+	duration := func(name string, d *time.Duration) {
+		if v := obj.Get(name); v != nil && !sobek.IsUndefined(v) && v.ToFloat() >= 0 {
+			*d = time.Duration(v.ToFloat() * float64(time.Millisecond))
+		}
+	}
+	duration("window", &sup.Window)
+	duration("backoff", &sup.Backoff)
+	duration("maxBackoff", &sup.MaxBackoff)
+	return sup
+}
+
+// supervisorHooks receive, on the parent's loop, what the current run of a
+// supervised worker reports.
+type supervisorHooks struct {
+	message func(msg interface{})
+	error   func(ev *ErrorEvent)
+	exit    func(code int, restarting bool)
+	restart func(attempt int, delay time.Duration, code int)
+}
+
+// supervisor keeps a worker running, starting a new run of its script after
+// it exits as its Supervision allows. Its state is only touched on the
+// parent's loop; reports of runs it has moved past are dropped.
+type supervisor struct {
+	el     *eventloop.EventLoop
+	spawn  func(Parent) (Handle, error)
+	policy Supervision
+	hooks  supervisorHooks
+
+	handle   Handle        // nil while the worker is down
+	run      int           // Current run, to ignore reports of earlier ones
+	pending  []interface{} // Messages posted while the worker was down
+	recent   []time.Time   // Restarts within the window
+	restarts int
+	timer    *time.Timer
+	stopped  bool
+}
+
+func newSupervisor(el *eventloop.EventLoop, policy Supervision, spawn func(Parent) (Handle, error), hooks supervisorHooks) *supervisor {
+	return &supervisor{el: el, spawn: spawn, policy: policy, hooks: hooks}
+}
+
+// start begins a new run of the worker, delivering messages posted while it
+// was down.
+func (s *supervisor) start() error {
+	s.run++
+	run := s.run
+	current := func(f func()) {
+		s.el.RunOnLoop(func() {
+			if s.run == run && !s.stopped {
+				f()
+			}
+		})
+	}
+
+	handle, err := s.spawn(Parent{
+		OnMessage: func(msg interface{}) {
+			current(func() { s.hooks.message(msg) })
+		},
+		OnError: func(ev *ErrorEvent) {
+			current(func() { s.hooks.error(ev) })
+		},
+		OnExit: func(code int) {
+			current(func() { s.exited(code) })
+		},
+	})
+	if err != nil {
+		return err
+	}
+	s.handle = handle
+	for _, msg := range s.pending {
+		handle.PostMessage(msg)
+	}
+	s.pending = nil
+	return nil
+}
+
+// up reports whether the worker is running.
+func (s *supervisor) up() bool {
+	return s.handle != nil && !s.stopped
+}
+
+func (s *supervisor) postMessage(msg interface{}) {
+	switch {
+	case s.stopped:
+	case s.handle == nil:
+		s.pending = append(s.pending, msg)
+	default:
+		s.handle.PostMessage(msg)
+	}
+}
+
+// exited handles the worker stopping by itself: it is restarted after a
+// backoff when its policy and the restart limit allow, or stays down.
+func (s *supervisor) exited(code int) {
+	s.handle = nil
+	delay, restarting := s.plan(code)
+	if !restarting {
+		s.stopped = true
+		s.pending = nil
+	}
+	s.hooks.exit(code, restarting)
+	if !restarting || s.stopped {
+		return
+	}
+
+	run := s.run
+	s.timer = time.AfterFunc(delay, func() {
+		s.el.RunOnLoop(func() {
+			if s.run != run || s.stopped {
+				return
+			}
+			s.timer = nil
+			s.restarts++
+			if err := s.start(); err != nil {
+				s.hooks.error(&ErrorEvent{Message: err.Error(), Err: err})
+				s.exited(1)
+				return
+			}
+			s.hooks.restart(s.restarts, delay, code)
+		})
+	})
+}
+
+// plan returns the delay before restarting a worker that exited with code,
+// and false when it should stay down.
+func (s *supervisor) plan(code int) (time.Duration, bool) {
+	switch s.policy.Restart {
+	case Temporary:
+		return 0, false
+	case Transient:
+		if code == 0 {
+			return 0, false
+		}
+	}
+
+	now := time.Now()
+	recent := s.recent[:0]
+	for _, at := range s.recent {
+		if now.Sub(at) < s.policy.Window {
+			recent = append(recent, at)
+		}
+	}
+	s.recent = recent
+	if len(recent) >= s.policy.MaxRestarts {
+		return 0, false
+	}
+
+	delay := s.policy.Backoff
+	for i := 0; i < len(recent) && delay < s.policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.policy.MaxBackoff {
+		delay = s.policy.MaxBackoff
+	}
+	s.recent = append(s.recent, now)
+	return delay, true
+}
+
+// replace stops the current run and starts a new one at once, outside of the
+// restart policy; WorkerPool uses it for workers stuck on a task.
+func (s *supervisor) replace() error {
+	if s.stopped {
+		return nil
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.handle != nil {
+		s.handle.Terminate()
+		s.handle = nil
+	}
+	return s.start()
+}
+
+// terminate stops the worker for good.
+func (s *supervisor) terminate() {
+	s.stopped = true
+	s.pending = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.handle != nil {
+		s.handle.Terminate()
+		s.handle = nil
+	}
+}
@@ -1,12 +1,188 @@
 // MODULE: typego:worker
 declare module "typego:worker" {
+    export interface WorkerOptions {
+        /** Name of the worker, visible as self.name inside it. */
+        name?: string;
+        /** Entries added to process.env inside the worker. */
+        env?: Record<string, string>;
+        /**
+         * Memory limit of the worker's runtime in bytes. Defaults to the
+         * parent's. Heap segments the worker creates count against it and
+         * against the parent's limit.
+         */
+        memoryLimit?: number;
+        /** How the worker is restarted after it exits. */
+        supervise?: SupervisionOptions;
+    }
+
+    export interface SupervisionOptions {
+        /**
+         * Which exits restart the worker: always ("permanent"), after a
+         * non-zero exit code ("transient", the default) or never
+         * ("temporary").
+         */
+        restart?: "permanent" | "transient" | "temporary";
+        /**
+         * For a WorkerPool, whether a crash restarts only the crashed worker
+         * ("one-for-one", the default) or every worker ("one-for-all").
+         */
+        strategy?: "one-for-one" | "one-for-all";
+        /** Restarts allowed within window before giving up. Defaults to 5. */
+        maxRestarts?: number;
+        /** Period in milliseconds over which restarts count. Defaults to 60000. */
+        window?: number;
+        /** Delay of the first restart in milliseconds, doubled for each recent one. Defaults to 100. */
+        backoff?: number;
+        /** Longest delay between restarts in milliseconds. Defaults to 30000. */
+        maxBackoff?: number;
+    }
+
+    export interface MessageEvent<T = any> {
+        readonly type: "message";
+        readonly data: T;
+        readonly target: Worker;
+    }
+
+    export interface MessageErrorEvent {
+        readonly type: "messageerror";
+        readonly data: null;
+        /** Why the message could not be deserialized. */
+        readonly error: Error;
+        readonly target: Worker;
+    }
+
+    export interface ErrorEvent {
+        readonly type: "error";
+        readonly message: string;
+        readonly filename: string;
+        readonly lineno: number;
+        readonly colno: number;
+        readonly error: Error;
+        readonly target: Worker;
+    }
+
+    export interface ExitEvent {
+        readonly type: "exit";
+        /** The code passed to process.exit, or 1 when the script failed. */
+        readonly code: number;
+        /** Whether the worker will be restarted. */
+        readonly restarting: boolean;
+        readonly target: Worker;
+    }
+
+    export interface RestartEvent {
+        readonly type: "restart";
+        /** Restarts of this worker so far. */
+        readonly attempt: number;
+        /** Milliseconds waited before restarting. */
+        readonly delay: number;
+        /** Exit code of the previous run. */
+        readonly code: number;
+        readonly target: Worker;
+    }
+
+    /**
+     * ArrayBuffers to move rather than copy. Moved buffers are detached in
+     * the sender.
+     */
+    export type Transfer = ArrayBuffer[] | { transfer?: ArrayBuffer[] };
+
     export interface Worker {
-        postMessage(msg: any): void;
+        readonly name: string;
+        /**
+         * Sends a structured clone of msg: primitives, arrays, plain objects,
+         * Maps, Sets, Dates, Errors, ArrayBuffers and typed arrays.
+         * SharedArrayBuffers and segments from typego:memory makeShared are
+         * passed by reference.
+         * Throws a DataCloneError for values that cannot be cloned.
+         */
+        postMessage(msg: any, transfer?: Transfer): void;
         terminate(): void;
-        onmessage: (msg: { data: any }) => void;
+        onmessage: ((ev: MessageEvent) => void) | null;
+        onmessageerror: ((ev: MessageErrorEvent) => void) | null;
+        /** Compile errors and uncaught exceptions of the worker. */
+        onerror: ((ev: ErrorEvent) => void) | null;
+        /**
+         * The worker stopped by itself: it called process.exit or
+         * self.close(), or its script failed to compile or run. Messages
+         * posted while it restarts are delivered to the new run.
+         */
+        onexit: ((ev: ExitEvent) => void) | null;
+        onrestart: ((ev: RestartEvent) => void) | null;
     }
     export var Worker: {
-        new(scriptPath: string): Worker;
+        /**
+         * Starts a worker running a script, or the body of a function. The
+         * function runs in the worker's own runtime, so it cannot use
+         * closures of the caller.
+         */
+        new(script: string | (() => void), options?: WorkerOptions): Worker;
+    }
+
+    export interface WorkerPoolOptions extends WorkerOptions {
+        /** Number of workers. Defaults to the number of CPUs. */
+        size?: number;
+        /**
+         * Tasks that may wait for a free worker. Unbounded by default.
+         */
+        maxQueue?: number;
+        /**
+         * What run() does once the queue is full: wait for space in it (the
+         * default), or reject with a QueueFullError.
+         */
+        overflow?: "wait" | "reject";
+        /** Default per-task timeout in milliseconds. */
+        timeout?: number;
+    }
+
+    export interface RunOptions {
+        /**
+         * Timeout in milliseconds, counted from run(); the worker running
+         * the task is restarted.
+         */
+        timeout?: number;
+        /** ArrayBuffers to move to the worker rather than copy. */
+        transfer?: ArrayBuffer[];
+    }
+
+    export interface WorkerPoolStats {
+        size: number;
+        busy: number;
+        idle: number;
+        queued: number;
+        /** Tasks waiting for space in a full queue. */
+        waiting: number;
+        respawns: number;
+        completed: number;
+        failed: number;
+    }
+
+    export interface WorkerPool<T = any, R = any> {
+        readonly size: number;
+        /**
+         * Runs task on the next free worker, which handles it with
+         * self.ontask. Resolves with the handler's result, or rejects with
+         * what it threw, a TimeoutError, a QueueFullError or the error that
+         * made the worker exit. While the queue is full the promise also
+         * waits for space in it, so awaiting each run() paces the caller.
+         */
+        run(task: T, options?: RunOptions): Promise<R>;
+        stats(): WorkerPoolStats;
+        /**
+         * Stops every worker; pending tasks reject. The pool also closes
+         * once all its workers have exited without restarting.
+         */
+        terminate(): void;
+        /** Messages the workers post with self.postMessage. */
+        onmessage: ((ev: MessageEvent) => void) | null;
+        onerror: ((ev: ErrorEvent) => void) | null;
+    }
+    export var WorkerPool: {
+        /**
+         * Starts a pool running a script that sets self.ontask, or running
+         * the given function as the task handler.
+         */
+        new <T = any, R = any>(script: string | ((task: T) => R | Promise<R>), options?: WorkerPoolOptions): WorkerPool<T, Awaited<R>>;
     }
 }
 // END: typego:worker
@@ -1,67 +1,353 @@
 // Package worker provides the typego:worker module for multi-threading.
+//
+// Workers follow the Web Worker API: messages are structured clones, with
+// ArrayBuffers moved through transfer lists and typego:memory segments
+// passed by reference, and failures surface as error and messageerror
+// events instead of being printed.
 package worker
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
 	"github.com/repyh/typego/eventloop"
 )
 
+// Script is what a worker runs: a script path, or inline JS code.
+type Script struct {
+	Path string
+	Code string
+}
+
+// Options are the options of new Worker(script, options).
+type Options struct {
+	Name        string
+	Env         map[string]string
+	MemoryLimit uint64 // Bytes; 0 inherits the parent's limit
+	Supervise   Supervision
+}
+
+// ErrorEvent describes a failure inside a worker: a script that did not
+// compile, or an uncaught exception.
+type ErrorEvent struct {
+	Message  string
+	Filename string
+	Lineno   int
+	Colno    int
+	Err      error
+}
+
+// NewErrorEvent describes err, locating it from the stack of a JS exception.
+// It must be called on the JS thread of the runtime that raised err.
+func NewErrorEvent(err error, filename string) *ErrorEvent {
+	ev := &ErrorEvent{Message: err.Error(), Filename: filename, Err: err}
+	var ex *sobek.Exception
+	if errors.As(err, &ex) {
+		pe := intrinsics.ParallelErrorOf(ex.Value())
+		ev.Message, ev.Err = pe.Error(), pe
+		if stack := ex.Stack(); len(stack) > 0 {
+			pos := stack[0].Position()
+			ev.Lineno, ev.Colno = pos.Line, pos.Column
+			if pos.Filename != "" {
+				ev.Filename = pos.Filename
+			}
+		}
+	}
+	return ev
+}
+
+// Parent receives what a worker reports. All are called from the worker's
+// goroutines; messages are exported with intrinsics.ExportTransferable.
+// OnExit is called once when the worker stops by itself, with the code it
+// passed to process.exit, or 1 when its script failed to compile or run. It
+// is not called after Terminate.
+type Parent struct {
+	OnMessage func(msg interface{})
+	OnError   func(ev *ErrorEvent)
+	OnExit    func(code int)
+}
+
 type Handle interface {
-	PostMessage(msg sobek.Value)
+	PostMessage(msg interface{})
 	Terminate()
 }
 
-type Spawner func(scriptPath string, onMessage func(sobek.Value)) (Handle, error)
+type Spawner func(script Script, opts Options, parent Parent) (Handle, error)
 
 func Register(vm *sobek.Runtime, el *eventloop.EventLoop, spawner Spawner) {
 	obj := vm.NewObject()
 
 	_ = obj.Set("Worker", func(call sobek.ConstructorCall) *sobek.Object {
-		scriptPath := call.Argument(0).String()
+		script := parseScript(vm, call.Argument(0))
+		opts := parseOptions(vm, call.Argument(1))
 
 		workerObj := vm.NewObject()
 
-		onWorkerMessage := func(msg sobek.Value) {
-			el.RunOnLoop(func() {
-				if onMsg := workerObj.Get("onmessage"); onMsg != nil {
-					if fn, ok := sobek.AssertFunction(onMsg); ok {
-						event := vm.NewObject()
-						_ = event.Set("data", msg)
-						_, _ = fn(workerObj, event)
-					}
+		sup := newSupervisor(el, opts.Supervise, func(parent Parent) (Handle, error) {
+			return spawner(script, opts, parent)
+		}, supervisorHooks{
+			message: func(msg interface{}) {
+				if err := deliver(el, workerObj, msg); err != nil {
+					reportUnhandled(el, fmt.Errorf("worker onmessage: %w", err))
 				}
-			})
-		}
-
-		handle, err := spawner(scriptPath, onWorkerMessage)
-		if err != nil {
+			},
+			error: func(ev *ErrorEvent) {
+				handled, err := dispatch(vm, workerObj, "onerror", errorEventObject(vm, ev))
+				switch {
+				case err != nil:
+					reportUnhandled(el, fmt.Errorf("worker onerror: %w", err))
+				case !handled:
+					reportUnhandled(el, fmt.Errorf("worker %s: %s", ev.Filename, ev.Message))
+				}
+			},
+			exit: func(code int, restarting bool) {
+				event := vm.NewObject()
+				_ = event.Set("code", code)
+				_ = event.Set("restarting", restarting)
+				if _, err := dispatch(vm, workerObj, "onexit", event); err != nil {
+					reportUnhandled(el, fmt.Errorf("worker onexit: %w", err))
+				}
+			},
+			restart: func(attempt int, delay time.Duration, code int) {
+				event := vm.NewObject()
+				_ = event.Set("attempt", attempt)
+				_ = event.Set("delay", delay.Milliseconds())
+				_ = event.Set("code", code)
+				if _, err := dispatch(vm, workerObj, "onrestart", event); err != nil {
+					reportUnhandled(el, fmt.Errorf("worker onrestart: %w", err))
+				}
+			},
+		})
+		if err := sup.start(); err != nil {
 			panic(vm.NewGoError(err))
 		}
 
+		_ = workerObj.Set("name", opts.Name)
 		_ = workerObj.Set("postMessage", func(call sobek.FunctionCall) sobek.Value {
-			msg := call.Argument(0)
-			handle.PostMessage(msg)
+			sup.postMessage(exportMessage(vm, call))
 			return sobek.Undefined()
 		})
 
 		_ = workerObj.Set("terminate", func(call sobek.FunctionCall) sobek.Value {
-			handle.Terminate()
+			sup.terminate()
 			return sobek.Undefined()
 		})
 
 		return workerObj
 	})
 
+	_ = obj.Set("WorkerPool", func(call sobek.ConstructorCall) *sobek.Object {
+		return newPool(vm, el, spawner, call).Object()
+	})
+
 	_ = vm.Set("__typego_worker__", obj)
 }
 
-func RegisterSelf(vm *sobek.Runtime, postToParent func(msg sobek.Value)) {
+// Self is the worker side of a Worker: its runtime's global scope.
+type Self struct {
+	el       *eventloop.EventLoop
+	parent   Parent
+	file     string
+	exited   chan int
+	exitOnce sync.Once
+}
+
+// exitSignal interrupts the worker's JS when it calls process.exit.
+var exitSignal = errors.New("worker exited")
+
+// RegisterSelf installs self, postMessage, close, name and process.exit in a
+// worker's runtime. Messages posted by the worker go to parent.OnMessage.
+func RegisterSelf(el *eventloop.EventLoop, name, file string, parent Parent) *Self {
+	vm := el.VM
+	s := &Self{el: el, parent: parent, file: file, exited: make(chan int, 1)}
 	self := vm.GlobalObject()
 	_ = vm.Set("self", self)
+	_ = self.Set("name", name)
 
 	_ = self.Set("postMessage", func(call sobek.FunctionCall) sobek.Value {
-		msg := call.Argument(0)
-		postToParent(msg)
+		parent.OnMessage(exportMessage(vm, call))
+		return sobek.Undefined()
+	})
+
+	exit := func(code int) {
+		s.Exit(code)
+		vm.Interrupt(exitSignal)
+	}
+	_ = self.Set("close", func(sobek.FunctionCall) sobek.Value {
+		exit(0)
 		return sobek.Undefined()
 	})
+	if proc, ok := vm.Get("process").(*sobek.Object); ok {
+		_ = proc.Set("exit", func(call sobek.FunctionCall) sobek.Value {
+			code := call.Argument(0)
+			if sobek.IsUndefined(code) {
+				code = proc.Get("exitCode")
+			}
+			if code == nil || sobek.IsUndefined(code) || sobek.IsNull(code) {
+				exit(0)
+			} else {
+				exit(int(code.ToInteger()))
+			}
+			return sobek.Undefined()
+		})
+	}
+
+	return s
+}
+
+// Exit ends the worker with code. Only the first exit counts.
+func (s *Self) Exit(code int) {
+	s.exitOnce.Do(func() { s.exited <- code })
+}
+
+// Exited yields the worker's exit code once it exits; the worker's engine
+// should then be closed and the code passed to Parent.OnExit.
+func (s *Self) Exited() <-chan int {
+	return s.exited
+}
+
+// Report sends an exception of the worker's script to the parent as an error
+// event. The interruption caused by process.exit is not reported. It must be
+// called on the worker's JS thread.
+func (s *Self) Report(err error) {
+	if errors.Is(err, exitSignal) {
+		return
+	}
+	s.parent.OnError(NewErrorEvent(err, s.file))
+}
+
+// Deliver dispatches a message from the parent to self.onmessage, reporting
+// an exception it throws to the parent. It must be called on the worker's
+// loop.
+func (s *Self) Deliver(msg interface{}) {
+	if t, ok := msg.(*task); ok {
+		s.runTask(t)
+		return
+	}
+	if err := deliver(s.el, s.el.VM.GlobalObject(), msg); err != nil {
+		s.Report(err)
+	}
+}
+
+// deliver dispatches msg to target.onmessage, or a messageerror event when it
+// cannot be deserialized here. It returns the exception a handler threw.
+func deliver(el *eventloop.EventLoop, target *sobek.Object, msg interface{}) error {
+	vm := el.VM
+	event := vm.NewObject()
+	data, err := intrinsics.ImportTransferable(el, msg)
+	if err != nil {
+		_ = event.Set("data", sobek.Null())
+		_ = event.Set("error", errorObject(vm, err))
+		_, err = dispatch(vm, target, "onmessageerror", event)
+		return err
+	}
+	_ = event.Set("data", data)
+	_, err = dispatch(vm, target, "onmessage", event)
+	return err
+}
+
+// dispatch calls target[handler](event), reporting whether a handler was set
+// and the exception it threw.
+func dispatch(vm *sobek.Runtime, target *sobek.Object, handler string, event *sobek.Object) (bool, error) {
+	fn, ok := sobek.AssertFunction(target.Get(handler))
+	if !ok {
+		return false, nil
+	}
+	_ = event.Set("type", strings.TrimPrefix(handler, "on"))
+	_ = event.Set("target", target)
+	_, err := fn(target, event)
+	return true, err
+}
+
+// exportMessage clones postMessage(msg, transfer) for another runtime, where
+// transfer is an array of ArrayBuffers or { transfer: [...] }.
+func exportMessage(vm *sobek.Runtime, call sobek.FunctionCall) interface{} {
+	var transfer []sobek.Value
+	if arg, ok := call.Argument(1).(*sobek.Object); ok {
+		list := arg
+		if arg.ClassName() != "Array" {
+			list, _ = arg.Get("transfer").(*sobek.Object)
+		}
+		if list != nil {
+			n := int(list.Get("length").ToInteger())
+			for i := 0; i < n; i++ {
+				transfer = append(transfer, list.Get(fmt.Sprintf("%d", i)))
+			}
+		}
+	}
+
+	msg, err := intrinsics.ExportTransferable(vm, call.Argument(0), transfer)
+	if err != nil {
+		panic(errorObject(vm, err))
+	}
+	return msg
+}
+
+func parseScript(vm *sobek.Runtime, arg sobek.Value) Script {
+	if _, ok := sobek.AssertFunction(arg); ok {
+		return Script{Code: "(" + intrinsics.FunctionSource(arg) + ")();"}
+	}
+	if sobek.IsUndefined(arg) || sobek.IsNull(arg) {
+		panic(vm.NewTypeError("Worker requires a script path or a function"))
+	}
+	return Script{Path: strings.TrimPrefix(arg.String(), "file://")}
+}
+
+func parseOptions(vm *sobek.Runtime, arg sobek.Value) Options {
+	opts := Options{Supervise: DefaultSupervision()}
+	obj, ok := arg.(*sobek.Object)
+	if !ok {
+		return opts
+	}
+	if v := obj.Get("name"); v != nil && !sobek.IsUndefined(v) {
+		opts.Name = v.String()
+	}
+	if v := obj.Get("memoryLimit"); v != nil && !sobek.IsUndefined(v) {
+		if limit := v.ToInteger(); limit > 0 {
+			opts.MemoryLimit = uint64(limit)
+		}
+	}
+	if env, ok := obj.Get("env").(*sobek.Object); ok {
+		opts.Env = make(map[string]string)
+		for _, key := range env.Keys() {
+			opts.Env[key] = env.Get(key).String()
+		}
+	}
+	if sup, ok := obj.Get("supervise").(*sobek.Object); ok {
+		opts.Supervise = parseSupervision(vm, sup)
+	}
+	return opts
+}
+
+func errorEventObject(vm *sobek.Runtime, ev *ErrorEvent) *sobek.Object {
+	event := vm.NewObject()
+	_ = event.Set("message", ev.Message)
+	_ = event.Set("filename", ev.Filename)
+	_ = event.Set("lineno", ev.Lineno)
+	_ = event.Set("colno", ev.Colno)
+	_ = event.Set("error", errorObject(vm, ev.Err))
+	return event
+}
+
+// errorObject returns err as a JS error: the original class for exceptions
+// and clone failures, a GoError otherwise.
+func errorObject(vm *sobek.Runtime, err error) *sobek.Object {
+	var pe *intrinsics.ParallelError
+	if errors.As(intrinsics.NewParallelError(err), &pe) {
+		return pe.Object(vm)
+	}
+	return vm.NewGoError(err)
+}
+
+// reportUnhandled reports a worker error nobody handled, as an uncaught
+// error of the parent would be.
+func reportUnhandled(el *eventloop.EventLoop, err error) {
+	if el.OnUnhandledRejection != nil {
+		el.OnUnhandledRejection(err)
+	}
 }
@@ -10,7 +10,7 @@ import (
 	"path/filepath"
 )
 
-const CacheVersion = "v1"
+const CacheVersion = "v2"
 
 type CacheEntry struct {
 	Hash      string   `json:"hash"`
@@ -51,7 +51,7 @@ func Compile(entryPoint string, virtualModules map[string]string) (*Result, erro
 						collectedImports = append(collectedImports, args.Path)
 
 						switch args.Path {
-						case "go:fmt", "go:os", "go:sync", "go:net/http", "go:memory", "go:crypto":
+						case "go:fmt", "go:os", "go:sync", "go:net/http", "go:memory", "go:crypto", "go:errors", "go:context":
 							return api.OnResolveResult{Path: args.Path, Namespace: "typego-internal"}, nil
 						}
 
@@ -82,19 +82,25 @@ func Compile(entryPoint string, virtualModules map[string]string) (*Result, erro
 						case "go:fmt":
 							content = "const f = (globalThis as any).__go_fmt__; export const Println = f.Println; export const Printf = f.Printf;"
 						case "go:os":
-							content = "const o = (globalThis as any).__go_os__; export const WriteFile = o.WriteFile; export const ReadFile = o.ReadFile;"
+							content = "const o = (globalThis as any).__go_os__; export const WriteFile = o.WriteFile; export const ReadFile = o.ReadFile; export const ErrNotExist = o.ErrNotExist; export const ErrExist = o.ErrExist; export const ErrPermission = o.ErrPermission; export const ErrClosed = o.ErrClosed;"
 						case "go:net/http":
 							content = "const h = (globalThis as any).__go_http__; export const Get = h.Get; export const Fetch = h.Fetch; export const Post = h.Post; export const ListenAndServe = h.ListenAndServe;"
 						case "go:sync":
 							content = "const s = (globalThis as any).__go_sync__; export const Spawn = s.Spawn; export const Sleep = s.Sleep; export const Chan = (globalThis as any).Chan;"
+						case "go:errors":
+							content = "const e = (globalThis as any).__go_errors__; export const is = e.is; export const as = e.as; export const unwrap = e.unwrap; export const Is = e.Is; export const As = e.As; export const Unwrap = e.Unwrap; export const New = e.New; export const Join = e.Join;"
+						case "go:context":
+							content = "const c = (globalThis as any).__go_context__; export const Background = c.Background; export const WithCancel = c.WithCancel; export const WithTimeout = c.WithTimeout; export const WithDeadline = c.WithDeadline; export const Canceled = c.Canceled; export const DeadlineExceeded = c.DeadlineExceeded;"
 						case "go:crypto":
 							content = "const c = (globalThis as any).__go_crypto__; export const Sha256 = c.Sha256; export const Sha512 = c.Sha512; export const HmacSha256 = c.HmacSha256; export const HmacSha256Verify = c.HmacSha256Verify; export const RandomBytes = c.RandomBytes; export const Uuid = c.Uuid;"
 
 						// TypeGo Stdlib
 						case "typego:memory":
-							content = "const m = (globalThis as any).__typego_memory__; export const makeShared = m.makeShared; export const stats = m.stats; export const ptr = m.ptr;"
+							content = "const m = (globalThis as any).__typego_memory__; export const makeShared = m.makeShared; export const stats = m.stats; export const segments = m.segments; export const free = m.free; export const profile = m.profile; export const snapshot = m.snapshot; export const writeSnapshot = m.writeSnapshot; export const diff = m.diff; export const ptr = m.ptr; export const defineLayout = m.defineLayout; export const ringBuffer = m.ringBuffer; export const spscQueue = m.spscQueue; export const sharedMap = m.sharedMap;"
+						case "typego:sync":
+							content = "const s = (globalThis as any).__typego_sync__; export const WaitGroup = s.WaitGroup; export const Group = s.Group; export const withContext = s.withContext; export const Once = s.Once; export const Semaphore = s.Semaphore;"
 						case "typego:worker":
-							content = "const w = (globalThis as any).__typego_worker__; export const Worker = w.Worker;"
+							content = "const w = (globalThis as any).__typego_worker__; export const Worker = w.Worker; export const WorkerPool = w.WorkerPool;"
 						default:
 							return api.OnLoadResult{Errors: []api.Message{{Text: "Unknown virtual module: " + args.Path}}}, nil
 						}
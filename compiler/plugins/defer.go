@@ -36,7 +36,13 @@ func DeferPlugin() api.Plugin {
 				jsRes := api.Transform(string(source), api.TransformOptions{
 					Loader: api.LoaderTS,
 					Format: api.FormatCommonJS,
-					Target: api.ES2015,
+					// ES2017 keeps async functions native: go.parallel, inline
+					// Workers and WorkerPool serialize them into runtimes that
+					// lack esbuild's lowering helpers.
+					Target: api.ES2017,
+					// Legacy decorators give field decorators the prototype, which the
+					// bridge decorators (@GoType, @Pointer) rely on to store metadata.
+					TsconfigRaw: `{"compilerOptions":{"experimentalDecorators":true}}`,
 				})
 				if len(jsRes.Errors) > 0 {
 					return api.OnLoadResult{Errors: jsRes.Errors}, nil
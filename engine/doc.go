@@ -27,6 +27,10 @@
 //
 // The engine supports spawning worker threads via the SpawnWorker method. Workers
 // run in isolated Goja runtimes but can share memory through the MemoryFactory.
+// A WorkerInstance is a single run of a worker script, ending when the script
+// exits; the typego:worker module supervises restarts.
+// go.parallel calls are served by a pool of such runtimes, one per CPU, which
+// RunParallel creates on first use and Close shuts down.
 //
 // # Event Loop
 //
@@ -6,17 +6,21 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/grafana/sobek"
 	"github.com/repyh/typego/bridge/core"
 	"github.com/repyh/typego/bridge/intrinsics"
 	"github.com/repyh/typego/bridge/stdlib/memory"
+	tgsync "github.com/repyh/typego/bridge/stdlib/sync"
 	"github.com/repyh/typego/bridge/stdlib/worker"
 	"github.com/repyh/typego/eventloop"
 
 	_ "github.com/repyh/typego/bridge/intrinsics"
+	_ "github.com/repyh/typego/bridge/modules/context"
 	_ "github.com/repyh/typego/bridge/modules/crypto"
+	_ "github.com/repyh/typego/bridge/modules/errors"
 	_ "github.com/repyh/typego/bridge/modules/fmt"
 	_ "github.com/repyh/typego/bridge/modules/json"
 	_ "github.com/repyh/typego/bridge/modules/net"
@@ -43,6 +47,9 @@ type Engine struct {
 	EventLoop     *eventloop.EventLoop
 	MemoryFactory *memory.Factory
 	Intrinsics    *intrinsics.Registry
+	// Console is the console global; its SetOutput and SetHandler route
+	// script output to the host.
+	Console *core.Console
 
 	// OnError is called when an unhandled error occurs in the engine
 
@@ -50,6 +57,9 @@ type Engine struct {
 
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	parallel     *parallelPool
+	parallelOnce sync.Once
 }
 
 func (e *Engine) WrapError(recovered interface{}) error {
@@ -66,16 +76,33 @@ func (e *Engine) WrapError(recovered interface{}) error {
 }
 
 func NewEngine(memoryLimit uint64, mf *memory.Factory) *Engine {
+	eng := newEngine(memoryLimit, mf)
+	if memoryLimit > 0 {
+		eng.StartMemoryMonitor(100 * time.Millisecond)
+	}
+	return eng
+}
+
+// newEngine is NewEngine without the memory monitor. The engines go.parallel
+// pools share one process with their parent, whose monitor already watches
+// it.
+func newEngine(memoryLimit uint64, mf *memory.Factory) *Engine {
 	vm := sobek.New()
 	vm.SetMaxCallStackSize(1000)
 
 	el := eventloop.NewEventLoop(vm)
 
+	// An engine's own factory counts its segments against memoryLimit.
+	// Workers get a scope of their parent's, go.parallel engines share it.
 	if mf == nil {
 		mf = memory.NewFactory()
+		mf.SetQuota(memoryLimit)
 	}
 
-	core.RegisterConsole(vm)
+	console := core.RegisterConsole(vm)
+	// Failures nobody can catch, like those of unawaited go() tasks and
+	// unhandled worker errors, are reported as console errors
+	el.OnUnhandledRejection = console.ReportError
 	core.RegisterGlobals(vm)
 
 	memory.Register(vm, el, mf)
@@ -93,15 +120,18 @@ func NewEngine(memoryLimit uint64, mf *memory.Factory) *Engine {
 		EventLoop:     el,
 		MemoryFactory: mf,
 		Intrinsics:    intrinsicsReg,
+		Console:       console,
 		ctx:           ctx,
 		cancel:        cancel,
 	}
 
 	worker.Register(vm, el, eng.SpawnWorker)
-
-	if memoryLimit > 0 {
-		eng.StartMemoryMonitor(100 * time.Millisecond)
-	}
+	tgsync.Register(vm, el, intrinsicsReg)
+	intrinsicsReg.SetParallelRunner(eng.RunParallel)
+	intrinsicsReg.SetExternalMemory(func() uint64 {
+		used, _ := mf.Usage()
+		return used
+	})
 
 	// Apply global hooks
 	for _, hook := range GlobalHooks {
@@ -112,16 +142,16 @@ func NewEngine(memoryLimit uint64, mf *memory.Factory) *Engine {
 }
 
 func (e *Engine) Run(js string) (sobek.Value, error) {
-	e.Intrinsics.VMLock.Lock()
-	defer e.Intrinsics.VMLock.Unlock()
+	unlock := e.lockVM()
+	defer unlock()
 	return e.VM.RunString(js)
 }
 
 // RunSafe executes JS code with panic recovery. If a panic occurs, it is
 // converted to an error and passed to OnError if set.
 func (e *Engine) RunSafe(js string) (result sobek.Value, err error) {
-	e.Intrinsics.VMLock.Lock()
-	defer e.Intrinsics.VMLock.Unlock()
+	unlock := e.lockVM()
+	defer unlock()
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -134,6 +164,16 @@ func (e *Engine) RunSafe(js string) (result sobek.Value, err error) {
 	return e.VM.RunString(js)
 }
 
+// lockVM takes VMLock unless called under it, from a loop job or a go()
+// task running JS.
+func (e *Engine) lockVM() (unlock func()) {
+	if e.Intrinsics.VMLock.Owned() {
+		return func() {}
+	}
+	e.Intrinsics.VMLock.Lock()
+	return e.Intrinsics.VMLock.Unlock
+}
+
 func (e *Engine) getStack() string {
 	buf := make([]byte, 4096)
 	n := runtime.Stack(buf, false)
@@ -148,13 +188,35 @@ func (e *Engine) GlobalSet(name string, value interface{}) error {
 	return e.VM.GlobalObject().Set(name, value)
 }
 
-func (e *Engine) BindStruct(name string, s interface{}) error {
-	return core.BindStruct(e.VM, name, s)
+// BindStruct binds s under name. Methods taking a context.Context first, or
+// listed with core.AsyncMethods, run on a goroutine and return a promise.
+func (e *Engine) BindStruct(name string, s interface{}, opts ...core.BindOption) error {
+	return core.BindStruct(e.VM, name, s, e.bindOptions(opts)...)
+}
+
+// BindStructLive binds a struct pointer so that field reads and writes from
+// JS go straight to the Go struct instead of a copy.
+func (e *Engine) BindStructLive(name string, ptr interface{}, opts ...core.BindOption) error {
+	return core.BindStructLive(e.VM, name, ptr, e.bindOptions(opts)...)
+}
+
+// WrapFunc converts fn into a JS function using the bridge's converter, which
+// lets scripts pass JS objects for interface parameters.
+func (e *Engine) WrapFunc(name string, fn interface{}, opts ...core.BindOption) sobek.Value {
+	return core.WrapFunc(e.VM, name, fn, e.bindOptions(opts)...)
+}
+
+func (e *Engine) bindOptions(opts []core.BindOption) []core.BindOption {
+	return append(e.Intrinsics.BindOptions(), opts...)
 }
 
 func (e *Engine) Close() {
 	e.cancel()
 	e.EventLoop.Stop()
+	e.parallelOnce.Do(func() {})
+	if e.parallel != nil {
+		e.parallel.close()
+	}
 }
 
 func (e *Engine) StartMemoryMonitor(interval time.Duration) {
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
+)
+
+// parallelPool lends isolated engines to go.parallel. At most one engine per
+// CPU runs at a time; further calls queue. Engines are reused between calls
+// unless a call was abandoned mid-flight.
+type parallelPool struct {
+	parent *Engine
+	slots  chan struct{}
+
+	mu     sync.Mutex
+	idle   []*Engine
+	closed bool
+}
+
+func newParallelPool(parent *Engine) *parallelPool {
+	return &parallelPool{
+		parent: parent,
+		slots:  make(chan struct{}, runtime.GOMAXPROCS(0)),
+	}
+}
+
+func (p *parallelPool) get(ctx context.Context) (*Engine, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		<-p.slots
+		return nil, errors.New("engine closed")
+	}
+	if n := len(p.idle); n > 0 {
+		eng := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		return eng, nil
+	}
+
+	eng := newEngine(p.parent.MemoryLimit, p.parent.MemoryFactory)
+	eng.Console.Inherit(p.parent.Console)
+	eng.EventLoop.SetAutoStop(false)
+	go eng.EventLoop.Start()
+	return eng, nil
+}
+
+func (p *parallelPool) put(eng *Engine, reuse bool) {
+	p.mu.Lock()
+	if reuse && !p.closed {
+		p.idle = append(p.idle, eng)
+	} else {
+		eng.Close()
+	}
+	p.mu.Unlock()
+	<-p.slots
+}
+
+func (p *parallelPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, eng := range p.idle {
+		eng.Close()
+	}
+	p.idle = nil
+}
+
+type parallelResult struct {
+	val interface{}
+	err error
+}
+
+// RunParallel calls the function with source src on a pooled isolated
+// engine. It implements intrinsics.ParallelRunner for go.parallel.
+func (e *Engine) RunParallel(ctx context.Context, src string, args []interface{}) (interface{}, error) {
+	e.parallelOnce.Do(func() { e.parallel = newParallelPool(e) })
+	if e.parallel == nil {
+		return nil, errors.New("engine closed")
+	}
+
+	iso, err := e.parallel.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan parallelResult, 1)
+	iso.EventLoop.RunOnLoop(func() { iso.callParallel(src, args, done) })
+
+	select {
+	case res := <-done:
+		e.parallel.put(iso, true)
+		return res.val, res.err
+	case <-ctx.Done():
+		iso.VM.Interrupt(ctx.Err())
+		e.parallel.put(iso, false)
+		return nil, ctx.Err()
+	}
+}
+
+// callParallel runs on the isolated engine's loop and reports on done, after
+// any returned promise settles.
+func (e *Engine) callParallel(src string, args []interface{}, done chan<- parallelResult) {
+	fail := func(err error) {
+		done <- parallelResult{err: intrinsics.NewParallelError(err)}
+	}
+	succeed := func(v sobek.Value) {
+		res, err := intrinsics.ExportTransferable(e.VM, v, nil)
+		if err != nil {
+			fail(err)
+			return
+		}
+		done <- parallelResult{val: res}
+	}
+
+	fnVal, err := e.Run("(" + src + ")")
+	if err != nil {
+		fail(err)
+		return
+	}
+	fn, ok := sobek.AssertFunction(fnVal)
+	if !ok {
+		fail(errors.New("go.parallel: source is not a function"))
+		return
+	}
+
+	jsArgs := make([]sobek.Value, len(args))
+	for i, arg := range args {
+		v, err := intrinsics.ImportTransferable(e.EventLoop, arg)
+		if err != nil {
+			fail(err)
+			return
+		}
+		jsArgs[i] = v
+	}
+	val, err := fn(sobek.Undefined(), jsArgs...)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if _, ok := val.Export().(*sobek.Promise); !ok {
+		succeed(val)
+		return
+	}
+	obj := val.ToObject(e.VM)
+	then, _ := sobek.AssertFunction(obj.Get("then"))
+	onFulfilled := e.VM.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		succeed(call.Argument(0))
+		return sobek.Undefined()
+	})
+	onRejected := e.VM.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		done <- parallelResult{err: intrinsics.ParallelErrorOf(call.Argument(0))}
+		return sobek.Undefined()
+	})
+	if _, err := then(obj, onFulfilled, onRejected); err != nil {
+		fail(err)
+	}
+}
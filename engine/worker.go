@@ -1,43 +1,56 @@
 package engine
 
 import (
-	"fmt"
+	"sync"
 
-	"github.com/grafana/sobek"
 	"github.com/repyh/typego/bridge/stdlib/worker"
 	"github.com/repyh/typego/compiler"
 )
 
+// WorkerInstance is one run of a worker script on its own engine. It ends
+// when the script exits or crashes, reported through Parent.OnExit, or when
+// terminated; restarting is up to the worker's supervisor.
 type WorkerInstance struct {
-	vm          *sobek.Runtime
-	engine      *Engine
-	inbox       chan interface{}
-	stop        chan struct{}
-	scriptPath  string
-	onMessage   func(sobek.Value)
-	autoRespawn bool
+	script   worker.Script
+	opts     worker.Options
+	parent   worker.Parent
+	inbox    chan interface{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
 }
 
-func (w *WorkerInstance) PostMessage(msg sobek.Value) {
-	data := msg.Export()
-	w.inbox <- data
+func (w *WorkerInstance) PostMessage(msg interface{}) {
+	select {
+	case w.inbox <- msg:
+	case <-w.done:
+	}
 }
 
 func (w *WorkerInstance) Terminate() {
-	w.autoRespawn = false
-	close(w.stop)
+	w.stopOnce.Do(func() { close(w.stop) })
 }
 
-func (e *Engine) SpawnWorker(scriptPath string, onMessage func(sobek.Value)) (worker.Handle, error) {
-	inbox := make(chan interface{}, 100)
-	stop := make(chan struct{})
+// filename names the worker's script in error events.
+func (w *WorkerInstance) filename() string {
+	switch {
+	case w.script.Path != "":
+		return w.script.Path
+	case w.opts.Name != "":
+		return w.opts.Name
+	default:
+		return "<inline worker>"
+	}
+}
 
+func (e *Engine) SpawnWorker(script worker.Script, opts worker.Options, parent worker.Parent) (worker.Handle, error) {
 	w := &WorkerInstance{
-		scriptPath:  scriptPath,
-		onMessage:   onMessage,
-		inbox:       inbox,
-		stop:        stop,
-		autoRespawn: true,
+		script: script,
+		opts:   opts,
+		parent: parent,
+		inbox:  make(chan interface{}, 100),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
 	}
 
 	e.startWorker(w)
@@ -47,66 +60,58 @@ func (e *Engine) SpawnWorker(scriptPath string, onMessage func(sobek.Value)) (wo
 
 func (e *Engine) startWorker(w *WorkerInstance) {
 	go func() {
-		for {
-			res, err := compiler.Compile(w.scriptPath, nil)
+		defer close(w.done)
+
+		js := w.script.Code
+		if w.script.Path != "" {
+			res, err := compiler.Compile(w.script.Path, nil)
 			if err != nil {
-				fmt.Printf("Worker Compile Error [%s]: %v\n", w.scriptPath, err)
+				w.parent.OnError(&worker.ErrorEvent{Message: err.Error(), Filename: w.script.Path, Err: err})
+				w.parent.OnExit(1)
 				return
 			}
+			js = res.JS
+		}
+
+		limit := w.opts.MemoryLimit
+		if limit == 0 {
+			limit = e.MemoryLimit
+		}
 
-			workerEng := NewEngine(e.MemoryLimit, e.MemoryFactory)
-			w.vm = workerEng.VM
-			w.engine = workerEng
-
-			worker.RegisterSelf(workerEng.VM, func(msg sobek.Value) {
-				data := msg.Export()
-				e.EventLoop.RunOnLoop(func() {
-					val := e.VM.ToValue(data)
-					w.onMessage(val)
-				})
-			})
-
-			// Run Loop
-			go func() {
-				_, err := workerEng.Run(res.JS)
-				if err != nil {
-					fmt.Printf("Worker Runtime Error [%s]: %v\n", w.scriptPath, err)
-				}
-				workerEng.EventLoop.WGAdd(1)
-				workerEng.EventLoop.Start()
-			}()
-
-			// Message Bridge
-			bridgeDone := make(chan struct{})
-			go func() {
-				defer close(bridgeDone)
-				for {
-					select {
-					case msg := <-w.inbox:
-						workerEng.EventLoop.RunOnLoop(func() {
-							val := workerEng.VM.ToValue(msg)
-							if onMsg := workerEng.VM.GlobalObject().Get("onmessage"); onMsg != nil {
-								if fn, ok := sobek.AssertFunction(onMsg); ok {
-									event := workerEng.VM.NewObject()
-									_ = event.Set("data", val)
-									_, _ = fn(workerEng.VM.GlobalObject(), event)
-								}
-							}
-						})
-					case <-w.stop:
-						workerEng.EventLoop.Stop()
-						return
-					case <-workerEng.Context().Done():
-						return
-					}
-				}
-			}()
-
-			<-bridgeDone
-			if !w.autoRespawn {
+		// The worker's segments count against its limit and its parent's
+		workerEng := NewEngine(limit, e.MemoryFactory.Scope(limit))
+		workerEng.EventLoop.SetAutoStop(false)
+		workerEng.setEnv(w.opts.Env)
+		workerEng.Console.Inherit(e.Console)
+		self := worker.RegisterSelf(workerEng.EventLoop, w.opts.Name, w.filename(), w.parent)
+
+		// Run Loop
+		go func() {
+			if _, err := workerEng.Run(js); err != nil {
+				self.Report(err)
+				self.Exit(1)
+			}
+			workerEng.EventLoop.Start()
+		}()
+
+		// Message Bridge
+		for {
+			select {
+			case msg := <-w.inbox:
+				workerEng.EventLoop.RunOnLoop(func() { self.Deliver(msg) })
+			case <-w.stop:
+				workerEng.Close()
+				return
+			case code := <-self.Exited():
+				workerEng.Close()
+				w.parent.OnExit(code)
 				return
 			}
-			fmt.Printf("Worker [%s] exited unexpectedly, respawning...\n", w.scriptPath)
 		}
 	}()
 }
+
+// setEnv adds entries to the process.env of the engine, local to it.
+func (e *Engine) setEnv(env map[string]string) {
+	e.Intrinsics.SetLocalEnv(env)
+}
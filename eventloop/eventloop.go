@@ -17,6 +17,7 @@ type EventLoop struct {
 	running  bool
 	mu       sync.Mutex
 	autoStop bool
+	locker   *Lock // held while a job runs
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -61,10 +62,11 @@ func (el *EventLoop) Start() {
 		}()
 	}
 
+	id := goid()
 	for {
 		select {
 		case job := <-el.jobQueue:
-			job()
+			el.runJob(id, job)
 			el.wg.Done()
 		case <-el.stopChan:
 			return
@@ -72,12 +74,68 @@ func (el *EventLoop) Start() {
 	}
 }
 
+// SetLocker makes jobs run holding l. Goroutines that execute JS outside the
+// loop (go() tasks) take the same lock, so they never overlap with the loop.
+func (el *EventLoop) SetLocker(l *Lock) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	el.locker = l
+}
+
+// runJob runs job holding the locker on behalf of the loop goroutine id.
+func (el *EventLoop) runJob(id uint64, job func()) {
+	el.mu.Lock()
+	l := el.locker
+	el.mu.Unlock()
+	if l != nil {
+		l.lockAs(id)
+		defer l.Unlock()
+	}
+	job()
+}
+
 // RunOnLoop schedules a function to run on the JS thread. Safe for concurrent use.
 func (el *EventLoop) RunOnLoop(f func()) {
 	el.wg.Add(1)
 	el.jobQueue <- f
 }
 
+// OnLoop reports whether the caller may touch the VM directly: it holds the
+// loop's lock, so it is Go code called from JS, or the loop is not running
+// and the caller owns the VM.
+func (el *EventLoop) OnLoop() bool {
+	el.mu.Lock()
+	l, running := el.locker, el.running
+	el.mu.Unlock()
+	if l != nil && l.Owned() {
+		return true
+	}
+	return !running
+}
+
+// Await runs f on the JS thread and blocks until it returns. When the caller
+// may touch the VM f runs inline. It returns false if the loop stopped before
+// f ran.
+func (el *EventLoop) Await(f func()) bool {
+	if el.OnLoop() {
+		f()
+		return true
+	}
+
+	done := make(chan struct{})
+	el.RunOnLoop(func() {
+		defer close(done)
+		f()
+	})
+
+	select {
+	case <-done:
+		return true
+	case <-el.stopChan:
+		return false
+	}
+}
+
 func (el *EventLoop) Stop() {
 	el.mu.Lock()
 	defer el.mu.Unlock()
@@ -0,0 +1,62 @@
+package eventloop
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Lock is the runtime lock. Whoever runs JS holds it: a loop job, a go()
+// task or Engine.Run. Go code called from JS runs on the holder's goroutine,
+// so Owned tells such code, which may use the VM directly, from another
+// goroutine that must queue its work on the loop, even while JS is running.
+type Lock struct {
+	mu    sync.Mutex
+	owner atomic.Uint64 // goroutine id of the holder, 0 when free
+}
+
+func (l *Lock) Lock() {
+	l.lockAs(goid())
+}
+
+// lockAs locks on behalf of goroutine id, which must be the caller's. The
+// loop passes its id, known from Start, so jobs do not look it up.
+func (l *Lock) lockAs(id uint64) {
+	l.mu.Lock()
+	l.owner.Store(id)
+}
+
+func (l *Lock) TryLock() bool {
+	if !l.mu.TryLock() {
+		return false
+	}
+	l.owner.Store(goid())
+	return true
+}
+
+func (l *Lock) Unlock() {
+	l.owner.Store(0)
+	l.mu.Unlock()
+}
+
+// Owned reports whether the calling goroutine holds the lock. Only when the
+// lock is held does it look up the caller's goroutine id.
+func (l *Lock) Owned() bool {
+	owner := l.owner.Load()
+	return owner != 0 && owner == goid()
+}
+
+// goid returns the current goroutine's id, parsed from the stack header
+// ("goroutine 42 [running]:").
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -6,6 +6,9 @@ require (
 	github.com/evanw/esbuild v0.27.2
 	github.com/grafana/sobek v0.0.0-20260121195222-d8d9202018c5
 	github.com/spf13/cobra v1.10.2
+	golang.org/x/sync v0.19.0
+	golang.org/x/sys v0.40.0
+	golang.org/x/text v0.3.8
 	golang.org/x/tools v0.41.0
 )
 
@@ -16,7 +19,4 @@ require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	golang.org/x/mod v0.32.0 // indirect
-	golang.org/x/sync v0.19.0 // indirect
-	golang.org/x/sys v0.40.0 // indirect
-	golang.org/x/text v0.3.8 // indirect
 )
@@ -16,9 +16,10 @@ func TestShimTemplate_Validity(t *testing.T) {
 	jsCode := `"console.log('hello')"`
 	bindings := "// bindings"
 	memLimit := 64 * 1024 * 1024
+	adapters := "// adapters"
 
 	// Generate the code
-	code := fmt.Sprintf(builder.ShimTemplate, imports, jsCode, bindings, memLimit)
+	code := fmt.Sprintf(builder.ShimTemplate, imports, jsCode, bindings, memLimit, adapters)
 
 	// Verify it's valid Go code
 	fset := token.NewFileSet()
@@ -8,19 +8,23 @@ package builder
 //   - %[2]s: The bundled JavaScript code (quoted string)
 //   - %[3]s: Hyper-linker binding code (generated shims)
 //   - %[4]d: Memory limit in bytes
+//   - %[5]s: Top-level declarations (interface adapters for hyper-linked packages)
 const ShimTemplate = `package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	%[1]s
 
 	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/intrinsics"
 	"github.com/repyh/typego/engine"
 )
 
 const jsBundle = %[2]s
+%[5]s
 
 type NativeTools struct {
 	StartTime string
@@ -41,6 +45,10 @@ func main() {
 
 	eng.EventLoop.RunOnLoop(func() {
 		val, err := eng.Run(jsBundle)
+		var exit *intrinsics.ExitError
+		if errors.As(err, &exit) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("Runtime Error: %%v\n", err)
 			os.Exit(1)
@@ -66,5 +74,6 @@ func main() {
 	})
 
 	eng.EventLoop.Start()
+	os.Exit(eng.Intrinsics.ExitCode())
 }
 `
@@ -13,22 +13,26 @@ import (
 var mainTmplStr string
 
 type MainTemplateData struct {
-	NamedImports map[string]string // Path -> Name
-	Shims        map[string]string
-	Bridge       string
+	NamedImports   map[string]string // Path -> Name
+	AdapterImports map[string]string // Alias -> Path
+	Shims          map[string]string
+	Bridge         string
+	Adapters       string // Top-level interface adapter declarations
 }
 
 // ScaffoldMain generates the main.go file in the specified directory
-func ScaffoldMain(dir string, namedImports map[string]string, shims map[string]string, bridge string) error {
+func ScaffoldMain(dir string, namedImports map[string]string, shims map[string]string, bridge string, adapterImports map[string]string, adapters string) error {
 	tmpl, err := template.New("main").Parse(mainTmplStr)
 	if err != nil {
 		return fmt.Errorf("failed to parse main template: %w", err)
 	}
 
 	data := MainTemplateData{
-		NamedImports: namedImports,
-		Shims:        shims,
-		Bridge:       bridge,
+		NamedImports:   namedImports,
+		AdapterImports: adapterImports,
+		Shims:          shims,
+		Bridge:         bridge,
+		Adapters:       adapters,
 	}
 
 	var buf bytes.Buffer
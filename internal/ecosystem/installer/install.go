@@ -122,7 +122,7 @@ func RunInstall(cwd string) error {
 
 			// Skip internal/stdlib packages
 			switch cleanName {
-			case "fmt", "os", "sync", "net/http", "memory", "crypto":
+			case "fmt", "os", "sync", "net/http", "memory", "crypto", "errors", "context":
 				continue
 			}
 
@@ -157,7 +157,7 @@ func RunInstall(cwd string) error {
 
 	// Scaffold main.go before tidy so go mod tidy sees the imports
 	fmt.Println("🏗️  Scaffolding binary...")
-	if err := builder.ScaffoldMain(workDir, namedImports, tsShims, bridgeBlock.String()); err != nil {
+	if err := builder.ScaffoldMain(workDir, namedImports, tsShims, bridgeBlock.String(), linker.AdapterImports(packageInfos...), linker.GenerateAdapters(packageInfos...)); err != nil {
 		return err
 	}
 
@@ -0,0 +1,338 @@
+package linker
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+const corePath = "github.com/repyh/typego/bridge/core"
+
+// Interfaces the bridge already ships adapters for.
+var builtinAdapters = map[string]bool{
+	"fmt.Stringer":   true,
+	"io.Reader":      true,
+	"io.Writer":      true,
+	"io.Closer":      true,
+	"sort.Interface": true,
+}
+
+// InterfaceAdapter describes a Go interface that TS objects may implement.
+// A concrete adapter type is generated for it in the JIT binary, since Go
+// cannot create types with methods at runtime.
+type InterfaceAdapter struct {
+	Name    string // Interface name, e.g. "Handler"
+	GoType  string // Import path qualified name, e.g. "net/http.Handler"
+	Local   bool   // Declared in the inspected package
+	Doc     string
+	Expr    string // Go expression in generated code, e.g. "typego_net_http.Handler"
+	Methods []AdapterMethod
+	Imports map[string]string // alias -> import path
+}
+
+type AdapterMethod struct {
+	Name      string
+	Args      []ArgInfo // Package-relative types, for TS generation
+	Returns   []string
+	GoParams  []string // Aliased types, for code generation
+	GoResults []string
+	Variadic  bool
+}
+
+// collectAdapters finds the exported interfaces of pkg and the interfaces
+// taken by its exported functions. It marks functions with interface or
+// channel parameters so their shims route through the bridge converter.
+func collectAdapters(pkg *types.Package, info *PackageInfo) {
+	seen := make(map[string]bool)
+	add := func(named *types.Named) bool {
+		if isBuiltinAdapter(named) {
+			return true
+		}
+		a, ok := newAdapter(named, pkg)
+		if !ok {
+			return false
+		}
+		if !seen[a.GoType] {
+			seen[a.GoType] = true
+			info.Adapters = append(info.Adapters, a)
+		}
+		return true
+	}
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if tn, ok := scope.Lookup(name).(*types.TypeName); ok && tn.Exported() {
+			if named, ok := tn.Type().(*types.Named); ok {
+				add(named)
+			}
+		}
+	}
+
+	for i := range info.Exports {
+		fn, ok := scope.Lookup(info.Exports[i].Name).(*types.Func)
+		if !ok {
+			continue
+		}
+		params := fn.Type().(*types.Signature).Params()
+		for j := 0; j < params.Len(); j++ {
+			switch t := params.At(j).Type().(type) {
+			case *types.Named:
+				if add(t) {
+					info.Exports[i].NeedsBridge = true
+				}
+			case *types.Chan, *types.Slice, *types.Pointer:
+				// Channel objects, GoSlices and refs pass their Go value
+				info.Exports[i].NeedsBridge = true
+			}
+		}
+	}
+
+	sort.Slice(info.Adapters, func(i, j int) bool { return info.Adapters[i].GoType < info.Adapters[j].GoType })
+}
+
+func newAdapter(named *types.Named, local *types.Package) (InterfaceAdapter, bool) {
+	iface, ok := named.Underlying().(*types.Interface)
+	obj := named.Obj()
+	if !ok || obj.Pkg() == nil || !obj.Exported() || named.TypeParams().Len() > 0 ||
+		!iface.IsMethodSet() || iface.NumMethods() == 0 {
+		return InterfaceAdapter{}, false
+	}
+
+	a := InterfaceAdapter{
+		Name:    obj.Name(),
+		GoType:  obj.Pkg().Path() + "." + obj.Name(),
+		Local:   obj.Pkg() == local,
+		Imports: make(map[string]string),
+	}
+	aliased := func(p *types.Package) string {
+		alias := importAlias(p.Path())
+		a.Imports[alias] = p.Path()
+		return alias
+	}
+	relative := func(p *types.Package) string {
+		if p == local {
+			return ""
+		}
+		return p.Name()
+	}
+
+	a.Expr = aliased(obj.Pkg()) + "." + obj.Name()
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		if !m.Exported() {
+			return InterfaceAdapter{}, false
+		}
+		sig := m.Type().(*types.Signature)
+		am := AdapterMethod{Name: m.Name(), Variadic: sig.Variadic()}
+
+		for j := 0; j < sig.Params().Len(); j++ {
+			p := sig.Params().At(j)
+			if !nameable(p.Type()) {
+				return InterfaceAdapter{}, false
+			}
+			goType := types.TypeString(p.Type(), aliased)
+			tsType := types.TypeString(p.Type(), relative)
+			if am.Variadic && j == sig.Params().Len()-1 {
+				goType = "..." + strings.TrimPrefix(goType, "[]")
+				tsType = "..." + strings.TrimPrefix(tsType, "[]")
+			}
+			am.GoParams = append(am.GoParams, goType)
+			am.Args = append(am.Args, ArgInfo{Name: p.Name(), Type: tsType})
+		}
+		for j := 0; j < sig.Results().Len(); j++ {
+			r := sig.Results().At(j)
+			if !nameable(r.Type()) {
+				return InterfaceAdapter{}, false
+			}
+			am.GoResults = append(am.GoResults, types.TypeString(r.Type(), aliased))
+			am.Returns = append(am.Returns, types.TypeString(r.Type(), relative))
+		}
+		a.Methods = append(a.Methods, am)
+	}
+	return a, true
+}
+
+func isBuiltinAdapter(named *types.Named) bool {
+	obj := named.Obj()
+	return obj.Pkg() != nil && builtinAdapters[obj.Pkg().Path()+"."+obj.Name()]
+}
+
+// nameable reports whether generated code in package main can spell t.
+func nameable(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return true
+	case *types.Named:
+		if t.Obj().Pkg() != nil && !t.Obj().Exported() {
+			return false
+		}
+		args := t.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if !nameable(args.At(i)) {
+				return false
+			}
+		}
+		return true
+	case *types.Pointer:
+		return nameable(t.Elem())
+	case *types.Slice:
+		return nameable(t.Elem())
+	case *types.Array:
+		return nameable(t.Elem())
+	case *types.Chan:
+		return nameable(t.Elem())
+	case *types.Map:
+		return nameable(t.Key()) && nameable(t.Elem())
+	case *types.Signature:
+		return tupleNameable(t.Params()) && tupleNameable(t.Results())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !t.Field(i).Exported() || !nameable(t.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Interface:
+		for i := 0; i < t.NumMethods(); i++ {
+			if !t.Method(i).Exported() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func tupleNameable(t *types.Tuple) bool {
+	for i := 0; i < t.Len(); i++ {
+		if !nameable(t.At(i).Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// importAlias gives every package a collision-free name in generated code.
+func importAlias(path string) string {
+	var sb strings.Builder
+	sb.WriteString("typego_")
+	for _, r := range path {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// AdapterImports returns the imports (alias -> path) needed by the code from
+// GenerateAdapters for the given packages.
+func AdapterImports(infos ...*PackageInfo) map[string]string {
+	imports := make(map[string]string)
+	for _, info := range infos {
+		for _, a := range info.Adapters {
+			for alias, path := range a.Imports {
+				imports[alias] = path
+			}
+			imports[importAlias(corePath)] = corePath
+			imports[importAlias("github.com/grafana/sobek")] = "github.com/grafana/sobek"
+		}
+	}
+	return imports
+}
+
+// GenerateAdapters emits top-level Go declarations implementing every
+// collected interface over a JS object, registered with the bridge from init.
+// Interfaces shared by several packages are generated once.
+func GenerateAdapters(infos ...*PackageInfo) string {
+	core := importAlias(corePath)
+	sobek := importAlias("github.com/grafana/sobek")
+
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, info := range infos {
+		for _, a := range info.Adapters {
+			if seen[a.GoType] {
+				continue
+			}
+			seen[a.GoType] = true
+
+			typeName := "jsAdapter_" + strings.TrimPrefix(importAlias(a.GoType), "typego_")
+
+			sb.WriteString(fmt.Sprintf("\n// %s implements %s by calling methods on a JS object.\n", typeName, a.GoType))
+			sb.WriteString(fmt.Sprintf("type %s struct {\n\timpl *%s.JSImpl\n", typeName, core))
+			for _, m := range a.Methods {
+				sb.WriteString(fmt.Sprintf("\tm_%s func(%s)%s\n", m.Name, strings.Join(m.GoParams, ", "), resultList(m.GoResults)))
+			}
+			sb.WriteString("}\n\n")
+
+			for _, m := range a.Methods {
+				var params, args []string
+				for i, p := range m.GoParams {
+					params = append(params, fmt.Sprintf("p%d %s", i, p))
+					arg := fmt.Sprintf("p%d", i)
+					if strings.HasPrefix(p, "...") {
+						arg += "..."
+					}
+					args = append(args, arg)
+				}
+				call := fmt.Sprintf("a.m_%s(%s)", m.Name, strings.Join(args, ", "))
+				if len(m.GoResults) > 0 {
+					call = "return " + call
+				}
+				sb.WriteString(fmt.Sprintf("func (a *%s) %s(%s)%s { %s }\n", typeName, m.Name, strings.Join(params, ", "), resultList(m.GoResults), call))
+			}
+			sb.WriteString(fmt.Sprintf("func (a *%s) TypeGoObject() *%s.Object { return a.impl.Object() }\n\n", typeName, sobek))
+
+			sb.WriteString("func init() {\n")
+			sb.WriteString(fmt.Sprintf("\t%s.RegisterInterface((*%s)(nil), func(impl *%s.JSImpl) (interface{}, error) {\n", core, a.Expr, core))
+			sb.WriteString(fmt.Sprintf("\t\ta := &%s{impl: impl}\n", typeName))
+			for _, m := range a.Methods {
+				sb.WriteString(fmt.Sprintf("\t\tif err := impl.Bind(%q, &a.m_%s); err != nil {\n\t\t\treturn nil, err\n\t\t}\n", m.Name, m.Name))
+			}
+			sb.WriteString("\t\treturn a, nil\n\t})\n}\n")
+		}
+	}
+	return sb.String()
+}
+
+// resultList formats a result list including its leading space.
+func resultList(results []string) string {
+	switch len(results) {
+	case 0:
+		return ""
+	case 1:
+		return " " + results[0]
+	}
+	return " (" + strings.Join(results, ", ") + ")"
+}
+
+// generateInterfaceDecl emits the TS interface a script implements. A
+// trailing error result is signalled by throwing, so it is not part of the
+// TS return type.
+func generateInterfaceDecl(a InterfaceAdapter, knownStructs map[string]bool) string {
+	var sb strings.Builder
+	if a.Doc != "" {
+		sb.WriteString("\t/**\n")
+		for _, line := range strings.Split(a.Doc, "\n") {
+			sb.WriteString(fmt.Sprintf("\t * %s\n", line))
+		}
+		sb.WriteString("\t */\n")
+	}
+	sb.WriteString(fmt.Sprintf("\texport interface %s {\n", a.Name))
+	for _, m := range a.Methods {
+		returns := m.Returns
+		if len(returns) > 0 && returns[len(returns)-1] == "error" {
+			returns = returns[:len(returns)-1]
+		}
+		sb.WriteString(generateMethodSignatureWithContext(MethodInfo{
+			Name:    m.Name,
+			Args:    m.Args,
+			Returns: returns,
+		}, knownStructs))
+	}
+	sb.WriteString("\t}\n\n")
+	return sb.String()
+}
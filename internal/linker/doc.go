@@ -50,6 +50,14 @@
 // The generated code creates a global object (e.g., _go_hyper_color) with methods
 // that forward calls to the actual Go functions.
 //
+// # Interface Adapters
+//
+// Go cannot define types with methods at runtime, so for every interface a
+// script may implement (exported interfaces of the package and interface
+// parameters of its functions) GenerateAdapters emits a concrete adapter type
+// that forwards calls to the JS object, registered with core.RegisterInterface
+// from init. AdapterImports lists the imports that code needs.
+//
 // # Type Generation
 //
 // GenerateTypes produces TypeScript declaration content for IDE support.
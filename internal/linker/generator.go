@@ -14,9 +14,20 @@ func GenerateShim(info *PackageInfo, variableName string) string {
 
 	// Bind top-level functions
 	for _, fn := range info.Exports {
+		if fn.NeedsBridge {
+			// Routed through the bridge converter so JS objects can satisfy
+			// interfaces and channel objects unwrap to chan T
+			sb.WriteString(fmt.Sprintf("\t%s.Set(%q, eng.WrapFunc(%q, %s.%s))\n", variableName, fn.Name, fn.Name, info.Name, fn.Name))
+			continue
+		}
 		sb.WriteString(fmt.Sprintf("\t%s.Set(%q, %s.%s)\n", variableName, fn.Name, info.Name, fn.Name))
 	}
 
+	// Bind sentinel errors by value to keep their identity
+	for _, v := range info.Errors {
+		sb.WriteString(fmt.Sprintf("\t%s.Set(%q, %s.%s)\n", variableName, v.Name, info.Name, v.Name))
+	}
+
 	// Bind struct constructors (factory functions)
 	for _, st := range info.Structs {
 		// Skip unexported structs (defensive check)
@@ -40,6 +51,11 @@ func GenerateTypes(info *PackageInfo) string {
 	for _, st := range info.Structs {
 		knownStructs[st.Name] = true
 	}
+	for _, a := range info.Adapters {
+		if a.Local {
+			knownStructs[a.Name] = true
+		}
+	}
 
 	sb.WriteString(fmt.Sprintf("// MODULE: go:%s\n", info.ImportPath))
 	sb.WriteString(fmt.Sprintf("declare module \"go:%s\" {\n", info.ImportPath))
@@ -77,15 +93,59 @@ func GenerateTypes(info *PackageInfo) string {
 		sb.WriteString(generateStructInterfaceWithContext(st, knownStructs))
 	}
 
+	for _, a := range info.Adapters {
+		if a.Local {
+			sb.WriteString(generateInterfaceDecl(a, knownStructs))
+		}
+	}
+
 	for _, fn := range info.Exports {
 		sb.WriteString(generateFunctionDeclWithContext(fn, knownStructs))
 	}
 
+	for _, v := range info.Errors {
+		if v.Doc != "" {
+			sb.WriteString("\t/**\n")
+			for _, line := range strings.Split(v.Doc, "\n") {
+				sb.WriteString(fmt.Sprintf("\t * %s\n", line))
+			}
+			sb.WriteString("\t */\n")
+		}
+		sb.WriteString(fmt.Sprintf("\texport const %s: GoError;\n", v.Name))
+	}
+
+	sb.WriteString(generateErrorTypeMap(info))
+
 	sb.WriteString("}\n")
 	sb.WriteString(fmt.Sprintf("// END: go:%s\n", info.ImportPath))
 	return sb.String()
 }
 
+// generateErrorTypeMap registers the package's error structs in the global
+// GoErrorTypes interface, keyed by their %T name, so errors.as is typed.
+func generateErrorTypeMap(info *PackageInfo) string {
+	var entries []string
+	for _, st := range info.Structs {
+		for _, m := range st.Methods {
+			if m.Name != "Error" || len(m.Args) != 0 || len(m.Returns) != 1 || m.Returns[0] != "string" {
+				continue
+			}
+			goType := info.Name + "." + st.Name
+			if m.IsPointerRecv {
+				goType = "*" + goType
+			}
+			if len(st.TypeParams) == 0 {
+				entries = append(entries, fmt.Sprintf("\t\t\t%q: %s;\n", goType, st.Name))
+			}
+			break
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	return "\tglobal {\n\t\tinterface GoErrorTypes {\n" + strings.Join(entries, "") + "\t\t}\n\t}\n"
+}
+
 func generateStructInterfaceWithContext(st ExportedStruct, knownStructs map[string]bool) string {
 	var sb strings.Builder
 
@@ -253,6 +313,9 @@ func GenerateTSShim(info *PackageInfo) string {
 	for _, fn := range info.Exports {
 		sb.WriteString(fmt.Sprintf("export const %s = (globalThis as any)._go_hyper_%s.%s;\n", fn.Name, info.Name, fn.Name))
 	}
+	for _, v := range info.Errors {
+		sb.WriteString(fmt.Sprintf("export const %s = (globalThis as any)._go_hyper_%s.%s;\n", v.Name, info.Name, v.Name))
+	}
 	// Add struct factory functions if any
 	for _, st := range info.Structs {
 		if len(st.Name) > 0 && st.Name[0] >= 'A' && st.Name[0] <= 'Z' {
@@ -3,6 +3,7 @@ package linker
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
 
@@ -14,13 +15,21 @@ type PackageInfo struct {
 	ImportPath string
 	Exports    []ExportedFunc
 	Structs    []ExportedStruct
+	Errors     []ExportedVar // Sentinel error variables (e.g. fs.ErrNotExist)
+	Adapters   []InterfaceAdapter
 }
 
-type ExportedFunc struct {
+type ExportedVar struct {
 	Name string
 	Doc  string
-	Args []ArgInfo
-	Ret  []string
+}
+
+type ExportedFunc struct {
+	Name        string
+	Doc         string
+	Args        []ArgInfo
+	Ret         []string
+	NeedsBridge bool // Has a parameter only the bridge converter handles (interface, channel, slice, pointer)
 }
 
 type ExportedStruct struct {
@@ -97,8 +106,10 @@ func goToTSTypeWithContext(goType string, knownStructs map[string]bool) string {
 			return goToTSTypeWithContext(baseType, knownStructs) + "[]"
 		}
 		// Handle channels
-		if strings.HasPrefix(goType, "chan ") || strings.HasPrefix(goType, "<-chan ") || strings.HasPrefix(goType, "chan<- ") {
-			return "any"
+		for _, prefix := range []string{"chan<- ", "<-chan ", "chan "} {
+			if strings.HasPrefix(goType, prefix) {
+				return "Chan<" + goToTSTypeWithContext(goType[len(prefix):], knownStructs) + ">"
+			}
 		}
 		// Handle anonymous structs
 		if strings.HasPrefix(goType, "struct{") {
@@ -116,7 +127,7 @@ func goToTSTypeWithContext(goType string, knownStructs map[string]bool) string {
 func Inspect(importPath string, dir string) (*PackageInfo, error) {
 	cfg := &packages.Config{
 		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
-			packages.NeedImports | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
 		Dir: dir,
 	}
 
@@ -146,6 +157,7 @@ func Inspect(importPath string, dir string) (*PackageInfo, error) {
 			switch d := decl.(type) {
 			case *ast.GenDecl:
 				parseTypeDecl(d, structMap, pkg.PkgPath, pkg.TypesInfo)
+				info.Errors = append(info.Errors, parseErrorVars(d, pkg.TypesInfo)...)
 			case *ast.FuncDecl:
 				if d.Recv == nil {
 					if ast.IsExported(d.Name.Name) {
@@ -162,6 +174,16 @@ func Inspect(importPath string, dir string) (*PackageInfo, error) {
 		info.Structs = append(info.Structs, *s)
 	}
 
+	if pkg.Types != nil {
+		collectAdapters(pkg.Types, info)
+		docs := interfaceDocs(pkg.Syntax)
+		for i := range info.Adapters {
+			if info.Adapters[i].Local {
+				info.Adapters[i].Doc = docs[info.Adapters[i].Name]
+			}
+		}
+	}
+
 	return info, nil
 }
 
@@ -269,6 +291,68 @@ func parseTypeDecl(decl *ast.GenDecl, structMap map[string]*ExportedStruct, pkgP
 	}
 }
 
+func interfaceDocs(files []*ast.File) map[string]string {
+	docs := make(map[string]string)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if _, ok := ts.Type.(*ast.InterfaceType); !ok {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil {
+					doc = gd.Doc
+				}
+				docs[ts.Name.Name] = strings.TrimSpace(doc.Text())
+			}
+		}
+	}
+	return docs
+}
+
+// parseErrorVars collects exported package-level variables of type error.
+// They are bound by value so errors.is can match them by identity.
+func parseErrorVars(decl *ast.GenDecl, info *types.Info) []ExportedVar {
+	if decl.Tok != token.VAR || info == nil {
+		return nil
+	}
+
+	errorType := types.Universe.Lookup("error").Type()
+	var vars []ExportedVar
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		doc := vs.Doc
+		if doc == nil {
+			doc = decl.Doc
+		}
+		for _, name := range vs.Names {
+			if !ast.IsExported(name.Name) {
+				continue
+			}
+			obj := info.Defs[name]
+			if obj == nil || !types.Identical(obj.Type(), errorType) {
+				continue
+			}
+			vars = append(vars, ExportedVar{
+				Name: name.Name,
+				Doc:  strings.TrimSpace(doc.Text()),
+			})
+		}
+	}
+	return vars
+}
+
 func parseFunc(fn *ast.FuncDecl) ExportedFunc {
 	var args []ArgInfo
 	if fn.Type.Params != nil {
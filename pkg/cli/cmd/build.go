@@ -56,6 +56,7 @@ var BuildCmd = &cobra.Command{
 
 		virtualModules := make(map[string]string)
 		var bindBlock string
+		var infos []*linker.PackageInfo
 
 		if res != nil {
 			for _, imp := range res.Imports {
@@ -73,14 +74,8 @@ var BuildCmd = &cobra.Command{
 
 					bindBlock += linker.GenerateShim(info, "pkg_"+info.Name)
 
-					var vmContent strings.Builder
-					for _, fn := range info.Exports {
-						// e.g. export const Red = (globalThis as any)._go_hyper_color.Red;
-						// Note: variable name matches GenerateShim output: "_go_hyper_" + info.Name
-						vmContent.WriteString(fmt.Sprintf("export const %s = (globalThis as any)._go_hyper_%s.%s;\n", fn.Name, info.Name, fn.Name))
-					}
-
-					virtualModules[imp] = vmContent.String()
+					virtualModules[imp] = linker.GenerateTSShim(info)
+					infos = append(infos, info)
 				}
 			}
 		}
@@ -104,7 +99,11 @@ var BuildCmd = &cobra.Command{
 			}
 		}
 
-		shimContent := fmt.Sprintf(builder.ShimTemplate, importBlock.String(), fmt.Sprintf("%q", res.JS), bindBlock, MemoryLimit*1024*1024)
+		for alias, path := range linker.AdapterImports(infos...) {
+			importBlock.WriteString(fmt.Sprintf("\t%s %q\n", alias, path))
+		}
+
+		shimContent := fmt.Sprintf(builder.ShimTemplate, importBlock.String(), fmt.Sprintf("%q", res.JS), bindBlock, MemoryLimit*1024*1024, linker.GenerateAdapters(infos...))
 
 		shimPath := filepath.Join(tmpDir, "main.go")
 		if err := os.WriteFile(shimPath, []byte(shimContent), 0644); err != nil {
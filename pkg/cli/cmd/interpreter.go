@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 
+	"github.com/repyh/typego/bridge/intrinsics"
 	"github.com/repyh/typego/compiler"
 	"github.com/repyh/typego/engine"
 )
@@ -11,16 +13,17 @@ import (
 var MemoryLimit uint64 = 128
 
 // runInterpreter executes TypeScript directly using the embedded Goja engine.
-// This is the fast path - no Go compilation required.
-func runInterpreter(filename string) error {
+// This is the fast path - no Go compilation required. It returns the exit
+// code of the script, set by process.exit or process.exitCode.
+func runInterpreter(filename string) (int, error) {
 	absPath, err := filepath.Abs(filename)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
+		return 0, fmt.Errorf("failed to resolve path: %w", err)
 	}
 
 	res, err := compiler.Compile(absPath, nil)
 	if err != nil {
-		return fmt.Errorf("compilation failed: %w", err)
+		return 0, fmt.Errorf("compilation failed: %w", err)
 	}
 
 	eng := engine.NewEngine(MemoryLimit*1024*1024, nil)
@@ -34,9 +37,10 @@ func runInterpreter(filename string) error {
 
 	eng.EventLoop.Start()
 
-	if runErr != nil {
-		return fmt.Errorf("runtime error: %w", runErr)
+	var exit *intrinsics.ExitError
+	if runErr != nil && !errors.As(runErr, &exit) {
+		return 0, fmt.Errorf("runtime error: %w", runErr)
 	}
 
-	return nil
+	return eng.Intrinsics.ExitCode(), nil
 }
@@ -51,10 +51,14 @@ Use --compile to generate a standalone Go binary (slower, but more portable).`,
 		if compileMode {
 			runStandalone(filename)
 		} else {
-			if err := runInterpreter(filename); err != nil {
+			code, err := runInterpreter(filename)
+			if err != nil {
 				fmt.Printf("Error: %v\n", err)
 				os.Exit(1)
 			}
+			if code != 0 {
+				os.Exit(code)
+			}
 		}
 	},
 }
@@ -86,6 +90,7 @@ func runStandalone(filename string) {
 
 	virtualModules := make(map[string]string)
 	var bindBlock string
+	var infos []*linker.PackageInfo
 
 	if res != nil {
 		for _, imp := range res.Imports {
@@ -93,17 +98,14 @@ func runStandalone(filename string) {
 				cleanImp := imp[3:]
 				// Skip internal modules handled by bridge
 				switch cleanImp {
-				case "fmt", "os", "sync", "net/http", "memory":
+				case "fmt", "os", "sync", "net/http", "memory", "errors", "context":
 					continue
 				}
 				if err := fetcher.Get(cleanImp); err == nil {
 					if info, err := linker.Inspect(cleanImp, fetcher.TempDir); err == nil {
 						bindBlock += linker.GenerateShim(info, "pkg_"+info.Name)
-						var vmContent strings.Builder
-						for _, fn := range info.Exports {
-							vmContent.WriteString(fmt.Sprintf("export const %s = (globalThis as any)._go_hyper_%s.%s;\n", fn.Name, info.Name, fn.Name))
-						}
-						virtualModules[imp] = vmContent.String()
+						virtualModules[imp] = linker.GenerateTSShim(info)
+						infos = append(infos, info)
 					}
 				}
 			}
@@ -122,14 +124,18 @@ func runStandalone(filename string) {
 			cleanImp := imp[3:]
 			// Skip internal modules handled by bridge
 			switch cleanImp {
-			case "fmt", "os", "sync", "net/http", "memory":
+			case "fmt", "os", "sync", "net/http", "memory", "errors", "context":
 				continue
 			}
 			importBlock.WriteString(fmt.Sprintf("\t\"%s\"\n", cleanImp))
 		}
 	}
 
-	shimContent := fmt.Sprintf(builder.ShimTemplate, importBlock.String(), fmt.Sprintf("%q", res.JS), bindBlock, MemoryLimit*1024*1024)
+	for alias, path := range linker.AdapterImports(infos...) {
+		importBlock.WriteString(fmt.Sprintf("\t%s %q\n", alias, path))
+	}
+
+	shimContent := fmt.Sprintf(builder.ShimTemplate, importBlock.String(), fmt.Sprintf("%q", res.JS), bindBlock, MemoryLimit*1024*1024, linker.GenerateAdapters(infos...))
 	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(shimContent), 0644); err != nil {
 		fmt.Printf("Error writing shim: %v\n", err)
 		os.Exit(1)
@@ -190,7 +196,11 @@ go 1.23.6
 	run := exec.Command(exePath)
 	run.Stdout = os.Stdout
 	run.Stderr = os.Stderr
+	run.Stdin = os.Stdin
 	if err := run.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
@@ -9,11 +9,14 @@ import (
 
 	"github.com/repyh/typego/bridge/core"
 	"github.com/repyh/typego/bridge/intrinsics"
+	bridge_context "github.com/repyh/typego/bridge/modules/context"
 	bridge_crypto "github.com/repyh/typego/bridge/modules/crypto"
+	bridge_errors "github.com/repyh/typego/bridge/modules/errors"
 
 	bridge_net "github.com/repyh/typego/bridge/modules/net"
 	bridge_sync "github.com/repyh/typego/bridge/modules/sync"
 	bridge_memory "github.com/repyh/typego/bridge/stdlib/memory"
+	bridge_tgsync "github.com/repyh/typego/bridge/stdlib/sync"
 	bridge_worker "github.com/repyh/typego/bridge/stdlib/worker"
 	"github.com/repyh/typego/compiler"
 	"github.com/repyh/typego/internal/linker"
@@ -132,7 +135,7 @@ var TypesCmd = &cobra.Command{
 			processed[imp] = true
 
 			// Skip modules that are already fully defined in std.d.ts
-			if imp == "go:net/http" || imp == "go:sync" || imp == "typego:memory" || imp == "typego:worker" || imp == "go:memory" || imp == "go:crypto" {
+			if imp == "go:net/http" || imp == "go:sync" || imp == "typego:memory" || imp == "typego:worker" || imp == "typego:sync" || imp == "go:memory" || imp == "go:crypto" || imp == "go:errors" || imp == "go:context" {
 				continue
 			}
 
@@ -201,7 +204,10 @@ var TypesCmd = &cobra.Command{
 		currentContent = updateTypeBlock(currentContent, "go:memory", string(bridge_memory.Types))
 		currentContent = updateTypeBlock(currentContent, "typego:memory", string(bridge_memory.Types))
 		currentContent = updateTypeBlock(currentContent, "typego:worker", string(bridge_worker.Types))
+		currentContent = updateTypeBlock(currentContent, "typego:sync", string(bridge_tgsync.Types))
 		currentContent = updateTypeBlock(currentContent, "go:crypto", string(bridge_crypto.Types))
+		currentContent = updateTypeBlock(currentContent, "go:errors", string(bridge_errors.Types))
+		currentContent = updateTypeBlock(currentContent, "go:context", string(bridge_context.Types))
 
 		if err := os.WriteFile(dtsPath, currentContent, 0644); err != nil {
 			fmt.Printf("Error writing types: %v\n", err)
@@ -75,8 +75,8 @@ export function main() {
 		`declare module "go:net/url"`,
 		`declare module "go:net/http"`,
 		`declare module "typego:memory"`,
-		`function isGoError(e: unknown): e is Error;`, // Global helper
-		`Parse(rawURL: string): URL;`,                 // Ensure net/url Parse exists
+		`function isGoError(e: unknown): e is GoError;`, // Global helper
+		`Parse(rawURL: string): URL;`,                   // Ensure net/url Parse exists
 	}
 
 	for _, check := range checks {
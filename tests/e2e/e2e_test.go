@@ -48,6 +48,7 @@ func TestCLI_Run_HelloWorld(t *testing.T) {
 	examplePath := filepath.Join(rootDir, "examples", "01-hello-world.ts")
 
 	cmd := exec.Command(typegoBinary, "run", examplePath)
+	cmd.Dir = t.TempDir() // Receives the compiler's cache
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -80,6 +81,8 @@ func TestCLI_Build_HelloWorld(t *testing.T) {
 
 	// 1. Build Compilation
 	t.Logf("Running typego build...")
+	// Build runs here to find the local source; drop the cache it leaves
+	t.Cleanup(func() { os.RemoveAll(".typego") })
 	cmd := exec.Command(typegoBinary, "build", "-o", outputPath, examplePath)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
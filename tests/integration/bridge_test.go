@@ -1,8 +1,28 @@
 package integration
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/grafana/sobek"
+	"github.com/repyh/typego/bridge/core"
+	"github.com/repyh/typego/bridge/intrinsics"
+	"github.com/repyh/typego/compiler"
+	"github.com/repyh/typego/engine"
 )
 
 func TestBridge_Fmt(t *testing.T) {
@@ -42,3 +62,2247 @@ func TestBridge_Worker_Init(t *testing.T) {
         // but verifying the constructor exists proves the bridge registered it.
 	`)
 }
+
+type decoratedUser struct {
+	ID    int64 `json:"user_id"`
+	Name  string
+	Score any
+}
+
+type userStore struct {
+	last decoratedUser
+}
+
+func (s *userStore) Save(u decoratedUser) decoratedUser {
+	s.last = u
+	return u
+}
+
+func TestBridge_Decorators_RoundTrip(t *testing.T) {
+	harness := NewHarness(t)
+	store := &userStore{}
+	if err := harness.Engine.BindStruct("store", store); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decorators are applied by hand because the harness skips the TS compiler
+	harness.Run(t, `
+		class User {
+			label() { return this.Name + "#" + this.id; }
+		}
+		GoType("integration.decoratedUser")(User);
+		JSONName("user_id")(User.prototype, "id");
+		JSONName("Score")(User.prototype, "score");
+		GoType("int32")(User.prototype, "score");
+		Pointer(User.prototype, "score");
+
+		const u = new User();
+		u.id = 42;
+		u.Name = "gopher";
+		u.score = 7;
+
+		const back = store.Save(u);
+		if (!(back instanceof User)) {
+			throw new Error("expected a User instance");
+		}
+		if (back.label() !== "gopher#42") {
+			throw new Error("unexpected label: " + back.label());
+		}
+	`)
+
+	if store.last.ID != 42 || store.last.Name != "gopher" {
+		t.Fatalf("unexpected struct: %+v", store.last)
+	}
+	if p, ok := store.last.Score.(*int32); !ok || *p != 7 {
+		t.Fatalf("expected *int32 score, got %T", store.last.Score)
+	}
+}
+
+func TestBridge_Decorators_Compiled(t *testing.T) {
+	harness := NewHarness(t)
+	store := &userStore{}
+	if err := harness.Engine.BindStruct("store", store); err != nil {
+		t.Fatal(err)
+	}
+
+	harness.Run(t, compileTS(t, `
+		@GoType("integration.decoratedUser")
+		class User {
+			@JSONName("user_id") id!: number;
+			Name!: string;
+			@JSONName("Score") @GoType("int32") @Pointer score!: number;
+
+			label(): string { return this.Name + "#" + this.id; }
+		}
+
+		(globalThis as any).done = (async () => {
+			const u = new User();
+			u.id = 42;
+			u.Name = "gopher";
+			u.score = 7;
+
+			const back = (globalThis as any).store.Save(u);
+			if (!(back instanceof User)) throw new Error("expected a User instance");
+			if (back.label() !== "gopher#42") throw new Error("unexpected label: " + back.label());
+		})();
+	`))
+
+	if store.last.ID != 42 || store.last.Name != "gopher" {
+		t.Fatalf("unexpected struct: %+v", store.last)
+	}
+	if p, ok := store.last.Score.(*int32); !ok || *p != 7 {
+		t.Fatalf("expected *int32 score, got %T", store.last.Score)
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+type shape struct {
+	Label   string           `json:"label"`
+	Points  []point          `json:"points"`
+	Named   map[string]point `json:"named"`
+	Origin  *point           `json:"origin,omitempty"`
+	Payload []byte           `json:"payload"`
+	Created time.Time        `json:"created"`
+	TTL     time.Duration    `json:"ttl"`
+	Secret  string           `json:"-"`
+}
+
+type shapeService struct{}
+
+func (shapeService) Echo(s shape) shape { return s }
+
+func (shapeService) Grid() map[point]string {
+	return map[point]string{{X: 1, Y: 2}: "a"}
+}
+
+func (shapeService) Count(m map[point]string) int { return len(m) }
+
+func (shapeService) Scale(n int) int { return n * 2 }
+
+func (shapeService) Negate(b bool) bool { return !b }
+
+func TestBridge_Reflection_Converter(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("shapes", shapeService{}); err != nil {
+		t.Fatal(err)
+	}
+
+	harness.Run(t, `
+		const created = new Date(Date.UTC(2024, 0, 2));
+		const out = shapes.Echo({
+			label: "tri",
+			points: [{ X: 1, Y: 1 }, { X: 2, Y: 3 }],
+			named: { top: { X: 0, Y: 9 } },
+			payload: new Uint8Array([1, 2, 3]),
+			created: created,
+			ttl: 1500,
+			Secret: "dropped",
+		});
+
+		if (out.label !== "tri" || out.points[1].Y !== 3 || out.named.top.Y !== 9) {
+			throw new Error("nested values lost: " + JSON.stringify(out));
+		}
+		if ("origin" in out || "Secret" in out) {
+			throw new Error("omitempty or json:\"-\" ignored");
+		}
+		if (!(out.payload instanceof Uint8Array) || out.payload[2] !== 3) {
+			throw new Error("payload is not a Uint8Array");
+		}
+		if (!(out.created instanceof Date) || out.created.getTime() !== created.getTime()) {
+			throw new Error("created is not the same Date");
+		}
+		if (out.ttl !== 1500) {
+			throw new Error("ttl mismatch: " + out.ttl);
+		}
+
+		const grid = shapes.Grid();
+		if (!(grid instanceof Map) || grid.size !== 1) {
+			throw new Error("struct-keyed map should be a Map");
+		}
+		if (shapes.Count(grid) !== 1) {
+			throw new Error("Map did not convert back to a struct-keyed map");
+		}
+
+		if (shapes.Scale(2.0) !== 4 || shapes.Negate(false) !== true) {
+			throw new Error("scalar arguments not converted");
+		}
+		for (const bad of [() => shapes.Scale(true), () => shapes.Negate(1)]) {
+			try {
+				bad();
+				throw new Error("bool and number should not convert");
+			} catch (e) {
+				if (!(e instanceof TypeError)) throw e;
+			}
+		}
+	`)
+}
+
+type LiveBase struct {
+	ID int
+}
+
+type liveCounter struct {
+	*LiveBase
+	Count int
+	Inner point
+}
+
+func (c *liveCounter) Inc() int {
+	c.Count++
+	return c.Count
+}
+
+func TestBridge_BindStructLive(t *testing.T) {
+	harness := NewHarness(t)
+	counter := &liveCounter{LiveBase: &LiveBase{ID: 1}}
+	if err := harness.Engine.BindStructLive("counter", counter); err != nil {
+		t.Fatal(err)
+	}
+
+	harness.Run(t, `
+		counter.Count = 5;
+		counter.Inner.X = 3;
+		counter.ID = 9;
+		if (counter.Inc() !== 6 || counter.Count !== 6) {
+			throw new Error("method and field disagree: " + counter.Count);
+		}
+		if (Object.keys(counter).join(",") !== "ID,Count,Inner") {
+			throw new Error("unexpected keys: " + Object.keys(counter));
+		}
+		const inner = counter.Inner;
+		if (counter.Inner !== inner || new Set([inner, counter.Inner]).size !== 1) {
+			throw new Error("nested struct should keep its wrapper");
+		}
+	`)
+
+	if counter.Count != 6 || counter.Inner.X != 3 || counter.ID != 9 {
+		t.Fatalf("JS writes not visible in Go: %+v %+v", *counter, *counter.LiveBase)
+	}
+
+	counter.Count = 100
+	harness.Run(t, `
+		if (counter.Count !== 100) {
+			throw new Error("Go write not visible in JS: " + counter.Count);
+		}
+	`)
+}
+
+type slowService struct{}
+
+func (slowService) Double(ctx context.Context, n int) (int, error) {
+	select {
+	case <-time.After(10 * time.Millisecond):
+		return n * 2, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (slowService) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (slowService) Square(n int) int {
+	time.Sleep(10 * time.Millisecond)
+	return n * n
+}
+
+func TestBridge_AsyncMethods(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("slow", slowService{}, core.AsyncMethods("Square")); err != nil {
+		t.Fatal(err)
+	}
+
+	harness.Run(t, `
+		const doubled = slow.Double(21);
+		if (typeof doubled.then !== "function") {
+			throw new Error("context-first method should return a promise");
+		}
+		if (await doubled !== 42) {
+			throw new Error("unexpected Double result");
+		}
+		if (await slow.Square(5) !== 25) {
+			throw new Error("unexpected Square result");
+		}
+
+		const waiting = slow.Wait();
+		waiting.cancel();
+		let cancelled = false;
+		await waiting.catch(() => { cancelled = true; });
+		if (!cancelled) {
+			throw new Error("cancel() should reject the promise");
+		}
+	`)
+}
+
+type QuotaError struct {
+	Limit int
+}
+
+func (e *QuotaError) Error() string { return fmt.Sprintf("quota of %d exceeded", e.Limit) }
+
+var errQuota = errors.New("quota")
+
+type quotaService struct{}
+
+func (quotaService) Reserve(n int) (int, error) {
+	return 0, fmt.Errorf("reserve %d: %w", n, errors.Join(errQuota, &QuotaError{Limit: 10}))
+}
+
+func TestBridge_GoErrors(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("quota", quotaService{}); err != nil {
+		t.Fatal(err)
+	}
+	_ = harness.Engine.VM.Set("errQuota", errQuota)
+	_ = harness.Engine.VM.Set("osErrNotExist", os.ErrNotExist)
+
+	harness.Run(t, `
+		const errors = __go_errors__;
+		const goos = __go_os__;
+
+		try {
+			goos.ReadFile("missing-file.txt");
+			throw new Error("ReadFile should throw");
+		} catch (e) {
+			if (!(e instanceof GoError) || !isGoError(e)) throw new Error("expected a GoError");
+			if (!errors.is(e, goos.ErrNotExist) || !e.is(osErrNotExist)) throw new Error("errors.is should match ErrNotExist");
+			const pe = errors.as(e, "*fs.PathError");
+			if (pe === null || pe.Op !== "open" || !pe.Path.endsWith("missing-file.txt")) {
+				throw new Error("errors.as should expose *fs.PathError fields");
+			}
+			if (e.goType !== "*fmt.wrapError" || e.chain[1].type !== "*fs.PathError") {
+				throw new Error("unexpected chain: " + e.goType + " " + JSON.stringify(e.chain));
+			}
+		}
+
+		try {
+			quota.Reserve(3);
+		} catch (e) {
+			if (!errors.is(e, errQuota)) throw new Error("sentinel identity lost");
+			const qe = errors.as(e, "QuotaError");
+			if (qe === null || qe.Limit !== 10) throw new Error("errors.as by bare name failed");
+			if (errors.as(e, "*fs.PathError") !== null) throw new Error("unexpected match");
+			if (e.unwrap() === null || e.unwrap().unwrap() !== null) throw new Error("unwrap follows single-error wrapping only");
+		}
+
+		if (isGoError(new Error("plain"))) throw new Error("plain JS errors are not Go errors");
+	`)
+}
+
+type callbackService struct{}
+
+func (callbackService) Map(xs []int, f func(int) (string, error)) ([]string, error) {
+	out := make([]string, 0, len(xs))
+	for _, x := range xs {
+		s, err := f(x)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (callbackService) Split(f func() (int, string)) string {
+	n, s := f()
+	return fmt.Sprintf("%s=%d", s, n)
+}
+
+func (callbackService) Each(xs []int, f func(int)) {
+	for _, x := range xs {
+		f(x)
+	}
+}
+
+func (callbackService) IsQuota(f func() error) bool {
+	return errors.Is(f(), errQuota)
+}
+
+// Later invokes f from its own goroutine, as libraries with worker pools do.
+func (callbackService) Later(ctx context.Context, n int, f func(int) (int, error)) (int, error) {
+	return f(n)
+}
+
+func TestBridge_Callbacks(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("cb", callbackService{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := harness.Engine.BindStruct("quota", quotaService{}); err != nil {
+		t.Fatal(err)
+	}
+
+	harness.Run(t, `
+		const words = cb.Map([1, 2], (n) => "n" + n);
+		if (words.join(",") !== "n1,n2") throw new Error("unexpected Map result: " + words);
+
+		try {
+			cb.Map([1, 2, 3], (n) => { if (n === 2) throw new Error("bad " + n); return String(n); });
+			throw new Error("Map should fail");
+		} catch (e) {
+			if (!String(e.message).includes("bad 2")) throw e;
+		}
+
+		if (cb.Split(() => [7, "seven"]) !== "seven=7") throw new Error("multiple results not mapped");
+
+		let seen = 0;
+		try {
+			cb.Each([1, 2, 3], (n) => { seen = n; if (n === 2) throw new Error("stop"); });
+		} catch (e) {
+			if (e.message !== "stop") throw e;
+		}
+		if (seen !== 2) throw new Error("exception should propagate without an error result");
+
+		const isQuota = cb.IsQuota(() => {
+			try { quota.Reserve(1); } catch (e) { throw e; }
+		});
+		if (!isQuota) throw new Error("rethrown GoError should keep its Go identity");
+
+		const tripled = await cb.Later(5, async (n) => {
+			await new Promise((r) => setTimeout(r, 5));
+			return n * 3;
+		});
+		if (tripled !== 15) throw new Error("async callback from a foreign goroutine: " + tripled);
+
+		let rejected = false;
+		await cb.Later(1, async () => { throw new Error("nope"); }).catch((e) => { rejected = e.message.includes("nope"); });
+		if (!rejected) throw new Error("rejection should surface as the Go error");
+	`)
+}
+
+// TestBridge_CallbacksInTasks calls JS back from Go methods called inside
+// go() tasks, which already hold the runtime lock.
+func TestBridge_CallbacksInTasks(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("cb", callbackService{}); err != nil {
+		t.Fatal(err)
+	}
+	_ = harness.Engine.VM.Set("describe", harness.Engine.WrapFunc("describe", describe))
+
+	harness.Run(t, `
+		let sum = 0;
+		await go(() => cb.Each([1, 2, 3], (x) => { sum += x; }));
+		if (sum !== 6) throw new Error("callback inside go(): " + sum);
+
+		const words = await go(() => cb.Map([4, 5], (n) => "n" + n));
+		if (words.join(",") !== "n4,n5") throw new Error("callback results inside go(): " + words);
+
+		const shape = await go(() => describe({ Area: () => 2, Name: () => "unit" }));
+		if (shape !== "unit:2.0") throw new Error("interface adapter inside go(): " + shape);
+
+		const tripled = await go(() => cb.Later(5, (n) => n * 3));
+		if (tripled !== 15) throw new Error("foreign callback from a go() task: " + tripled);
+	`)
+}
+
+func TestBridge_UnobservedFailures(t *testing.T) {
+	harness := NewHarness(t)
+	var stdout, stderr bytes.Buffer
+	harness.Engine.Console.SetOutput(&stdout, &stderr)
+	// Failures are reported on the loop, where the script reads them back
+	_ = harness.Engine.VM.Set("reported", func() string { return stderr.String() })
+
+	harness.Run(t, `
+		const { Worker } = __typego_worker__;
+		const waitFor = async (text) => {
+			for (let i = 0; i < 200 && !reported().includes(text); i++) await new Promise((r) => setTimeout(r, 5));
+			if (!reported().includes(text)) throw new Error("not reported: " + text + " in " + JSON.stringify(reported()));
+		};
+
+		go(() => { throw new Error("lost task"); });
+		await waitFor("Uncaught lost task");
+		const w = new Worker(() => { throw new Error("lost worker"); });
+		await waitFor("lost worker");
+		w.terminate();
+	`)
+	if stdout.Len() != 0 {
+		t.Errorf("failures printed to stdout: %q", stdout.String())
+	}
+}
+
+func TestBridge_ForeignCallbackWhileBusy(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("cb", callbackService{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Later calls back from its own goroutine while the loop is still running
+	// this script; the call must wait for the loop rather than run inline,
+	// which go test -race reports as a race inside the VM.
+	harness.Run(t, `
+		let busy = true;
+		let sawBusy;
+		const pending = cb.Later(2, (n) => { sawBusy = busy; return n * 2; });
+		const until = Date.now() + 50;
+		while (Date.now() < until) {}
+		busy = false;
+		const got = await pending;
+		if (got !== 4 || sawBusy !== false) throw new Error("callback ran during the script: " + got + " " + sawBusy);
+	`)
+}
+
+// Shape is implemented by scripts through the adapter below, written the way
+// the linker generates adapters for hyperlinked packages.
+type Shape interface {
+	Area() (float64, error)
+	Name() string
+}
+
+type jsShapeAdapter struct {
+	impl   *core.JSImpl
+	m_Area func() (float64, error)
+	m_Name func() string
+}
+
+func (a *jsShapeAdapter) Area() (float64, error)      { return a.m_Area() }
+func (a *jsShapeAdapter) Name() string                { return a.m_Name() }
+func (a *jsShapeAdapter) TypeGoObject() *sobek.Object { return a.impl.Object() }
+
+func init() {
+	core.RegisterInterface((*Shape)(nil), func(impl *core.JSImpl) (interface{}, error) {
+		a := &jsShapeAdapter{impl: impl}
+		if err := impl.Bind("Area", &a.m_Area); err != nil {
+			return nil, err
+		}
+		if err := impl.Bind("Name", &a.m_Name); err != nil {
+			return nil, err
+		}
+		return a, nil
+	})
+}
+
+func describe(s Shape) (string, error) {
+	area, err := s.Area()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%.1f", s.Name(), area), nil
+}
+
+type ifaceService struct{}
+
+func (ifaceService) Sort(data sort.Interface) { sort.Sort(data) }
+
+func (ifaceService) ReadAll(r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
+
+func (ifaceService) Same(s fmt.Stringer) fmt.Stringer { return s }
+
+func TestBridge_InterfaceAdapters(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("ifaces", ifaceService{}); err != nil {
+		t.Fatal(err)
+	}
+	_ = harness.Engine.VM.Set("describe", harness.Engine.WrapFunc("describe", describe))
+	_ = harness.Engine.VM.Set("EOF", io.EOF)
+
+	harness.Run(t, `
+		const items = [3, 1, 2];
+		ifaces.Sort({
+			Len: () => items.length,
+			Less: (i, j) => items[i] < items[j],
+			swap(i, j) { const t = items[i]; items[i] = items[j]; items[j] = t; },
+		});
+		if (items.join(",") !== "1,2,3") throw new Error("sort.Interface not honoured: " + items);
+
+		const chunks = ["hello ", "world"];
+		const text = ifaces.ReadAll({
+			Read(p) {
+				const chunk = chunks.shift();
+				if (chunk === undefined) throw EOF;
+				for (let i = 0; i < chunk.length; i++) p[i] = chunk.charCodeAt(i);
+				return chunk.length;
+			},
+		});
+		if (text !== "hello world") throw new Error("io.Reader adapter: " + text);
+
+		const square = { side: 2, Area() { return this.side * this.side; }, Name: () => "square" };
+		if (describe(square) !== "square:4.0") throw new Error("custom interface adapter failed");
+
+		try {
+			describe({ Area() { throw new Error("no area"); }, Name: () => "x" });
+			throw new Error("describe should fail");
+		} catch (e) {
+			if (!String(e.message).includes("no area")) throw e;
+		}
+
+		try {
+			describe({ Name: () => "partial" });
+			throw new Error("missing methods should be rejected");
+		} catch (e) {
+			if (!String(e.message).includes("missing method Area")) throw e;
+		}
+
+		const s = { String: () => "me" };
+		if (ifaces.Same(s) !== s) throw new Error("adapters should hand back the JS object");
+	`)
+}
+
+type chanService struct{}
+
+func (chanService) Sum(ch <-chan int) int {
+	total := 0
+	for v := range ch {
+		total += v
+	}
+	return total
+}
+
+func (chanService) Words(words []string) <-chan string {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, w := range words {
+			ch <- w
+		}
+	}()
+	return ch
+}
+
+func TestBridge_Channels(t *testing.T) {
+	harness := NewHarness(t)
+	if err := harness.Engine.BindStruct("chans", chanService{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Scripts are bundled down to ES2017, which is how for-await reaches sobek
+	loop := api.Transform(`
+		globalThis.collect = async (ch) => {
+			const out = [];
+			for await (const v of ch) out.push(v);
+			return out;
+		};
+	`, api.TransformOptions{Target: api.ES2017})
+	if len(loop.Errors) > 0 {
+		t.Fatal(loop.Errors[0].Text)
+	}
+
+	harness.Run(t, string(loop.Code)+`
+		const nums = makeChan(3, "int");
+		if (!nums.trySend(1) || !nums.trySend(2) || !nums.trySend(3)) throw new Error("buffered trySend failed");
+		if (nums.trySend(4)) throw new Error("trySend on a full channel should fail");
+		if (nums.len() !== 3 || nums.cap() !== 3 || cap(nums) !== 3) throw new Error("len/cap");
+		nums.close();
+		if (!nums.closed) throw new Error("closed flag not set");
+		if (chans.Sum(nums) !== 6) throw new Error("chan int not passed to Go");
+
+		try {
+			nums.send(5);
+			throw new Error("send on closed channel should panic");
+		} catch (e) {
+			if (!String(e.message).includes("send on closed channel")) throw e;
+		}
+		try {
+			nums.close();
+			throw new Error("double close should panic");
+		} catch (e) {
+			if (!String(e.message).includes("close of closed channel")) throw e;
+		}
+
+		const words = await collect(chans.Words(["a", "b", "c"]));
+		if (words.join("") !== "abc") throw new Error("for await over Go channel: " + words);
+
+		const box = makeChan(1);
+		const obj = {};
+		const [none, ready] = box.tryRecv();
+		if (ready || none !== undefined) throw new Error("tryRecv on empty channel");
+		const pending = box.recvAsync();
+		box.send(obj);
+		if (await pending !== obj) throw new Error("untyped channel should keep identity");
+
+		const done = makeChan(0);
+		setTimeout(() => done.close(), 10);
+		if (await done.recvAsync() !== undefined || !done.closed) throw new Error("recvAsync on close");
+
+		try {
+			makeChan(1, "int").send("x");
+			throw new Error("typed send should convert");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+	`)
+}
+
+func TestBridge_SelectAsync(t *testing.T) {
+	harness := NewHarness(t)
+	_ = harness.Engine.VM.Set("countdown", func(n int) <-chan int {
+		ch := make(chan int)
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			ch <- n
+		}()
+		return ch
+	})
+	_ = harness.Engine.VM.Set("expired", harness.Engine.WrapFunc("expired", func(ctx context.Context) string {
+		<-ctx.Done()
+		return ctx.Err().Error()
+	}))
+
+	harness.Run(t, `
+		const gocontext = __go_context__;
+
+		let ticked = false;
+		setTimeout(() => { ticked = true; }, 1);
+		let fired = null;
+		const idle = makeChan(0);
+		const chosen = await selectAsync([
+			{ chan: idle, recv: () => { throw new Error("idle channel received"); } },
+			{ chan: after(30), recv: (v) => { fired = v; } },
+		]);
+		if (chosen !== 1 || !(fired instanceof Date)) throw new Error("after() case not chosen");
+		if (!ticked) throw new Error("timers should run while selectAsync waits");
+
+		const [ctx, cancel] = gocontext.WithCancel();
+		setTimeout(cancel, 1);
+		let reason = null;
+		await selectAsync([{ chan: idle }, { ctx, done: (err) => { reason = err; } }]);
+		if (!reason || !reason.is(gocontext.Canceled) || !ctx.Err()) throw new Error("ctx case: " + reason);
+
+		const [short] = gocontext.WithTimeout(undefined, 1);
+		if (await expired(short) !== "context deadline exceeded") throw new Error("context not passed to Go");
+
+		const sc = Chan(1);
+		setTimeout(() => sc.Send("from go:sync"), 1);
+		let got;
+		await selectAsync([{ chan: sc, recv: (v) => { got = v; } }]);
+		if (got !== "from go:sync") throw new Error("go:sync Chan: " + got);
+
+		let n;
+		await selectAsync([{ chan: countdown(3), recv: (v) => { n = v; } }, { chan: after(1000) }]);
+		if (n !== 3) throw new Error("hyperlinked channel: " + n);
+
+		let fellThrough = false;
+		const idx = await selectAsync([{ chan: idle }, { default: () => { fellThrough = true; } }]);
+		if (idx !== 1 || !fellThrough) throw new Error("default case");
+
+		const closed = makeChan(0);
+		closed.close();
+		try {
+			await selectAsync([{ chan: closed, send: 1 }]);
+			throw new Error("send on closed channel should reject");
+		} catch (e) {
+			if (!String(e.message).includes("send on closed channel")) throw e;
+		}
+	`)
+}
+
+func TestBridge_SyncGroups(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const tgsync = __typego_sync__;
+
+		if (await go((a, b) => a + b, 2, 3) !== 5) throw new Error("go() handle result");
+		const late = go(async () => { await new Promise((r) => setTimeout(r, 1)); return "late"; });
+		if (await late.wait() !== "late" || !late.done) throw new Error("async go() result");
+		try {
+			await go(() => { throw new Error("boom"); });
+			throw new Error("go() failure should reject");
+		} catch (e) {
+			if (e.message !== "boom") throw e;
+		}
+
+		const wg = new tgsync.WaitGroup();
+		let count = 0;
+		for (let i = 0; i < 5; i++) wg.go(() => { count++; });
+		await wg.wait();
+		if (count !== 5) throw new Error("WaitGroup.go: " + count);
+		wg.add(1);
+		setTimeout(() => wg.done(), 1);
+		await wg.wait();
+		try {
+			wg.done();
+			throw new Error("negative counter should panic");
+		} catch (e) {
+			if (!String(e.message).includes("negative WaitGroup counter")) throw e;
+		}
+
+		const [g, ctx] = tgsync.withContext();
+		let sawCancel = false;
+		g.go(async () => { await selectAsync([{ ctx, done: () => { sawCancel = true; } }]); });
+		g.go(() => { throw new Error("first"); });
+		try {
+			await g.wait();
+			throw new Error("errgroup should reject");
+		} catch (e) {
+			if (e.message !== "first") throw e;
+		}
+		if (!sawCancel || !ctx.Err()) throw new Error("errgroup context not cancelled");
+
+		const limited = new tgsync.Group();
+		limited.setLimit(1);
+		let active = 0, peak = 0;
+		for (let i = 0; i < 3; i++) {
+			limited.go(async () => {
+				peak = Math.max(peak, ++active);
+				await new Promise((r) => setTimeout(r, 2));
+				active--;
+			});
+		}
+		await limited.wait();
+		if (peak !== 1) throw new Error("limit not honoured: " + peak);
+
+		const single = new tgsync.Group();
+		single.setLimit(1);
+		const first = single.tryGo(() => new Promise((r) => setTimeout(r, 5)));
+		if (!first || single.tryGo(() => {}) !== null) throw new Error("tryGo at limit");
+		await single.wait();
+
+		const once = new tgsync.Once();
+		let calls = 0;
+		once.do(() => calls++);
+		once.do(() => calls++);
+		if (calls !== 1) throw new Error("Once ran " + calls);
+
+		const sem = new tgsync.Semaphore(2);
+		await sem.acquire(2);
+		if (sem.tryAcquire()) throw new Error("semaphore over-acquired");
+		setTimeout(() => sem.release(2), 1);
+		await sem.acquire();
+		sem.release();
+		const [short] = __go_context__.WithTimeout(undefined, 1);
+		try {
+			await sem.acquire(3, short);
+			throw new Error("acquire should honour ctx");
+		} catch (e) {
+			if (!e.is(__go_context__.DeadlineExceeded)) throw e;
+		}
+	`)
+}
+
+func TestBridge_GoParallel(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const fib = (n) => n < 2 ? n : fib(n - 1) + fib(n - 2);
+		const task = (n) => { const f = (k) => k < 2 ? k : f(k - 1) + f(k - 2); return f(n); };
+		const results = await Promise.all([20, 21, 22].map((n) => go.parallel(task, n)));
+		if (results.join() !== [20, 21, 22].map(fib).join()) throw new Error("parallel results: " + results);
+
+		const out = await go.parallel((o, bytes) => ({
+			sum: o.xs.reduce((a, b) => a + b, 0),
+			when: o.when,
+			bytes: new Uint8Array(bytes.buffer.slice(0)).map((b) => b * 2),
+		}), { xs: [1, 2, 3], when: new Date(0) }, new Uint8Array([1, 2]));
+		if (out.sum !== 6 || !(out.when instanceof Date) || out.bytes[1] !== 4) throw new Error("transfer: " + JSON.stringify(out));
+
+		const later = await go.parallel(async (v) => { await new Promise((r) => setTimeout(r, 1)); return v + 1; }, 1);
+		if (later !== 2) throw new Error("async parallel: " + later);
+
+		try {
+			await go.parallel(() => { throw new RangeError("bad"); });
+			throw new Error("parallel throw should reject");
+		} catch (e) {
+			if (!(e instanceof RangeError) || e.message !== "bad") throw e;
+		}
+
+		for (const name of ["Function", "Worker"]) {
+			try {
+				await go.parallel((n) => { const e = new Error("globalThis.hijacked = true"); e.name = n; throw e; }, name);
+				throw new Error("parallel throw should reject");
+			} catch (e) {
+				if (!(e instanceof Error) || e.name !== name || e.message !== "globalThis.hijacked = true") throw new Error("foreign error name " + name + ": " + e);
+			}
+		}
+
+		const captured = 1;
+		try {
+			await go.parallel(() => captured);
+			throw new Error("closures should not cross runtimes");
+		} catch (e) {
+			if (e.name !== "ReferenceError") throw e;
+		}
+
+		try {
+			go.parallel((f) => f(), () => {});
+			throw new Error("functions should not transfer");
+		} catch (e) {
+			if (!String(e.message).includes("not transferable")) throw e;
+		}
+	`)
+}
+
+// compileTS bundles src as a .ts entry point, the way typego run does. The
+// bundle is an IIFE, so scripts hand their work to the harness through
+// globalThis.done. It runs in a temporary directory, which receives the
+// compiler's cache.
+func compileTS(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	path := filepath.Join(dir, "main.ts")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := compiler.Compile(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.JS + "\nawait globalThis.done;"
+}
+
+func TestBridge_CompiledParallel(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, compileTS(t, `
+		(globalThis as any).done = (async () => {
+			const got = await go.parallel(async (n: number) => {
+				await new Promise((r) => setTimeout(r, 1));
+				return n + 1;
+			}, 1);
+			if (got !== 2) throw new Error("compiled async parallel: " + got);
+		})();
+	`))
+}
+
+func TestBridge_WorkerMessaging(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const { Worker } = __typego_worker__;
+		const next = (w, kind) => new Promise((resolve) => { w["on" + kind] = resolve; });
+
+		const w = new Worker(() => {
+			self.onmessage = (e) => {
+				const { map, set, when, floats, seg, buf } = e.data;
+				new Uint8Array(seg.buffer)[0] = 42;
+				if (e.data.boom) throw new TypeError("boom in " + self.name);
+				self.postMessage({
+					name: self.name,
+					env: process.env.MODE,
+					map: map.get("k").nested,
+					set: set.has(2),
+					year: when.getUTCFullYear(),
+					floats: Array.from(floats),
+					bytes: buf.byteLength,
+					self: e.data.cycle.self === e.data.cycle,
+				});
+			};
+		}, { name: "inline", env: { MODE: "test" } });
+
+		const seg = __typego_memory__.makeShared("worker-msg", 4);
+		const buf = new ArrayBuffer(8);
+		const cycle = {};
+		cycle.self = cycle;
+		const reply = next(w, "message");
+		w.postMessage({
+			map: new Map([["k", { nested: "v" }]]),
+			set: new Set([1, 2]),
+			when: new Date(Date.UTC(2024, 0, 1)),
+			floats: new Float64Array([1.5, 2.5]),
+			seg, buf, cycle,
+		}, [buf]);
+		if (buf.byteLength !== 0) throw new Error("transferred buffer not detached");
+
+		const { data } = await reply;
+		const want = { name: "inline", env: "test", map: "v", set: true, year: 2024, floats: [1.5, 2.5], bytes: 8, self: true };
+		if (JSON.stringify(data) !== JSON.stringify(want)) throw new Error("worker reply: " + JSON.stringify(data));
+		if (new Uint8Array(seg.buffer)[0] !== 42) throw new Error("shared segment not passed by reference");
+
+		const failed = next(w, "error");
+		w.postMessage({ boom: true, seg });
+		const ev = await failed;
+		if (!(ev.error instanceof TypeError) || ev.message !== "TypeError: boom in inline") throw new Error("onerror: " + ev.message);
+
+		try {
+			w.postMessage({ fn() {} });
+			throw new Error("functions should not clone");
+		} catch (e) {
+			if (e.name !== "DataCloneError") throw e;
+		}
+		w.terminate();
+
+		const broken = new Worker("does/not/exist.ts");
+		const compileErr = await next(broken, "error");
+		if (compileErr.filename !== "does/not/exist.ts" || !compileErr.message) throw new Error("compile error event: " + compileErr.message);
+		broken.terminate();
+	`)
+}
+
+func TestBridge_CompiledWorker(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, compileTS(t, `
+		import { Worker } from "typego:worker";
+
+		(globalThis as any).done = (async () => {
+			const w = new Worker(async () => {
+				await new Promise((r) => setTimeout(r, 1));
+				self.onmessage = async (e: any) => {
+					await new Promise((r) => setTimeout(r, 1));
+					self.postMessage(e.data + 1);
+				};
+				self.postMessage("ready");
+			});
+			const next = () => new Promise<any>((resolve, reject) => {
+				w.onmessage = (e: any) => resolve(e.data);
+				w.onerror = (e: any) => reject(new Error(e.message));
+			});
+			if ((await next()) !== "ready") throw new Error("compiled async worker did not start");
+			const reply = next();
+			w.postMessage(1);
+			if ((await reply) !== 2) throw new Error("compiled async worker reply");
+			w.terminate();
+		})();
+	`))
+}
+
+func TestBridge_WorkerPool(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const { WorkerPool } = __typego_worker__;
+
+		const pool = new WorkerPool(async (task) => {
+			if (task.fail) throw new RangeError("bad task " + task.n);
+			if (task.hang) await new Promise(() => {});
+			await new Promise((r) => setTimeout(r, 1));
+			return task.n * 2;
+		}, { size: 2, maxQueue: 2 });
+
+		const first = [1, 2, 3, 4, 5].map((n) => pool.run({ n }));
+		const stats = pool.stats();
+		if (stats.busy !== 2 || stats.idle !== 0 || stats.queued !== 2 || stats.waiting !== 1) throw new Error("stats: " + JSON.stringify(stats));
+		if ((await Promise.all(first)).join() !== "2,4,6,8,10") throw new Error("results not routed");
+		if (pool.stats().waiting !== 0) throw new Error("waiting task not admitted");
+
+		const strict = new WorkerPool((n) => new Promise((r) => setTimeout(() => r(n), 5)), { size: 1, maxQueue: 0, overflow: "reject" });
+		const running = strict.run(1);
+		try {
+			await strict.run(2);
+			throw new Error("full queue should reject");
+		} catch (e) {
+			if (e.name !== "QueueFullError") throw e;
+		}
+		if (await running !== 1) throw new Error("strict pool result");
+		strict.terminate();
+		try {
+			new WorkerPool(() => {}, { overflow: "drop" });
+			throw new Error("unknown overflow policy should throw");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+
+		try {
+			await pool.run({ fail: true, n: 1 });
+			throw new Error("task failure should reject");
+		} catch (e) {
+			if (!(e instanceof RangeError) || e.message !== "bad task 1") throw e;
+		}
+
+		try {
+			await pool.run({ hang: true }, { timeout: 20 });
+			throw new Error("timeout should reject");
+		} catch (e) {
+			if (e.name !== "TimeoutError") throw e;
+		}
+		if (await pool.run({ n: 21 }) !== 42) throw new Error("pool unusable after respawn");
+
+		const after = pool.stats();
+		if (after.respawns !== 1 || after.completed !== 6 || after.failed !== 2) throw new Error("metrics: " + JSON.stringify(after));
+
+		pool.terminate();
+		try {
+			await pool.run({ n: 1 });
+			throw new Error("terminated pool should reject");
+		} catch (e) {
+			if (!String(e.message).includes("terminated")) throw e;
+		}
+
+		const broken = new WorkerPool("does/not/exist.ts", { size: 1 });
+		broken.onerror = () => {};
+		try {
+			await broken.run(1);
+			throw new Error("compile failure should reject");
+		} catch (e) {
+			if (!e.message) throw e;
+		}
+		broken.terminate();
+	`)
+}
+
+func TestBridge_CompiledWorkerPool(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, compileTS(t, `
+		import { WorkerPool } from "typego:worker";
+
+		(globalThis as any).done = (async () => {
+			const pool = new WorkerPool(async (task: { n: number }) => {
+				await new Promise((r) => setTimeout(r, 1));
+				return task.n * 2;
+			}, { size: 2 });
+			const got = await Promise.all([1, 2, 3].map((n) => pool.run({ n })));
+			if (got.join() !== "2,4,6") throw new Error("compiled async pool: " + got);
+			pool.terminate();
+		})();
+	`))
+}
+
+func TestBridge_WorkerSupervision(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const { Worker, WorkerPool } = __typego_worker__;
+		const next = (w, kind) => new Promise((resolve) => { w["on" + kind] = resolve; });
+
+		const w = new Worker(() => {
+			self.onmessage = (e) => {
+				if (e.data === "crash") process.exit(2);
+				self.postMessage("pong");
+			};
+		}, { supervise: { backoff: 10, maxRestarts: 2 } });
+
+		for (const attempt of [1, 2]) {
+			const exited = next(w, "exit");
+			const restarted = next(w, "restart");
+			w.postMessage("crash");
+			const exit = await exited;
+			if (exit.code !== 2 || !exit.restarting) throw new Error("onexit: " + JSON.stringify(exit));
+			const restart = await restarted;
+			if (restart.attempt !== attempt || restart.delay !== 10 * attempt || restart.code !== 2) throw new Error("onrestart: " + JSON.stringify(restart));
+			const pong = next(w, "message");
+			w.postMessage("ping");
+			if ((await pong).data !== "pong") throw new Error("restarted worker not answering");
+		}
+		const gaveUp = next(w, "exit");
+		w.postMessage("crash");
+		if ((await gaveUp).restarting) throw new Error("restart limit not enforced");
+
+		const clean = new Worker(() => { process.exit(0); });
+		const cleanExit = await next(clean, "exit");
+		if (cleanExit.code !== 0 || cleanExit.restarting) throw new Error("transient worker restarted after clean exit");
+
+		const failing = new Worker(() => { throw new Error("startup"); }, { supervise: { restart: "temporary" } });
+		const failed = next(failing, "exit");
+		const err = await next(failing, "error");
+		if (err.message !== "Error: startup") throw new Error("onerror: " + err.message);
+		const failedExit = await failed;
+		if (failedExit.code !== 1 || failedExit.restarting) throw new Error("crash exit: " + JSON.stringify(failedExit));
+
+		const pool = new WorkerPool((task) => {
+			if (task.exit) process.exit(5);
+			return task.n * 2;
+		}, { size: 1, supervise: { backoff: 5 } });
+		try {
+			await pool.run({ exit: true });
+			throw new Error("exiting task should reject");
+		} catch (e) {
+			if (!String(e.message).includes("code 5")) throw e;
+		}
+		if (await pool.run({ n: 2 }) !== 4) throw new Error("pool worker not restarted");
+		if (pool.stats().respawns !== 1) throw new Error("respawns: " + JSON.stringify(pool.stats()));
+		pool.terminate();
+
+		try {
+			new Worker(() => {}, { supervise: { restart: "sometimes" } });
+			throw new Error("unknown policy should throw");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+	`)
+}
+
+func TestBridge_SharedArrayBufferAtomics(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const sab = new SharedArrayBuffer(16);
+		if (!(sab instanceof SharedArrayBuffer) || !(sab instanceof ArrayBuffer) || sab.byteLength !== 16) throw new Error("SharedArrayBuffer shape");
+		if (Object.prototype.toString.call(sab) !== "[object SharedArrayBuffer]") throw new Error("toStringTag");
+
+		const i32 = new Int32Array(sab);
+		if (Atomics.add(i32, 0, 5) !== 0 || Atomics.sub(i32, 0, 2) !== 5 || Atomics.load(i32, 0) !== 3) throw new Error("add/sub/load");
+		if (Atomics.compareExchange(i32, 0, 1, 9) !== 3 || Atomics.compareExchange(i32, 0, 3, 9) !== 3 || i32[0] !== 9) throw new Error("compareExchange");
+		if (Atomics.exchange(i32, 1, -1) !== 0 || Atomics.store(i32, 2, 7.9) !== 7 || i32[2] !== 7) throw new Error("exchange/store");
+		if (Atomics.or(i32, 3, 6) !== 0 || Atomics.and(i32, 3, 3) !== 6 || Atomics.xor(i32, 3, 1) !== 2 || i32[3] !== 3) throw new Error("bitwise");
+
+		const i8 = new Int8Array(sab, 4, 4);
+		Atomics.store(i8, 0, 0);
+		if (Atomics.add(i8, 1, 200) !== -1 || i8[1] !== -57) throw new Error("Int8 wrap: " + i8[1]);
+		if (i32[0] !== 9) throw new Error("sub-word op touched its neighbours");
+		const u16 = new Uint16Array(sab, 12, 2);
+		Atomics.store(u16, 1, 0);
+		if (Atomics.sub(u16, 1, 1) !== 0 || u16[1] !== 65535) throw new Error("Uint16 wrap");
+
+		const big = new BigInt64Array(new SharedArrayBuffer(16));
+		if (Atomics.add(big, 1, 10n) !== 0n || Atomics.load(big, 1) !== 10n) throw new Error("BigInt64");
+
+		for (const [fn, kind] of [[() => Atomics.add(new Float64Array(1), 0, 1), TypeError], [() => Atomics.load(i32, 4), RangeError], [() => Atomics.wait(new Int32Array(4), 0, 0, 0), TypeError]]) {
+			try {
+				fn();
+				throw new Error("should throw " + kind.name);
+			} catch (e) {
+				if (!(e instanceof kind)) throw e;
+			}
+		}
+
+		const counter = new Int32Array(new SharedArrayBuffer(4));
+		await Promise.all([1, 2, 3, 4].map(() => go.parallel((c) => {
+			for (let i = 0; i < 1000; i++) Atomics.add(c, 0, 1);
+		}, counter)));
+		if (counter[0] !== 4000) throw new Error("parallel adds: " + counter[0]);
+
+		const seg = __typego_memory__.makeShared("atomics-seg", 8);
+		if (!(seg.buffer instanceof SharedArrayBuffer)) throw new Error("segment is not a SharedArrayBuffer");
+		await go.parallel((s) => { Atomics.store(new Int32Array(s.buffer), 1, 77); }, seg);
+		if (Atomics.load(new Int32Array(seg.buffer), 1) !== 77) throw new Error("segment not shared");
+
+		const flag = new Int32Array(new SharedArrayBuffer(8));
+		if (Atomics.wait(flag, 0, 1, 0) !== "not-equal" || Atomics.wait(flag, 0, 0, 1) !== "timed-out") throw new Error("wait results");
+
+		// The notifying task needs the lock the waiting script holds
+		const gate = new Int32Array(new SharedArrayBuffer(4));
+		go(() => { Atomics.store(gate, 0, 1); Atomics.notify(gate, 0); });
+		if (Atomics.wait(gate, 0, 0, 5000) === "timed-out" || gate[0] !== 1) throw new Error("wait blocked go() tasks");
+
+		const { Worker } = __typego_worker__;
+		const w = new Worker(() => {
+			self.onmessage = (e) => {
+				const flag = e.data;
+				self.postMessage("waiting");
+				const res = Atomics.wait(flag, 0, 0, 5000);
+				Atomics.store(flag, 1, 1);
+				Atomics.notify(flag, 1);
+				self.postMessage(res);
+			};
+		});
+		const replies = [];
+		let woken;
+		const done = new Promise((resolve) => {
+			w.onmessage = (e) => {
+				replies.push(e.data);
+				if (e.data === "waiting") {
+					woken = Atomics.waitAsync(flag, 1, 0);
+					// The worker parks right after posting; retry until it has
+					const spin = () => Atomics.notify(flag, 0) === 1 ? undefined : setTimeout(spin, 1);
+					spin();
+				} else {
+					resolve();
+				}
+			};
+		});
+		w.postMessage(flag);
+		await done;
+		if (!woken.async) throw new Error("waitAsync resolved early");
+		replies.push("async:" + await woken.value);
+		w.terminate();
+		if (replies.join() !== "waiting,ok,async:ok") throw new Error("wait/notify: " + replies.join());
+
+		try {
+			w.postMessage(null, [sab]);
+			throw new Error("SharedArrayBuffer should not transfer");
+		} catch (e) {
+			if (e.name !== "DataCloneError") throw e;
+		}
+	`)
+}
+
+func TestBridge_AtomicsWaitAsyncCancel(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		globalThis.pending = Atomics.waitAsync(new Int32Array(new SharedArrayBuffer(4)), 0, 0);
+		if (!pending.async) throw new Error("waitAsync resolved early");
+	`)
+
+	// Stopping the runtime must release a wait that is never notified
+	harness.Engine.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := harness.Engine.EventLoop.Shutdown(ctx); err != nil {
+		t.Fatalf("waitAsync outlived its runtime: %v", err)
+	}
+}
+
+func TestBridge_SharedStructures(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const mem = __typego_memory__;
+
+		const Point = mem.defineLayout({ x: "f32", y: "f32" });
+		const Player = mem.defineLayout({ alive: "bool", id: "u32", score: "f64", pos: Point, big: "i64" });
+		if (Player.size !== 32 || Player.fields.id.offset !== 4 || Player.fields.score.offset !== 8 || Player.fields.pos.offset !== 16) {
+			throw new Error("layout: " + JSON.stringify(Player.fields));
+		}
+
+		const seg = mem.makeShared("players", Player.size * 2);
+		const p = Player.view(seg, 1);
+		p.id = 7;
+		p.score = 1.5;
+		p.pos.y = 2;
+		p.big = -5n;
+		p.alive = true;
+		const copy = Player.read(seg, 1);
+		if (copy.id !== 7 || copy.score !== 1.5 || copy.pos.y !== 2 || copy.big !== -5n || copy.alive !== true) throw new Error("read: " + JSON.stringify(Object.keys(copy)));
+		if (new Uint32Array(seg.buffer)[9] !== 7) throw new Error("view does not write the segment");
+		try {
+			Player.view(seg, 2);
+			throw new Error("out of range view");
+		} catch (e) {
+			if (!(e instanceof RangeError)) throw e;
+		}
+
+		const ringSeg = mem.makeShared("ring", mem.ringBuffer.bytes({ capacity: 3, type: "i32" }));
+		const ring = mem.ringBuffer(ringSeg, { capacity: 3, type: "i32" });
+		[1, 2, 3].forEach((n) => ring.push(n));
+		if (ring.push(4) !== true || ring.toArray().join() !== "2,3,4") throw new Error("ring overwrite: " + ring.toArray());
+		if (ring.shift() !== 2 || ring.length !== 2 || ring.peek() !== 3) throw new Error("ring shift");
+		const again = mem.ringBuffer(mem.makeShared("ring"), { capacity: 3, type: "i32" });
+		if (again.toArray().join() !== "3,4") throw new Error("reattached ring lost its contents");
+		try {
+			mem.ringBuffer(ringSeg, { capacity: 2, type: "i32" });
+			throw new Error("mismatched ring should throw");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+
+		const mapSeg = mem.makeShared("map", mem.sharedMap.bytes({ capacity: 4, type: Point, keySize: 8 }));
+		const map = mem.sharedMap(mapSeg, { capacity: 4, type: Point, keySize: 8 });
+		map.set("a", { x: 1, y: 2 }).set("b", { x: 3, y: 4 });
+		map.delete("a");
+		map.set("c", { x: 5, y: 6 }).set("d", { x: 0, y: 0 }).set("e", { x: 0, y: 0 });
+		if (map.size !== 4 || map.has("a") || map.get("c").x !== 5) throw new Error("map: " + JSON.stringify(map.entries()));
+		for (const fn of [() => map.set("f", { x: 0 }), () => map.set("toolongkey", { x: 0 })]) {
+			try {
+				fn();
+				throw new Error("should throw RangeError");
+			} catch (e) {
+				if (!(e instanceof RangeError)) throw e;
+			}
+		}
+
+		// The queue holds every item, so the producer never waits on a
+		// consumer that may not have been scheduled yet.
+		const qSeg = mem.makeShared("queue", mem.spscQueue.bytes({ capacity: 128, type: "u32" }));
+		const queue = mem.spscQueue(qSeg, { capacity: 128, type: "u32" });
+		const sum = go.parallel((q, n) => {
+			let total = 0;
+			for (let got = 0; got < n;) {
+				const v = q.pop();
+				if (v !== undefined) { total += v; got++; }
+			}
+			return total;
+		}, queue, 100);
+		for (let i = 1; i <= 100; i++) {
+			if (!queue.push(i)) throw new Error("queue full at " + i);
+		}
+		if (await sum !== 5050) throw new Error("spsc sum");
+		if (queue.size !== 0 || queue.pop() !== undefined) throw new Error("queue not drained");
+
+		const total = await go.parallel((m, seg, layout) => {
+			layout.view(seg, 0).score = 9;
+			return m.get("c").y;
+		}, map, seg, Player);
+		if (total !== 6 || Player.view(seg, 0).score !== 9) throw new Error("structures not shared with parallel runtime");
+	`)
+}
+
+func TestBridge_FileSegments(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("file-backed segments need Linux")
+	}
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, []byte("typego"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	harness := NewHarness(t)
+
+	harness.Run(t, fmt.Sprintf(`
+		const mem = __typego_memory__;
+		const path = %q;
+
+		const ro = mem.makeShared("ro", 0, { file: path, readOnly: true });
+		const roBytes = new Uint8Array(ro.buffer);
+		if (ro.size !== 6 || String.fromCharCode(...roBytes) !== "typego") throw new Error("read-only mapping: " + ro.size);
+		roBytes[0] = 84; // Copy-on-write, the file keeps "t"
+
+		const seg = mem.makeShared("rw", 4096, { file: path });
+		const bytes = new Uint8Array(seg.buffer);
+		if (seg.file !== path || bytes[0] !== 116 || bytes[6] !== 0) throw new Error("mapping: " + seg.file);
+		bytes[6] = 33;
+		seg.resize(8192);
+		if (seg.size !== 8192 || seg.buffer.byteLength !== 8192 || new Uint8Array(seg.buffer)[6] !== 33) throw new Error("resize");
+		new Uint8Array(seg.buffer)[8191] = 1;
+		seg.sync();
+		seg.flush();
+		try {
+			seg.resize(100);
+			throw new Error("shrinking resize");
+		} catch (e) {
+			if (!String(e).includes("can only grow")) throw e;
+		}
+
+		const again = mem.makeShared("rw");
+		let info = mem.stats().segments.find(s => s.name === "rw");
+		if (!info || info.holders !== 2 || info.size !== 8192 || info.file !== path) throw new Error("stats: " + JSON.stringify(info));
+
+		again.release();
+		again.release(); // Releasing a segment object twice is a no-op
+		if (again.buffer.byteLength !== 0) throw new Error("release does not detach");
+		if (mem.stats().segments.find(s => s.name === "rw").holders !== 1) throw new Error("refs after release");
+		seg.release();
+		ro.release();
+		if (mem.stats().segments.some(s => s.name === "rw" || s.name === "ro")) throw new Error("segments not closed");
+
+		try {
+			seg.sync();
+			throw new Error("sync after release");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+	`, path))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 8192 || string(data[:7]) != "typego!" || data[8191] != 1 {
+		t.Fatalf("file after release: %d bytes, %q", len(data), data[:7])
+	}
+}
+
+func TestBridge_WorkerSegmentQuota(t *testing.T) {
+	harness := NewHarness(t)
+
+	// The worker's limit stays above what the process uses, which its
+	// memory monitor watches, and below the harness's 64MB
+	harness.Run(t, `
+		const mem = __typego_memory__;
+		const { Worker } = __typego_worker__;
+
+		const w = new Worker(() => {
+			const mem = __typego_memory__;
+			let refused = "";
+			try {
+				mem.makeShared("worker-huge", 56 * 1024 * 1024);
+			} catch (e) {
+				refused = String(e);
+			}
+			mem.makeShared("worker-small", 1024 * 1024);
+			const { segmentBytes, quota } = mem.stats();
+			self.postMessage({ refused, segmentBytes, quota });
+		}, { memoryLimit: 48 * 1024 * 1024 });
+		const got = (await new Promise((resolve) => { w.onmessage = resolve; })).data;
+		w.terminate();
+
+		if (!got.refused.includes("quota exceeded")) throw new Error("worker limit not enforced: " + got.refused);
+		if (got.quota !== 48 * 1024 * 1024 || got.segmentBytes !== 1024 * 1024) throw new Error("worker usage: " + JSON.stringify(got));
+		if (mem.stats().segmentBytes !== 1024 * 1024) throw new Error("parent usage: " + mem.stats().segmentBytes);
+		mem.free("worker-small");
+		if (mem.stats().segmentBytes !== 0) throw new Error("freed segment still counted: " + mem.stats().segmentBytes);
+	`)
+}
+
+func TestBridge_SegmentLifecycle(t *testing.T) {
+	harness := NewHarness(t)
+
+	harness.Run(t, `
+		const mem = __typego_memory__;
+		const expectError = (fn, text) => {
+			try {
+				fn();
+			} catch (e) {
+				if (!String(e).includes(text)) throw e;
+				return;
+			}
+			throw new Error("expected an error with " + text);
+		};
+
+		const a = mem.makeShared("lifecycle", 1024);
+		const b = mem.makeShared("lifecycle", 0);
+		if (b.size !== 1024) throw new Error("open by name: " + b.size);
+		expectError(() => mem.makeShared("lifecycle", 2048), "already exists with 1024 bytes");
+		expectError(() => mem.makeShared("missing"), "no such segment");
+
+		const stats = mem.stats();
+		if (stats.quota !== 64 * 1024 * 1024 || stats.segmentBytes < 1024) throw new Error("usage: " + JSON.stringify(stats));
+		expectError(() => mem.makeShared("huge", 128 * 1024 * 1024), "quota exceeded");
+
+		await a.mutex.lock();
+		let info = mem.segments().find(s => s.name === "lifecycle");
+		if (info.holders !== 2 || info.size !== 1024 || info.locked !== "write") throw new Error("segments: " + JSON.stringify(info));
+		a.mutex.unlock();
+		await a.mutex.rlock();
+		if (mem.segments().find(s => s.name === "lifecycle").locked !== "read") throw new Error("read lock state");
+		a.mutex.runlock();
+		if (mem.segments().find(s => s.name === "lifecycle").locked !== false) throw new Error("unlocked state");
+
+		const used = mem.stats().segmentBytes;
+		if (!mem.free("lifecycle") || mem.free("lifecycle")) throw new Error("free");
+		if (mem.segments().some(s => s.name === "lifecycle")) throw new Error("freed segment listed");
+		if (mem.stats().segmentBytes !== used - 1024) throw new Error("quota not given back");
+		a.release();
+		b.release();
+
+		const c = mem.makeShared("lifecycle", 2048);
+		if (c.size !== 2048) throw new Error("recreated after free");
+		c.release();
+	`)
+
+	// Go code written against the Data field still sees the segment's memory
+	seg, err := harness.Engine.MemoryFactory.MakeShared("go-side", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg.Data[0] = 7
+	if len(seg.Data) != 8 || seg.Bytes()[0] != 7 {
+		t.Fatalf("Data is not the segment's memory: %v", seg.Data)
+	}
+	harness.Run(t, `
+		if (new Uint8Array(__typego_memory__.makeShared("go-side").buffer)[0] !== 7) throw new Error("Data write not visible in JS");
+	`)
+}
+
+type refStats struct {
+	HP   int
+	Name string
+}
+
+type refPlayer struct {
+	Stats  refStats
+	Scores []int32
+	Tags   []string
+}
+
+func TestBridge_Refs(t *testing.T) {
+	harness := NewHarness(t)
+	player := &refPlayer{Stats: refStats{HP: 50, Name: "ann"}, Scores: []int32{1, 2, 3}, Tags: []string{"a"}}
+	if err := harness.Engine.BindStructLive("player", player); err != nil {
+		t.Fatal(err)
+	}
+	var healed *int
+	harness.Engine.VM.Set("heal", harness.Engine.WrapFunc("heal", func(hp *int) {
+		healed = hp
+		*hp += 25
+	}))
+	harness.Engine.VM.Set("rename", harness.Engine.WrapFunc("rename", func(s *refStats, name string) {
+		s.Name = name
+	}))
+
+	harness.Run(t, `
+		const hp = ref(player, "Stats", "HP");
+		if (hp.value !== 50 || hp.type !== "int") throw new Error("field ref: " + hp.value + " " + hp.type);
+		hp.value -= 10;
+		heal(hp);
+		if (hp.value !== 65 || player.Stats.HP !== 65) throw new Error("heal: " + hp.value);
+		if (ref(player, "Stats", "HP") !== hp || ref(player, "Stats", "Name") === hp) throw new Error("identity");
+		if (typeof hp.ptr !== "number" || deref(hp.ptr) !== 65) throw new Error("deref of an address");
+
+		if (hp.swap(1) !== 65 || hp.compareAndSwap(2, 3) || !hp.compareAndSwap(1, 100) || hp.load() !== 100) {
+			throw new Error("atomics: " + hp.value);
+		}
+
+		const stats = ref(player, "Stats");
+		rename(stats, "bob");
+		stats.value.HP = 7; // Structs come back live
+		if (player.Stats.Name !== "bob" || hp.value !== 7) throw new Error("struct ref: " + player.Stats.Name);
+		const name = ref(stats, "Name");
+		if (!name.compareAndSwap("bob", "cy") || name.value !== "cy") throw new Error("string CAS");
+
+		const score = ref(player, "Scores", 2);
+		score.value = 30;
+		const tag = ref(player, "Tags", 0);
+		tag.value = "z";
+
+		const arr = new Int32Array([1, 2, 3]);
+		const el = ref(arr, 1);
+		el.value = 20;
+		if (arr[1] !== 20) throw new Error("typed array element ref");
+
+		const box = ref(5);
+		box.value = 6;
+		if (deref(box) !== 6 || ref(box) !== box) throw new Error("boxed ref");
+
+		for (const bad of [() => ref(player, "Missing"), () => ref(player, "Scores", 9), () => ref({ a: 1 }, "a")]) {
+			try {
+				bad();
+				throw new Error("expected a TypeError");
+			} catch (e) {
+				if (!(e instanceof TypeError)) throw e;
+			}
+		}
+		try {
+			deref(1);
+			throw new Error("deref of a dead address");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+	`)
+
+	if healed != &player.Stats.HP {
+		t.Fatal("heal did not receive the field's address")
+	}
+	if player.Stats.HP != 7 || player.Stats.Name != "cy" || player.Scores[2] != 30 || player.Tags[0] != "z" {
+		t.Fatalf("writes through refs not visible in Go: %+v", *player)
+	}
+}
+
+type slicePoint struct {
+	X, Y int
+}
+
+func TestBridge_GoSlices(t *testing.T) {
+	core.RegisterGoType("slicePoint", slicePoint{})
+	harness := NewHarness(t)
+	var summed []int32
+	harness.Engine.VM.Set("sum", harness.Engine.WrapFunc("sum", func(xs []int32) int32 {
+		summed = xs
+		var total int32
+		for _, x := range xs {
+			total += x
+		}
+		return total
+	}))
+	harness.Engine.VM.Set("shift", harness.Engine.WrapFunc("shift", func(ps []slicePoint) {
+		for i := range ps {
+			ps[i].X++
+		}
+	}))
+
+	harness.Run(t, `
+		let s = make("int32", 0, 4);
+		if (s.len() !== 0 || s.cap() !== 4 || cap(s) !== 4 || s.type !== "int32") throw new Error("make");
+		s = append(s, 1, 2, 3);
+		if (s.length !== 3 || s[2] !== 3 || s.cap() !== 4) throw new Error("append within capacity: " + s.toArray());
+
+		const head = s.slice(0, 2, 2);
+		head[0] = 10;
+		if (s[0] !== 10 || head.cap() !== 2) throw new Error("subslice shares the backing array");
+		const grown = head.append(99);
+		if (s[2] !== 3 || grown[2] !== 99 || grown.cap() < 3) throw new Error("append past capacity reallocates");
+		s.slice(1).append(7);
+		if (s.slice(0, 4)[3] !== 7) throw new Error("append within capacity writes the backing array");
+		try {
+			s.slice(0, 5);
+			throw new Error("slice past capacity");
+		} catch (e) {
+			if (!String(e).includes("out of range")) throw e;
+		}
+
+		let total = 0;
+		for (const v of s) total += v;
+		if (total !== 15 || sum(s) !== 15 || s.map(v => v * 2)[0] !== 20) throw new Error("iteration: " + total);
+		s[1] = 5;
+		if (sum(s) !== 18) throw new Error("sum does not see the slice");
+
+		const dst = make("int32", 2);
+		if (copy(dst, s) !== 2 || dst[0] !== 10 || copy(dst, [4, 4, 4]) !== 2 || dst[1] !== 4) throw new Error("copy");
+		const words = append(make("[]string", 0), "a", "b");
+		if (words.join("") !== "ab" || words.type !== "string") throw new Error("string slice");
+
+		const pts = make("slicePoint", 2);
+		pts[1].Y = 4; // Struct elements are live
+		shift(pts);
+		if (pts[0].X !== 1 || pts[1].X !== 1 || pts[1].Y !== 4) throw new Error("struct slice: " + JSON.stringify(pts.toArray()));
+		const y = ref(pts, 1, "Y");
+		y.value = 8;
+		if (pts[1].Y !== 8) throw new Error("ref into a slice");
+
+		try {
+			make("complex64", 1);
+			throw new Error("unknown type");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+	`)
+
+	if len(summed) != 3 || summed[1] != 5 {
+		t.Fatalf("sum received %v", summed)
+	}
+}
+
+type sizedRecord struct {
+	Flag  bool
+	Count int64
+	Small int16
+	Name  string
+	Data  []byte
+}
+
+func TestBridge_Sizeof(t *testing.T) {
+	harness := NewHarness(t)
+	record := &sizedRecord{Name: "abc", Data: make([]byte, 4, 10)}
+	if err := harness.Engine.BindStructLive("record", record); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "heap.json")
+	harness.Engine.VM.Set("snapshotPath", path)
+	harness.Engine.VM.Set("totalAlloc", func() uint64 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		return ms.TotalAlloc
+	})
+
+	harness.Run(t, `
+		const mem = __typego_memory__;
+
+		// 1+7 padding, 8, 2+6 padding, a string and a slice header
+		const p = mem.profile(record);
+		if (p.kind !== "go" || p.type !== "integration.sizedRecord" || p.size !== 64 || p.align !== 8 || p.padding !== 13) {
+			throw new Error("layout: " + JSON.stringify(p));
+		}
+		if (p.fields[2].offset !== 16 || p.fields[2].padding !== 6 || p.retained !== 64 + 3 + 10) throw new Error("fields: " + JSON.stringify(p));
+		if (sizeof(record) !== p.retained) throw new Error("sizeof of a live struct: " + sizeof(record));
+		if (sizeof(make("[]int32", 4, 8)) !== 24 + 32) throw new Error("slice: " + sizeof(make("[]int32", 4, 8)));
+		if (sizeof(ref(record, "Count")) !== 8) throw new Error("ref");
+
+		if (sizeof(1) !== 8 || sizeof("abcd") !== 20 || sizeof(true) !== 1) throw new Error("primitives");
+		const shared = { text: "hello" };
+		if (sizeof([shared, shared]) - sizeof([shared]) !== 16) throw new Error("shared objects are counted once");
+		const cyclic = { name: "loop" };
+		cyclic.self = cyclic;
+		if (sizeof(cyclic) !== sizeof({ name: "loop", self: null })) throw new Error("cycle: " + sizeof(cyclic));
+		const bytes = new Uint8Array(1000);
+		if (sizeof(bytes) < 1000 || sizeof({ a: bytes, b: bytes.subarray(1) }) > sizeof(bytes) * 2) throw new Error("buffers");
+
+		const big = { items: new Array(100).fill(0).map((_, i) => ({ i })), small: 1 };
+		const bp = mem.profile(big);
+		if (bp.kind !== "js" || bp.children[0].name !== "items" || bp.retained !== sizeof(big)) throw new Error("js profile: " + JSON.stringify(bp));
+
+		class Cache { constructor() { this.entries = []; } }
+		globalThis.cache = new Cache();
+		const before = mem.snapshot();
+		if (before.format !== "typego-heap-snapshot" || before.root !== "globalThis") throw new Error("snapshot: " + before.format);
+		const node = before.nodes.find(n => n.path === "globalThis.cache");
+		if (!node || node.type !== "Cache") throw new Error("class instance node: " + JSON.stringify(node));
+
+		for (let i = 0; i < 10; i++) cache.entries.push({ key: "k" + i });
+		const after = mem.snapshot();
+		const d = mem.diff(before, JSON.stringify(after));
+		if (d.bytes <= 0 || d.added.length !== 10 || d.added[0].path !== "globalThis.cache.entries[0]") throw new Error("diff: " + JSON.stringify(d.added));
+		if (!d.changed.some(c => c.path === "globalThis.cache.entries" && c.after > c.before)) throw new Error("changed: " + JSON.stringify(d.changed));
+		if (d.types.Object.count !== 10) throw new Error("types: " + JSON.stringify(d.types));
+
+		// Neither copies arrays nor looks into proxies to rule out a Go value
+		const sparse = [];
+		sparse[1e7] = 1;
+		const allocated = totalAlloc();
+		if (sizeof(sparse) > 1000 || mem.profile(sparse).type !== "Array") throw new Error("sparse: " + sizeof(sparse));
+		if (totalAlloc() - allocated > 10e6) throw new Error("sparse array copied: " + (totalAlloc() - allocated));
+		let traps = 0;
+		const trap = () => { traps++; };
+		globalThis.guarded = new Proxy({ a: 1 }, { get: trap, has: trap, ownKeys: trap, getOwnPropertyDescriptor: trap, getPrototypeOf: trap });
+		if (sizeof(guarded) <= sizeof({ a: 1 }) || mem.profile(guarded).type !== "Proxy") throw new Error("proxy: " + JSON.stringify(mem.profile(guarded)));
+		if (mem.snapshot().nodes.find(n => n.path === "globalThis.guarded").type !== "Proxy") throw new Error("proxy node");
+		if (traps !== 0) throw new Error("proxy traps fired: " + traps);
+		const revocable = Proxy.revocable({}, {});
+		revocable.revoke();
+		sizeof(revocable.proxy);
+		delete globalThis.guarded;
+
+		mem.writeSnapshot(snapshotPath, cache);
+	`)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"path": "root.entries[9]"`) {
+		t.Fatalf("snapshot file: %.300s", data)
+	}
+}
+
+// closeTracker is an io.ReadCloser recording whether it was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestBridge_Streams(t *testing.T) {
+	harness := NewHarness(t)
+	text := strings.Repeat("héllo wörld ", 100)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(text))
+	_ = zw.Close()
+
+	var out bytes.Buffer
+	tracked := &closeTracker{Reader: strings.NewReader(strings.Repeat("x", 1000))}
+	harness.Engine.VM.Set("text", text)
+	harness.Engine.VM.Set("gzReader", bytes.NewReader(gz.Bytes()))
+	harness.Engine.VM.Set("utf8Reader", strings.NewReader("añb€c"))
+	harness.Engine.VM.Set("tracked", tracked)
+	harness.Engine.VM.Set("goBuf", &out)
+	hosted, err := harness.Engine.Intrinsics.ReadableStream(strings.NewReader("from the host"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	harness.Engine.VM.Set("hosted", hosted)
+
+	harness.Run(t, `
+		const collect = async (stream) => {
+			const reader = stream.getReader();
+			const chunks = [];
+			for (;;) {
+				const { value, done } = await reader.read();
+				if (done) return chunks;
+				chunks.push(value);
+			}
+		};
+
+		// Pulls fill the queue up to the high water mark, then on demand
+		let pulls = 0;
+		const counted = new ReadableStream({
+			pull(c) { pulls++; c.enqueue(pulls); if (pulls === 5) c.close(); },
+		}, { highWaterMark: 2 });
+		await new Promise(r => setTimeout(r, 0));
+		if (pulls !== 2) throw new Error("pulls before reading: " + pulls);
+		if ((await collect(counted)).join() !== "1,2,3,4,5") throw new Error("pull");
+
+		// Writes run one at a time, in order, with backpressure
+		const log = [];
+		const ws = new WritableStream({
+			write(chunk) { log.push("w" + chunk); return new Promise(r => setTimeout(r, 1)); },
+			close() { log.push("close"); },
+		}, new CountQueuingStrategy({ highWaterMark: 2 }));
+		const writer = ws.getWriter();
+		writer.write(1); writer.write(2); writer.write(3);
+		if (writer.desiredSize !== -1) throw new Error("desiredSize: " + writer.desiredSize);
+		await writer.close();
+		if (log.join() !== "w1,w2,w3,close") throw new Error("writes: " + log);
+
+		const upper = new TransformStream({ transform(chunk, c) { c.enqueue(chunk.toUpperCase()); } });
+		const [a, b] = ReadableStream.from(["a", "b"]).pipeThrough(upper).tee();
+		if ((await collect(a)).join() !== "A,B" || (await collect(b)).join() !== "A,B") throw new Error("tee");
+
+		const it = ReadableStream.from(["p"])[Symbol.asyncIterator]();
+		if ((await it.next()).value !== "p" || !(await it.next()).done) throw new Error("async iterator");
+
+		const failing = new ReadableStream({ start(c) { c.enqueue("a"); c.error(new Error("boom")); } });
+		let caught;
+		try { await failing.pipeTo(new WritableStream()); } catch (e) { caught = e.message; }
+		if (caught !== "boom") throw new Error("pipe error: " + caught);
+
+		// Go readers and writers, and multi-byte characters split across chunks
+		const decoded = await collect(toReadableStream(utf8Reader, { chunkSize: 1 }).pipeThrough(new TextDecoderStream()));
+		if (decoded.join("") !== "añb€c") throw new Error("decoded: " + decoded);
+		if ((await collect(hosted.pipeThrough(new TextDecoderStream()))).join("") !== "from the host") throw new Error("host stream");
+		await ReadableStream.from(["x", "y"]).pipeThrough(new TextEncoderStream()).pipeTo(toWritableStream(goBuf));
+
+		const gr = toReadableStream(tracked, { chunkSize: 10 }).getReader();
+		if ((await gr.read()).value.length !== 10) throw new Error("chunk size");
+		await gr.cancel();
+
+		// Compression, both ways, against Go's gzip
+		const gunzipped = await collect(ReadableStream.from(gzReader).pipeThrough(new DecompressionStream("gzip")).pipeThrough(new TextDecoderStream()));
+		if (gunzipped.join("") !== text) throw new Error("gunzip");
+		for (const format of ["gzip", "deflate", "deflate-raw"]) {
+			const round = await collect(ReadableStream.from([text, "!"])
+				.pipeThrough(new TextEncoderStream())
+				.pipeThrough(new CompressionStream(format))
+				.pipeThrough(new DecompressionStream(format))
+				.pipeThrough(new TextDecoderStream()));
+			if (round.join("") !== text + "!") throw new Error("round trip " + format);
+		}
+		let corrupt;
+		try { await collect(ReadableStream.from([new Uint8Array([1, 2, 3])]).pipeThrough(new DecompressionStream("gzip"))); } catch (e) { corrupt = e; }
+		if (!corrupt) throw new Error("corrupt gzip was accepted");
+		try { new CompressionStream("brotli"); throw new Error("brotli"); } catch (e) { if (!(e instanceof TypeError)) throw e; }
+	`)
+
+	if out.String() != "xy" {
+		t.Errorf("writer got %q", out.String())
+	}
+	if !tracked.closed {
+		t.Error("cancelled reader was not closed")
+	}
+}
+
+func TestBridge_Buffer(t *testing.T) {
+	harness := NewHarness(t)
+	var received []byte
+	harness.Engine.VM.Set("receive", func(b []byte) { received = append([]byte(nil), b...) })
+
+	harness.Run(t, `
+		const eq = (got, want, what) => {
+			if (got !== want) throw new Error(what + ": got " + JSON.stringify(got) + ", want " + JSON.stringify(want));
+		};
+
+		const b = Buffer.from("héllo");
+		eq(b instanceof Uint8Array && Buffer.isBuffer(b), true, "subclass");
+		eq(b.toString("hex"), "68c3a96c6c6f", "hex");
+		eq(Buffer.from("68c3a96c6c6fzz", "hex").toString(), "héllo", "hex stops at invalid pairs");
+		eq(b.toString("base64"), "aMOpbGxv", "base64");
+		eq(Buffer.from("aMOp bGxv", "base64").toString(), "héllo", "base64 skips whitespace");
+		eq(Buffer.from([0xfb, 0xff]).toString("base64url"), "-_8", "base64url");
+		eq(Buffer.from("-_8", "base64")[1], 0xff, "base64 accepts the URL alphabet");
+		eq(Buffer.from("é", "latin1")[0], 0xe9, "latin1");
+		eq(Buffer.from([0xe9]).toString("binary"), "é", "binary");
+		eq(Buffer.from("a€", "utf16le").toString("hex"), "6100ac20", "utf16le");
+		eq(Buffer.from("6100ac20", "hex").toString("ucs2"), "a€", "ucs2");
+		eq(Buffer.from([0xff, 0x41]).toString(), "�A", "invalid UTF-8");
+		eq(Buffer.byteLength("€"), 3, "byteLength");
+		eq(Buffer.isEncoding("HEX") && !Buffer.isEncoding("nope"), true, "isEncoding");
+
+		const n = Buffer.alloc(8);
+		eq(n.writeUInt32LE(0xdeadbeef), 4, "write returns the next offset");
+		n.writeUInt32BE(0x01020304, 4);
+		eq(n.readUInt32LE(0), 0xdeadbeef, "readUInt32LE");
+		eq(n.readUint32BE(4), 0x01020304, "readUint32BE alias");
+		eq(n.readInt8(0), -17, "readInt8");
+		eq(n.readUInt16BE(4), 0x0102, "readUInt16BE");
+		eq(n.readIntLE(0, 3), 0xadbeef - 0x1000000, "readIntLE");
+		let err;
+		try { n.readUInt32LE(5); } catch (e) { err = e; }
+		eq(err instanceof RangeError && err.code, "ERR_OUT_OF_RANGE", "reads are bounds checked");
+		err = undefined;
+		try { n.writeUInt8(256); } catch (e) { err = e; }
+		eq(err instanceof RangeError, true, "values are range checked");
+		n.writeBigUInt64BE(2n ** 63n);
+		eq(n.readBigUInt64BE(), 2n ** 63n, "BigUInt64");
+		n.writeDoubleLE(1.5);
+		eq(n.readDoubleLE(), 1.5, "double");
+
+		const c = Buffer.concat([Buffer.from("ab"), new Uint8Array([99]), Buffer.from("de")]);
+		eq(c.toString(), "abcde", "concat");
+		eq(Buffer.concat([c], 2).toString(), "ab", "concat truncates to totalLength");
+		eq(Buffer.compare(Buffer.from("a"), Buffer.from("b")), -1, "compare");
+		eq(c.indexOf("cd"), 2, "indexOf");
+		eq(c.indexOf(0x65), 4, "indexOf a byte");
+		eq(c.indexOf("b", 2), -1, "indexOf from an offset");
+		eq(c.lastIndexOf("b"), 1, "lastIndexOf");
+		eq(c.indexOf("", 99), 5, "an empty needle is found at the clamped offset");
+
+		const s = c.slice(1, 3);
+		s[0] = 0x42;
+		eq(c.toString() + (s instanceof Buffer), "aBcdetrue", "slice shares memory");
+		const ab = new ArrayBuffer(4);
+		Buffer.from(ab, 1, 2)[0] = 7;
+		eq(new Uint8Array(ab)[1], 7, "from an ArrayBuffer shares memory");
+		eq(Buffer.from(new Uint16Array([1, 256])).join(), "1,0", "from a view copies its elements");
+
+		eq(Buffer.alloc(5, "ab").toString(), "ababa", "alloc with a fill");
+		const w = Buffer.alloc(4);
+		eq(w.write("a€"), 4, "write");
+		eq(w.write("€", 2), 0, "characters are not split");
+		const target = Buffer.alloc(3);
+		eq(Buffer.from("xyz").copy(target, 1), 2, "copy");
+		eq(target.toString("latin1"), "\0xy", "copied bytes");
+		eq(Buffer.from("abcd").swap16().toString(), "badc", "swap16");
+		eq(Buffer.from(JSON.parse(JSON.stringify(Buffer.from("hi")))).toString(), "hi", "toJSON round trip");
+
+		receive(Buffer.from("to go"));
+	`)
+	if string(received) != "to go" {
+		t.Errorf("Go received %q", received)
+	}
+}
+
+func TestBridge_TextCoding(t *testing.T) {
+	harness := NewHarness(t)
+	harness.Run(t, `
+		const eq = (got, want, what) => {
+			if (got !== want) throw new Error(what + ": got " + JSON.stringify(got) + ", want " + JSON.stringify(want));
+		};
+		const bytes = (...b) => new Uint8Array(b);
+
+		const enc = new TextEncoder();
+		const dst = new Uint8Array(4);
+		const res = enc.encodeInto("a€b", dst);
+		eq(res.read + "," + res.written, "2,4", "encodeInto writes whole characters");
+		eq(enc.encode("\ud800").join(), "239,191,189", "lone surrogates encode as U+FFFD");
+
+		const dec = new TextDecoder();
+		const euro = enc.encode("€uro");
+		eq(dec.decode(euro.subarray(0, 2), { stream: true }) + dec.decode(euro.subarray(2)), "€uro", "streaming");
+		eq(dec.decode(bytes(0xe2), { stream: true }) + dec.decode(), "�", "an incomplete end is flushed");
+		eq(dec.decode(bytes(0xef, 0xbb, 0xbf, 0x41)), "A", "the BOM is stripped");
+		eq(new TextDecoder("utf-8", { ignoreBOM: true }).decode(bytes(0xef, 0xbb, 0xbf, 0x41)), "\ufeffA", "ignoreBOM");
+		eq(dec.decode(bytes(0xe2, 0x82, 0x41)), "�A", "a truncated sequence is one U+FFFD");
+		let err;
+		try { new TextDecoder("utf-8", { fatal: true }).decode(bytes(0xff)); } catch (e) { err = e; }
+		eq(err instanceof TypeError, true, "fatal");
+
+		eq(new TextDecoder("latin1").encoding, "windows-1252", "labels");
+		eq(new TextDecoder("windows-1252").decode(bytes(0x80, 0xe9)), "€é", "windows-1252");
+		const sjis = new TextDecoder("shift_jis");
+		eq(sjis.decode(bytes(0x82), { stream: true }) + sjis.decode(bytes(0xa0)), "あ", "Shift_JIS streaming");
+		eq(new TextDecoder("utf-16le").decode(bytes(0xff, 0xfe, 0x61, 0x00, 0x3d, 0xd8, 0x00, 0xde)), "a😀", "UTF-16LE");
+		eq(new TextDecoder("UTF-16BE").decode(bytes(0x00, 0x61)), "a", "UTF-16BE");
+		err = undefined;
+		try { new TextDecoder("nope"); } catch (e) { err = e; }
+		eq(err instanceof RangeError, true, "unknown labels");
+	`)
+}
+
+func TestBridge_Process(t *testing.T) {
+	t.Setenv("TYPEGO_TEST_MODE", "on")
+	t.Setenv("TYPEGO_TEST_GONE", "1")
+	t.Setenv("SECRET_TOKEN", "hidden")
+
+	// The script writes these through to the process environment
+	t.Cleanup(func() {
+		os.Unsetenv("TYPEGO_TEST_WRITTEN")
+		os.Unsetenv("APP_SETTING")
+	})
+
+	harness := NewHarness(t)
+	var stdout, stderr bytes.Buffer
+	harness.Engine.Intrinsics.SetStdio(strings.NewReader("line one\nline two\n"), &stdout, &stderr)
+
+	harness.Run(t, `
+		const eq = (got, want, what) => {
+			if (got !== want) throw new Error(what + ": got " + got + ", want " + want);
+		};
+
+		eq(process.env.TYPEGO_TEST_MODE, "on", "TYPEGO_ variables are visible");
+		eq(process.env.SECRET_TOKEN, undefined, "other variables are hidden");
+		eq("SECRET_TOKEN" in process.env, false, "hidden variables are not in env");
+		process.env.TYPEGO_TEST_WRITTEN = "yes";
+		process.env.APP_SETTING = "set";
+		eq(process.env.APP_SETTING, "set", "written variables are visible");
+		eq(Object.keys(process.env).includes("APP_SETTING"), true, "written variables are listed");
+		delete process.env.TYPEGO_TEST_GONE;
+		eq(process.env.TYPEGO_TEST_GONE, undefined, "delete");
+
+		const order = [];
+		process.nextTick((a, b) => order.push("tick " + a + b), 1, 2);
+		Promise.resolve().then(() => order.push("promise"));
+		const timedOut = new Promise((resolve) => setTimeout(() => resolve(order.push("timeout")), 0));
+		order.push("sync");
+		await timedOut;
+		eq(order.join(), "sync,tick 12,promise,timeout", "nextTick order");
+
+		const [sec, nsec] = process.hrtime();
+		eq(Number.isInteger(sec) && nsec >= 0 && nsec < 1e9, true, "hrtime");
+		eq(typeof process.hrtime.bigint(), "bigint", "hrtime.bigint");
+		eq(process.hrtime([sec, nsec])[0] >= 0, true, "hrtime(previous)");
+		eq(process.uptime() > 0, true, "uptime");
+		eq(process.pid > 0 && process.ppid > 0, true, "pid");
+		const mem = process.memoryUsage();
+		eq(mem.heapUsed > 0 && mem.heapTotal >= mem.heapUsed && mem.rss > 0, true, "memoryUsage");
+		eq(typeof mem.external, "number", "memoryUsage.external");
+
+		eq(process.stdout.fd, 1, "stdout fd");
+		eq(process.stdout.write("héllo "), true, "stdout.write");
+		process.stdout.write(new Uint8Array([0x21, 0x0a]));
+		process.stderr.write("6869", "hex");
+		let written;
+		process.stdout.write("", () => { written = true; });
+		const writer = process.stdout.getWriter();
+		await writer.write("streamed\n");
+		await writer.close();
+		eq(written, true, "write callback");
+
+		const reader = process.stdin.getReader();
+		const dec = new TextDecoder();
+		let input = "";
+		for (;;) {
+			const { done, value } = await reader.read();
+			if (done) break;
+			input += dec.decode(value, { stream: true });
+		}
+		eq(input, "line one\nline two\n", "stdin");
+	`)
+
+	if got := os.Getenv("TYPEGO_TEST_WRITTEN"); got != "yes" {
+		t.Errorf("env write-through: got %q", got)
+	}
+	if _, ok := os.LookupEnv("TYPEGO_TEST_GONE"); ok {
+		t.Error("delete did not unset the variable")
+	}
+	if got, want := stdout.String(), "héllo !\nstreamed\n"; got != want {
+		t.Errorf("stdout: got %q, want %q", got, want)
+	}
+	if got := stderr.String(); got != "hi" {
+		t.Errorf("stderr: got %q, want %q", got, "hi")
+	}
+
+	// These engines run without a memory limit: the limit is checked against
+	// the whole test process, which a loaded run can push past it.
+	exitCode := func(js string) (int, error) {
+		eng := engine.NewEngine(0, nil)
+		defer eng.Close()
+		var runErr error
+		eng.EventLoop.RunOnLoop(func() {
+			_, runErr = eng.Run(js)
+		})
+		eng.EventLoop.Start()
+		return eng.Intrinsics.ExitCode(), runErr
+	}
+	// The pending wait keeps the loop alive until exit stops it, and unlike a
+	// long timer it ends with the runtime.
+	code, err := exitCode(`setTimeout(() => { process.exit(3); throw new Error("unreachable"); }, 0); Atomics.waitAsync(new Int32Array(new SharedArrayBuffer(4)), 0, 0);`)
+	if code != 3 || err != nil {
+		t.Errorf("process.exit in a timer: code %d, err %v", code, err)
+	}
+	code, err = exitCode(`process.exitCode = 4; process.exit(); globalThis.after = true;`)
+	var exit *intrinsics.ExitError
+	if code != 4 || !errors.As(err, &exit) || exit.Code != 4 {
+		t.Errorf("process.exit() with exitCode: code %d, err %v", code, err)
+	}
+	code, _ = exitCode(`process.exitCode = 5;`)
+	if code != 5 {
+		t.Errorf("process.exitCode: code %d, want 5", code)
+	}
+	code, _ = exitCode(`process.exit(1); process.exit(2);`)
+	if code != 1 {
+		t.Errorf("only the first exit counts: code %d, want 1", code)
+	}
+}
+
+// recordHandler is a slog.Handler keeping the records it handles.
+type recordHandler struct {
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestBridge_Console(t *testing.T) {
+	harness := NewHarness(t)
+	var stdout, stderr bytes.Buffer
+	harness.Engine.Console.SetOutput(&stdout, &stderr)
+
+	harness.Run(t, `
+		console.log("%s is %d years and %i days, %f%%", "Bob", 42, 3.7, 1.5, "extra", 7);
+		console.log("%j %o", { a: [1] }, "str");
+		console.log({ a: 1, b: "two", "c-d": [1, { e: { f: { g: 1 } } }], fn() {} });
+		console.log({ alpha: "aaaaaaaaaaaaaaaa", beta: "bbbbbbbbbbbbbbbb", gamma: "cccccccccccccccc", delta: 1 });
+		const circular = { name: "loop" };
+		circular.self = circular;
+		console.info(circular, new Map([["k", 1]]), new Set([1, 2]), 10n, -0, null, undefined);
+		console.log(Buffer.from("hi"), new Uint8Array([1, 2]), Symbol("s"), new (class Point { constructor() { this.x = 1; } })());
+		console.debug([undefined, "a'b"]);
+		console.dir({ a: { b: { c: { d: 1 } } } }, { depth: 0 });
+		console.group("Group");
+		console.warn("inside");
+		console.log("line1\nline2");
+		console.groupEnd();
+		console.count();
+		console.count("x");
+		console.count();
+		console.countReset();
+		console.count();
+		console.assert(true, "never");
+		console.assert(false, "%s failed", "check");
+		console.error(new Error("boom").message);
+		console.table([{ a: 1, b: "x" }, { a: 2, c: true }, 3]);
+		console.time("t");
+		console.timeEnd("t");
+		console.timeEnd("t");
+		console.trace("here");
+	`)
+
+	wantOut := []string{
+		"Bob is 42 years and 3 days, 1.5% extra 7",
+		`{"a":[1]} 'str'`,
+		"{ a: 1, b: 'two', 'c-d': [ 1, { e: [Object] } ], fn: [Function: fn] }",
+		"{\n  alpha: 'aaaaaaaaaaaaaaaa',\n  beta: 'bbbbbbbbbbbbbbbb',\n  gamma: 'cccccccccccccccc',\n  delta: 1\n}",
+		"{ name: 'loop', self: [Circular] } Map(1) { 'k' => 1 } Set(2) { 1, 2 } 10n -0 null undefined",
+		"<Buffer 68 69> Uint8Array(2) [ 1, 2 ] Symbol(s) Point { x: 1 }",
+		`[ undefined, "a'b" ]`,
+		"{ a: [Object] }",
+		"Group",
+		"  line1\n  line2",
+		"default: 1\nx: 1\ndefault: 2\ndefault: 1",
+		"┌─────────┬───┬─────┬──────┬────────┐\n" +
+			"│ (index) │ a │ b   │ c    │ Values │\n" +
+			"├─────────┼───┼─────┼──────┼────────┤\n" +
+			"│ 0       │ 1 │ 'x' │      │        │\n" +
+			"│ 1       │ 2 │     │ true │        │\n" +
+			"│ 2       │   │     │      │ 3      │\n" +
+			"└─────────┴───┴─────┴──────┴────────┘",
+	}
+	out := stdout.String()
+	for _, want := range wantOut {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("stdout missing %q; got:\n%s", want, out)
+		}
+	}
+	if !regexp.MustCompile(`\nt: \d+\.\d{3}ms\n`).MatchString(out) {
+		t.Errorf("stdout missing the timer; got:\n%s", out)
+	}
+
+	errOut := stderr.String()
+	for _, want := range []string{
+		"  inside\n",
+		"Assertion failed: check failed\n",
+		"boom\n",
+		"Warning: No such label 't' for console.timeEnd()\n",
+		"Trace: here\n    at ",
+	} {
+		if !strings.Contains(errOut, want) {
+			t.Errorf("stderr missing %q; got:\n%s", want, errOut)
+		}
+	}
+	if strings.Contains(errOut, "never") {
+		t.Errorf("a passing assertion wrote output:\n%s", errOut)
+	}
+
+	// A worker started before SetHandler follows it, as go.parallel does
+	harness.Run(t, `
+		globalThis.logger = new __typego_worker__.Worker(() => {
+			self.onmessage = (e) => { console.log(e.data); self.postMessage("logged"); };
+		});
+	`)
+	h := &recordHandler{}
+	harness.Engine.Console.SetHandler(h)
+	harness.Run(t, `
+		console.debug("dbg");
+		console.group("outer");
+		console.info("hello", { n: 1 });
+		console.groupEnd();
+		console.warn("careful");
+		console.error("bad");
+		console.trace();
+		await go.parallel(() => console.log("from parallel"));
+		const logged = new Promise((resolve) => { logger.onmessage = resolve; });
+		logger.postMessage("from worker");
+		await logged;
+		logger.terminate();
+	`)
+	want := []struct {
+		level slog.Level
+		msg   string
+	}{
+		{slog.LevelDebug, "dbg"},
+		{slog.LevelInfo, "outer"},
+		{slog.LevelInfo, "hello { n: 1 }"},
+		{slog.LevelWarn, "careful"},
+		{slog.LevelError, "bad"},
+		{slog.LevelDebug, "Trace"},
+		{slog.LevelInfo, "from parallel"},
+		{slog.LevelInfo, "from worker"},
+	}
+	if len(h.records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(h.records), len(want))
+	}
+	for i, w := range want {
+		if r := h.records[i]; r.Level != w.level || r.Message != w.msg {
+			t.Errorf("record %d: got %v %q, want %v %q", i, r.Level, r.Message, w.level, w.msg)
+		}
+	}
+	attrs := map[string]string{}
+	for _, r := range h.records {
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value.String()
+			return true
+		})
+	}
+	if attrs["group"] != "outer" || !strings.HasPrefix(attrs["stack"], "    at ") {
+		t.Errorf("record attributes: %v", attrs)
+	}
+	if stdout.Len() != len(out) {
+		t.Error("a handler console still wrote to its writers")
+	}
+}